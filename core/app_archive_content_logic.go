@@ -0,0 +1,93 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+type archiveContentLogic struct {
+	logger logs.Logger
+
+	storage interfaces.Storage
+
+	//archive timer
+	archiveTimer *time.Timer
+	timerDone    chan bool
+}
+
+func (a archiveContentLogic) start() error {
+
+	//set up archive timer
+	go a.setupTimerForArchive()
+
+	return nil
+}
+
+func (a archiveContentLogic) setupTimerForArchive() {
+	a.logger.Info("Archive content timer")
+
+	//cancel if active
+	if a.archiveTimer != nil {
+		a.logger.Info("setupTimerForArchive -> there is active timer, so cancel it")
+
+		a.timerDone <- true
+		a.archiveTimer.Stop()
+	}
+
+	//process on start, then every hour
+	a.process()
+}
+
+func (a archiveContentLogic) process() {
+	a.logger.Info("Archiving content process")
+
+	//process work
+	a.processArchive()
+
+	//generate new processing after an hour
+	duration := time.Hour
+	a.logger.Infof("Archiving content process -> next call after %s", duration)
+	a.archiveTimer = time.NewTimer(duration)
+	select {
+	case <-a.archiveTimer.C:
+		a.logger.Info("Archiving content process -> timer expired")
+		a.archiveTimer = nil
+
+		a.process()
+	case <-a.timerDone:
+		// timer aborted
+		a.logger.Info("Archiving content process -> timer aborted")
+		a.archiveTimer = nil
+	}
+}
+
+func (a archiveContentLogic) processArchive() {
+	archivedCount, err := a.storage.ArchiveExpiredContentItems(time.Now().UTC())
+	if err != nil {
+		a.logger.Errorf("error on archiving expired content items - %s", err)
+		return
+	}
+
+	a.logger.Infof("archived %d expired content items", archivedCount)
+}
+
+func archiveLogic(logger logs.Logger, storage interfaces.Storage) archiveContentLogic {
+	timerDone := make(chan bool)
+	return archiveContentLogic{logger: logger, storage: storage, timerDone: timerDone}
+}
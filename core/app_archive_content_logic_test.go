@@ -0,0 +1,111 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"testing"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+func TestProcessArchive(t *testing.T) {
+	appID := "app"
+	now := time.Now().UTC()
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	archivedAt := now.Add(-24 * time.Hour)
+
+	storage := &memoryStorage{contentItems: []model.ContentItem{
+		{ID: "expired", AppID: &appID, OrgID: "org", ExpireAt: &earlier, WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "expired draft", AppID: &appID, OrgID: "org", ExpireAt: &earlier, WorkflowState: model.ContentItemWorkflowDraft},
+		{ID: "live", AppID: &appID, OrgID: "org", PublishAt: &earlier, ExpireAt: &later, WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "scheduled", AppID: &appID, OrgID: "org", PublishAt: &later, WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "no window", AppID: &appID, OrgID: "org", WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "archived", AppID: &appID, OrgID: "org", ExpireAt: &earlier, DateArchived: &archivedAt, WorkflowState: model.ContentItemWorkflowArchived},
+	}}
+
+	archiveLogic(*logs.NewLogger("content-test", nil), storage).processArchive()
+
+	want := map[string]string{
+		"expired":       model.ContentItemWorkflowArchived,
+		"expired draft": model.ContentItemWorkflowArchived,
+		"live":          model.ContentItemWorkflowPublished,
+		"scheduled":     model.ContentItemWorkflowPublished,
+		"no window":     model.ContentItemWorkflowPublished,
+		"archived":      model.ContentItemWorkflowArchived,
+	}
+	for _, item := range storage.contentItems {
+		if item.WorkflowState != want[item.ID] {
+			t.Errorf("%s item state = %s, want %s", item.ID, item.WorkflowState, want[item.ID])
+		}
+		if want[item.ID] == model.ContentItemWorkflowArchived && item.DateArchived == nil {
+			t.Errorf("%s item is not marked archived", item.ID)
+		}
+		if item.ID == "archived" && !item.DateArchived.Equal(archivedAt) {
+			t.Errorf("archived item date = %v, want it kept %v", item.DateArchived, archivedAt)
+		}
+		if item.ID == "expired" && served(item) {
+			t.Errorf("expired item is served")
+		}
+	}
+}
+
+func TestUpdateContentItemDataPublishWindow(t *testing.T) {
+	appID := "app"
+	now := time.Now().UTC()
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+	archivedAt := now.Add(-time.Minute)
+	reminders := model.Category{Name: "campus_reminders", AppID: &appID, OrgID: "org"}
+
+	tests := []struct {
+		name         string
+		publishAt    *time.Time
+		expireAt     *time.Time
+		wantExpireAt *time.Time
+		wantServed   bool
+	}{
+		{name: "window kept", wantExpireAt: &earlier},
+		{name: "expiry extended", expireAt: &later, wantExpireAt: &later, wantServed: true},
+		{name: "publish time moved", publishAt: &earlier, wantExpireAt: &earlier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{categories: []model.Category{reminders}, contentItems: []model.ContentItem{
+				{ID: "item", Category: "campus_reminders", Data: "a", AppID: &appID, OrgID: "org", DateCreated: now, ExpireAt: &earlier,
+					DateArchived: &archivedAt, WorkflowState: model.ContentItemWorkflowPublished}}}
+			services := testServices(storage)
+
+			item, err := services.UpdateContentItemData(nil, false, appID, "org", "item", "campus_reminders", "b", model.Nullable[string]{},
+				model.Nullable[map[string]interface{}]{}, tt.publishAt, tt.expireAt)
+			if err != nil {
+				t.Fatalf("UpdateContentItemData() error = %v", err)
+			}
+			if !item.ExpireAt.Equal(*tt.wantExpireAt) {
+				t.Errorf("UpdateContentItemData() expire_at = %v, want %v", item.ExpireAt, tt.wantExpireAt)
+			}
+			windowChanged := tt.publishAt != nil || tt.expireAt != nil
+			if (item.DateArchived == nil) != windowChanged {
+				t.Errorf("UpdateContentItemData() date_archived = %v, want it cleared %v", item.DateArchived, windowChanged)
+			}
+			if served(*item) != tt.wantServed {
+				t.Errorf("UpdateContentItemData() served = %v, want %v", served(*item), tt.wantServed)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"sync"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+// changeSubscriberBuffer is how many changes a subscriber can fall behind before it is dropped
+const changeSubscriberBuffer = 64
+
+// changeFeed fans out the content changes to the subscribed clients
+type changeFeed struct {
+	logger  *logs.Logger
+	storage interfaces.Storage
+
+	lock        *sync.Mutex
+	subscribers map[*changeSubscriber]bool
+}
+
+type changeSubscriber struct {
+	claims     *tokenauth.Claims
+	appID      *string // nil for the items of all the apps in the organization
+	orgID      string
+	categories []string // empty for all the categories
+
+	changes chan model.ContentChange
+}
+
+func (s *changeSubscriber) matches(change model.ContentChange) bool {
+	//the meta data has no category, the global one is for all the organizations
+	if change.Collection == "meta_data" {
+		if len(s.categories) > 0 {
+			return false
+		}
+		if change.Global {
+			return true
+		}
+	}
+
+	if change.OrgID != s.orgID {
+		return false
+	}
+	if (change.AppID == nil) != (s.appID == nil) || (change.AppID != nil && *change.AppID != *s.appID) {
+		return false
+	}
+	if len(s.categories) == 0 {
+		return true
+	}
+	for _, category := range s.categories {
+		if category == change.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// OnContentChanged implements interfaces.StorageListener
+func (f *changeFeed) OnContentChanged(change model.ContentChange) {
+	//the clients are told about a content item only while they see it, or when they stop seeing it - like when it is archived once it expires
+	if change.Collection == "content_items" {
+		now := time.Now().UTC()
+		if !change.ItemBefore.Published(now) && !change.ItemAfter.Served(now) {
+			return
+		}
+	}
+
+	f.lock.Lock()
+	subscribers := []*changeSubscriber{}
+	for subscriber := range f.subscribers {
+		if subscriber.matches(change) {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	f.lock.Unlock()
+
+	if change.Collection == "data_content_items" {
+		subscribers = f.categoryReaders(change.Category, subscribers)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, subscriber := range subscribers {
+		if !f.subscribers[subscriber] {
+			//unsubscribed meanwhile
+			continue
+		}
+		select {
+		case subscriber.changes <- change:
+		default:
+			//the client does not keep up, end its stream so that it reconnects and reloads the content
+			f.logger.Infof("dropping a change feed subscriber for org %s which falls behind", subscriber.orgID)
+			delete(f.subscribers, subscriber)
+			close(subscriber.changes)
+		}
+	}
+}
+
+// categoryReaders gives the subscribers which may read the items of a category, the access of the category is found once per app
+func (f *changeFeed) categoryReaders(category string, subscribers []*changeSubscriber) []*changeSubscriber {
+	access := map[string]*model.CategoryAccess{}
+	readers := make([]*changeSubscriber, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		categoryAccess, ok := access[subscriber.claims.AppID]
+		if !ok {
+			var err error
+			categoryAccess, err = findCategoryAccess(f.storage, subscriber.claims.AppID, subscriber.claims.OrgID, category)
+			if err != nil {
+				f.logger.Errorf("error on finding the access of category %s for the change feed - %s", category, err)
+				continue
+			}
+			access[subscriber.claims.AppID] = categoryAccess
+		}
+		if categoryAccess == nil || canReadCategory(categoryAccess, subscriber.claims) {
+			readers = append(readers, subscriber)
+		}
+	}
+	return readers
+}
+
+func (f *changeFeed) subscribe(claims *tokenauth.Claims, appID *string, categories []string) *changeSubscriber {
+	subscriber := &changeSubscriber{claims: claims, appID: appID, orgID: claims.OrgID, categories: categories,
+		changes: make(chan model.ContentChange, changeSubscriberBuffer)}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.subscribers[subscriber] = true
+	return subscriber
+}
+
+func (f *changeFeed) unsubscribe(subscriber *changeSubscriber) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.subscribers[subscriber] {
+		delete(f.subscribers, subscriber)
+		close(subscriber.changes)
+	}
+}
+
+func newChangeFeed(logger *logs.Logger, storage interfaces.Storage) *changeFeed {
+	return &changeFeed{logger: logger, storage: storage, lock: &sync.Mutex{}, subscribers: map[*changeSubscriber]bool{}}
+}
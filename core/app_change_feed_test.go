@@ -0,0 +1,141 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"testing"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+func TestChangeSubscriberMatches(t *testing.T) {
+	appID := "app"
+	otherAppID := "other"
+	appSubscriber := &changeSubscriber{appID: &appID, orgID: "org"}
+	allAppsSubscriber := &changeSubscriber{orgID: "org"}
+	eventsSubscriber := &changeSubscriber{appID: &appID, orgID: "org", categories: []string{"events"}}
+
+	tests := []struct {
+		name       string
+		subscriber *changeSubscriber
+		change     model.ContentChange
+		want       bool
+	}{
+		{name: "item of the app", subscriber: appSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "item of another app", subscriber: appSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", AppID: &otherAppID, OrgID: "org"}},
+		{name: "item of another organization", subscriber: appSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", AppID: &appID, OrgID: "other"}},
+		{name: "item of all the apps", subscriber: allAppsSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", OrgID: "org"}, want: true},
+		{name: "item of the app for all the apps", subscriber: allAppsSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", AppID: &appID, OrgID: "org"}},
+		{name: "item of the category", subscriber: eventsSubscriber, change: model.ContentChange{Collection: "content_items", Category: "events", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "item of another category", subscriber: eventsSubscriber, change: model.ContentChange{Collection: "content_items", Category: "news", AppID: &appID, OrgID: "org"}},
+		{name: "meta data of the app", subscriber: appSubscriber, change: model.ContentChange{Collection: "meta_data", Key: "a", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "meta data of another organization", subscriber: appSubscriber, change: model.ContentChange{Collection: "meta_data", Key: "a", AppID: &appID, OrgID: "other"}},
+		{name: "global meta data", subscriber: appSubscriber, change: model.ContentChange{Collection: "meta_data", Key: "a", Global: true}, want: true},
+		{name: "global meta data for a category", subscriber: eventsSubscriber, change: model.ContentChange{Collection: "meta_data", Key: "a", Global: true}},
+		{name: "meta data of an unknown scope", subscriber: appSubscriber, change: model.ContentChange{Collection: "meta_data", Operation: model.ContentChangeDelete, Key: "a"}},
+		{name: "meta data of an unknown scope for all the apps", subscriber: allAppsSubscriber, change: model.ContentChange{Collection: "meta_data", Operation: model.ContentChangeDelete, Key: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscriber.matches(tt.change); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeFeedOnContentChanged(t *testing.T) {
+	appID := "app"
+	now := time.Now().UTC()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+	published := &model.ContentItemChangeState{WorkflowState: model.ContentItemWorkflowPublished}
+	draft := &model.ContentItemChangeState{WorkflowState: model.ContentItemWorkflowDraft}
+	storage := &memoryStorage{categories: []model.Category{
+		{Name: "public", AppID: &appID, OrgID: "org"},
+		{Name: "staff", AppID: &appID, OrgID: "org", ReadPermissions: []string{"staff"}},
+		{Name: "members", AppID: &appID, OrgID: "org", RequiresAuth: true},
+	}}
+
+	tests := []struct {
+		name   string
+		claims tokenauth.Claims
+		change model.ContentChange
+		want   bool
+	}{
+		{name: "published item", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org", ItemAfter: published}, want: true},
+		{name: "draft item", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org", ItemAfter: draft}},
+		{name: "edited draft item", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org", ItemBefore: draft, ItemAfter: draft}},
+		{name: "published item moved to draft", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org", ItemBefore: published, ItemAfter: draft}, want: true},
+		{name: "deleted published item", change: model.ContentChange{Collection: "content_items", Operation: model.ContentChangeDelete, AppID: &appID, OrgID: "org", ItemBefore: published}, want: true},
+		{name: "scheduled item", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org",
+			ItemAfter: &model.ContentItemChangeState{WorkflowState: model.ContentItemWorkflowPublished, PublishAt: &later}}},
+		{name: "expired item", change: model.ContentChange{Collection: "content_items", AppID: &appID, OrgID: "org",
+			ItemAfter: &model.ContentItemChangeState{WorkflowState: model.ContentItemWorkflowPublished, ExpireAt: &earlier}}},
+		{name: "data item of a public category", claims: tokenauth.Claims{Anonymous: true},
+			change: model.ContentChange{Collection: "data_content_items", Category: "public", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "data item of a category without a record", claims: tokenauth.Claims{Anonymous: true},
+			change: model.ContentChange{Collection: "data_content_items", Category: "unknown", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "data item of a category the user reads", claims: tokenauth.Claims{Permissions: "staff"},
+			change: model.ContentChange{Collection: "data_content_items", Category: "staff", AppID: &appID, OrgID: "org"}, want: true},
+		{name: "data item of a category the user does not read", claims: tokenauth.Claims{Permissions: "student"},
+			change: model.ContentChange{Collection: "data_content_items", Category: "staff", AppID: &appID, OrgID: "org"}},
+		{name: "data item of an authenticated category for an anonymous user", claims: tokenauth.Claims{Anonymous: true},
+			change: model.ContentChange{Collection: "data_content_items", Category: "members", AppID: &appID, OrgID: "org"}},
+		{name: "data item of another organization", claims: tokenauth.Claims{},
+			change: model.ContentChange{Collection: "data_content_items", Category: "public", AppID: &appID, OrgID: "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := newChangeFeed(logs.NewLogger("content-test", nil), storage)
+			claims := tt.claims
+			claims.AppID, claims.OrgID = appID, "org"
+			subscriber := feed.subscribe(&claims, &appID, nil)
+			defer feed.unsubscribe(subscriber)
+
+			feed.OnContentChanged(tt.change)
+
+			got := len(subscriber.changes) > 0
+			if got != tt.want {
+				t.Errorf("OnContentChanged() delivered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeFeedDropsSlowSubscribers(t *testing.T) {
+	appID := "app"
+	feed := newChangeFeed(logs.NewLogger("content-test", nil), &memoryStorage{})
+	subscriber := feed.subscribe(&tokenauth.Claims{AppID: appID, OrgID: "org"}, &appID, nil)
+
+	change := model.ContentChange{Collection: "meta_data", Key: "a", AppID: &appID, OrgID: "org"}
+	for i := 0; i <= changeSubscriberBuffer; i++ {
+		feed.OnContentChanged(change)
+	}
+
+	received := 0
+	for range subscriber.changes {
+		received++
+	}
+	if received != changeSubscriberBuffer {
+		t.Errorf("received %d changes before the stream ended, want %d", received, changeSubscriberBuffer)
+	}
+	feed.unsubscribe(subscriber)
+}
@@ -20,8 +20,6 @@ package core
 import (
 	"content/core/interfaces"
 	"content/core/model"
-	"content/driven/awsstorage"
-	"fmt"
 	"time"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
@@ -32,9 +30,9 @@ type deleteDataLogic struct {
 
 	serviceID string
 
-	storage    interfaces.Storage
-	awsAdapter *awsstorage.Adapter
-	core       interfaces.Core
+	storage       interfaces.Storage
+	objectStorage interfaces.ObjectStorage
+	core          interfaces.Core
 
 	//delete data timer
 	dailyDeleteTimer *time.Timer
@@ -161,13 +159,20 @@ func (d deleteDataLogic) deleteAppOrgUsersData(appID string, orgID string, accou
 	for _, accountID := range accountsIDs {
 
 		//delete profile images
-		err := d.deleteProfileImage(accountID)
+		err := changeObjects(d.storage, d.objectStorage, &d.logger, appID, orgID, model.StorageUsageProfilePhotos, model.ObjectBucketProfileImages,
+			profileImagesPrefix(accountID), profileImageKeys(accountID), 0, func() error {
+				return d.deleteProfileImage(accountID)
+			})
 		if err != nil {
 			d.logger.Debugf("error on delete profile image - %s", err)
 		}
 
 		//delete voice record
-		err = d.awsAdapter.DeleteUserVoiceRecord(accountID)
+		key := voiceRecordKey(accountID)
+		err = changeObjects(d.storage, d.objectStorage, &d.logger, appID, orgID, model.StorageUsageVoiceRecords, model.ObjectBucketUsersAudios,
+			key, []string{key}, 0, func() error {
+				return d.objectStorage.Delete(model.ObjectBucketUsersAudios, key)
+			})
 		if err != nil {
 			d.logger.Debugf("error on delete voice record - %s", err)
 		}
@@ -175,19 +180,7 @@ func (d deleteDataLogic) deleteAppOrgUsersData(appID string, orgID string, accou
 }
 
 func (d deleteDataLogic) deleteProfileImage(accountID string) error {
-	err := d.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-default.webp", accountID))
-	if err != nil {
-		return err
-	}
-	err = d.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-medium.webp", accountID))
-	if err != nil {
-		return err
-	}
-	err = d.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-small.webp", accountID))
-	if err != nil {
-		return err
-	}
-	return nil
+	return deleteProfileImages(d.objectStorage, accountID)
 }
 
 func (d deleteDataLogic) getAccountsIDs(memberships []model.DeletedMembership) []string {
@@ -199,6 +192,6 @@ func (d deleteDataLogic) getAccountsIDs(memberships []model.DeletedMembership) [
 }
 
 // deleteLogic creates new deleteLogic
-func deleteLogic(logger logs.Logger, coreBB interfaces.Core, serviceID string, storage interfaces.Storage, awsAdapter *awsstorage.Adapter) deleteDataLogic {
-	return deleteDataLogic{logger: logger, core: coreBB, serviceID: serviceID, storage: storage, awsAdapter: awsAdapter}
+func deleteLogic(logger logs.Logger, coreBB interfaces.Core, serviceID string, storage interfaces.Storage, objectStorage interfaces.ObjectStorage) deleteDataLogic {
+	return deleteDataLogic{logger: logger, core: coreBB, serviceID: serviceID, storage: storage, objectStorage: objectStorage}
 }
@@ -0,0 +1,143 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+// staleMultipartUploadAge is how long a multipart upload may stay incomplete before it is aborted
+const staleMultipartUploadAge = 24 * time.Hour
+
+// stalePendingUploadAge is how long an uploaded file may wait for its confirmation before it is deleted
+const stalePendingUploadAge = 24 * time.Hour
+
+type multipartUploadsLogic struct {
+	logger logs.Logger
+
+	storage       interfaces.Storage
+	objectStorage interfaces.ObjectStorage
+
+	//sweep timer
+	sweepTimer *time.Timer
+	timerDone  chan bool
+}
+
+func (m multipartUploadsLogic) start() error {
+
+	//set up sweep timer
+	go m.setupTimerForSweep()
+
+	return nil
+}
+
+func (m multipartUploadsLogic) setupTimerForSweep() {
+	m.logger.Info("Stale multipart uploads timer")
+
+	//cancel if active
+	if m.sweepTimer != nil {
+		m.logger.Info("setupTimerForSweep -> there is active timer, so cancel it")
+
+		m.timerDone <- true
+		m.sweepTimer.Stop()
+	}
+
+	//process on start, then every hour
+	m.process()
+}
+
+func (m multipartUploadsLogic) process() {
+	m.logger.Info("Aborting stale multipart uploads process")
+
+	//process work
+	m.processSweep()
+	m.processPendingUploadsSweep()
+
+	//generate new processing after an hour
+	duration := time.Hour
+	m.logger.Infof("Aborting stale multipart uploads process -> next call after %s", duration)
+	m.sweepTimer = time.NewTimer(duration)
+	select {
+	case <-m.sweepTimer.C:
+		m.logger.Info("Aborting stale multipart uploads process -> timer expired")
+		m.sweepTimer = nil
+
+		m.process()
+	case <-m.timerDone:
+		// timer aborted
+		m.logger.Info("Aborting stale multipart uploads process -> timer aborted")
+		m.sweepTimer = nil
+	}
+}
+
+func (m multipartUploadsLogic) processSweep() {
+	uploads, err := m.storage.FindMultipartUploads(time.Now().UTC().Add(-staleMultipartUploadAge))
+	if err != nil {
+		m.logger.Errorf("error on finding stale multipart uploads - %s", err)
+		return
+	}
+
+	abortedCount := 0
+	for _, upload := range uploads {
+		err = m.objectStorage.AbortMultipartUpload(model.ObjectBucketContent, pendingUploadPath(upload.Path, upload.Public), upload.UploadID)
+		if err != nil {
+			//keep the record, so that the next sweep tries again
+			m.logger.Errorf("error on aborting the multipart upload %s - %s", upload.ID, err)
+			continue
+		}
+		err = m.storage.DeleteMultipartUpload(upload.ID)
+		if err != nil {
+			m.logger.Errorf("error on deleting the multipart upload %s - %s", upload.ID, err)
+			continue
+		}
+		abortedCount++
+	}
+
+	m.logger.Infof("aborted %d stale multipart uploads", abortedCount)
+}
+
+// processPendingUploadsSweep deletes the uploaded files which have not been confirmed, they are never served
+func (m multipartUploadsLogic) processPendingUploadsSweep() {
+	objects, err := m.objectStorage.List(model.ObjectBucketContent, pendingUploadsPrefix)
+	if err != nil {
+		m.logger.Errorf("error on listing pending uploads - %s", err)
+		return
+	}
+
+	staleTime := time.Now().UTC().Add(-stalePendingUploadAge)
+	deletedCount := 0
+	for _, object := range objects {
+		if object.LastModified.After(staleTime) {
+			continue
+		}
+		err = m.objectStorage.Delete(model.ObjectBucketContent, object.Key)
+		if err != nil {
+			m.logger.Errorf("error on deleting pending upload %s - %s", object.Key, err)
+			continue
+		}
+		deletedCount++
+	}
+
+	m.logger.Infof("deleted %d stale pending uploads", deletedCount)
+}
+
+func multipartLogic(logger logs.Logger, storage interfaces.Storage, objectStorage interfaces.ObjectStorage) multipartUploadsLogic {
+	timerDone := make(chan bool)
+	return multipartUploadsLogic{logger: logger, storage: storage, objectStorage: objectStorage, timerDone: timerDone}
+}
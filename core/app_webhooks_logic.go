@@ -0,0 +1,226 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	webhookMaxAttempts     = 6
+	webhookRetryDelay      = 30 * time.Second // doubled after every failed attempt
+	webhookDeliveryTimeout = 10 * time.Second
+	webhookDeliveryLease   = 12 * webhookDeliveryTimeout // a delivery taken by an instance is not retried by the others meanwhile
+	webhookDeliveryWorkers = 4                           // the deliveries attempted at the same time by an instance
+	webhookPollInterval    = time.Minute
+)
+
+// webhooksLogic delivers the content events to the webhooks, retrying the failed deliveries with a backoff
+type webhooksLogic struct {
+	logger *logs.Logger
+
+	storage  interfaces.Storage
+	webhooks interfaces.Webhooks
+
+	//wakes up the deliveries processing when new deliveries are queued
+	trigger chan bool
+}
+
+func (w webhooksLogic) start() {
+	go w.processDeliveries()
+}
+
+func (w webhooksLogic) processDeliveries() {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.processDueDeliveries()
+
+		select {
+		case <-ticker.C:
+		case <-w.trigger:
+		}
+	}
+}
+
+// notify queues the deliveries of an event to the webhooks subscribed to it, it does not block the caller
+func (w webhooksLogic) notify(event model.WebhookEvent) {
+	go func() {
+		err := w.queueDeliveries(event)
+		if err != nil {
+			w.logger.Errorf("error queueing the deliveries of %s event for item %s - %s", event.Type, event.ItemID, err)
+		}
+	}()
+}
+
+func (w webhooksLogic) queueDeliveries(event model.WebhookEvent) error {
+	webhooks, err := w.storage.FindWebhooks(event.AppID, event.OrgID)
+	if err != nil {
+		return err
+	}
+	if event.AppID != nil {
+		//the webhooks of all the apps get the events of the app too
+		allAppsWebhooks, err := w.storage.FindWebhooks(nil, event.OrgID)
+		if err != nil {
+			return err
+		}
+		webhooks = append(webhooks, allAppsWebhooks...)
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(event)
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		delivery := model.WebhookDelivery{ID: uuid.NewString(), WebhookID: webhook.ID, AppID: webhook.AppID, OrgID: webhook.OrgID,
+			EventID: event.ID, EventType: event.Type, Payload: string(payload), Status: model.WebhookDeliveryPending,
+			Attempts: []model.WebhookDeliveryAttempt{}, NextAttemptAt: &now, DateCreated: now}
+		err = w.storage.CreateWebhookDelivery(delivery)
+		if err != nil {
+			return err
+		}
+	}
+
+	if payload != nil {
+		w.wake()
+	}
+	return nil
+}
+
+// wake starts processing the due deliveries unless it is already about to start
+func (w webhooksLogic) wake() {
+	select {
+	case w.trigger <- true:
+	default:
+	}
+}
+
+// processDueDeliveries attempts the due deliveries with a bounded number of workers, so a slow receiver does not hold up the others
+func (w webhooksLogic) processDueDeliveries() {
+	var wg sync.WaitGroup
+	for i := 0; i < webhookDeliveryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.processDueDeliveriesWorker()
+		}()
+	}
+	wg.Wait()
+}
+
+func (w webhooksLogic) processDueDeliveriesWorker() {
+	for {
+		delivery, err := w.storage.ClaimDueWebhookDelivery(time.Now().UTC(), webhookDeliveryLease)
+		if err != nil {
+			w.logger.Errorf("error on claiming a due webhook delivery - %s", err)
+			return
+		}
+		if delivery == nil {
+			return
+		}
+
+		err = w.attemptDelivery(*delivery)
+		if err != nil {
+			//it is attempted again once the lease expires
+			w.logger.Errorf("error on attempting webhook delivery %s - %s", delivery.ID, err)
+		}
+	}
+}
+
+func (w webhooksLogic) attemptDelivery(delivery model.WebhookDelivery) error {
+	start := time.Now()
+	attempt := model.WebhookDeliveryAttempt{Date: start.UTC()}
+
+	webhook, err := w.storage.FindWebhook(delivery.AppID, delivery.OrgID, delivery.WebhookID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	switch {
+	case webhook == nil:
+		attempt.Error = "the webhook has been deleted"
+	case !webhook.Active:
+		attempt.Error = "the webhook is not active"
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), webhookDeliveryTimeout)
+		statusCode, err := w.webhooks.Deliver(ctx, webhook.URL, webhook.Secret, delivery.ID, delivery.EventType, []byte(delivery.Payload))
+		cancel()
+		attempt.StatusCode = statusCode
+		if err != nil {
+			attempt.Error = err.Error()
+		} else if statusCode < 200 || statusCode > 299 {
+			attempt.Error = fmt.Sprintf("the receiver responded with status %d", statusCode)
+		}
+	}
+	attempt.DurationMS = time.Since(start).Milliseconds()
+
+	now := time.Now().UTC()
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.DateUpdated = &now
+	delivery.NextAttemptAt = nil
+	switch {
+	case len(attempt.Error) == 0:
+		delivery.Status = model.WebhookDeliverySucceeded
+	case webhook == nil || !webhook.Active || len(delivery.Attempts) >= webhookMaxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+	default:
+		nextAttemptAt := now.Add(webhookRetryDelay << (len(delivery.Attempts) - 1))
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+	return w.storage.UpdateWebhookDelivery(delivery)
+}
+
+// contentItemEvent gives the webhook event of a content item change, the item is not sent for the deleted items
+func contentItemEvent(eventType string, item model.ContentItem) model.WebhookEvent {
+	event := model.WebhookEvent{ID: uuid.NewString(), Type: eventType, Date: time.Now().UTC(), AppID: item.AppID, OrgID: item.OrgID,
+		Category: item.Category, ItemID: item.ID}
+	if eventType != model.WebhookEventContentItemDeleted {
+		event.Item = plainContentItem(item)
+	}
+	return event
+}
+
+// dataContentItemEvent gives the webhook event of a data content item change, the item is not sent for the deleted items
+func dataContentItemEvent(eventType string, item model.DataContentItem) model.WebhookEvent {
+	event := model.WebhookEvent{ID: uuid.NewString(), Type: eventType, Date: time.Now().UTC(), AppID: item.AppID, OrgID: item.OrgID,
+		Category: item.Category, ItemID: item.ID, Key: item.Key}
+	if eventType != model.WebhookEventDataContentItemDeleted {
+		event.Item = plainDataContentItem(item)
+	}
+	return event
+}
+
+func newWebhooksLogic(logger *logs.Logger, storage interfaces.Storage, webhooks interfaces.Webhooks) webhooksLogic {
+	return webhooksLogic{logger: logger, storage: storage, webhooks: webhooks, trigger: make(chan bool, 1)}
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+func TestQueueDeliveries(t *testing.T) {
+	appID := "app"
+	otherAppID := "other"
+	storage := &memoryStorage{webhooks: []model.Webhook{
+		{ID: "app", AppID: &appID, OrgID: "org", Active: true},
+		{ID: "other-app", AppID: &otherAppID, OrgID: "org", Active: true},
+		{ID: "all-apps", OrgID: "org", Active: true},
+		{ID: "all-apps-news", OrgID: "org", Active: true, Categories: []string{"news"}},
+		{ID: "other-org", OrgID: "other", Active: true},
+	}}
+
+	tests := []struct {
+		name  string
+		event model.WebhookEvent
+		want  []string
+	}{
+		{name: "app event", event: model.WebhookEvent{ID: "1", Type: model.WebhookEventContentItemCreated, AppID: &appID, OrgID: "org", Category: "events"},
+			want: []string{"all-apps", "app"}},
+		{name: "all apps event", event: model.WebhookEvent{ID: "2", Type: model.WebhookEventContentItemCreated, OrgID: "org", Category: "events"},
+			want: []string{"all-apps"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage.deliveries = nil
+			logic := newWebhooksLogic(logs.NewLogger("content-test", nil), storage, nil)
+
+			err := logic.queueDeliveries(tt.event)
+			if err != nil {
+				t.Fatalf("queueDeliveries() error = %v", err)
+			}
+
+			var got []string
+			for _, delivery := range storage.deliveries {
+				got = append(got, delivery.WebhookID)
+			}
+			slices.Sort(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queueDeliveries() webhooks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
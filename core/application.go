@@ -16,7 +16,6 @@ package core
 
 import (
 	"content/core/interfaces"
-	"content/driven/awsstorage"
 	cacheadapter "content/driven/cache"
 	"content/driven/twitter"
 	"log"
@@ -35,7 +34,8 @@ type Application struct {
 	Services interfaces.Services //expose to the drivers adapters
 
 	storage        interfaces.Storage
-	awsAdapter     *awsstorage.Adapter
+	objectStorage  interfaces.ObjectStorage
+	scanner        interfaces.Scanner //nil when the files are not scanned
 	twitterAdapter *twitter.Adapter
 	cacheAdapter   *cacheadapter.CacheAdapter
 
@@ -47,6 +47,17 @@ type Application struct {
 
 	//delete data logic
 	deleteDataLogic deleteDataLogic
+
+	//archive expired content logic
+	archiveContentLogic archiveContentLogic
+
+	//aborts the stale multipart uploads
+	multipartUploadsLogic multipartUploadsLogic
+	//content changes pushed to the clients
+	changeFeed *changeFeed
+
+	//content events delivered to the webhooks
+	webhooksLogic webhooksLogic
 }
 
 // Start starts the core part of the application
@@ -58,7 +69,17 @@ func (app *Application) Start() {
 		log.Fatalf("error initializing multi-tenancy data: %s", err.Error())
 	}
 
+	err = app.storeMetaDataMultiTenancyData()
+	if err != nil {
+		log.Fatalf("error initializing multi-tenancy meta data: %s", err.Error())
+	}
+
 	app.deleteDataLogic.start()
+	app.archiveContentLogic.start()
+	app.multipartUploadsLogic.start()
+
+	app.storage.RegisterStorageListener(app.changeFeed)
+	app.webhooksLogic.start()
 }
 
 // as the service starts supporting multi-tenancy we need to add the needed multi-tenancy fields for the existing data,
@@ -109,16 +130,33 @@ func (app *Application) storeMultiTenancyData() error {
 	return nil
 }
 
+// the meta data stored before it was scoped belongs to the multi-tenancy app and organization, only the documents without an organization are moved
+func (app *Application) storeMetaDataMultiTenancyData() error {
+	count, err := app.storage.StoreMetaDataMultiTenancyData(app.multiTenancyAppID, app.multiTenancyOrgID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("storeMetaDataMultiTenancyData: moved %d meta data items", count)
+	}
+	return nil
+}
+
 // NewApplication creates new Application
-func NewApplication(version string, build string, storage interfaces.Storage, awsAdapter *awsstorage.Adapter,
+func NewApplication(version string, build string, storage interfaces.Storage, objectStorage interfaces.ObjectStorage,
 	twitterAdapter *twitter.Adapter, cacheadapter *cacheadapter.CacheAdapter, mtAppID string, mtOrgID string,
-	serviceID string, coreBB interfaces.Core, logger *logs.Logger) *Application {
+	serviceID string, coreBB interfaces.Core, webhooks interfaces.Webhooks, scanner interfaces.Scanner, logger *logs.Logger) *Application {
 	cacheLock := &sync.Mutex{}
-	deleteDataLogic := deleteLogic(*logger, coreBB, serviceID, storage, awsAdapter)
+	deleteDataLogic := deleteLogic(*logger, coreBB, serviceID, storage, objectStorage)
+	archiveContentLogic := archiveLogic(*logger, storage)
+	multipartUploadsLogic := multipartLogic(*logger, storage, objectStorage)
+	webhooksLogic := newWebhooksLogic(logger, storage, webhooks)
 
 	application := Application{version: version, build: build, cacheLock: cacheLock, storage: storage,
-		awsAdapter: awsAdapter, twitterAdapter: twitterAdapter, cacheAdapter: cacheadapter,
-		multiTenancyAppID: mtAppID, multiTenancyOrgID: mtOrgID, deleteDataLogic: deleteDataLogic, logger: logger}
+		objectStorage: objectStorage, scanner: scanner, twitterAdapter: twitterAdapter, cacheAdapter: cacheadapter,
+		multiTenancyAppID: mtAppID, multiTenancyOrgID: mtOrgID, deleteDataLogic: deleteDataLogic,
+		archiveContentLogic: archiveContentLogic, multipartUploadsLogic: multipartUploadsLogic, changeFeed: newChangeFeed(logger, storage),
+		webhooksLogic: webhooksLogic, logger: logger}
 
 	// add the drivers ports/interfaces
 	application.Services = &servicesImpl{app: &application}
@@ -0,0 +1,86 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"content/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// audit records an admin mutation in the audit log with the changes from before to after, nil stands for a created or a deleted resource.
+// Nothing is recorded without an actor - the mutations not done by an admin are not audited.
+func (s *servicesImpl) audit(storage interfaces.Storage, actor *model.AuditActor, resource string, resourceID string, category string,
+	operation string, before interface{}, after interface{}) error {
+	if actor == nil {
+		return nil
+	}
+
+	beforeValue, err := jsonValue(utils.NormalizeData(before))
+	if err != nil {
+		return err
+	}
+	afterValue, err := jsonValue(utils.NormalizeData(after))
+	if err != nil {
+		return err
+	}
+
+	var changes []model.ContentItemChange
+	switch {
+	case beforeValue == nil && afterValue == nil:
+		changes = []model.ContentItemChange{}
+	case beforeValue == nil:
+		changes = []model.ContentItemChange{{Path: "", Op: "added", To: afterValue}}
+	case afterValue == nil:
+		changes = []model.ContentItemChange{{Path: "", Op: "removed", From: beforeValue}}
+	default:
+		changes = diffData("", beforeValue, afterValue)
+	}
+
+	permissions := actor.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	entry := model.AuditLogEntry{ID: uuid.NewString(), AppID: actor.AppID, OrgID: actor.OrgID, AccountID: actor.AccountID,
+		AccountName: actor.AccountName, Permissions: permissions, RequestID: actor.RequestID, Resource: resource, ResourceID: resourceID,
+		Category: category, Operation: operation, Changes: changes, Date: time.Now().UTC()}
+	return storage.CreateAuditLogEntry(entry)
+}
+
+// auditCommitted records a mutation which is already stored, a failure is only logged as the mutation cannot be undone anymore
+func (s *servicesImpl) auditCommitted(actor *model.AuditActor, resource string, resourceID string, category string,
+	operation string, before interface{}, after interface{}) {
+	err := s.audit(s.app.storage, actor, resource, resourceID, category, operation, before, after)
+	if err != nil {
+		s.app.logger.Errorf("error recording the %s of %s %s in the audit log - %s", operation, resource, resourceID, err)
+	}
+}
+
+// plainContentItem gives a content item with its data and translations in plain json form
+func plainContentItem(item model.ContentItem) model.ContentItem {
+	item.Data = utils.NormalizeData(item.Data)
+	item.Locales, _ = utils.NormalizeData(item.Locales).(map[string]interface{})
+	return item
+}
+
+// plainDataContentItem gives a data content item with its data and translations in plain json form
+func plainDataContentItem(item model.DataContentItem) model.DataContentItem {
+	item.Data = utils.NormalizeData(item.Data)
+	item.Locales, _ = utils.NormalizeData(item.Locales).(map[string]interface{})
+	return item
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"reflect"
+	"testing"
+)
+
+func TestAuditContentItemMutations(t *testing.T) {
+	storage := &memoryStorage{}
+	services := testServices(storage)
+	actor := &model.AuditActor{AccountID: "admin", AccountName: "Admin", AppID: "app", OrgID: "org", RequestID: "request"}
+
+	created, err := services.CreateContentItem(actor, false, "app", "org", "events", map[string]interface{}{"title": "a", "place": "quad"}, "", nil, nil, nil,
+		model.ContentItemWorkflowDraft)
+	if err != nil {
+		t.Fatalf("CreateContentItem() error = %v", err)
+	}
+	_, err = services.UpdateContentItem(actor, false, "app", "org", created.ID, "events", map[string]interface{}{"title": "b", "place": "quad"},
+		model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpdateContentItem() error = %v", err)
+	}
+
+	//the mutations which fail and the ones not done by an admin are not recorded
+	_, err = services.UpdateContentItem(actor, false, "app", "org", "missing", "events", "c", model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+	if err == nil {
+		t.Fatalf("UpdateContentItem() of a missing item succeeded")
+	}
+	_, err = services.UpdateContentItem(nil, false, "app", "org", created.ID, "events", map[string]interface{}{"title": "b", "place": "hall"},
+		model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpdateContentItem() error = %v", err)
+	}
+
+	err = services.DeleteContentItem(actor, false, "app", "org", created.ID)
+	if err != nil {
+		t.Fatalf("DeleteContentItem() error = %v", err)
+	}
+
+	if len(storage.auditLog) != 3 {
+		t.Fatalf("audit log = %+v, want 3 entries", storage.auditLog)
+	}
+	for _, entry := range storage.auditLog {
+		if entry.AccountID != "admin" || entry.AccountName != "Admin" || entry.AppID != "app" || entry.OrgID != "org" || entry.RequestID != "request" ||
+			entry.Resource != model.AuditResourceContentItem || entry.ResourceID != created.ID || entry.Category != "events" ||
+			entry.Permissions == nil || entry.Date.IsZero() {
+			t.Errorf("audit log entry = %+v, want the actor and the item", entry)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		entry     model.AuditLogEntry
+		operation string
+		check     func(changes []model.ContentItemChange) bool
+	}{
+		{name: "create", entry: storage.auditLog[0], operation: model.AuditOperationCreate, check: func(changes []model.ContentItemChange) bool {
+			return len(changes) == 1 && changes[0].Path == "" && changes[0].Op == "added" && changes[0].From == nil && changes[0].To != nil
+		}},
+		{name: "update", entry: storage.auditLog[1], operation: model.AuditOperationUpdate, check: func(changes []model.ContentItemChange) bool {
+			//only the changed fields are recorded
+			title := false
+			for _, change := range changes {
+				if change.Path == "/data/place" {
+					return false
+				}
+				if change.Path == "/data/title" {
+					title = reflect.DeepEqual(change, model.ContentItemChange{Path: "/data/title", Op: "changed", From: "a", To: "b"})
+				}
+			}
+			return title
+		}},
+		{name: "delete", entry: storage.auditLog[2], operation: model.AuditOperationDelete, check: func(changes []model.ContentItemChange) bool {
+			return len(changes) == 1 && changes[0].Path == "" && changes[0].Op == "removed" && changes[0].From != nil && changes[0].To == nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.entry.Operation != tt.operation || !tt.check(tt.entry.Changes) {
+				t.Errorf("audit log entry = %s %+v, want the %s changes", tt.entry.Operation, tt.entry.Changes, tt.operation)
+			}
+		})
+	}
+}
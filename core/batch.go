@@ -0,0 +1,277 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// batchOperationFunc applies the operation of a batch at index, it gives the id and the stored item for the result
+type batchOperationFunc func(storage interfaces.Storage, index int) (id string, item interface{}, event *model.WebhookEvent, err error)
+
+// performBatch applies the operations of a batch in a single transaction. It stops at the first failed operation and then nothing is stored.
+// The webhook events are sent once the transaction is committed.
+func (s *servicesImpl) performBatch(ops []string, apply batchOperationFunc) (*model.BatchResult, error) {
+	var result *model.BatchResult
+	var events []model.WebhookEvent
+	failed := false
+	transaction := func(storage interfaces.Storage) error {
+		//the transaction may be retried
+		result = model.NewBatchResult(ops)
+		events = nil
+		failed = false
+
+		for i := range ops {
+			id, item, event, err := apply(storage, i)
+			if err != nil {
+				result.Fail(i, id, err.Error())
+				failed = true
+				return err
+			}
+			result.Done(i, id, item)
+			if event != nil {
+				events = append(events, *event)
+			}
+		}
+		return nil
+	}
+
+	err := s.app.storage.PerformTransaction(transaction)
+	if failed {
+		result.RollBack()
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Applied = true
+	for _, event := range events {
+		s.app.webhooksLogic.notify(event)
+	}
+	return result, nil
+}
+
+func (s *servicesImpl) applyContentItemOperation(storage interfaces.Storage, actor *model.AuditActor, appIDParam *string, appID string, orgID string,
+	operation model.ContentItemBatchOperation) (string, interface{}, *model.WebhookEvent, error) {
+	switch operation.Op {
+	case model.BatchOperationCreate:
+		err := s.validateContentItemData(appID, orgID, operation.Category, operation.Data, operation.Locales.Value)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		cItem := model.ContentItem{ID: uuid.NewString(), Category: operation.Category, DateCreated: time.Now().UTC(),
+			Data: operation.Data, DefaultLocale: operation.DefaultLocale.Value, Locales: operation.Locales.Value, OrgID: orgID, AppID: appIDParam,
+			PublishAt: operation.PublishAt, ExpireAt: operation.ExpireAt, WorkflowState: model.ContentItemWorkflowDraft}
+		item, err := storage.CreateContentItem(cItem)
+		if err != nil {
+			return cItem.ID, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceContentItem, item.ID, item.Category, model.AuditOperationCreate, nil, plainContentItem(*item))
+		if err != nil {
+			return item.ID, nil, nil, err
+		}
+		event := contentItemEvent(model.WebhookEventContentItemCreated, *item)
+		return item.ID, item, &event, nil
+	case model.BatchOperationUpdate:
+		current, err := findBatchContentItem(storage, appIDParam, orgID, operation.ID)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		//the translations are kept unless they are provided
+		defaultLocale := operation.DefaultLocale.Or(current.DefaultLocale)
+		locales := operation.Locales.Or(current.Locales)
+		err = s.validateContentItemData(appID, orgID, operation.Category, operation.Data, locales)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		//keep the current revision
+		err = s.storeContentItemVersion(storage, *current)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		item, err := storage.UpdateContentItem(appIDParam, orgID, operation.ID, operation.Category, operation.Data, defaultLocale,
+			locales, operation.PublishAt, operation.ExpireAt, editedWorkflowState(current.WorkflowState))
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceContentItem, item.ID, item.Category, model.AuditOperationUpdate,
+			plainContentItem(*current), plainContentItem(*item))
+		if err != nil {
+			return item.ID, nil, nil, err
+		}
+		event := contentItemEvent(model.WebhookEventContentItemUpdated, *item)
+		return item.ID, plainContentItem(*item), &event, nil
+	case model.BatchOperationDelete:
+		current, err := findBatchContentItem(storage, appIDParam, orgID, operation.ID)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		err = storage.DeleteContentItem(appIDParam, orgID, operation.ID)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		//the history goes with the item
+		err = storage.DeleteContentItemVersions(appIDParam, orgID, operation.ID)
+		if err != nil {
+			return operation.ID, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceContentItem, current.ID, current.Category, model.AuditOperationDelete, plainContentItem(*current), nil)
+		if err != nil {
+			return current.ID, nil, nil, err
+		}
+		event := contentItemEvent(model.WebhookEventContentItemDeleted, *current)
+		return current.ID, nil, &event, nil
+	default:
+		return operation.ID, nil, nil, fmt.Errorf("invalid operation %s", operation.Op)
+	}
+}
+
+func findBatchContentItem(storage interfaces.Storage, appIDParam *string, orgID string, id string) (*model.ContentItem, error) {
+	items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != 1 {
+		return nil, fmt.Errorf("content item %s is not found", id)
+	}
+	return &items[0], nil
+}
+
+func (s *servicesImpl) applyDataContentItemOperation(storage interfaces.Storage, actor *model.AuditActor, claims *tokenauth.Claims,
+	operation model.DataContentItemBatchOperation) (string, interface{}, *model.WebhookEvent, error) {
+	current, err := storage.FindDataContentItem(&claims.AppID, claims.OrgID, operation.Key)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return operation.Key, nil, nil, err
+	}
+
+	//the caller must be allowed to use the category of the item and the category it is moved to
+	categories := []string{}
+	if operation.Op != model.BatchOperationDelete {
+		categories = append(categories, operation.Category)
+	}
+	if current != nil && current.Category != operation.Category {
+		categories = append(categories, current.Category)
+	}
+	access := categoryAccessWrite
+	if operation.Op == model.BatchOperationDelete {
+		access = categoryAccessDelete
+	}
+	var category *model.Category
+	for i, name := range categories {
+		categoryItem, err := storage.FindCategory(&claims.AppID, claims.OrgID, name)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return operation.Key, nil, nil, fmt.Errorf("category %s does not exist", name)
+			}
+			return operation.Key, nil, nil, err
+		}
+		permissions, err := categoryPermissions(storage, categoryItem, access)
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+		if !checkPermissions(permissions, claims.Permissions) {
+			return operation.Key, nil, nil, fmt.Errorf("unauthorized to %s data content item: [%s]", operation.Op, strings.Join(permissions, ", "))
+		}
+		if i == 0 {
+			category = categoryItem
+		}
+	}
+
+	switch operation.Op {
+	case model.BatchOperationCreate:
+		if current != nil {
+			return operation.Key, nil, nil, fmt.Errorf("data content item %s already exists", operation.Key)
+		}
+		err = validateCategoryData(category, operation.Data, operation.Locales.Value)
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+
+		item := &model.DataContentItem{ID: uuid.NewString(), Key: operation.Key, Category: operation.Category, Data: operation.Data,
+			DefaultLocale: operation.DefaultLocale.Value, Locales: operation.Locales.Value, AppID: &claims.AppID, OrgID: claims.OrgID, DateCreated: time.Now().UTC()}
+		item, err = storage.CreateDataContentItem(item)
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceDataContentItem, item.Key, item.Category, model.AuditOperationCreate, nil, plainDataContentItem(*item))
+		if err != nil {
+			return item.Key, nil, nil, err
+		}
+		event := dataContentItemEvent(model.WebhookEventDataContentItemCreated, *item)
+		return item.Key, item, &event, nil
+	case model.BatchOperationUpdate:
+		if current == nil {
+			return operation.Key, nil, nil, fmt.Errorf("data content item %s is not found", operation.Key)
+		}
+		//the translations are kept unless they are provided
+		locales := operation.Locales.Or(current.Locales)
+		err = validateCategoryData(category, operation.Data, locales)
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+
+		item, err := storage.UpdateDataContentItem(&claims.AppID, claims.OrgID, &model.DataContentItem{Key: operation.Key, Category: operation.Category,
+			Data: operation.Data, DefaultLocale: operation.DefaultLocale.Or(current.DefaultLocale), Locales: locales})
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceDataContentItem, item.Key, item.Category, model.AuditOperationUpdate,
+			plainDataContentItem(*current), plainDataContentItem(*item))
+		if err != nil {
+			return item.Key, nil, nil, err
+		}
+		event := dataContentItemEvent(model.WebhookEventDataContentItemUpdated, *item)
+		return item.Key, plainDataContentItem(*item), &event, nil
+	case model.BatchOperationDelete:
+		if current == nil {
+			return operation.Key, nil, nil, fmt.Errorf("data content item %s is not found", operation.Key)
+		}
+
+		err = storage.DeleteDataContentItem(&claims.AppID, claims.OrgID, operation.Key)
+		if err != nil {
+			return operation.Key, nil, nil, err
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceDataContentItem, current.Key, current.Category, model.AuditOperationDelete, plainDataContentItem(*current), nil)
+		if err != nil {
+			return current.Key, nil, nil, err
+		}
+		event := dataContentItemEvent(model.WebhookEventDataContentItemDeleted, *current)
+		return current.Key, nil, &event, nil
+	default:
+		return operation.Key, nil, nil, fmt.Errorf("invalid operation %s", operation.Op)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyContentItemsBatch(t *testing.T) {
+	appID := "app"
+	actor := &model.AuditActor{AccountID: "admin", AppID: appID, OrgID: "org"}
+	items := []model.ContentItem{
+		{ID: "a", Category: "events", Data: "a", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC(), WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "b", Category: "events", Data: "b", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC(), WorkflowState: model.ContentItemWorkflowPublished},
+	}
+	create := model.ContentItemBatchOperation{Op: model.BatchOperationCreate, Category: "events", Data: "c"}
+	update := model.ContentItemBatchOperation{Op: model.BatchOperationUpdate, ID: "a", Category: "events", Data: "d"}
+	deleteOp := model.ContentItemBatchOperation{Op: model.BatchOperationDelete, ID: "b"}
+
+	tests := []struct {
+		name       string
+		operations []model.ContentItemBatchOperation
+		failingIDs []string
+		want       []string
+	}{
+		{name: "applied", operations: []model.ContentItemBatchOperation{create, update, deleteOp},
+			want: []string{model.BatchStatusApplied, model.BatchStatusApplied, model.BatchStatusApplied}},
+		{name: "missing item", operations: []model.ContentItemBatchOperation{create, {Op: model.BatchOperationUpdate, ID: "missing", Category: "events", Data: "d"}, deleteOp},
+			want: []string{model.BatchStatusRolledBack, model.BatchStatusFailed, model.BatchStatusSkipped}},
+		{name: "first operation fails", operations: []model.ContentItemBatchOperation{{Op: model.BatchOperationDelete, ID: "missing"}, update, deleteOp},
+			want: []string{model.BatchStatusFailed, model.BatchStatusSkipped, model.BatchStatusSkipped}},
+		{name: "failed write", operations: []model.ContentItemBatchOperation{deleteOp, create, update}, failingIDs: []string{"a"},
+			want: []string{model.BatchStatusRolledBack, model.BatchStatusRolledBack, model.BatchStatusFailed}},
+		{name: "invalid operation", operations: []model.ContentItemBatchOperation{create, {Op: "replace", ID: "a"}},
+			want: []string{model.BatchStatusRolledBack, model.BatchStatusFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{contentItems: slices.Clone(items), failingIDs: tt.failingIDs}
+
+			result, err := testServices(storage).ApplyContentItemsBatch(actor, false, appID, "org", tt.operations)
+			if err != nil {
+				t.Fatalf("ApplyContentItemsBatch() error = %v", err)
+			}
+
+			applied := !slices.Contains(tt.want, model.BatchStatusFailed)
+			got := []string{}
+			for i, operationResult := range result.Results {
+				got = append(got, operationResult.Status)
+				if operationResult.Index != i || operationResult.Op != tt.operations[i].Op {
+					t.Errorf("result %d = %+v, want the operation %s", i, operationResult, tt.operations[i].Op)
+				}
+				if (operationResult.Status == model.BatchStatusFailed) != (len(operationResult.Error) > 0) {
+					t.Errorf("result %d = %+v, want an error only when it failed", i, operationResult)
+				}
+				if operationResult.Status != model.BatchStatusApplied && operationResult.Item != nil {
+					t.Errorf("result %d = %+v, want no item when it is not stored", i, operationResult)
+				}
+			}
+			if result.Applied != applied || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ApplyContentItemsBatch() = %v %v, want %v %v", result.Applied, got, applied, tt.want)
+			}
+
+			if !applied {
+				if !reflect.DeepEqual(storage.contentItems, items) || len(storage.versions) != 0 || len(storage.auditLog) != 0 {
+					t.Errorf("stored items = %+v, versions = %+v, audit log = %+v, want the batch rolled back", storage.contentItems, storage.versions, storage.auditLog)
+				}
+				return
+			}
+			stored := map[string]interface{}{}
+			for _, item := range storage.contentItems {
+				stored[item.ID] = item.Data
+			}
+			want := map[string]interface{}{"a": "d", result.Results[0].ID: "c"}
+			if !reflect.DeepEqual(stored, want) || len(storage.versions) != 1 || len(storage.auditLog) != 3 {
+				t.Errorf("stored items = %v, versions = %+v, audit log = %+v, want %v stored", stored, storage.versions, storage.auditLog, want)
+			}
+		})
+	}
+}
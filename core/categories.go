@@ -0,0 +1,261 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxCategoryDepth bounds the walks up the category tree, so a broken tree cannot make them loop
+const maxCategoryDepth = 32
+
+const (
+	categoryAccessWrite  = "write"
+	categoryAccessDelete = "delete"
+)
+
+// findCategoryFunc finds a category by name, it gives nil when there is none
+type findCategoryFunc func(name string) (*model.Category, error)
+
+func storageCategoryFinder(storage interfaces.Storage, appID *string, orgID string) findCategoryFunc {
+	return func(name string) (*model.Category, error) {
+		category, err := storage.FindCategory(appID, orgID, name)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return category, err
+	}
+}
+
+func loadedCategoryFinder(categories map[string]model.Category) findCategoryFunc {
+	return func(name string) (*model.Category, error) {
+		if category, ok := categories[name]; ok {
+			return &category, nil
+		}
+		return nil, nil
+	}
+}
+
+// effectiveCategoryAccess gives the access of a category. Every permission list the category does not set (nil) comes from its closest ancestor which sets it,
+// an empty list is set and overrides the ancestors. The items require an authenticated user when the category or any of its ancestors requires it.
+func effectiveCategoryAccess(category *model.Category, findCategory findCategoryFunc) (*model.CategoryAccess, error) {
+	var access model.CategoryAccess
+	current := category
+	for depth := 0; current != nil && depth < maxCategoryDepth; depth++ {
+		if access.ReadPermissions == nil && current.ReadPermissions != nil {
+			access.ReadPermissions = current.ReadPermissions
+		}
+		if access.Permissions == nil && current.Permissions != nil {
+			access.Permissions = current.Permissions
+		}
+		if access.DeletePermissions == nil && current.DeletePermissions != nil {
+			access.DeletePermissions = current.DeletePermissions
+		}
+		access.RequiresAuth = access.RequiresAuth || current.RequiresAuth
+
+		if len(current.Parent) == 0 {
+			break
+		}
+		parent, err := findCategory(current.Parent)
+		if err != nil {
+			return nil, err
+		}
+		//a category which parent is gone is treated as a top level one
+		current = parent
+	}
+
+	if access.DeletePermissions == nil {
+		access.DeletePermissions = access.Permissions
+	}
+	for _, permissions := range []*[]string{&access.ReadPermissions, &access.Permissions, &access.DeletePermissions} {
+		if *permissions == nil {
+			*permissions = []string{}
+		}
+	}
+	return &access, nil
+}
+
+// effectiveFilePolicy gives the file policy of a category, a category without one uses the policy of its closest ancestor which has one.
+// It gives nil when none of them has a policy.
+func effectiveFilePolicy(category *model.Category, findCategory findCategoryFunc) (*model.FilePolicy, error) {
+	current := category
+	for depth := 0; current != nil && depth < maxCategoryDepth; depth++ {
+		if current.FilePolicy != nil {
+			return current.FilePolicy, nil
+		}
+		if len(current.Parent) == 0 {
+			break
+		}
+		parent, err := findCategory(current.Parent)
+		if err != nil {
+			return nil, err
+		}
+		current = parent
+	}
+	return nil, nil
+}
+
+// categoryPermissions gives the effective permissions of a category for an access - write or delete
+func categoryPermissions(storage interfaces.Storage, category *model.Category, access string) ([]string, error) {
+	effective, err := effectiveCategoryAccess(category, storageCategoryFinder(storage, category.AppID, category.OrgID))
+	if err != nil {
+		return nil, err
+	}
+	if access == categoryAccessDelete {
+		return effective.DeletePermissions, nil
+	}
+	return effective.Permissions, nil
+}
+
+// checkCategoryRead checks the claims may read the items of a category, the items of the categories without a record can be read by anyone
+func checkCategoryRead(storage interfaces.Storage, claims *tokenauth.Claims, category string) error {
+	access, err := findCategoryAccess(storage, claims.AppID, claims.OrgID, category)
+	if err != nil {
+		return err
+	}
+	if access != nil && !canReadCategory(access, claims) {
+		return &model.CategoryAccessError{Category: category}
+	}
+	return nil
+}
+
+// findCategoryAccess gives the access of a category with what it inherits, nil when there is no such category
+func findCategoryAccess(storage interfaces.Storage, appID string, orgID string, category string) (*model.CategoryAccess, error) {
+	categoryItem, err := storage.FindCategory(&appID, orgID, category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return effectiveCategoryAccess(categoryItem, storageCategoryFinder(storage, &appID, orgID))
+}
+
+// checkCategoryAccess checks the claims may write or delete the items of a category, the category must exist
+func checkCategoryAccess(storage interfaces.Storage, claims *tokenauth.Claims, category string, access string) error {
+	categoryItem, err := storage.FindCategory(&claims.AppID, claims.OrgID, category)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := categoryPermissions(storage, categoryItem, access)
+	if err != nil {
+		return err
+	}
+	if !checkPermissions(permissions, claims.Permissions) {
+		return &model.CategoryAccessError{Category: category, Access: access}
+	}
+	return nil
+}
+
+func canReadCategory(access *model.CategoryAccess, claims *tokenauth.Claims) bool {
+	if access.RequiresAuth && claims.Anonymous {
+		return false
+	}
+	return len(access.ReadPermissions) == 0 || checkPermissions(access.ReadPermissions, claims.Permissions)
+}
+
+// validateCategoryParent checks the parent of a category exists and it is not the category itself or one of its subcategories.
+// names are the names the category has - the current and the new one when it is renamed.
+func validateCategoryParent(storage interfaces.Storage, appID string, orgID string, parent string, names ...string) error {
+	if len(parent) == 0 {
+		return nil
+	}
+
+	current := parent
+	for depth := 0; len(current) > 0; depth++ {
+		for _, name := range names {
+			if current == name {
+				return &model.CategoryHierarchyError{Category: names[0], Reason: "a category cannot be placed under itself or its subcategories"}
+			}
+		}
+		if depth == maxCategoryDepth {
+			return &model.CategoryHierarchyError{Category: names[0], Reason: "the category tree is too deep"}
+		}
+
+		category, err := storage.FindCategory(&appID, orgID, current)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				if current == parent {
+					return &model.CategoryHierarchyError{Category: names[0], Reason: "parent category " + parent + " does not exist"}
+				}
+				return nil
+			}
+			return err
+		}
+		current = category.Parent
+	}
+	return nil
+}
+
+// loadCategoryTree gives all the categories of an app by name and the names of their subcategories by parent name
+func loadCategoryTree(storage interfaces.Storage, appID string, orgID string) (map[string]model.Category, map[string][]string, error) {
+	categories := map[string]model.Category{}
+	children := map[string][]string{}
+	err := storage.IterateCategories(&appID, orgID, func(item model.Category) error {
+		categories[item.Name] = item
+		if len(item.Parent) > 0 {
+			children[item.Parent] = append(children[item.Parent], item.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return categories, children, nil
+}
+
+// descendantCategories gives the name of a category followed by the names of all its descendants
+func descendantCategories(children map[string][]string, name string) []string {
+	result := []string{name}
+	visited := map[string]bool{name: true}
+	for i := 0; i < len(result); i++ {
+		for _, child := range children[result[i]] {
+			if !visited[child] {
+				visited[child] = true
+				result = append(result, child)
+			}
+		}
+	}
+	return result
+}
+
+// buildCategoryTree builds the subtree of a category from all the categories of the app
+func buildCategoryTree(categories map[string]model.Category, children map[string][]string, category model.Category, visited map[string]bool) (model.CategoryTree, error) {
+	visited[category.Name] = true
+
+	access, err := effectiveCategoryAccess(&category, loadedCategoryFinder(categories))
+	if err != nil {
+		return model.CategoryTree{}, err
+	}
+
+	tree := model.CategoryTree{Category: category, Effective: *access, Children: []model.CategoryTree{}}
+	for _, child := range children[category.Name] {
+		if visited[child] {
+			continue
+		}
+		subtree, err := buildCategoryTree(categories, children, categories[child], visited)
+		if err != nil {
+			return model.CategoryTree{}, err
+		}
+		tree.Children = append(tree.Children, subtree)
+	}
+	return tree, nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+func TestEffectiveCategoryAccessInheritance(t *testing.T) {
+	categories := map[string]model.Category{
+		"root":     {Name: "root", Permissions: []string{"root_write"}},
+		"child":    {Name: "child", Parent: "root"},
+		"grand":    {Name: "grand", Parent: "child", Permissions: []string{"grand_write"}},
+		"leaf":     {Name: "leaf", Parent: "grand"},
+		"orphan":   {Name: "orphan", Parent: "gone"},
+		"cycle-a":  {Name: "cycle-a", Parent: "cycle-b"},
+		"cycle-b":  {Name: "cycle-b", Parent: "cycle-a"},
+		"empty":    {Name: "empty", Parent: "root", Permissions: []string{}},
+		"unset":    {Name: "unset", Parent: "root", Permissions: nil},
+		"top":      {Name: "top"},
+		"auth":     {Name: "auth", RequiresAuth: true},
+		"auth-sub": {Name: "auth-sub", Parent: "auth", Permissions: []string{"sub_write"}},
+	}
+
+	tests := []struct {
+		name string
+		want model.CategoryAccess
+	}{
+		{name: "root", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_write"}}},
+		{name: "child", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_write"}}},
+		{name: "grand", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"grand_write"}, DeletePermissions: []string{"grand_write"}}},
+		{name: "leaf", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"grand_write"}, DeletePermissions: []string{"grand_write"}}},
+		{name: "orphan", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{}, DeletePermissions: []string{}}},
+		{name: "cycle-a", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{}, DeletePermissions: []string{}}},
+		{name: "empty", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{}, DeletePermissions: []string{}}},
+		{name: "unset", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_write"}}},
+		{name: "top", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{}, DeletePermissions: []string{}}},
+		{name: "auth-sub", want: model.CategoryAccess{RequiresAuth: true, ReadPermissions: []string{}, Permissions: []string{"sub_write"}, DeletePermissions: []string{"sub_write"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := categories[tt.name]
+			got, err := effectiveCategoryAccess(&category, loadedCategoryFinder(categories))
+			if err != nil {
+				t.Fatalf("effectiveCategoryAccess() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("effectiveCategoryAccess() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveCategoryAccessFindError(t *testing.T) {
+	findErr := errors.New("storage is down")
+	category := model.Category{Name: "child", Parent: "root"}
+
+	_, err := effectiveCategoryAccess(&category, func(name string) (*model.Category, error) {
+		return nil, findErr
+	})
+	if !errors.Is(err, findErr) {
+		t.Errorf("effectiveCategoryAccess() error = %v, want %v", err, findErr)
+	}
+}
+
+func TestEffectiveCategoryAccessSeparatePermissions(t *testing.T) {
+	categories := map[string]model.Category{
+		"root":       {Name: "root", ReadPermissions: []string{"root_read"}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_delete"}},
+		"read":       {Name: "read", Parent: "root", ReadPermissions: []string{"read"}},
+		"write":      {Name: "write", Parent: "root", Permissions: []string{"write"}},
+		"write-only": {Name: "write-only", Permissions: []string{"write"}},
+		"delete":     {Name: "delete", Permissions: []string{"write"}, DeletePermissions: []string{"delete"}},
+		"public":     {Name: "public", Parent: "root", ReadPermissions: []string{}},
+		"no-delete":  {Name: "no-delete", Parent: "root", DeletePermissions: []string{}},
+		"public-sub": {Name: "public-sub", Parent: "public"},
+	}
+
+	tests := []struct {
+		name string
+		want model.CategoryAccess
+	}{
+		{name: "root", want: model.CategoryAccess{ReadPermissions: []string{"root_read"}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_delete"}}},
+		{name: "read", want: model.CategoryAccess{ReadPermissions: []string{"read"}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_delete"}}},
+		{name: "write", want: model.CategoryAccess{ReadPermissions: []string{"root_read"}, Permissions: []string{"write"}, DeletePermissions: []string{"root_delete"}}},
+		{name: "write-only", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"write"}, DeletePermissions: []string{"write"}}},
+		{name: "delete", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"write"}, DeletePermissions: []string{"delete"}}},
+		{name: "public", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_delete"}}},
+		{name: "no-delete", want: model.CategoryAccess{ReadPermissions: []string{"root_read"}, Permissions: []string{"root_write"}, DeletePermissions: []string{}}},
+		{name: "public-sub", want: model.CategoryAccess{ReadPermissions: []string{}, Permissions: []string{"root_write"}, DeletePermissions: []string{"root_delete"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := categories[tt.name]
+			got, err := effectiveCategoryAccess(&category, loadedCategoryFinder(categories))
+			if err != nil {
+				t.Fatalf("effectiveCategoryAccess() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("effectiveCategoryAccess() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanReadCategory(t *testing.T) {
+	tests := []struct {
+		name   string
+		access model.CategoryAccess
+		claims tokenauth.Claims
+		want   bool
+	}{
+		{name: "public", access: model.CategoryAccess{}, claims: tokenauth.Claims{Anonymous: true}, want: true},
+		{name: "authenticated user", access: model.CategoryAccess{RequiresAuth: true}, claims: tokenauth.Claims{}, want: true},
+		{name: "anonymous user", access: model.CategoryAccess{RequiresAuth: true}, claims: tokenauth.Claims{Anonymous: true}},
+		{name: "read permission", access: model.CategoryAccess{ReadPermissions: []string{"a", "b"}}, claims: tokenauth.Claims{Permissions: "c,b"}, want: true},
+		{name: "no read permission", access: model.CategoryAccess{ReadPermissions: []string{"a"}}, claims: tokenauth.Claims{Permissions: "c"}},
+		{name: "write permission does not read", access: model.CategoryAccess{ReadPermissions: []string{"a"}, Permissions: []string{"w"}}, claims: tokenauth.Claims{Permissions: "w"}},
+		{name: "anonymous user with permission", access: model.CategoryAccess{RequiresAuth: true, ReadPermissions: []string{"a"}}, claims: tokenauth.Claims{Anonymous: true, Permissions: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canReadCategory(&tt.access, &tt.claims); got != tt.want {
+				t.Errorf("canReadCategory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// categoryTestStorage gives the storage of the category management tests - events with a subcategory, items, history, a file record and a webhook
+func categoryTestStorage() *memoryStorage {
+	appID := "app"
+	return &memoryStorage{
+		categories: []model.Category{
+			{ID: "1", Name: "events", AppID: &appID, OrgID: "org"},
+			{ID: "2", Name: "concerts", Parent: "events", AppID: &appID, OrgID: "org"},
+			{ID: "3", Name: "news", AppID: &appID, OrgID: "org"},
+		},
+		contentItems: []model.ContentItem{
+			{ID: "event", Category: "events", Data: "a", AppID: &appID, OrgID: "org"},
+			{ID: "article", Category: "news", Data: "a", AppID: &appID, OrgID: "org"},
+		},
+		versions:         []model.ContentItemVersion{{ID: "v1", ContentItemID: "event", Version: 1, Category: "events", AppID: &appID, OrgID: "org"}},
+		dataContentItems: []model.DataContentItem{{ID: "d1", Key: "schedule", Category: "events", Data: "a", AppID: &appID, OrgID: "org"}},
+		fileContentItems: []model.FileContentItem{{ID: "f1", Key: "flyer", Path: "org/app/events/flyer", Category: "events", AppID: appID, OrgID: "org",
+			Quarantined: true}},
+		webhooks: []model.Webhook{{ID: "w1", AppID: &appID, OrgID: "org", Categories: []string{"news", "events"}}},
+	}
+}
+
+func TestRenameCategory(t *testing.T) {
+	actor := &model.AuditActor{AccountID: "admin", AppID: "app", OrgID: "org"}
+	claims := &tokenauth.Claims{AppID: "app", OrgID: "org"}
+
+	t.Run("rename", func(t *testing.T) {
+		storage := categoryTestStorage()
+		services := testServices(storage)
+		services.app.objectStorage = &memoryObjectStorage{}
+
+		item, err := services.RenameCategory(actor, claims, "events", "happenings")
+		if err != nil {
+			t.Fatalf("RenameCategory() error = %v", err)
+		}
+		if item.Name != "happenings" || storage.categories[0].Name != "happenings" {
+			t.Errorf("RenameCategory() = %+v, stored %+v, want it renamed", item, storage.categories[0])
+		}
+		got := []string{storage.categories[1].Parent, storage.contentItems[0].Category, storage.contentItems[1].Category, storage.versions[0].Category,
+			storage.dataContentItems[0].Category, storage.fileContentItems[0].Category}
+		want := []string{"happenings", "happenings", "news", "happenings", "happenings", "happenings"}
+		if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(storage.webhooks[0].Categories, []string{"news", "happenings"}) {
+			t.Errorf("references = %v, webhook categories = %v, want them moved to the new name", got, storage.webhooks[0].Categories)
+		}
+		if len(storage.auditLog) != 1 || storage.auditLog[0].Resource != model.AuditResourceCategory {
+			t.Errorf("audit log = %+v, want the category update", storage.auditLog)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		newName string
+		objects map[string][]byte
+		wantErr error
+	}{
+		{name: "to an existing name", newName: "news", wantErr: &model.CategoryExistsError{Category: "news"}},
+		{name: "with files", newName: "happenings", objects: map[string][]byte{objectID(model.ObjectBucketContent, "org/app/events/flyer"): []byte("a")},
+			wantErr: &model.CategoryInUseError{Category: "events", Files: 1}},
+		{name: "with pending uploads", newName: "happenings", objects: map[string][]byte{objectID(model.ObjectBucketContent, pendingUploadsPrefix+"public/org/app/events/flyer"): []byte("a")},
+			wantErr: &model.CategoryInUseError{Category: "events", Files: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := categoryTestStorage()
+			services := testServices(storage)
+			services.app.objectStorage = &memoryObjectStorage{objects: tt.objects}
+
+			_, err := services.RenameCategory(actor, claims, "events", tt.newName)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("RenameCategory() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(storage, categoryTestStorage()) {
+				t.Errorf("storage = %+v, want it unchanged", storage)
+			}
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	actor := &model.AuditActor{AccountID: "admin", AppID: "app", OrgID: "org"}
+	claims := &tokenauth.Claims{AppID: "app", OrgID: "org"}
+	quarantined := objectID(model.ObjectBucketQuarantine, "org/app/events/flyer")
+
+	tests := []struct {
+		name     string
+		category string
+		cascade  bool
+		objects  map[string][]byte
+		wantErr  error
+	}{
+		{name: "items without cascade", category: "news", wantErr: &model.CategoryInUseError{Category: "news", ContentItems: 1}},
+		{name: "subcategories", category: "events", cascade: true, wantErr: &model.CategoryInUseError{Category: "events", ContentItems: 1, DataContentItems: 1, Subcategories: 1}},
+		{name: "files", category: "concerts", cascade: true, objects: map[string][]byte{objectID(model.ObjectBucketContent, "org/app/concerts/poster"): []byte("a")},
+			wantErr: &model.CategoryInUseError{Category: "concerts", Files: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := categoryTestStorage()
+			services := testServices(storage)
+			services.app.objectStorage = &memoryObjectStorage{objects: tt.objects}
+
+			err := services.DeleteCategory(actor, claims, tt.category, tt.cascade)
+			var inUseErr *model.CategoryInUseError
+			if !errors.As(err, &inUseErr) || !reflect.DeepEqual(inUseErr, tt.wantErr) {
+				t.Fatalf("DeleteCategory() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(storage, categoryTestStorage()) {
+				t.Errorf("storage = %+v, want it unchanged", storage)
+			}
+		})
+	}
+
+	t.Run("cascade", func(t *testing.T) {
+		storage := categoryTestStorage()
+		services := testServices(storage)
+		objects := &memoryObjectStorage{objects: map[string][]byte{quarantined: []byte("a")}}
+		services.app.objectStorage = objects
+
+		//the subcategory goes first
+		err := services.DeleteCategory(actor, claims, "concerts", false)
+		if err != nil {
+			t.Fatalf("DeleteCategory() error = %v", err)
+		}
+		err = services.DeleteCategory(actor, claims, "events", true)
+		if err != nil {
+			t.Fatalf("DeleteCategory() error = %v", err)
+		}
+
+		if len(storage.categories) != 1 || storage.categories[0].Name != "news" || len(storage.contentItems) != 1 || storage.contentItems[0].ID != "article" {
+			t.Errorf("categories = %+v, items = %+v, want only the news left", storage.categories, storage.contentItems)
+		}
+		if len(storage.versions) != 0 || len(storage.dataContentItems) != 0 || len(storage.fileContentItems) != 0 || len(objects.objects) != 0 {
+			t.Errorf("versions = %+v, data items = %+v, files = %+v, objects = %v, want them deleted with the category",
+				storage.versions, storage.dataContentItems, storage.fileContentItems, objects.objects)
+		}
+		operations := map[string]int{}
+		for _, entry := range storage.auditLog {
+			operations[entry.Resource+" "+entry.Operation]++
+		}
+		want := map[string]int{"category delete": 2, "content_item delete": 1, "data_content_item delete": 1}
+		if !reflect.DeepEqual(operations, want) {
+			t.Errorf("audit log operations = %v, want %v", operations, want)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+// fileHeadSize is how much of the beginning of a file the MIME type is detected from, the default limit of mimetype
+const fileHeadSize = 3072
+
+// fileInspector gathers the size, the checksum and the head of a file while it is written to it
+type fileInspector struct {
+	size int64
+	hash hash.Hash
+	head []byte
+}
+
+func newFileInspector() *fileInspector {
+	return &fileInspector{hash: sha256.New(), head: make([]byte, 0, fileHeadSize)}
+}
+
+func (f *fileInspector) Write(p []byte) (int, error) {
+	f.size += int64(len(p))
+	f.hash.Write(p)
+	if missing := fileHeadSize - len(f.head); missing > 0 {
+		f.head = append(f.head, p[:min(missing, len(p))]...)
+	}
+	return len(p), nil
+}
+
+func (f *fileInspector) detect() *mimetype.MIME {
+	return mimetype.Detect(f.head)
+}
+
+// fileContentItem gives the record of the inspected file
+func (f *fileInspector) fileContentItem(claims *tokenauth.Claims, key string, path string, category string, entityID string) model.FileContentItem {
+	return model.FileContentItem{ID: uuid.NewString(), AppID: claims.AppID, OrgID: claims.OrgID, Key: key, Path: path,
+		Category: category, EntityID: entityID, AccountID: claims.Subject, Size: f.size,
+		MimeType: f.detect().String(), Checksum: hex.EncodeToString(f.hash.Sum(nil))}
+}
+
+// allowedMimeType checks a detected MIME type against a policy, a type is allowed when it or one of its parents is allowed -
+// like a docx file when application/zip is allowed. application/octet-stream allows only the files of unknown types.
+func allowedMimeType(policy *model.FilePolicy, detected *mimetype.MIME) bool {
+	if policy == nil || len(policy.AllowedMimeTypes) == 0 {
+		return true
+	}
+	for current := detected; current != nil; current = current.Parent() {
+		if current != detected && current.Parent() == nil {
+			//every type descends from application/octet-stream
+			break
+		}
+		for _, allowed := range policy.AllowedMimeTypes {
+			if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(current.String(), prefix+"/") {
+				return true
+			}
+			if current.Is(allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spooledFile is an uploaded file kept in a temporary file while it is checked
+type spooledFile struct {
+	file      *os.File
+	inspector *fileInspector
+}
+
+// spoolFile copies a file to a temporary file and checks it against the file policy of its category, it stops reading at the max size
+func spoolFile(body io.Reader, policy *model.FilePolicy, category string) (*spooledFile, error) {
+	file, err := os.CreateTemp("", "content-file-*")
+	if err != nil {
+		return nil, err
+	}
+	spool := &spooledFile{file: file, inspector: newFileInspector()}
+
+	if policy != nil && policy.MaxSize > 0 {
+		body = io.LimitReader(body, policy.MaxSize+1)
+	}
+	_, err = io.Copy(file, io.TeeReader(body, spool.inspector))
+	if err != nil {
+		spool.close()
+		return nil, err
+	}
+	if policy != nil && policy.MaxSize > 0 && spool.inspector.size > policy.MaxSize {
+		spool.close()
+		return nil, &model.FilePolicyError{Category: category, Reason: model.FilePolicySize, MaxSize: policy.MaxSize}
+	}
+	detected := spool.inspector.detect()
+	if !allowedMimeType(policy, detected) {
+		spool.close()
+		return nil, &model.FilePolicyError{Category: category, Reason: model.FilePolicyMimeType, MimeType: detected.String()}
+	}
+
+	err = spool.rewind()
+	if err != nil {
+		spool.close()
+		return nil, err
+	}
+	return spool, nil
+}
+
+func (f *spooledFile) rewind() error {
+	_, err := f.file.Seek(0, io.SeekStart)
+	return err
+}
+
+func (f *spooledFile) close() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// scanFile scans a spooled file, the files are clean when there is no scanner
+func (s *servicesImpl) scanFile(spool *spooledFile) (*model.ScanResult, error) {
+	if s.app.scanner == nil {
+		return &model.ScanResult{Clean: true}, nil
+	}
+
+	result, err := s.app.scanner.Scan(spool.file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan the file: %s", err)
+	}
+	err = spool.rewind()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// quarantineFile keeps a flagged file in the quarantine bucket with its own record, the record of the accepted file at the path stays.
+// It gives the error the upload fails with.
+func (s *servicesImpl) quarantineFile(claims *tokenauth.Claims, spool *spooledFile, key string, path string, category string, entityID string, signature string) error {
+	s.app.logger.Errorf("file %s of category %s is flagged - %s", path, category, signature)
+
+	_, err := s.app.objectStorage.Upload(model.ObjectBucketQuarantine, path, spool.file, false)
+	if err != nil {
+		//the file is not accepted anyway
+		s.app.logger.Errorf("error on quarantining file %s - %s", path, err)
+	} else {
+		item := spool.inspector.fileContentItem(claims, key, path, category, entityID)
+		item.Quarantined = true
+		item.Signature = signature
+		_, err = s.app.storage.SaveFileContentItem(item)
+		if err != nil {
+			s.app.logger.Errorf("error on recording quarantined file %s - %s", path, err)
+		}
+	}
+	return &model.FileQuarantinedError{Key: key, Signature: signature}
+}
+
+// pendingUploadsPrefix is where the files uploaded directly to the object storage wait for their check, they are not served from there
+const pendingUploadsPrefix = "pending_uploads/"
+
+// pendingUploadPath gives the key a file uploaded directly to the object storage is kept under until it is checked,
+// the key says whether the file is public once it is moved to its path
+func pendingUploadPath(path string, public bool) string {
+	visibility := "private/"
+	if public {
+		visibility = "public/"
+	}
+	return pendingUploadsPrefix + visibility + strings.TrimPrefix(path, "/")
+}
+
+// openPendingUpload opens a file waiting in the pending uploads, it gives its key and whether it is public once it is moved to its path
+func (s *servicesImpl) openPendingUpload(path string) (io.ReadCloser, string, bool, error) {
+	for _, public := range []bool{false, true} {
+		pendingPath := pendingUploadPath(path, public)
+		object, err := s.app.objectStorage.Download(model.ObjectBucketContent, pendingPath)
+		if err == nil {
+			return object, pendingPath, public, nil
+		}
+		if !errors.Is(err, model.ErrObjectNotFound) {
+			return nil, "", false, err
+		}
+	}
+	return nil, "", false, fmt.Errorf("%w: %s", model.ErrObjectNotFound, path)
+}
+
+// reconcileFileContentItem checks and records a file which was uploaded directly to the object storage, then moves it from the pending uploads to its path.
+// A file which is not accepted is removed from the pending uploads and the file at the path stays. It gives the location of the file.
+func (s *servicesImpl) reconcileFileContentItem(claims *tokenauth.Claims, key string, path string, category string, entityID string) (*model.FileContentItem, string, error) {
+	categoryItem, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, category)
+	if err != nil {
+		return nil, "", err
+	}
+	policy, err := effectiveFilePolicy(categoryItem, storageCategoryFinder(s.app.storage, &claims.AppID, claims.OrgID))
+	if err != nil {
+		return nil, "", err
+	}
+
+	object, pendingPath, public, err := s.openPendingUpload(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer object.Close()
+
+	spool, err := spoolFile(object, policy, category)
+	if err != nil {
+		var policyErr *model.FilePolicyError
+		if errors.As(err, &policyErr) {
+			s.removePendingUpload(pendingPath)
+		}
+		return nil, "", err
+	}
+	defer spool.close()
+
+	previous, err := s.findFileContentItem(claims, path)
+	if err != nil {
+		return nil, "", err
+	}
+	err = checkStorageQuota(s.app.storage, claims.AppID, claims.OrgID, category, spool.inspector.size-fileSize(previous))
+	if err != nil {
+		var quotaErr *model.StorageQuotaExceededError
+		if errors.As(err, &quotaErr) {
+			s.removePendingUpload(pendingPath)
+		}
+		return nil, "", err
+	}
+
+	//a file which cannot be scanned stays pending, it may be confirmed again
+	result, err := s.scanFile(spool)
+	if err != nil {
+		return nil, "", err
+	}
+	if !result.Clean {
+		quarantineErr := s.quarantineFile(claims, spool, key, path, category, entityID, result.Signature)
+		s.removePendingUpload(pendingPath)
+		return nil, "", quarantineErr
+	}
+
+	location, err := s.app.objectStorage.Upload(model.ObjectBucketContent, path, spool.file, public)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to move the file from the pending uploads: %s", err)
+	}
+	item, err := s.app.storage.SaveFileContentItem(spool.inspector.fileContentItem(claims, key, path, category, entityID))
+	if err != nil {
+		return nil, "", err
+	}
+	s.recordFileUsage(claims, previous, item)
+	s.removePendingUpload(pendingPath)
+	return item, location, nil
+}
+
+// removePendingUpload removes a file from the pending uploads, the sweeper removes it later when this fails
+func (s *servicesImpl) removePendingUpload(pendingPath string) {
+	err := s.app.objectStorage.Delete(model.ObjectBucketContent, pendingPath)
+	if err != nil {
+		s.app.logger.Errorf("error on deleting pending upload %s - %s", pendingPath, err)
+	}
+}
+
+func (s *servicesImpl) ConfirmFileContentUploads(actor *model.AuditActor, claims *tokenauth.Claims, fileKeys []string, entityID string, category string,
+	addAppOrgIDToPath bool) ([]model.FileContentItem, error) {
+	err := checkCategoryAccess(s.app.storage, claims, category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.FileContentItem, len(fileKeys))
+	for i, key := range fileKeys {
+		path := s.getFilePath(claims, key, category, entityID, addAppOrgIDToPath)
+		item, _, err := s.reconcileFileContentItem(claims, key, path, category, entityID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to confirm the upload of %s: %w", key, err)
+		}
+		items[i] = *item
+
+		s.auditCommitted(actor, model.AuditResourceFile, key, category, model.AuditOperationCreate, nil, map[string]interface{}{"path": path})
+	}
+	return items, nil
+}
+
+func (s *servicesImpl) GetFileContentItems(claims *tokenauth.Claims, category string, entityID *string, search *string, offset *int64, limit *int64) ([]model.FileContentItem, error) {
+	err := checkCategoryRead(s.app.storage, claims, category)
+	if err != nil {
+		return nil, err
+	}
+	quarantined := false
+	return s.app.storage.FindFileContentItems(&claims.AppID, claims.OrgID, &category, entityID, nil, search, nil, &quarantined, offset, limit)
+}
+
+func (s *servicesImpl) ListFileContentItems(allApps bool, appID string, orgID string, category *string, entityID *string, accountID *string, search *string,
+	mimeType *string, quarantined *bool, offset *int64, limit *int64) ([]model.FileContentItem, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+	return s.app.storage.FindFileContentItems(appIDParam, orgID, category, entityID, accountID, search, mimeType, quarantined, offset, limit)
+}
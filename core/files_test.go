@@ -0,0 +1,242 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+func TestAllowedMimeType(t *testing.T) {
+	png := mimetype.Detect([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	text := mimetype.Detect([]byte("plain text"))
+	unknown := mimetype.Detect([]byte{0x00, 0x01, 0x02, 0x03, 0xfe, 0xff})
+	docx := mimetype.Lookup("application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+
+	tests := []struct {
+		name     string
+		policy   *model.FilePolicy
+		detected *mimetype.MIME
+		want     bool
+	}{
+		{name: "no policy", policy: nil, detected: png, want: true},
+		{name: "no allowed types", policy: &model.FilePolicy{MaxSize: 10}, detected: png, want: true},
+		{name: "exact type", policy: &model.FilePolicy{AllowedMimeTypes: []string{"image/png"}}, detected: png, want: true},
+		{name: "other type", policy: &model.FilePolicy{AllowedMimeTypes: []string{"image/jpeg"}}, detected: png},
+		{name: "wildcard", policy: &model.FilePolicy{AllowedMimeTypes: []string{"image/*"}}, detected: png, want: true},
+		{name: "other wildcard", policy: &model.FilePolicy{AllowedMimeTypes: []string{"video/*"}}, detected: png},
+		{name: "parent type", policy: &model.FilePolicy{AllowedMimeTypes: []string{"application/zip"}}, detected: docx, want: true},
+		{name: "child type does not allow the parent", policy: &model.FilePolicy{AllowedMimeTypes: []string{docx.String()}}, detected: mimetype.Lookup("application/zip")},
+		{name: "text with charset", policy: &model.FilePolicy{AllowedMimeTypes: []string{"text/plain"}}, detected: text, want: true},
+		{name: "octet stream allows unknown types", policy: &model.FilePolicy{AllowedMimeTypes: []string{"application/octet-stream"}}, detected: unknown, want: true},
+		{name: "octet stream does not allow everything", policy: &model.FilePolicy{AllowedMimeTypes: []string{"application/octet-stream"}}, detected: png},
+		{name: "one of the types", policy: &model.FilePolicy{AllowedMimeTypes: []string{"application/pdf", "image/png"}}, detected: png, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowedMimeType(tt.policy, tt.detected); got != tt.want {
+				t.Errorf("allowedMimeType(%s) = %v, want %v", tt.detected, got, tt.want)
+			}
+		})
+	}
+}
+
+// signatureScanner flags the files which contain its signature
+type signatureScanner struct {
+	signature string
+}
+
+func (s signatureScanner) Scan(body io.Reader) (*model.ScanResult, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), s.signature) {
+		return &model.ScanResult{Signature: s.signature}, nil
+	}
+	return &model.ScanResult{Clean: true}, nil
+}
+
+func checksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestConfirmFileContentUploads(t *testing.T) {
+	services, storage, objects := uploadsTestServices()
+	services.app.scanner = signatureScanner{signature: "virus"}
+	claims := uploaderClaims("app", "account")
+	path := "org/app/events/entity/a.txt"
+
+	confirm := func(data string) (*model.FileContentItem, error) {
+		objects.Upload(model.ObjectBucketContent, pendingUploadPath(path, false), strings.NewReader(data), false)
+		items, err := services.ConfirmFileContentUploads(nil, claims, []string{"a.txt"}, "entity", "events", true)
+		if err != nil {
+			return nil, err
+		}
+		return &items[0], nil
+	}
+
+	first, err := confirm("first")
+	if err != nil {
+		t.Fatalf("ConfirmFileContentUploads() error = %v", err)
+	}
+	want := model.FileContentItem{ID: first.ID, AppID: "app", OrgID: "org", Key: "a.txt", Path: path, Category: "events", EntityID: "entity",
+		AccountID: "account", Size: 5, MimeType: "text/plain; charset=utf-8", Checksum: checksum("first"), DateCreated: first.DateCreated, DateUpdated: first.DateUpdated}
+	if !reflect.DeepEqual(*first, want) {
+		t.Errorf("ConfirmFileContentUploads() = %+v, want %+v", *first, want)
+	}
+
+	//the file uploaded again to the same path replaces the record
+	second, err := confirm("second")
+	if err != nil {
+		t.Fatalf("ConfirmFileContentUploads() error = %v", err)
+	}
+	if second.ID != first.ID || second.Size != 6 || second.Checksum != checksum("second") {
+		t.Errorf("ConfirmFileContentUploads() = %+v, want the record %s with the size and the checksum of the new file", *second, first.ID)
+	}
+
+	//a flagged file has its own record, the accepted file stays
+	_, err = confirm("a virus")
+	if err == nil {
+		t.Fatalf("ConfirmFileContentUploads() of a flagged file error = nil, want an error")
+	}
+	if len(storage.fileContentItems) != 2 {
+		t.Fatalf("file records = %+v, want the accepted and the quarantined file", storage.fileContentItems)
+	}
+	accepted, err := storage.FindFileContentItem("app", "org", path)
+	if err != nil || accepted.Checksum != checksum("second") {
+		t.Errorf("FindFileContentItem() = %+v, %v, want the accepted file", accepted, err)
+	}
+	quarantined := storage.fileContentItems[1]
+	if !quarantined.Quarantined || quarantined.Signature != "virus" || quarantined.Checksum != checksum("a virus") {
+		t.Errorf("quarantined file record = %+v, want the flagged file with its signature", quarantined)
+	}
+
+	_, err = services.ConfirmFileContentUploads(nil, claims, []string{"missing.txt"}, "entity", "events", true)
+	if !errors.Is(err, model.ErrObjectNotFound) {
+		t.Errorf("ConfirmFileContentUploads() of a missing upload error = %v, want %v", err, model.ErrObjectNotFound)
+	}
+}
+
+func TestDeleteFileContentItem(t *testing.T) {
+	services, storage, objects := uploadsTestServices()
+	storage.categories[0].DeletePermissions = []string{"upload"}
+	claims := uploaderClaims("app", "account")
+	path := "org/app/events/a.txt"
+	objects.Upload(model.ObjectBucketContent, path, strings.NewReader("a"), false)
+	storage.fileContentItems = []model.FileContentItem{
+		{ID: "accepted", AppID: "app", OrgID: "org", Key: "a.txt", Path: path, Category: "events", Size: 1},
+		{ID: "quarantined", AppID: "app", OrgID: "org", Key: "a.txt", Path: path, Category: "events", Size: 1, Quarantined: true},
+		{ID: "other app", AppID: "other", OrgID: "org", Key: "a.txt", Path: path, Category: "events", Size: 1},
+	}
+
+	err := services.DeleteFileContentItem(nil, claims, "a.txt", "events")
+	if err != nil {
+		t.Fatalf("DeleteFileContentItem() error = %v", err)
+	}
+
+	var ids []string
+	for _, item := range storage.fileContentItems {
+		ids = append(ids, item.ID)
+	}
+	if want := []string{"quarantined", "other app"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("file records = %v, want %v", ids, want)
+	}
+	if len(storage.storageUsage) != 1 || storage.storageUsage[0].DeletedSize != 1 || storage.storageUsage[0].DeletedObjects != 1 {
+		t.Errorf("storage usage = %+v, want the deleted file", storage.storageUsage)
+	}
+}
+
+func TestFindFileContentItems(t *testing.T) {
+	services, storage, _ := uploadsTestServices()
+	appID := "app"
+	storage.categories = append(storage.categories, model.Category{Name: "staff", AppID: &appID, OrgID: "org", ReadPermissions: []string{"staff"}})
+	storage.fileContentItems = []model.FileContentItem{
+		{ID: "a", AppID: "app", OrgID: "org", Key: "a.png", Category: "events", EntityID: "1", AccountID: "account", MimeType: "image/png"},
+		{ID: "b", AppID: "app", OrgID: "org", Key: "B.pdf", Category: "events", EntityID: "2", AccountID: "other", MimeType: "application/pdf"},
+		{ID: "c", AppID: "app", OrgID: "org", Key: "c.png", Category: "events", AccountID: "account", MimeType: "image/png", Quarantined: true},
+		{ID: "d", AppID: "app", OrgID: "org", Key: "d.png", Category: "news", AccountID: "account", MimeType: "image/png"},
+		{ID: "e", AppID: "other", OrgID: "org", Key: "e.png", Category: "events", AccountID: "account", MimeType: "image/png"},
+		{ID: "f", AppID: "app", OrgID: "other", Key: "f.png", Category: "events", AccountID: "account", MimeType: "image/png"},
+		{ID: "g", AppID: "app", OrgID: "org", Key: "g.png", Category: "staff", AccountID: "account", MimeType: "image/png"},
+	}
+	claims := &tokenauth.Claims{AppID: "app", OrgID: "org"}
+	entityID := "2"
+	search := "b"
+	image := "image/"
+	quarantined := true
+
+	tests := []struct {
+		name    string
+		find    func() ([]model.FileContentItem, error)
+		want    []string
+		wantErr bool
+	}{
+		{name: "category", find: func() ([]model.FileContentItem, error) {
+			return services.GetFileContentItems(claims, "events", nil, nil, nil, nil)
+		}, want: []string{"a", "b"}},
+		{name: "entity", find: func() ([]model.FileContentItem, error) {
+			return services.GetFileContentItems(claims, "events", &entityID, nil, nil, nil)
+		}, want: []string{"b"}},
+		{name: "search ignores the case", find: func() ([]model.FileContentItem, error) {
+			return services.GetFileContentItems(claims, "events", nil, &search, nil, nil)
+		}, want: []string{"b"}},
+		{name: "category the user does not read", find: func() ([]model.FileContentItem, error) {
+			return services.GetFileContentItems(claims, "staff", nil, nil, nil, nil)
+		}, wantErr: true},
+		{name: "admin app", find: func() ([]model.FileContentItem, error) {
+			return services.ListFileContentItems(false, "app", "org", nil, nil, nil, nil, nil, nil, nil, nil)
+		}, want: []string{"a", "b", "c", "d", "g"}},
+		{name: "admin all apps", find: func() ([]model.FileContentItem, error) {
+			return services.ListFileContentItems(true, "app", "org", nil, nil, nil, nil, nil, nil, nil, nil)
+		}, want: []string{"a", "b", "c", "d", "e", "g"}},
+		{name: "admin images of the account", find: func() ([]model.FileContentItem, error) {
+			account := "account"
+			return services.ListFileContentItems(false, "app", "org", nil, nil, &account, nil, &image, nil, nil, nil)
+		}, want: []string{"a", "c", "d", "g"}},
+		{name: "admin quarantined", find: func() ([]model.FileContentItem, error) {
+			return services.ListFileContentItems(true, "app", "org", nil, nil, nil, nil, nil, &quarantined, nil, nil)
+		}, want: []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := tt.find()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("find error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, item.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,7 +12,10 @@ package interfaces
 
 import (
 	"content/core/model"
+	"content/utils"
+	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
 	"go.mongodb.org/mongo-driver/bson"
@@ -23,54 +26,121 @@ type Services interface {
 	GetVersion() string
 	GetStudentGuides(appID string, orgID string, ids []string) ([]bson.M, error)
 	GetStudentGuide(appID string, orgID string, id string) (bson.M, error)
-	CreateStudentGuide(appID string, orgID string, item bson.M) (bson.M, error)
-	UpdateStudentGuide(appID string, orgID string, id string, item bson.M) (bson.M, error)
-	DeleteStudentGuide(appID string, orgID string, id string) error
+	CreateStudentGuide(actor *model.AuditActor, appID string, orgID string, item bson.M) (bson.M, error)
+	UpdateStudentGuide(actor *model.AuditActor, appID string, orgID string, id string, item bson.M) (bson.M, error)
+	DeleteStudentGuide(actor *model.AuditActor, appID string, orgID string, id string) error
 
 	GetHealthLocations(appID string, orgID string, ids []string) ([]bson.M, error)
 	GetHealthLocation(appID string, orgID string, id string) (bson.M, error)
-	CreateHealthLocation(appID string, orgID string, item bson.M) (bson.M, error)
-	UpdateHealthLocation(appID string, orgID string, id string, item bson.M) (bson.M, error)
-	DeleteHealthLocation(appID string, orgID string, id string) error
+	CreateHealthLocation(actor *model.AuditActor, appID string, orgID string, item bson.M) (bson.M, error)
+	UpdateHealthLocation(actor *model.AuditActor, appID string, orgID string, id string, item bson.M) (bson.M, error)
+	DeleteHealthLocation(actor *model.AuditActor, appID string, orgID string, id string) error
 
 	//allApps says if the data is associated with the current app or it is for all the apps within the organization
 	GetContentItemsCategories(allApps bool, appID string, orgID string) ([]string, error)
-	GetContentItems(allApps bool, appID string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error)
-	GetContentItem(allApps bool, appID string, orgID string, id string) (*model.ContentItemResponse, error)
-	CreateContentItem(allApps bool, appID string, orgID string, category string, data interface{}) (*model.ContentItem, error)
-	UpdateContentItem(allApps bool, appID string, orgID string, id string, category string, data interface{}) (*model.ContentItem, error)
-	UpdateContentItemData(allApps bool, appID string, orgID string, id string, category string, data interface{}) (*model.ContentItem, error)
-	DeleteContentItem(allApps bool, appID string, orgID string, id string) error
-	DeleteContentItemByCategory(allApps bool, appID string, orgID string, id string, category string) error
-
-	UploadImage(imageBytes []byte, path string, spec model.ImageSpec) (*string, error)
+	GetContentItems(allApps bool, appID string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error)
+	GetContentItemsPage(allApps bool, appID string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter,
+		cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.ContentItemsPage, error)
+	GetContentItem(allApps bool, appID string, orgID string, id string, state *string, workflowState *string) (*model.ContentItemResponse, error)
+	CreateContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, category string, data interface{}, defaultLocale string, locales map[string]interface{}, publishAt *time.Time, expireAt *time.Time, workflowState string) (*model.ContentItem, error)
+	UpdateContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string, data interface{}, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], publishAt *time.Time, expireAt *time.Time, ifMatch []string) (*model.ContentItem, error)
+	PatchContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, patch model.DataPatch, ifMatch []string) (*model.ContentItem, error)
+	UpdateContentItemData(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string, data interface{}, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], publishAt *time.Time, expireAt *time.Time) (*model.ContentItem, error)
+	DeleteContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string) error
+	DeleteContentItemByCategory(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string) error
+	GetContentItemVersions(allApps bool, appID string, orgID string, id string, offset *int64, limit *int64) ([]model.ContentItemVersion, error)
+	GetContentItemVersionsDiff(allApps bool, appID string, orgID string, id string, from int, to *int) (*model.ContentItemVersionDiff, error)
+	RestoreContentItemVersion(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, version int) (*model.ContentItem, error)
+	TransitionContentItemWorkflow(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, transition string) (*model.ContentItem, error)
+	SearchContentItems(claims *tokenauth.Claims, allApps bool, appID string, orgID string, text string, categoryList []string, state *string, workflowState *string, offset int64, limit int64) ([]model.SearchResult, error)
+	ExportContentItems(allApps bool, appID string, orgID string, categoryList []string, handle func(item model.ContentItem) error) error
+	ImportContentItems(actor *model.AuditActor, allApps bool, appID string, orgID string, items []model.ContentItem, dryRun bool) (*model.ImportReport, error)
+	ApplyContentItemsBatch(actor *model.AuditActor, allApps bool, appID string, orgID string, operations []model.ContentItemBatchOperation) (*model.BatchResult, error)
+
+	UploadImage(actor *model.AuditActor, claims *tokenauth.Claims, imageBytes []byte, path string, spec model.ImageSpec) (*string, error)
 	GetProfileImage(userID string, imageType string) ([]byte, error)
-	UploadProfileImage(userID string, bytes []byte) error
-	DeleteProfileImage(userID string) error
+	UploadProfileImage(claims *tokenauth.Claims, bytes []byte) error
+	DeleteProfileImage(claims *tokenauth.Claims) error
 
-	UploadVoiceRecord(userID string, bytes []byte) error
+	UploadVoiceRecord(claims *tokenauth.Claims, bytes []byte) error
 	GetVoiceRecord(userID string) ([]byte, error)
-	DeleteVoiceRecord(userID string) error
+	DeleteVoiceRecord(claims *tokenauth.Claims) error
+
+	//the presigned URLs served by this service for the object storage backends which the clients cannot reach
+	DownloadPresignedObject(request model.PresignedObjectRequest) (io.ReadCloser, error)
+	UploadPresignedObject(request model.PresignedObjectRequest, body io.Reader) (string, error)
 
 	GetTwitterPosts(userID string, twitterQueryParams string, force bool) (map[string]interface{}, error)
 
-	CreateDataContentItem(claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error)
+	CreateDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error)
 	GetDataContentItem(claims *tokenauth.Claims, key string) (*model.DataContentItem, error)
-	UpdateDataContentItem(claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error)
-	DeleteDataContentItem(claims *tokenauth.Claims, key string) error
-	GetDataContentItems(claims *tokenauth.Claims, category string) ([]*model.DataContentItem, error)
-	CreateOrUpdateMetaData(key string, value map[string]interface{}) (*model.MetaData, error)
-	GetMetaData(key *string) (*model.MetaData, error)
-	DeleteMetaData(key string) error
-
-	CreateCategory(claims *tokenauth.Claims, item *model.Category) (*model.Category, error)
+	UpdateDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, item *model.DataContentItem, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], ifMatch []string) (*model.DataContentItem, error)
+	PatchDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, key string, patch model.DataPatch, ifMatch []string) (*model.DataContentItem, error)
+	DeleteDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, key string) error
+	GetDataContentItems(claims *tokenauth.Claims, category string, withDescendants bool) ([]*model.DataContentItem, error)
+	GetDataContentItemsPage(claims *tokenauth.Claims, category string, withDescendants bool, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.DataContentItemsPage, error)
+	ExportDataContentItems(claims *tokenauth.Claims, categoryList []string, handle func(item model.DataContentItem) error) error
+	ImportDataContentItems(actor *model.AuditActor, claims *tokenauth.Claims, items []model.DataContentItem, dryRun bool) (*model.ImportReport, error)
+	ApplyDataContentItemsBatch(actor *model.AuditActor, claims *tokenauth.Claims, operations []model.DataContentItemBatchOperation) (*model.BatchResult, error)
+	GetMissingTranslations(claims *tokenauth.Claims, category string, expectedLocales []string) (*model.MissingTranslationsReport, error)
+
+	//preferredLocales are ordered by preference, the item gets the data in the best matching locale
+	LocalizeContentItem(item model.ContentItemResponse, preferredLocales []string) string
+	LocalizeDataContentItem(item *model.DataContentItem, preferredLocales []string) string
+
+	//depth is how many levels of references are resolved, the references the claims cannot see are left as they are
+	ExpandContentItemReferences(claims *tokenauth.Claims, items []model.ContentItemResponse, preferredLocales []string, depth int) error
+	ExpandDataContentItemReferences(claims *tokenauth.Claims, items []*model.DataContentItem, preferredLocales []string, depth int) error
+	GetDanglingReferences(allApps bool, appID string, orgID string) ([]model.DanglingReference, error)
+
+	//the channel is closed when the subscriber falls behind, the returned function ends the subscription
+	SubscribeContentChanges(claims *tokenauth.Claims, allApps bool, categories []string) (<-chan model.ContentChange, func())
+
+	GetWebhooks(allApps bool, appID string, orgID string) ([]model.Webhook, error)
+	CreateWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, item model.Webhook) (*model.Webhook, error)
+	GetWebhook(allApps bool, appID string, orgID string, id string) (*model.Webhook, error)
+	UpdateWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, item model.Webhook) (*model.Webhook, error)
+	DeleteWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, id string) error
+	GetWebhookDeliveries(allApps bool, appID string, orgID string, webhookID string, status *string, offset *int64, limit *int64) ([]model.WebhookDelivery, error)
+	RedeliverWebhookDelivery(allApps bool, appID string, orgID string, webhookID string, id string) (*model.WebhookDelivery, error)
+
+	GetAuditLog(allApps bool, appID string, orgID string, accountID *string, resource *string, resourceID *string, category *string,
+		from *time.Time, to *time.Time, offset *int64, limit *int64) ([]model.AuditLogEntry, error)
+
+	CreateOrUpdateMetaData(actor *model.AuditActor, claims *tokenauth.Claims, admin bool, global bool, key string, value map[string]interface{}, permissions *[]string) (*model.MetaData, error)
+	GetMetaData(claims *tokenauth.Claims, key *string) (*model.MetaData, error)
+	GetMetaDataList(claims *tokenauth.Claims, global bool, keyPrefix *string, offset *int64, limit *int64) ([]model.MetaData, error)
+	DeleteMetaData(actor *model.AuditActor, claims *tokenauth.Claims, admin bool, global bool, key string) error
+
+	CreateCategory(actor *model.AuditActor, claims *tokenauth.Claims, item *model.Category) (*model.Category, error)
 	GetCategory(claims *tokenauth.Claims, name string) (*model.Category, error)
-	UpdateCategory(claims *tokenauth.Claims, item *model.Category) (*model.Category, error)
-	DeleteCategory(claims *tokenauth.Claims, name string) error
+	UpdateCategory(actor *model.AuditActor, claims *tokenauth.Claims, item *model.Category) (*model.Category, error)
+	GetCategorySubtree(claims *tokenauth.Claims, name string) (*model.CategoryTree, error)
+	ValidateCategorySchema(claims *tokenauth.Claims, name string, schema json.RawMessage) (*model.CategorySchemaReport, error)
+	GetCategoriesPage(claims *tokenauth.Claims, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.CategoriesPage, error)
+	RenameCategory(actor *model.AuditActor, claims *tokenauth.Claims, name string, newName string) (*model.Category, error)
+	DeleteCategory(actor *model.AuditActor, claims *tokenauth.Claims, name string, cascade bool) error
+	ExportCategories(claims *tokenauth.Claims, handle func(item model.Category) error) error
+	ImportCategories(actor *model.AuditActor, claims *tokenauth.Claims, items []model.Category, dryRun bool) (*model.ImportReport, error)
 
-	UploadFileContentItem(file io.Reader, claims *tokenauth.Claims, fileName string, category string) error
+	UploadFileContentItem(actor *model.AuditActor, file io.Reader, claims *tokenauth.Claims, fileName string, category string) (*model.FileContentItem, error)
 	GetFileContentItem(claims *tokenauth.Claims, fileName string, category string) (io.ReadCloser, error)
 	GetFileContentUploadURLs(claims *tokenauth.Claims, fileNames []string, entityID string, category string, addAppOrgIDToPath bool, handleDuplicateFileNames bool, publicRead bool) ([]model.FileContentItemRef, error)
 	GetFileContentDownloadURLs(claims *tokenauth.Claims, fileKeys []string, entityID string, category string, addAppOrgIDToPath bool) ([]model.FileContentItemRef, error)
-	DeleteFileContentItem(claims *tokenauth.Claims, fileName string, category string) error
+	DeleteFileContentItem(actor *model.AuditActor, claims *tokenauth.Claims, fileName string, category string) error
+	ConfirmFileContentUploads(actor *model.AuditActor, claims *tokenauth.Claims, fileKeys []string, entityID string, category string, addAppOrgIDToPath bool) ([]model.FileContentItem, error)
+	GetFileContentItems(claims *tokenauth.Claims, category string, entityID *string, search *string, offset *int64, limit *int64) ([]model.FileContentItem, error)
+	ListFileContentItems(allApps bool, appID string, orgID string, category *string, entityID *string, accountID *string, search *string, mimeType *string, quarantined *bool, offset *int64, limit *int64) ([]model.FileContentItem, error)
+
+	//the large files are uploaded in parts
+	CreateFileContentMultipartUpload(claims *tokenauth.Claims, fileName string, entityID string, category string, addAppOrgIDToPath bool, handleDuplicateFileNames bool, publicRead bool) (*model.MultipartUpload, error)
+	GetFileContentUploadPartURLs(claims *tokenauth.Claims, id string, partNumbers []int) ([]model.UploadPartRef, error)
+	CompleteFileContentMultipartUpload(actor *model.AuditActor, claims *tokenauth.Claims, id string, parts []model.UploadedPart) (*model.FileContentItemRef, error)
+	AbortFileContentMultipartUpload(claims *tokenauth.Claims, id string) error
+
+	//the usage of the files, images and profile photos is tracked per category and month, the quotas reject the uploads
+	GetStorageUsage(allApps bool, appID string, orgID string, category *string, from *string, to *string) (*model.StorageUsageReport, error)
+	GetStorageQuotas(allApps bool, appID string, orgID string) ([]model.StorageQuota, error)
+	SaveStorageQuota(actor *model.AuditActor, allApps bool, appID string, orgID string, category string, maxSize int64) (*model.StorageQuota, error)
+	DeleteStorageQuota(actor *model.AuditActor, allApps bool, appID string, orgID string, category string) error
 }
@@ -12,12 +12,17 @@ package interfaces
 
 import (
 	"content/core/model"
+	"content/utils"
+	"context"
+	"io"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 // Storage is used by core to storage data - DB storage adapter, file storage adapter etc
 type Storage interface {
+	RegisterStorageListener(listener StorageListener)
 	PerformTransaction(transaction func(storage Storage) error) error
 
 	GetStudentGuides(appID string, orgID string, ids []string) ([]bson.M, error)
@@ -33,13 +38,24 @@ type Storage interface {
 	DeleteHealthLocation(appID string, orgID string, id string) error
 
 	GetContentItemsCategories(appID *string, orgID string) ([]string, error)
-	FindContentItems(appID *string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItem, error)
-	GetContentItems(appID *string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error)
-	GetContentItem(appID *string, orgID string, id string) (*model.ContentItemResponse, error)
+	FindContentItems(appID *string, orgID string, ids []string, categoryList []string, workflowState *string, offset *int64, limit *int64, order *string) ([]model.ContentItem, error)
+	IterateContentItems(appID *string, orgID string, categoryList []string, handle func(item model.ContentItem) error) error
+	GetContentItems(appID *string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error)
+	GetContentItemsPage(appID *string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter,
+		cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.ContentItemsPage, error)
+	GetContentItem(appID *string, orgID string, id string, state *string, workflowState *string) (*model.ContentItemResponse, error)
 	CreateContentItem(item model.ContentItem) (*model.ContentItem, error)
-	UpdateContentItem(appID *string, orgID string, id string, category string, data interface{}) (*model.ContentItem, error)
+	UpdateContentItem(appID *string, orgID string, id string, category string, data interface{}, defaultLocale string, locales map[string]interface{}, publishAt *time.Time, expireAt *time.Time, workflowState string) (*model.ContentItem, error)
 	DeleteContentItem(appID *string, orgID string, id string) error
 	SaveContentItem(item model.ContentItem) error
+	ArchiveExpiredContentItems(now time.Time) (int64, error)
+	UpdateContentItemWorkflowState(appID *string, orgID string, id string, fromStates []string, toState string) (*model.ContentItem, error)
+	SearchContentItems(appID *string, orgID string, text string, categoryList []string, state *string, workflowState *string, limit int64) ([]model.SearchResult, error)
+
+	CreateContentItemVersion(item model.ContentItemVersion) error
+	FindContentItemVersions(appID *string, orgID string, contentItemID string, offset *int64, limit *int64) ([]model.ContentItemVersion, error)
+	FindContentItemVersion(appID *string, orgID string, contentItemID string, version int) (*model.ContentItemVersion, error)
+	DeleteContentItemVersions(appID *string, orgID string, contentItemID string) error
 
 	//Used for multi-tenancy for already exisiting data.
 	//To be removed when this is applied to all environments.
@@ -49,19 +65,107 @@ type Storage interface {
 
 	CreateDataContentItem(item *model.DataContentItem) (*model.DataContentItem, error)
 	FindDataContentItem(appID *string, orgID string, key string) (*model.DataContentItem, error)
+	FindDataContentItemByID(appID *string, orgID string, id string) (*model.DataContentItem, error)
 	UpdateDataContentItem(appID *string, orgID string, item *model.DataContentItem) (*model.DataContentItem, error)
 	DeleteDataContentItem(appID *string, orgID string, key string) error
-	FindDataContentItems(appID *string, orgID string, key string) ([]*model.DataContentItem, error)
+	SaveDataContentItem(item model.DataContentItem) error
+	FindDataContentItems(appID *string, orgID string, categories []string) ([]*model.DataContentItem, error)
+	IterateDataContentItems(appID *string, orgID string, categoryList []string, handle func(item model.DataContentItem) error) error
+	FindDataContentItemsPage(appID *string, orgID string, categories []string, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.DataContentItemsPage, error)
+	SearchDataContentItems(appID *string, orgID string, text string, categoryList []string, excludedCategories []string, limit int64) ([]model.SearchResult, error)
 
 	CreateCategory(item *model.Category) (*model.Category, error)
 	FindCategory(appID *string, orgID string, name string) (*model.Category, error)
+	FindCategoryByID(appID *string, orgID string, id string) (*model.Category, error)
+	IterateCategories(appID *string, orgID string, handle func(item model.Category) error) error
+	FindCategoriesPage(appID *string, orgID string, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.CategoriesPage, error)
+	CountContentItemsByCategory(appID *string, orgID string, categories []string) (map[string]int64, error)
+	CountDataContentItemsByCategory(appID *string, orgID string, categories []string) (map[string]int64, error)
 	UpdateCategory(appID *string, orgID string, item *model.Category) (*model.Category, error)
+	RenameCategoryReferences(appID *string, orgID string, oldName string, newName string) error
+	DeleteCategoryReferences(appID *string, orgID string, name string) error
 	DeleteCategory(appID *string, orgID string, key string) error
+	DeleteContentItemsByCategory(appID *string, orgID string, category string) error
+	DeleteDataContentItemsByCategory(appID *string, orgID string, category string) error
+	SaveCategory(item model.Category) error
+
+	CreateMetaData(appID *string, orgID *string, key string, value map[string]interface{}, permissions []string) (*model.MetaData, error)
+	FindMetaData(appID *string, orgID *string, key *string) (*model.MetaData, error)
+	FindMetaDataList(appID *string, orgID *string, keyPrefix *string, offset *int64, limit *int64) ([]model.MetaData, error)
+	UpdateMetaData(item *model.MetaData, value map[string]interface{}, permissions []string) (*model.MetaData, error)
+	DeleteMetaData(appID *string, orgID *string, key string) error
+	StoreMetaDataMultiTenancyData(appID string, orgID string) (int64, error)
+
+	CreateWebhook(item model.Webhook) error
+	FindWebhooks(appID *string, orgID string) ([]model.Webhook, error)
+	FindWebhook(appID *string, orgID string, id string) (*model.Webhook, error)
+	UpdateWebhook(item model.Webhook) error
+	DeleteWebhook(appID *string, orgID string, id string) error
+
+	CreateWebhookDelivery(item model.WebhookDelivery) error
+	FindWebhookDeliveries(appID *string, orgID string, webhookID string, status *string, offset *int64, limit *int64) ([]model.WebhookDelivery, error)
+	FindWebhookDelivery(appID *string, orgID string, webhookID string, id string) (*model.WebhookDelivery, error)
+	ClaimDueWebhookDelivery(now time.Time, lease time.Duration) (*model.WebhookDelivery, error)
+	UpdateWebhookDelivery(item model.WebhookDelivery) error
+
+	SaveFileContentItem(item model.FileContentItem) (*model.FileContentItem, error)
+	FindFileContentItem(appID string, orgID string, path string) (*model.FileContentItem, error)
+	FindFileContentItems(appID *string, orgID string, category *string, entityID *string, accountID *string, search *string, mimeType *string,
+		quarantined *bool, offset *int64, limit *int64) ([]model.FileContentItem, error)
+	DeleteFileContentItem(appID string, orgID string, path string) error
+
+	CreateMultipartUpload(item model.MultipartUpload) error
+	FindMultipartUpload(appID string, orgID string, id string) (*model.MultipartUpload, error)
+	FindMultipartUploads(createdBefore time.Time) ([]model.MultipartUpload, error)
+	DeleteMultipartUpload(id string) error
+
+	AddStorageUsage(item model.StorageUsage) error
+	FindStorageUsage(appID *string, orgID string, category *string) ([]model.StorageUsage, error)
+
+	FindStorageQuotas(appID *string, orgID string) ([]model.StorageQuota, error)
+	SaveStorageQuota(item model.StorageQuota) (*model.StorageQuota, error)
+	DeleteStorageQuota(appID *string, orgID string, category string) error
+
+	CreateAuditLogEntry(item model.AuditLogEntry) error
+	FindAuditLogEntries(appID *string, orgID string, accountID *string, resource *string, resourceID *string, category *string,
+		from *time.Time, to *time.Time, offset *int64, limit *int64) ([]model.AuditLogEntry, error)
+}
+
+// StorageListener listens for the changes of the stored content
+type StorageListener interface {
+	OnContentChanged(change model.ContentChange)
+}
+
+// Webhooks delivers the webhook payloads, a delivery is given up once the context is done
+type Webhooks interface {
+	Deliver(ctx context.Context, url string, secret string, deliveryID string, eventType string, payload []byte) (int, error)
+}
+
+// Scanner checks the uploaded files for malware before they are accepted
+type Scanner interface {
+	Scan(body io.Reader) (*model.ScanResult, error)
+}
+
+// ObjectStorage keeps the files, the images, the profile photos and the voice records in the buckets the model defines
+type ObjectStorage interface {
+	//the public objects can be read by anyone, it gives the location of the object
+	Upload(bucket string, key string, body io.Reader, public bool) (string, error)
+	Download(bucket string, key string) (io.ReadCloser, error)
+	Delete(bucket string, key string) error
+	List(bucket string, prefix string) ([]model.StoredObject, error)
+
+	PresignUpload(bucket string, key string, public bool) (string, error)
+	PresignDownload(bucket string, key string) (string, error)
+
+	//the parts of the large objects are uploaded with presigned URLs, completing the upload gives the location of the object
+	CreateMultipartUpload(bucket string, key string, public bool) (string, error)
+	PresignUploadPart(bucket string, key string, uploadID string, partNumber int) (string, error)
+	UploadPart(bucket string, key string, uploadID string, partNumber int, body io.Reader) (string, error)
+	CompleteMultipartUpload(bucket string, key string, uploadID string, parts []model.UploadedPart) (string, error)
+	AbortMultipartUpload(bucket string, key string, uploadID string) error
 
-	CreateMetaData(key string, value map[string]interface{}) (*model.MetaData, error)
-	FindMetaData(key *string) (*model.MetaData, error)
-	UpdateMetaData(item *model.MetaData, value map[string]interface{}) (*model.MetaData, error)
-	DeleteMetaData(key string) error
+	//checks a presigned URL which this service serves for the backend, the backends the clients reach directly accept none
+	VerifyPresigned(request model.PresignedObjectRequest) error
 }
 
 // Core BB interface
@@ -0,0 +1,178 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"content/utils"
+	"sort"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"golang.org/x/text/language"
+)
+
+// localizedData picks the translation of the data which best matches the preferred locales,
+// the data itself is given when none of the translations matches
+func localizedData(data interface{}, defaultLocale string, locales map[string]interface{}, preferredLocales []string) (interface{}, string) {
+	desired := parseLocales(preferredLocales)
+	if len(desired) == 0 || len(locales) == 0 {
+		return data, defaultLocale
+	}
+
+	//the empty key stands for the data in the default locale
+	supported := []language.Tag{}
+	keys := []string{}
+	if tag, err := language.Parse(defaultLocale); err == nil {
+		supported = append(supported, tag)
+		keys = append(keys, "")
+	}
+	for _, locale := range sortedLocales(locales) {
+		if tag, err := language.Parse(locale); err == nil {
+			supported = append(supported, tag)
+			keys = append(keys, locale)
+		}
+	}
+	if len(supported) == 0 {
+		return data, defaultLocale
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(desired...)
+	if confidence == language.No || len(keys[index]) == 0 {
+		return data, defaultLocale
+	}
+	return utils.NormalizeData(locales[keys[index]]), keys[index]
+}
+
+// parseLocales gives the valid locales in the same order
+func parseLocales(locales []string) []language.Tag {
+	tags := []language.Tag{}
+	for _, locale := range locales {
+		if tag, err := language.Parse(locale); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func sortedLocales(locales map[string]interface{}) []string {
+	keys := make([]string, 0, len(locales))
+	for locale := range locales {
+		keys = append(keys, locale)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// canonicalLocale gives the BCP 47 form of a locale, so that different spellings of a locale compare equal
+func canonicalLocale(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return locale
+	}
+	return tag.String()
+}
+
+func (s *servicesImpl) LocalizeContentItem(item model.ContentItemResponse, preferredLocales []string) string {
+	defaultLocale, _ := item["default_locale"].(string)
+	locales, _ := utils.NormalizeData(item["locales"]).(map[string]interface{})
+
+	data, locale := localizedData(item["data"], defaultLocale, locales, preferredLocales)
+	item["data"] = data
+	delete(item, "locales")
+	return locale
+}
+
+func (s *servicesImpl) LocalizeDataContentItem(item *model.DataContentItem, preferredLocales []string) string {
+	locales, _ := utils.NormalizeData(item.Locales).(map[string]interface{})
+	data, locale := localizedData(item.Data, item.DefaultLocale, locales, preferredLocales)
+	item.Data = data
+	item.Locales = nil
+	return locale
+}
+
+func (s *servicesImpl) GetMissingTranslations(claims *tokenauth.Claims, category string, expectedLocales []string) (*model.MissingTranslationsReport, error) {
+	contentItems, err := s.app.storage.FindContentItems(&claims.AppID, claims.OrgID, nil, []string{category}, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	dataContentItems, err := s.app.storage.FindDataContentItems(&claims.AppID, claims.OrgID, []string{category})
+	if err != nil {
+		return nil, err
+	}
+
+	type translatedItem struct {
+		item    model.MissingTranslationsItem
+		locales map[string]bool
+	}
+	items := []translatedItem{}
+	allLocales := map[string]bool{}
+	addItem := func(item model.MissingTranslationsItem, defaultLocale string, locales map[string]interface{}) {
+		present := map[string]bool{}
+		if len(defaultLocale) > 0 {
+			present[canonicalLocale(defaultLocale)] = true
+		}
+		for locale := range locales {
+			present[canonicalLocale(locale)] = true
+		}
+		for locale := range present {
+			allLocales[locale] = true
+		}
+		items = append(items, translatedItem{item: item, locales: present})
+	}
+	for _, item := range contentItems {
+		addItem(model.MissingTranslationsItem{Collection: "content_items", ID: item.ID}, item.DefaultLocale, item.Locales)
+	}
+	for _, item := range dataContentItems {
+		addItem(model.MissingTranslationsItem{Collection: "data_content_items", ID: item.ID, Key: item.Key}, item.DefaultLocale, item.Locales)
+	}
+
+	//without the expected locales every item should have all the locales used in the category
+	locales := []string{}
+	if len(expectedLocales) > 0 {
+		seen := map[string]bool{}
+		for _, locale := range expectedLocales {
+			locale = canonicalLocale(locale)
+			if !seen[locale] {
+				seen[locale] = true
+				locales = append(locales, locale)
+			}
+		}
+	} else {
+		for locale := range allLocales {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+	}
+
+	report := model.MissingTranslationsReport{Category: category, Locales: locales,
+		MissingCounts: map[string]int{}, Items: []model.MissingTranslationsItem{}}
+	for _, locale := range locales {
+		report.MissingCounts[locale] = 0
+	}
+	for _, translated := range items {
+		missing := []string{}
+		for _, locale := range locales {
+			if !translated.locales[locale] {
+				missing = append(missing, locale)
+				report.MissingCounts[locale]++
+			}
+		}
+		if len(missing) > 0 {
+			translated.item.MissingLocales = missing
+			report.Items = append(report.Items, translated.item)
+		}
+	}
+	return &report, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocalizedData(t *testing.T) {
+	data := map[string]interface{}{"title": "Hello"}
+	locales := map[string]interface{}{
+		"es":    map[string]interface{}{"title": "Hola"},
+		"fr":    map[string]interface{}{"title": "Bonjour"},
+		"zh-TW": map[string]interface{}{"title": "你好"},
+	}
+
+	tests := []struct {
+		name             string
+		defaultLocale    string
+		locales          map[string]interface{}
+		preferredLocales []string
+		wantData         interface{}
+		wantLocale       string
+	}{
+		{name: "no preference", defaultLocale: "en", locales: locales, wantData: data, wantLocale: "en"},
+		{name: "no translations", defaultLocale: "en", preferredLocales: []string{"es"}, wantData: data, wantLocale: "en"},
+		{name: "exact match", defaultLocale: "en", locales: locales, preferredLocales: []string{"es"}, wantData: locales["es"], wantLocale: "es"},
+		{name: "regional preference", defaultLocale: "en", locales: locales, preferredLocales: []string{"fr-CA"}, wantData: locales["fr"], wantLocale: "fr"},
+		{name: "first match wins", defaultLocale: "en", locales: locales, preferredLocales: []string{"de", "fr", "es"}, wantData: locales["fr"], wantLocale: "fr"},
+		{name: "default locale preferred", defaultLocale: "en", locales: locales, preferredLocales: []string{"en-US", "es"}, wantData: data, wantLocale: "en"},
+		{name: "no match", defaultLocale: "en", locales: locales, preferredLocales: []string{"de"}, wantData: data, wantLocale: "en"},
+		{name: "invalid preferences are skipped", defaultLocale: "en", locales: locales, preferredLocales: []string{"not a locale", "es"}, wantData: locales["es"], wantLocale: "es"},
+		{name: "script match", defaultLocale: "en", locales: locales, preferredLocales: []string{"zh-Hant"}, wantData: locales["zh-TW"], wantLocale: "zh-TW"},
+		{name: "without default locale", locales: locales, preferredLocales: []string{"es"}, wantData: locales["es"], wantLocale: "es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, gotLocale := localizedData(data, tt.defaultLocale, tt.locales, tt.preferredLocales)
+			if !reflect.DeepEqual(gotData, tt.wantData) || gotLocale != tt.wantLocale {
+				t.Errorf("localizedData() = %v, %q, want %v, %q", gotData, gotLocale, tt.wantData, tt.wantLocale)
+			}
+		})
+	}
+}
+
+func TestCanonicalLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "en", want: "en"},
+		{locale: "en_us", want: "en-US"},
+		{locale: "EN-us", want: "en-US"},
+		{locale: "zh-hant-tw", want: "zh-Hant-TW"},
+		{locale: "not a locale", want: "not a locale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := canonicalLocale(tt.locale); got != tt.want {
+				t.Errorf("canonicalLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryObjectStorage keeps the objects in memory for the tests of the services, the calls the tests do not reach are not implemented
+type memoryObjectStorage struct {
+	interfaces.ObjectStorage
+
+	lock    sync.Mutex
+	objects map[string][]byte // by bucket and key
+	uploads map[string]*memoryMultipartUpload
+}
+
+// memoryMultipartUpload is an unfinished multipart upload with its parts by part number
+type memoryMultipartUpload struct {
+	objectID string
+	parts    map[int][]byte
+}
+
+func objectID(bucket string, key string) string {
+	return bucket + ":" + strings.TrimPrefix(key, "/")
+}
+
+func (s *memoryObjectStorage) Upload(bucket string, key string, body io.Reader, public bool) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.objects == nil {
+		s.objects = map[string][]byte{}
+	}
+	s.objects[objectID(bucket, key)] = data
+	return fmt.Sprintf("https://objects/%s/%s", bucket, strings.TrimPrefix(key, "/")), nil
+}
+
+func (s *memoryObjectStorage) Download(bucket string, key string) (io.ReadCloser, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.objects[objectID(bucket, key)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", model.ErrObjectNotFound, key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryObjectStorage) Delete(bucket string, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.objects, objectID(bucket, key))
+	return nil
+}
+
+func (s *memoryObjectStorage) List(bucket string, prefix string) ([]model.StoredObject, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	objects := []model.StoredObject{}
+	for id, data := range s.objects {
+		key, ok := strings.CutPrefix(id, bucket+":")
+		if ok && strings.HasPrefix(key, strings.TrimPrefix(prefix, "/")) {
+			objects = append(objects, model.StoredObject{Key: key, Size: int64(len(data)), LastModified: time.Now().UTC()})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+func (s *memoryObjectStorage) CreateMultipartUpload(bucket string, key string, public bool) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.uploads == nil {
+		s.uploads = map[string]*memoryMultipartUpload{}
+	}
+	uploadID := uuid.NewString()
+	s.uploads[uploadID] = &memoryMultipartUpload{objectID: objectID(bucket, key), parts: map[int][]byte{}}
+	return uploadID, nil
+}
+
+func (s *memoryObjectStorage) PresignUploadPart(bucket string, key string, uploadID string, partNumber int) (string, error) {
+	return fmt.Sprintf("https://objects/%s/%s?upload_id=%s&part_number=%d", bucket, strings.TrimPrefix(key, "/"), uploadID, partNumber), nil
+}
+
+// uploadPart is what a client does with a presigned part URL, the ETag of a part is its content
+func (s *memoryObjectStorage) uploadPart(uploadID string, partNumber int, data string) model.UploadedPart {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.uploads[uploadID].parts[partNumber] = []byte(data)
+	return model.UploadedPart{PartNumber: partNumber, ETag: data}
+}
+
+func (s *memoryObjectStorage) CompleteMultipartUpload(bucket string, key string, uploadID string, parts []model.UploadedPart) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.objectID != objectID(bucket, key) {
+		return "", fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, uploadID)
+	}
+	var data []byte
+	for _, part := range parts {
+		partData, ok := upload.parts[part.PartNumber]
+		if !ok || string(partData) != part.ETag {
+			return "", errors.New("invalid part")
+		}
+		data = append(data, partData...)
+	}
+	if s.objects == nil {
+		s.objects = map[string][]byte{}
+	}
+	s.objects[upload.objectID] = data
+	delete(s.uploads, uploadID)
+	return fmt.Sprintf("https://objects/%s/%s", bucket, strings.TrimPrefix(key, "/")), nil
+}
+
+func (s *memoryObjectStorage) AbortMultipartUpload(bucket string, key string, uploadID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.uploads, uploadID)
+	return nil
+}
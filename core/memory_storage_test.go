@@ -0,0 +1,686 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"content/utils"
+	"errors"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryStorage keeps the content in memory for the tests of the services, the storage calls the tests do not reach are not implemented.
+// A transaction which fails leaves the content as it was before.
+type memoryStorage struct {
+	interfaces.Storage
+
+	lock sync.Mutex
+
+	contentItems []model.ContentItem
+	versions     []model.ContentItemVersion
+	categories   []model.Category
+	auditLog     []model.AuditLogEntry
+
+	dataContentItems []model.DataContentItem
+	fileContentItems []model.FileContentItem
+	multipartUploads []model.MultipartUpload
+	storageUsage     []model.StorageUsage
+
+	metaData []model.MetaData
+
+	webhooks   []model.Webhook
+	deliveries []model.WebhookDelivery
+
+	failingIDs []string // the ids of the content items the storage fails to write
+}
+
+// errWriteFailed is the error of the writes of the failing ids
+var errWriteFailed = errors.New("write failed")
+
+// memoryStorageState is a copy of the content a failed transaction goes back to
+type memoryStorageState struct {
+	contentItems     []model.ContentItem
+	versions         []model.ContentItemVersion
+	categories       []model.Category
+	auditLog         []model.AuditLogEntry
+	dataContentItems []model.DataContentItem
+	fileContentItems []model.FileContentItem
+	webhooks         []model.Webhook
+}
+
+func (s *memoryStorage) state() memoryStorageState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return memoryStorageState{contentItems: slices.Clone(s.contentItems), versions: slices.Clone(s.versions),
+		categories: slices.Clone(s.categories), auditLog: slices.Clone(s.auditLog), dataContentItems: slices.Clone(s.dataContentItems),
+		fileContentItems: slices.Clone(s.fileContentItems), webhooks: cloneWebhooks(s.webhooks)}
+}
+
+func (s *memoryStorage) restore(state memoryStorageState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.contentItems, s.versions, s.categories, s.auditLog = state.contentItems, state.versions, state.categories, state.auditLog
+	s.dataContentItems, s.fileContentItems, s.webhooks = state.dataContentItems, state.fileContentItems, state.webhooks
+}
+
+// cloneWebhooks copies the webhooks with their categories, which the renames change in place
+func cloneWebhooks(webhooks []model.Webhook) []model.Webhook {
+	clone := slices.Clone(webhooks)
+	for i := range clone {
+		clone[i].Categories = slices.Clone(clone[i].Categories)
+	}
+	return clone
+}
+
+func (s *memoryStorage) RegisterStorageListener(listener interfaces.StorageListener) {}
+
+func (s *memoryStorage) PerformTransaction(transaction func(storage interfaces.Storage) error) error {
+	state := s.state()
+	err := transaction(s)
+	if err != nil {
+		s.restore(state)
+	}
+	return err
+}
+
+// scoped says if an item of an app and an organization is in the scope, a nil appID is the scope of all the apps of the organization
+func scoped(appID *string, orgID string, itemAppID *string, itemOrgID string) bool {
+	if itemOrgID != orgID {
+		return false
+	}
+	return appID == nil || (itemAppID != nil && *itemAppID == *appID)
+}
+
+func (s *memoryStorage) FindContentItems(appID *string, orgID string, ids []string, categoryList []string, workflowState *string, offset *int64, limit *int64, order *string) ([]model.ContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var items []model.ContentItem
+	for _, item := range s.contentItems {
+		if !scoped(appID, orgID, item.AppID, item.OrgID) || (ids != nil && !slices.Contains(ids, item.ID)) ||
+			(categoryList != nil && !slices.Contains(categoryList, item.Category)) ||
+			(workflowState != nil && contentItemWorkflowState(item) != *workflowState) {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *memoryStorage) CreateContentItem(item model.ContentItem) (*model.ContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if slices.Contains(s.failingIDs, item.ID) {
+		return nil, errWriteFailed
+	}
+	s.contentItems = append(s.contentItems, item)
+	return &item, nil
+}
+
+func (s *memoryStorage) SaveContentItem(item model.ContentItem) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if slices.Contains(s.failingIDs, item.ID) {
+		return errWriteFailed
+	}
+	for i, current := range s.contentItems {
+		if current.ID == item.ID {
+			s.contentItems[i] = item
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) UpdateContentItem(appID *string, orgID string, id string, category string, data interface{}, defaultLocale string, locales map[string]interface{},
+	publishAt *time.Time, expireAt *time.Time, workflowState string) (*model.ContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if slices.Contains(s.failingIDs, id) {
+		return nil, errWriteFailed
+	}
+	for i, item := range s.contentItems {
+		if item.ID != id || !scoped(appID, orgID, item.AppID, item.OrgID) {
+			continue
+		}
+		now := time.Now().UTC()
+		item.Category, item.Data, item.DefaultLocale, item.Locales = category, data, defaultLocale, locales
+		if publishAt != nil || expireAt != nil {
+			item.DateArchived = nil
+		}
+		if publishAt != nil {
+			item.PublishAt = publishAt
+		}
+		if expireAt != nil {
+			item.ExpireAt = expireAt
+		}
+		item.WorkflowState = workflowState
+		item.DateUpdated = &now
+		s.contentItems[i] = item
+		return &item, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) UpdateContentItemWorkflowState(appID *string, orgID string, id string, fromStates []string, toState string) (*model.ContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, item := range s.contentItems {
+		if item.ID != id || !scoped(appID, orgID, item.AppID, item.OrgID) || !slices.Contains(fromStates, contentItemWorkflowState(item)) {
+			continue
+		}
+		now := time.Now().UTC()
+		item.WorkflowState, item.DateUpdated = toState, &now
+		s.contentItems[i] = item
+		return &item, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// ArchiveExpiredContentItems archives the items which expire time has passed like the database does
+func (s *memoryStorage) ArchiveExpiredContentItems(now time.Time) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var count int64
+	for i, item := range s.contentItems {
+		if item.ExpireAt == nil || item.ExpireAt.After(now) || item.DateArchived != nil {
+			continue
+		}
+		item.DateArchived, item.DateUpdated = &now, &now
+		item.WorkflowState = model.ContentItemWorkflowArchived
+		s.contentItems[i] = item
+		count++
+	}
+	return count, nil
+}
+
+func (s *memoryStorage) DeleteContentItem(appID *string, orgID string, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.contentItems = slices.DeleteFunc(s.contentItems, func(item model.ContentItem) bool {
+		return item.ID == id && scoped(appID, orgID, item.AppID, item.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) CreateContentItemVersion(item model.ContentItemVersion) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.versions = append(s.versions, item)
+	return nil
+}
+
+// FindContentItemVersions gives the latest versions first
+func (s *memoryStorage) FindContentItemVersions(appID *string, orgID string, contentItemID string, offset *int64, limit *int64) ([]model.ContentItemVersion, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var versions []model.ContentItemVersion
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		version := s.versions[i]
+		if version.ContentItemID == contentItemID && scoped(appID, orgID, version.AppID, version.OrgID) {
+			versions = append(versions, version)
+		}
+	}
+	if limit != nil && int64(len(versions)) > *limit {
+		versions = versions[:*limit]
+	}
+	return versions, nil
+}
+
+func (s *memoryStorage) FindContentItemVersion(appID *string, orgID string, contentItemID string, version int) (*model.ContentItemVersion, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, item := range s.versions {
+		if item.ContentItemID == contentItemID && item.Version == version && scoped(appID, orgID, item.AppID, item.OrgID) {
+			return &item, nil
+		}
+	}
+	return nil, model.ErrContentItemVersionNotFound
+}
+
+func (s *memoryStorage) DeleteContentItemVersions(appID *string, orgID string, contentItemID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.versions = slices.DeleteFunc(s.versions, func(version model.ContentItemVersion) bool {
+		return version.ContentItemID == contentItemID && scoped(appID, orgID, version.AppID, version.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) FindDataContentItem(appID *string, orgID string, key string) (*model.DataContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, item := range s.dataContentItems {
+		if item.Key == key && scoped(appID, orgID, item.AppID, item.OrgID) {
+			return &item, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) CreateDataContentItem(item *model.DataContentItem) (*model.DataContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dataContentItems = append(s.dataContentItems, *item)
+	return item, nil
+}
+
+// UpdateDataContentItem sets the locales as they are given like the database does
+func (s *memoryStorage) UpdateDataContentItem(appID *string, orgID string, item *model.DataContentItem) (*model.DataContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, current := range s.dataContentItems {
+		if current.Key != item.Key || !scoped(appID, orgID, current.AppID, current.OrgID) {
+			continue
+		}
+		now := time.Now().UTC()
+		current.Category, current.Data, current.DefaultLocale, current.Locales = item.Category, item.Data, item.DefaultLocale, item.Locales
+		current.DateUpdated = &now
+		s.dataContentItems[i] = current
+		return &current, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) FindCategory(appID *string, orgID string, name string) (*model.Category, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, category := range s.categories {
+		if category.Name == name && scoped(appID, orgID, category.AppID, category.OrgID) {
+			return &category, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) FindCategoryByID(appID *string, orgID string, id string) (*model.Category, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, category := range s.categories {
+		if category.ID == id && scoped(appID, orgID, category.AppID, category.OrgID) {
+			return &category, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) UpdateCategory(appID *string, orgID string, item *model.Category) (*model.Category, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, category := range s.categories {
+		if category.ID == item.ID && scoped(appID, orgID, category.AppID, category.OrgID) {
+			now := time.Now().UTC()
+			updated := *item
+			updated.AppID, updated.OrgID, updated.DateCreated, updated.DateUpdated = category.AppID, category.OrgID, category.DateCreated, &now
+			s.categories[i] = updated
+		}
+	}
+	return item, nil
+}
+
+// RenameCategoryReferences moves what refers to a category by name like the database does
+func (s *memoryStorage) RenameCategoryReferences(appID *string, orgID string, oldName string, newName string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, item := range s.contentItems {
+		if item.Category == oldName && scoped(appID, orgID, item.AppID, item.OrgID) {
+			s.contentItems[i].Category = newName
+		}
+	}
+	for i, item := range s.dataContentItems {
+		if item.Category == oldName && scoped(appID, orgID, item.AppID, item.OrgID) {
+			s.dataContentItems[i].Category = newName
+		}
+	}
+	for i, category := range s.categories {
+		if category.Parent == oldName && scoped(appID, orgID, category.AppID, category.OrgID) {
+			s.categories[i].Parent = newName
+		}
+	}
+	for i, version := range s.versions {
+		if version.Category == oldName && scoped(appID, orgID, version.AppID, version.OrgID) {
+			s.versions[i].Category = newName
+		}
+	}
+	for i, file := range s.fileContentItems {
+		if file.Category == oldName && scoped(appID, orgID, &file.AppID, file.OrgID) {
+			s.fileContentItems[i].Category = newName
+		}
+	}
+	for _, webhook := range s.webhooks {
+		if !scoped(appID, orgID, webhook.AppID, webhook.OrgID) {
+			continue
+		}
+		for j, category := range webhook.Categories {
+			if category == oldName {
+				webhook.Categories[j] = newName
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) DeleteCategory(appID *string, orgID string, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.categories = slices.DeleteFunc(s.categories, func(category model.Category) bool {
+		return category.Name == name && scoped(appID, orgID, category.AppID, category.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) DeleteCategoryReferences(appID *string, orgID string, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.fileContentItems = slices.DeleteFunc(s.fileContentItems, func(file model.FileContentItem) bool {
+		return file.Category == name && scoped(appID, orgID, &file.AppID, file.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) DeleteContentItemsByCategory(appID *string, orgID string, category string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.contentItems = slices.DeleteFunc(s.contentItems, func(item model.ContentItem) bool {
+		return item.Category == category && scoped(appID, orgID, item.AppID, item.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) FindDataContentItems(appID *string, orgID string, categories []string) ([]*model.DataContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var items []*model.DataContentItem
+	for _, item := range s.dataContentItems {
+		if scoped(appID, orgID, item.AppID, item.OrgID) && (len(categories) == 0 || slices.Contains(categories, item.Category)) {
+			items = append(items, &item)
+		}
+	}
+	return items, nil
+}
+
+func (s *memoryStorage) DeleteDataContentItemsByCategory(appID *string, orgID string, category string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dataContentItems = slices.DeleteFunc(s.dataContentItems, func(item model.DataContentItem) bool {
+		return item.Category == category && scoped(appID, orgID, item.AppID, item.OrgID)
+	})
+	return nil
+}
+
+func (s *memoryStorage) FindFileContentItems(appID *string, orgID string, category *string, entityID *string, accountID *string, search *string, mimeType *string,
+	quarantined *bool, offset *int64, limit *int64) ([]model.FileContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var items []model.FileContentItem
+	for _, file := range s.fileContentItems {
+		if !scoped(appID, orgID, &file.AppID, file.OrgID) || (category != nil && file.Category != *category) ||
+			(entityID != nil && file.EntityID != *entityID) || (accountID != nil && file.AccountID != *accountID) ||
+			(search != nil && !strings.Contains(strings.ToLower(file.Key), strings.ToLower(*search))) ||
+			(mimeType != nil && !strings.HasPrefix(file.MimeType, *mimeType)) || (quarantined != nil && file.Quarantined != *quarantined) {
+			continue
+		}
+		items = append(items, file)
+	}
+	return items, nil
+}
+
+func (s *memoryStorage) SaveFileContentItem(item model.FileContentItem) (*model.FileContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now().UTC()
+	item.DateUpdated = &now
+	for i, file := range s.fileContentItems {
+		if file.AppID == item.AppID && file.OrgID == item.OrgID && file.Path == item.Path && file.Quarantined == item.Quarantined {
+			item.ID, item.DateCreated = file.ID, file.DateCreated
+			s.fileContentItems[i] = item
+			return &item, nil
+		}
+	}
+	item.DateCreated = now
+	s.fileContentItems = append(s.fileContentItems, item)
+	return &item, nil
+}
+
+func (s *memoryStorage) FindFileContentItem(appID string, orgID string, path string) (*model.FileContentItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, file := range s.fileContentItems {
+		if file.AppID == appID && file.OrgID == orgID && file.Path == path && !file.Quarantined {
+			return &file, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) DeleteFileContentItem(appID string, orgID string, path string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.fileContentItems = slices.DeleteFunc(s.fileContentItems, func(file model.FileContentItem) bool {
+		return file.AppID == appID && file.OrgID == orgID && file.Path == path && !file.Quarantined
+	})
+	return nil
+}
+
+func (s *memoryStorage) CreateMultipartUpload(item model.MultipartUpload) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.multipartUploads = append(s.multipartUploads, item)
+	return nil
+}
+
+func (s *memoryStorage) FindMultipartUpload(appID string, orgID string, id string) (*model.MultipartUpload, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, upload := range s.multipartUploads {
+		if upload.ID == id && upload.AppID == appID && upload.OrgID == orgID {
+			return &upload, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) DeleteMultipartUpload(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.multipartUploads = slices.DeleteFunc(s.multipartUploads, func(upload model.MultipartUpload) bool {
+		return upload.ID == id
+	})
+	return nil
+}
+
+func (s *memoryStorage) AddStorageUsage(item model.StorageUsage) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.storageUsage = append(s.storageUsage, item)
+	return nil
+}
+
+// FindStorageQuotas gives no quotas, the quotas are tested with the quota storage
+func (s *memoryStorage) FindStorageQuotas(appID *string, orgID string) ([]model.StorageQuota, error) {
+	return nil, nil
+}
+
+func (s *memoryStorage) IterateCategories(appID *string, orgID string, handle func(item model.Category) error) error {
+	s.lock.Lock()
+	categories := slices.Clone(s.categories)
+	s.lock.Unlock()
+	for _, item := range categories {
+		if !scoped(appID, orgID, item.AppID, item.OrgID) {
+			continue
+		}
+		err := handle(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameScope says if the meta data is of the app and the organization, both are nil for the global meta data
+func sameScope(appID *string, orgID *string, item model.MetaData) bool {
+	return reflect.DeepEqual(appID, item.AppID) && reflect.DeepEqual(orgID, item.OrgID)
+}
+
+func (s *memoryStorage) CreateMetaData(appID *string, orgID *string, key string, value map[string]interface{}, permissions []string) (*model.MetaData, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	item := model.MetaData{ID: uuid.NewString(), AppID: appID, OrgID: orgID, Key: key, Value: value, Permissions: permissions, DateCreated: time.Now().UTC()}
+	s.metaData = append(s.metaData, item)
+	return &item, nil
+}
+
+// FindMetaData gives nil when there is no meta data with the key, like the database does
+func (s *memoryStorage) FindMetaData(appID *string, orgID *string, key *string) (*model.MetaData, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, item := range s.metaData {
+		if key != nil && item.Key == *key && sameScope(appID, orgID, item) {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStorage) FindMetaDataList(appID *string, orgID *string, keyPrefix *string, offset *int64, limit *int64) ([]model.MetaData, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	items := []model.MetaData{}
+	for _, item := range s.metaData {
+		if sameScope(appID, orgID, item) && (keyPrefix == nil || strings.HasPrefix(item.Key, *keyPrefix)) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (s *memoryStorage) UpdateMetaData(item *model.MetaData, value map[string]interface{}, permissions []string) (*model.MetaData, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, current := range s.metaData {
+		if current.ID == item.ID {
+			now := time.Now().UTC()
+			current.Value, current.Permissions, current.DateUpdated = value, permissions, &now
+			s.metaData[i] = current
+			return &current, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memoryStorage) DeleteMetaData(appID *string, orgID *string, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.metaData = slices.DeleteFunc(s.metaData, func(item model.MetaData) bool {
+		return item.Key == key && sameScope(appID, orgID, item)
+	})
+	return nil
+}
+
+func (s *memoryStorage) CreateAuditLogEntry(item model.AuditLogEntry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.auditLog = append(s.auditLog, item)
+	return nil
+}
+
+// FindWebhooks finds the webhooks of exactly the app like the database does, a nil appID gives the webhooks of all the apps
+func (s *memoryStorage) FindWebhooks(appID *string, orgID string) ([]model.Webhook, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var webhooks []model.Webhook
+	for _, webhook := range s.webhooks {
+		if webhook.OrgID == orgID && reflect.DeepEqual(webhook.AppID, appID) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+// searchScore scores an item by the occurrences of the text in its data and translations, like a text index but without stemming
+func searchScore(text string, data interface{}, locales map[string]interface{}) float64 {
+	return float64(strings.Count(strings.ToLower(utils.ExtractSearchText([]interface{}{data, locales})), strings.ToLower(text)))
+}
+
+// searchResults keeps the best matches first
+func searchResults(results []model.SearchResult, limit int64) []model.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if int64(len(results)) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (s *memoryStorage) SearchContentItems(appID *string, orgID string, text string, categoryList []string, state *string, workflowState *string, limit int64) ([]model.SearchResult, error) {
+	items, _ := s.FindContentItems(appID, orgID, nil, categoryList, workflowState, nil, nil, nil)
+	results := []model.SearchResult{}
+	for _, item := range items {
+		score := searchScore(text, item.Data, item.Locales)
+		if score == 0 {
+			continue
+		}
+		results = append(results, model.SearchResult{Collection: "content_items", ID: item.ID, Category: item.Category, Data: item.Data, Score: score})
+	}
+	return searchResults(results, limit), nil
+}
+
+func (s *memoryStorage) SearchDataContentItems(appID *string, orgID string, text string, categoryList []string, excludedCategories []string, limit int64) ([]model.SearchResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	results := []model.SearchResult{}
+	for _, item := range s.dataContentItems {
+		if !scoped(appID, orgID, item.AppID, item.OrgID) || (len(categoryList) > 0 && !slices.Contains(categoryList, item.Category)) ||
+			slices.Contains(excludedCategories, item.Category) {
+			continue
+		}
+		score := searchScore(text, item.Data, item.Locales)
+		if score == 0 {
+			continue
+		}
+		results = append(results, model.SearchResult{Collection: "data_content_items", ID: item.ID, Key: item.Key, Category: item.Category, Data: item.Data, Score: score})
+	}
+	return searchResults(results, limit), nil
+}
+
+func (s *memoryStorage) CreateWebhookDelivery(item model.WebhookDelivery) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deliveries = append(s.deliveries, item)
+	return nil
+}
+
+// testServices gives the services on top of the storage
+func testServices(storage interfaces.Storage) *servicesImpl {
+	logger := logs.NewLogger("content-test", nil)
+	app := Application{storage: storage, logger: logger, webhooksLogic: newWebhooksLogic(logger, storage, nil)}
+	return &servicesImpl{app: &app}
+}
+
+// served says if the clients see a stored content item now
+func served(item model.ContentItem) bool {
+	state := model.ContentItemChangeState{WorkflowState: item.WorkflowState, PublishAt: item.PublishAt, ExpireAt: item.ExpireAt}
+	return state.Served(time.Now().UTC())
+}
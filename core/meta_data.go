@@ -0,0 +1,37 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+// metaDataScope gives the app and the organization of the meta data, both are nil for the global meta data
+func metaDataScope(claims *tokenauth.Claims, global bool) (*string, *string) {
+	if global {
+		return nil, nil
+	}
+	return &claims.AppID, &claims.OrgID
+}
+
+// canChangeMetaData says if the meta data can be changed with the claims, the meta data without permissions is changed only by the admins
+func canChangeMetaData(item *model.MetaData, claims *tokenauth.Claims, admin bool) bool {
+	if len(item.Permissions) == 0 {
+		return admin
+	}
+	return checkPermissions(item.Permissions, claims.Permissions)
+}
@@ -0,0 +1,203 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+func TestCanChangeMetaData(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		claims      tokenauth.Claims
+		admin       bool
+		want        bool
+	}{
+		{name: "admin without permissions", admin: true, want: true},
+		{name: "client without permissions"},
+		{name: "client with the permission", permissions: []string{"a", "b"}, claims: tokenauth.Claims{Permissions: "c,b"}, want: true},
+		{name: "client without the permission", permissions: []string{"a"}, claims: tokenauth.Claims{Permissions: "c"}},
+		{name: "admin without the permission", permissions: []string{"a"}, claims: tokenauth.Claims{Permissions: "c"}, admin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canChangeMetaData(&model.MetaData{Key: "a", Permissions: tt.permissions}, &tt.claims, tt.admin); got != tt.want {
+				t.Errorf("canChangeMetaData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetaDataScoping(t *testing.T) {
+	appID, orgID := "app", "org"
+	otherAppID := "other"
+	storage := &memoryStorage{metaData: []model.MetaData{
+		{ID: "1", Key: "banner", Value: map[string]interface{}{"text": "global"}},
+		{ID: "2", AppID: &appID, OrgID: &orgID, Key: "banner", Value: map[string]interface{}{"text": "app"}},
+		{ID: "3", Key: "theme", Value: map[string]interface{}{"color": "blue"}},
+		{ID: "4", AppID: &otherAppID, OrgID: &orgID, Key: "menu", Value: map[string]interface{}{"items": 3.0}},
+	}}
+	services := testServices(storage)
+	claims := &tokenauth.Claims{AppID: appID, OrgID: orgID}
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "meta data of the app", key: "banner", want: "2"},
+		{name: "global meta data", key: "theme", want: "3"},
+		{name: "meta data of another app", key: "menu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := services.GetMetaData(claims, &tt.key)
+			if err != nil {
+				t.Fatalf("GetMetaData() error = %v", err)
+			}
+			if (item == nil && len(tt.want) > 0) || (item != nil && item.ID != tt.want) {
+				t.Errorf("GetMetaData() = %+v, want %s", item, tt.want)
+			}
+		})
+	}
+
+	list, err := services.GetMetaDataList(claims, true, nil, nil, nil)
+	if err != nil || len(list) != 2 || list[0].ID != "1" || list[1].ID != "3" {
+		t.Errorf("GetMetaDataList() = %+v, %v, want the global meta data", list, err)
+	}
+	list, err = services.GetMetaDataList(claims, false, nil, nil, nil)
+	if err != nil || len(list) != 1 || list[0].ID != "2" {
+		t.Errorf("GetMetaDataList() = %+v, %v, want the meta data of the app", list, err)
+	}
+}
+
+func TestCreateOrUpdateMetaData(t *testing.T) {
+	appID, orgID := "app", "org"
+	value := map[string]interface{}{"text": "a"}
+	editors := []string{"edit_banner"}
+	noPermissions := []string{}
+	accessErr := &model.MetaDataAccessError{Key: "banner"}
+
+	tests := []struct {
+		name            string
+		stored          []string // the permissions of the stored meta data of the app, nil when there is none
+		claims          tokenauth.Claims
+		admin           bool
+		global          bool
+		permissions     *[]string
+		wantErr         error
+		wantPermissions []string
+	}{
+		{name: "admin creates", admin: true, permissions: &editors, wantPermissions: editors},
+		{name: "admin creates global", admin: true, global: true, wantPermissions: nil},
+		{name: "client creates", wantErr: accessErr},
+		{name: "admin updates and keeps the permissions", stored: editors, admin: true, claims: tokenauth.Claims{Permissions: "edit_banner"}, wantPermissions: editors},
+		{name: "admin updates and removes the permissions", stored: editors, admin: true, claims: tokenauth.Claims{Permissions: "edit_banner"}, permissions: &noPermissions, wantPermissions: noPermissions},
+		{name: "client with the permission updates", stored: editors, claims: tokenauth.Claims{Permissions: "edit_banner"}, wantPermissions: editors},
+		{name: "client without the permission updates", stored: editors, claims: tokenauth.Claims{Permissions: "view_banner"}, wantErr: accessErr},
+		{name: "client updates without permissions", stored: noPermissions, claims: tokenauth.Claims{Permissions: "edit_banner"}, wantErr: accessErr},
+		{name: "admin without the permission updates", stored: editors, admin: true, claims: tokenauth.Claims{Permissions: "view_banner"}, wantErr: accessErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{}
+			if tt.stored != nil {
+				storage.metaData = []model.MetaData{{ID: "1", AppID: &appID, OrgID: &orgID, Key: "banner", Value: map[string]interface{}{"text": "b"}, Permissions: tt.stored}}
+			}
+			before := storage.metaData
+			claims := tt.claims
+			claims.AppID, claims.OrgID = appID, orgID
+			actor := &model.AuditActor{AccountID: "admin", AppID: appID, OrgID: orgID}
+
+			item, err := testServices(storage).CreateOrUpdateMetaData(actor, &claims, tt.admin, tt.global, "banner", value, tt.permissions)
+			if tt.wantErr != nil {
+				var metaDataErr *model.MetaDataAccessError
+				if !errors.As(err, &metaDataErr) || !reflect.DeepEqual(metaDataErr, tt.wantErr) {
+					t.Fatalf("CreateOrUpdateMetaData() error = %v, want %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(storage.metaData, before) || len(storage.auditLog) != 0 {
+					t.Errorf("stored meta data = %+v, want it unchanged", storage.metaData)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateOrUpdateMetaData() error = %v", err)
+			}
+
+			wantAppID, wantOrgID := &appID, &orgID
+			if tt.global {
+				wantAppID, wantOrgID = nil, nil
+			}
+			if !reflect.DeepEqual(item.Value, value) || !reflect.DeepEqual(item.Permissions, tt.wantPermissions) ||
+				!reflect.DeepEqual(item.AppID, wantAppID) || !reflect.DeepEqual(item.OrgID, wantOrgID) {
+				t.Errorf("CreateOrUpdateMetaData() = %+v, want the value with the permissions %v", item, tt.wantPermissions)
+			}
+			if len(storage.metaData) != 1 || len(storage.auditLog) != 1 {
+				t.Errorf("stored meta data = %+v, audit log = %+v, want one of each", storage.metaData, storage.auditLog)
+			}
+		})
+	}
+}
+
+func TestDeleteMetaData(t *testing.T) {
+	appID, orgID := "app", "org"
+	stored := []model.MetaData{
+		{ID: "1", Key: "banner", Value: map[string]interface{}{"text": "global"}},
+		{ID: "2", AppID: &appID, OrgID: &orgID, Key: "banner", Value: map[string]interface{}{"text": "app"}, Permissions: []string{"edit_banner"}},
+	}
+
+	tests := []struct {
+		name    string
+		claims  tokenauth.Claims
+		admin   bool
+		global  bool
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "client with the permission", claims: tokenauth.Claims{Permissions: "edit_banner"}, wantIDs: []string{"1"}},
+		{name: "client without the permission", claims: tokenauth.Claims{Permissions: "view_banner"}, wantIDs: []string{"1", "2"}, wantErr: true},
+		{name: "global by a client", claims: tokenauth.Claims{Permissions: "edit_banner"}, global: true, wantIDs: []string{"1", "2"}, wantErr: true},
+		{name: "global by an admin", admin: true, global: true, wantIDs: []string{"2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{metaData: append([]model.MetaData{}, stored...)}
+			claims := tt.claims
+			claims.AppID, claims.OrgID = appID, orgID
+
+			err := testServices(storage).DeleteMetaData(nil, &claims, tt.admin, tt.global, "banner")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteMetaData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			ids := []string{}
+			for _, item := range storage.metaData {
+				ids = append(ids, item.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("stored meta data = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	// AuditOperationCreate is the operation of a created resource
+	AuditOperationCreate = "create"
+	// AuditOperationUpdate is the operation of an updated resource
+	AuditOperationUpdate = "update"
+	// AuditOperationDelete is the operation of a deleted resource
+	AuditOperationDelete = "delete"
+)
+
+const (
+	// AuditResourceContentItem is the content items resource
+	AuditResourceContentItem = "content_item"
+	// AuditResourceDataContentItem is the data content items resource
+	AuditResourceDataContentItem = "data_content_item"
+	// AuditResourceCategory is the categories resource
+	AuditResourceCategory = "category"
+	// AuditResourceMetaData is the meta data resource
+	AuditResourceMetaData = "meta_data"
+	// AuditResourceFile is the file content items resource
+	AuditResourceFile = "file"
+	// AuditResourceImage is the images resource
+	AuditResourceImage = "image"
+	// AuditResourceStudentGuide is the student guides resource
+	AuditResourceStudentGuide = "student_guide"
+	// AuditResourceHealthLocation is the health locations resource
+	AuditResourceHealthLocation = "health_location"
+	// AuditResourceWebhook is the webhooks resource
+	AuditResourceWebhook = "webhook"
+	// AuditResourceStorageQuota is the storage quotas resource
+	AuditResourceStorageQuota = "storage_quota"
+)
+
+// AuditActor is the admin account doing a mutation, in the request it comes with
+type AuditActor struct {
+	AccountID   string
+	AccountName string
+	Permissions []string
+	AppID       string
+	OrgID       string
+	RequestID   string
+}
+
+// AuditLogEntry records who changed what
+type AuditLogEntry struct {
+	ID          string              `json:"id" bson:"_id"`
+	AppID       string              `json:"app_id" bson:"app_id"`
+	OrgID       string              `json:"org_id" bson:"org_id"`
+	AccountID   string              `json:"account_id" bson:"account_id"`
+	AccountName string              `json:"account_name,omitempty" bson:"account_name,omitempty"`
+	Permissions []string            `json:"permissions" bson:"permissions"`
+	RequestID   string              `json:"request_id" bson:"request_id"`
+	Resource    string              `json:"resource" bson:"resource"`
+	ResourceID  string              `json:"resource_id" bson:"resource_id"`
+	Category    string              `json:"category,omitempty" bson:"category,omitempty"`
+	Operation   string              `json:"operation" bson:"operation"`
+	Changes     []ContentItemChange `json:"changes" bson:"changes"` // from the state before the mutation to the state after it
+	Date        time.Time           `json:"date" bson:"date"`
+} // @name AuditLogEntry
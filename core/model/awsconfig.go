@@ -20,8 +20,10 @@ type AWSConfig struct {
 	S3BucketAccelerate    bool
 	S3ProfileImagesBucket string
 	S3UsersAudiosBucket   string
+	S3QuarantineBucket    string // optional, the flagged files cannot be quarantined without it
 
 	S3Region           string
+	S3Endpoint         string // set for the S3 compatible services like MinIO, the buckets are addressed by path then
 	AWSAccessKeyID     string
 	AWSSecretAccessKey string
 }
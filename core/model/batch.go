@@ -0,0 +1,109 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	// BatchOperationCreate creates an item
+	BatchOperationCreate = "create"
+	// BatchOperationUpdate replaces an item
+	BatchOperationUpdate = "update"
+	// BatchOperationDelete deletes an item
+	BatchOperationDelete = "delete"
+)
+
+const (
+	// BatchStatusApplied is the status of a stored operation
+	BatchStatusApplied = "applied"
+	// BatchStatusFailed is the status of the operation which has stopped the batch
+	BatchStatusFailed = "failed"
+	// BatchStatusRolledBack is the status of a done operation which is not stored because another one has failed
+	BatchStatusRolledBack = "rolled_back"
+	// BatchStatusSkipped is the status of an operation which is not done because another one has failed
+	BatchStatusSkipped = "skipped"
+)
+
+// ContentItemBatchOperation is an operation of a content items batch
+type ContentItemBatchOperation struct {
+	Op            string                           `json:"op"`           // create, update or delete
+	ID            string                           `json:"id,omitempty"` // required for update and delete
+	Category      string                           `json:"category,omitempty"`
+	Data          interface{}                      `json:"data,omitempty"`
+	DefaultLocale Nullable[string]                 `json:"default_locale"` // an update keeps it when it is left out and removes it when it is null
+	Locales       Nullable[map[string]interface{}] `json:"locales"`        // an update keeps them when they are left out and removes them when they are null
+	PublishAt     *time.Time                       `json:"publish_at,omitempty"`
+	ExpireAt      *time.Time                       `json:"expire_at,omitempty"`
+} // @name ContentItemBatchOperation
+
+// DataContentItemBatchOperation is an operation of a data content items batch, the items are identified by key
+type DataContentItemBatchOperation struct {
+	Op            string                           `json:"op"` // create, update or delete
+	Key           string                           `json:"key"`
+	Category      string                           `json:"category,omitempty"`
+	Data          interface{}                      `json:"data,omitempty"`
+	DefaultLocale Nullable[string]                 `json:"default_locale"` // an update keeps it when it is left out and removes it when it is null
+	Locales       Nullable[map[string]interface{}] `json:"locales"`        // an update keeps them when they are left out and removes them when they are null
+} // @name DataContentItemBatchOperation
+
+// BatchResult is the result of a batch, its operations are stored all together or none of them
+type BatchResult struct {
+	Applied bool                   `json:"applied"`
+	Results []BatchOperationResult `json:"results"` // in the order of the operations
+} // @name BatchResult
+
+// BatchOperationResult is the result of an operation of a batch
+type BatchOperationResult struct {
+	Index  int         `json:"index"` // 0-based position in the batch
+	Op     string      `json:"op"`
+	ID     string      `json:"id,omitempty"` // the id of a content item, the key of a data content item
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Item   interface{} `json:"item,omitempty"` // the stored item of an applied create or update
+} // @name BatchOperationResult
+
+// NewBatchResult creates the result of a batch, every operation is skipped until it is done
+func NewBatchResult(ops []string) *BatchResult {
+	results := make([]BatchOperationResult, len(ops))
+	for i, op := range ops {
+		results[i] = BatchOperationResult{Index: i, Op: op, Status: BatchStatusSkipped}
+	}
+	return &BatchResult{Results: results}
+}
+
+// Done marks an operation as done
+func (r *BatchResult) Done(index int, id string, item interface{}) {
+	r.Results[index].ID = id
+	r.Results[index].Status = BatchStatusApplied
+	r.Results[index].Item = item
+}
+
+// Fail marks an operation as failed
+func (r *BatchResult) Fail(index int, id string, message string) {
+	r.Results[index].ID = id
+	r.Results[index].Status = BatchStatusFailed
+	r.Results[index].Error = message
+}
+
+// RollBack marks the done operations as rolled back once the batch is not stored
+func (r *BatchResult) RollBack() {
+	r.Applied = false
+	for i := range r.Results {
+		if r.Results[i].Status == BatchStatusApplied {
+			r.Results[i].Status = BatchStatusRolledBack
+			r.Results[i].Item = nil
+		}
+	}
+}
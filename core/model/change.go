@@ -0,0 +1,67 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	// ContentChangeInsert is the operation of a created item
+	ContentChangeInsert = "insert"
+	// ContentChangeUpdate is the operation of an updated item
+	ContentChangeUpdate = "update"
+	// ContentChangeReplace is the operation of an item replaced as a whole
+	ContentChangeReplace = "replace"
+	// ContentChangeDelete is the operation of a deleted item
+	ContentChangeDelete = "delete"
+)
+
+// ContentChange is a change of a content item, a data content item or a meta data entry
+type ContentChange struct {
+	Collection string    `json:"collection"` // content_items, data_content_items or meta_data
+	Operation  string    `json:"operation"`
+	ID         string    `json:"id"`
+	Key        string    `json:"key,omitempty"`      // set for the data content items and the meta data
+	Category   string    `json:"category,omitempty"` // empty for the meta data
+	Date       time.Time `json:"date"`
+	AppID      *string   `json:"-"` // nil for the items of all the apps in the organization
+	OrgID      string    `json:"-"`
+	Global     bool      `json:"-"` // the meta data of all the organizations
+
+	ItemBefore *ContentItemChangeState `json:"-"` // the content item before the change, set when the pre-images are available
+	ItemAfter  *ContentItemChangeState `json:"-"` // the content item after the change, nil for the deletes
+} // @name ContentChange
+
+// ContentItemChangeState is the workflow state and the publish window of a changed content item
+type ContentItemChangeState struct {
+	WorkflowState string
+	PublishAt     *time.Time
+	ExpireAt      *time.Time
+}
+
+// Published says if the content item is published and its publish time has come, it may have expired since
+func (s *ContentItemChangeState) Published(now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.WorkflowState) > 0 && s.WorkflowState != ContentItemWorkflowPublished {
+		return false
+	}
+	return s.PublishAt == nil || !s.PublishAt.After(now)
+}
+
+// Served says if the clients see the content item at the time - it is published and within its publish window
+func (s *ContentItemChangeState) Served(now time.Time) bool {
+	return s.Published(now) && (s.ExpireAt == nil || s.ExpireAt.After(now))
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContentItemChangeStateServed(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		state         *ContentItemChangeState
+		wantPublished bool
+		wantServed    bool
+	}{
+		{name: "no item"},
+		{name: "published", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished}, wantPublished: true, wantServed: true},
+		{name: "before the workflow", state: &ContentItemChangeState{}, wantPublished: true, wantServed: true},
+		{name: "draft", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowDraft}},
+		{name: "archived", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowArchived}},
+		{name: "scheduled", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished, PublishAt: &later}},
+		{name: "publish time has come", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished, PublishAt: &now},
+			wantPublished: true, wantServed: true},
+		{name: "within the window", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished, PublishAt: &earlier, ExpireAt: &later},
+			wantPublished: true, wantServed: true},
+		{name: "expired", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished, ExpireAt: &earlier}, wantPublished: true},
+		{name: "expires now", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowPublished, ExpireAt: &now}, wantPublished: true},
+		{name: "draft within the window", state: &ContentItemChangeState{WorkflowState: ContentItemWorkflowDraft, PublishAt: &earlier, ExpireAt: &later}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.Published(now); got != tt.wantPublished {
+				t.Errorf("Published() = %v, want %v", got, tt.wantPublished)
+			}
+			if got := tt.state.Served(now); got != tt.wantServed {
+				t.Errorf("Served() = %v, want %v", got, tt.wantServed)
+			}
+		})
+	}
+}
@@ -14,36 +14,218 @@
 
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // DataContentItem defines abstract data structure that would be used for any purpose
 type DataContentItem struct {
+	ID            string                 `json:"id" bson:"_id"`
+	Category      string                 `json:"category" bson:"category"`
+	DateCreated   time.Time              `json:"date_created" bson:"date_created"`
+	DateUpdated   *time.Time             `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+	Data          interface{}            `json:"data" bson:"data"`
+	DefaultLocale string                 `json:"default_locale,omitempty" bson:"default_locale,omitempty"` // the locale of the data
+	Locales       map[string]interface{} `json:"locales,omitempty" bson:"locales,omitempty"`               // the data translated to other locales, by locale
+	OrgID         string                 `json:"org_id" bson:"org_id"`
+	AppID         *string                `json:"app_id" bson:"app_id"`
+	Key           string                 `json:"key" bson:"key"`
+	SearchText    string                 `json:"-" bson:"search_text"` // the text of the data used by the full-text search
+} // @name DataContentItem
+
+// SearchResult is a content item or a data content item matching a full-text search
+type SearchResult struct {
+	Collection  string      `json:"collection" bson:"-"` // content_items or data_content_items
 	ID          string      `json:"id" bson:"_id"`
+	Key         string      `json:"key,omitempty" bson:"key,omitempty"` // set for the data content items
 	Category    string      `json:"category" bson:"category"`
+	Data        interface{} `json:"data" bson:"data"`
 	DateCreated time.Time   `json:"date_created" bson:"date_created"`
 	DateUpdated *time.Time  `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
-	Data        interface{} `json:"data" bson:"data"`
-	OrgID       string      `json:"org_id" bson:"org_id"`
-	AppID       *string     `json:"app_id" bson:"app_id"`
-	Key         string      `json:"key" bson:"key"`
-} // @name DataContentItem
+	Score       float64     `json:"score" bson:"score"`
+} // @name SearchResult
 
 // Category defines a category with permissions to allow editing of content items
 type Category struct {
-	ID          string     `json:"id" bson:"_id"`
-	Name        string     `json:"name" bson:"name"`
-	OrgID       string     `json:"org_id" bson:"org_id"`
-	AppID       *string    `json:"app_id" bson:"app_id"`
-	DateCreated time.Time  `json:"date_created" bson:"date_created"`
-	DateUpdated *time.Time `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
-	Permissions []string   `json:"permissions" bson:"permissions"`
+	ID          string          `json:"id" bson:"_id"`
+	Name        string          `json:"name" bson:"name"`
+	Parent      string          `json:"parent,omitempty" bson:"parent,omitempty"` // the name of the parent category, empty for a top level category
+	OrgID       string          `json:"org_id" bson:"org_id"`
+	AppID       *string         `json:"app_id" bson:"app_id"`
+	DateCreated time.Time       `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time      `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+	Permissions []string        `json:"permissions" bson:"permissions"`           // the permissions to create and update the items, a subcategory inherits them while they are null, an empty list overrides them
+	Schema      json.RawMessage `json:"schema,omitempty" bson:"schema,omitempty"` // optional JSON Schema the data of the category items must conform to
+
+	ReadPermissions   []string `json:"read_permissions" bson:"read_permissions"`     // inherited while null, the items can be read by anyone when they are empty
+	DeletePermissions []string `json:"delete_permissions" bson:"delete_permissions"` // inherited while null, the permissions to create and update the items are used when neither the category nor its ancestors have them
+	RequiresAuth      bool     `json:"requires_auth" bson:"requires_auth"`           // the items cannot be read with an anonymous token
+
+	FilePolicy *FilePolicy `json:"file_policy,omitempty" bson:"file_policy,omitempty"` // a subcategory without a policy uses the policy of its closest ancestor which has one
 } // @name Category
 
-// MetaData defines a meta_data object
+// FilePolicy is what files a category accepts
+type FilePolicy struct {
+	AllowedMimeTypes []string `json:"allowed_mime_types" bson:"allowed_mime_types"` // detected from the content, type/* allows all the subtypes, any type when empty
+	MaxSize          int64    `json:"max_size" bson:"max_size"`                     // in bytes, no limit when 0
+} // @name FilePolicy
+
+// CategoryAccess is who may use the items of a category, with what the category inherits from its ancestors
+type CategoryAccess struct {
+	RequiresAuth      bool     `json:"requires_auth"`
+	ReadPermissions   []string `json:"read_permissions"`
+	Permissions       []string `json:"permissions"`
+	DeletePermissions []string `json:"delete_permissions"`
+} // @name CategoryAccess
+
+// CategoryAccessError is returned when the items of a category cannot be accessed with a token
+type CategoryAccessError struct {
+	Category string
+	Access   string // write or delete, read when empty
+}
+
+func (e *CategoryAccessError) Error() string {
+	access := e.Access
+	if len(access) == 0 {
+		access = "read"
+	}
+	return fmt.Sprintf("unauthorized to %s the items of category %s", access, e.Category)
+}
+
+// SchemaViolation is a place in the data which does not conform to the category schema
+type SchemaViolation struct {
+	Locale  string `json:"locale,omitempty"` // set when the violation is in a translation of the data
+	Pointer string `json:"pointer"`          // JSON pointer relative to the data
+	Message string `json:"message"`
+} // @name SchemaViolation
+
+// SchemaValidationError is returned when the data of an item does not conform to its category schema
+type SchemaValidationError struct {
+	Category   string            `json:"category"`
+	Violations []SchemaViolation `json:"violations"`
+} // @name SchemaValidationError
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("data does not conform to the schema of category %s: %d violations", e.Category, len(e.Violations))
+}
+
+// InvalidSchemaError is returned when a category schema is not a valid JSON Schema
+type InvalidSchemaError struct {
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+} // @name InvalidSchemaError
+
+func (e *InvalidSchemaError) Error() string {
+	return fmt.Sprintf("invalid schema for category %s: %s", e.Category, e.Reason)
+}
+
+// CategorySchemaReport holds the result of validating the stored items of a category against a schema
+type CategorySchemaReport struct {
+	Category     string                      `json:"category"`
+	Valid        bool                        `json:"valid"`
+	CheckedCount int                         `json:"checked_count"`
+	InvalidItems []CategorySchemaInvalidItem `json:"invalid_items"`
+} // @name CategorySchemaReport
+
+// CategorySchemaInvalidItem is a stored item which does not conform to a schema
+type CategorySchemaInvalidItem struct {
+	Collection string            `json:"collection"` // content_items or data_content_items
+	ID         string            `json:"id"`
+	Key        string            `json:"key,omitempty"` // set for the data content items
+	Violations []SchemaViolation `json:"violations"`
+} // @name CategorySchemaInvalidItem
+
+// CategorySchemaError is returned when a category schema cannot be saved because the stored items do not conform to it
+type CategorySchemaError struct {
+	Report CategorySchemaReport
+}
+
+func (e *CategorySchemaError) Error() string {
+	return fmt.Sprintf("%d stored items do not conform to the schema of category %s", len(e.Report.InvalidItems), e.Report.Category)
+}
+
+// CategoryHierarchyError is returned when a category cannot be placed under its parent
+type CategoryHierarchyError struct {
+	Category string
+	Reason   string
+}
+
+func (e *CategoryHierarchyError) Error() string {
+	return fmt.Sprintf("invalid parent for category %s: %s", e.Category, e.Reason)
+}
+
+// CategoryInUseError is returned when a category cannot be deleted because items, subcategories or files still reference it, or renamed because it has files
+type CategoryInUseError struct {
+	Category         string `json:"category"`
+	ContentItems     int64  `json:"content_items_count"`
+	DataContentItems int64  `json:"data_content_items_count"`
+	Subcategories    int    `json:"subcategories_count"`
+	Files            int64  `json:"files_count"` // the files cannot be moved to another category, they must be deleted first
+} // @name CategoryInUseError
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category %s is in use by %d content items, %d data content items, %d subcategories and %d files",
+		e.Category, e.ContentItems, e.DataContentItems, e.Subcategories, e.Files)
+}
+
+// CategoryExistsError is returned when a category is renamed to the name of another category
+type CategoryExistsError struct {
+	Category string
+}
+
+func (e *CategoryExistsError) Error() string {
+	return fmt.Sprintf("category %s already exists", e.Category)
+}
+
+// CategorySummary is a category with the counts of the items in it
+type CategorySummary struct {
+	Category         `bson:",inline"`
+	ContentItems     int64 `json:"content_items_count" bson:"-"`
+	DataContentItems int64 `json:"data_content_items_count" bson:"-"`
+} // @name CategorySummary
+
+// CategoryTree is a category with its subcategories
+type CategoryTree struct {
+	Category
+	Effective CategoryAccess `json:"effective"` // the access of the category with what it inherits
+	Children  []CategoryTree `json:"children"`
+} // @name CategoryTree
+
+// MissingTranslationsReport lists the items of a category which are not translated to all the locales
+type MissingTranslationsReport struct {
+	Category      string                    `json:"category"`
+	Locales       []string                  `json:"locales"`        // the locales every item is expected to have
+	MissingCounts map[string]int            `json:"missing_counts"` // count of the items without a translation, by locale
+	Items         []MissingTranslationsItem `json:"items"`
+} // @name MissingTranslationsReport
+
+// MissingTranslationsItem is an item which is not translated to some of the locales
+type MissingTranslationsItem struct {
+	Collection     string   `json:"collection"` // content_items or data_content_items
+	ID             string   `json:"id"`
+	Key            string   `json:"key,omitempty"` // set for the data content items
+	MissingLocales []string `json:"missing_locales"`
+} // @name MissingTranslationsItem
+
+// MetaData defines a meta_data object. The global meta data has neither an app nor an organization.
 type MetaData struct {
 	ID          string                 `json:"id" bson:"_id"`
+	AppID       *string                `json:"app_id" bson:"app_id"`
+	OrgID       *string                `json:"org_id" bson:"org_id"`
 	Key         string                 `json:"key" bson:"key"`
 	Value       map[string]interface{} `json:"value" bson:"value"`
+	Permissions []string               `json:"permissions,omitempty" bson:"permissions,omitempty"` // any of them is needed to update and delete it, anyone of the app may when there are none
 	DateCreated time.Time              `json:"date_created" bson:"date_created"`
 	DateUpdated *time.Time             `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
 } // @name MetaData
+
+// MetaDataAccessError is returned when the meta data cannot be changed with a token
+type MetaDataAccessError struct {
+	Key string
+}
+
+func (e *MetaDataAccessError) Error() string {
+	return fmt.Sprintf("unauthorized to change meta data %s", e.Key)
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCategoryPermissionsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+	}{
+		{name: "inherited", permissions: nil},
+		{name: "empty", permissions: []string{}},
+		{name: "set", permissions: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := Category{Name: "events", ReadPermissions: tt.permissions, Permissions: tt.permissions, DeletePermissions: tt.permissions}
+
+			var fromJSON Category
+			data, err := json.Marshal(category)
+			if err == nil {
+				err = json.Unmarshal(data, &fromJSON)
+			}
+			if err != nil {
+				t.Fatalf("json error = %v", err)
+			}
+
+			var fromBSON Category
+			data, err = bson.Marshal(category)
+			if err == nil {
+				err = bson.Unmarshal(data, &fromBSON)
+			}
+			if err != nil {
+				t.Fatalf("bson error = %v", err)
+			}
+
+			for _, got := range []Category{fromJSON, fromBSON} {
+				for _, permissions := range [][]string{got.ReadPermissions, got.Permissions, got.DeletePermissions} {
+					if !reflect.DeepEqual(permissions, tt.permissions) {
+						t.Errorf("permissions = %#v, want %#v", permissions, tt.permissions)
+					}
+				}
+			}
+		})
+	}
+}
@@ -14,8 +14,141 @@
 
 package model
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // FileContentItemRef represents a reference to a file that is located in external storage at URL
 type FileContentItemRef struct {
 	Key string `json:"key"`
 	URL string `json:"url"`
 }
+
+// FileContentItem is the record of an uploaded file content item, the file itself is in the object storage
+type FileContentItem struct {
+	ID          string     `json:"id" bson:"_id"`
+	AppID       string     `json:"app_id" bson:"app_id"`
+	OrgID       string     `json:"org_id" bson:"org_id"`
+	Key         string     `json:"key" bson:"key"`   // the file key like in the file content item references
+	Path        string     `json:"path" bson:"path"` // the key of the object
+	Category    string     `json:"category" bson:"category"`
+	EntityID    string     `json:"entity_id" bson:"entity_id"`
+	AccountID   string     `json:"account_id" bson:"account_id"` // the account which uploaded the file
+	Size        int64      `json:"size" bson:"size"`
+	MimeType    string     `json:"mime_type" bson:"mime_type"`                     // detected from the content
+	Checksum    string     `json:"checksum" bson:"checksum"`                       // hex SHA-256 of the content
+	Quarantined bool       `json:"quarantined" bson:"quarantined"`                 // flagged by the scanner, the file is in the quarantine bucket
+	Signature   string     `json:"signature,omitempty" bson:"signature,omitempty"` // what the scanner found in a quarantined file
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
+} // @name FileContentItem
+
+// Object storage buckets, every backend maps them to its own buckets or directories
+const (
+	ObjectBucketContent       = "content" // the images and the file content items
+	ObjectBucketProfileImages = "profile_images"
+	ObjectBucketUsersAudios   = "users_audios"
+	ObjectBucketQuarantine    = "quarantine" // the flagged files, they are private
+)
+
+// ErrObjectNotFound is returned by the object storage when there is no object with the key
+var ErrObjectNotFound = errors.New("object not found")
+
+// StoredObject is an object in the object storage
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// PresignedObjectRequest is a request with a URL presigned by an object storage backend which this service serves
+type PresignedObjectRequest struct {
+	Method     string
+	Bucket     string
+	Key        string
+	UploadID   string // set for the parts of the multipart uploads
+	PartNumber int
+	Expires    int64 // unix time, 0 for the downloads of the public objects
+	Signature  string
+}
+
+// MaxUploadParts is the most parts a multipart upload may have
+const MaxUploadParts = 10000
+
+// MultipartUpload is a multipart upload of a large file content item which has not been completed or aborted yet
+type MultipartUpload struct {
+	ID          string    `json:"id" bson:"_id"`
+	UploadID    string    `json:"-" bson:"upload_id"` // the id the object storage gave the upload
+	AppID       string    `json:"app_id" bson:"app_id"`
+	OrgID       string    `json:"org_id" bson:"org_id"`
+	AccountID   string    `json:"account_id" bson:"account_id"` // only the account which initiated it may complete or abort it
+	Category    string    `json:"category" bson:"category"`
+	EntityID    string    `json:"entity_id" bson:"entity_id"`
+	Key         string    `json:"key" bson:"key"`   // the file key like in the file content item references
+	Path        string    `json:"path" bson:"path"` // the key of the object
+	Public      bool      `json:"public" bson:"public"`
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} // @name MultipartUpload
+
+// UploadPartRef is a presigned URL for uploading a part of a multipart upload
+type UploadPartRef struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+} // @name UploadPartRef
+
+// UploadedPart is a part of a multipart upload the client has uploaded, with the ETag the upload of the part responded with
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+} // @name UploadedPart
+
+// ErrMultipartUploadNotFound is returned when there is no multipart upload with the id for the account
+var ErrMultipartUploadNotFound = errors.New("multipart upload not found")
+
+// ScanResult is the verdict of the scanner on a file
+type ScanResult struct {
+	Clean     bool
+	Signature string // what the scanner found when the file is not clean
+}
+
+// Reasons of the file policy errors
+const (
+	FilePolicyMimeType = "mime_type"
+	FilePolicySize     = "size"
+)
+
+// FilePolicyError is returned when a file does not conform to the file policy of its category
+type FilePolicyError struct {
+	Category string
+	Reason   string
+	MimeType string
+	MaxSize  int64
+}
+
+func (e *FilePolicyError) Error() string {
+	if e.Reason == FilePolicySize {
+		return fmt.Sprintf("the files of category %s cannot be larger than %d bytes", e.Category, e.MaxSize)
+	}
+	return fmt.Sprintf("the files of category %s cannot be of type %s", e.Category, e.MimeType)
+}
+
+// FileQuarantinedError is returned when the scanner flags a file, the file is moved to the quarantine
+type FileQuarantinedError struct {
+	Key       string
+	Signature string
+}
+
+func (e *FileQuarantinedError) Error() string {
+	return fmt.Sprintf("file %s is quarantined - %s", e.Key, e.Signature)
+}
+
+// ObjectAccessError is returned when a presigned object URL is not valid or has expired
+type ObjectAccessError struct {
+	Reason string
+}
+
+func (e *ObjectAccessError) Error() string {
+	return "object access denied: " + e.Reason
+}
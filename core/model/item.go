@@ -14,18 +14,115 @@
 
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // ContentItemResponse is a workaround due to problem with data json & bson encode and decode with abstract type
 type ContentItemResponse = map[string]interface{}
 
+const (
+	// ContentItemStateScheduled is the state of a content item which publish time has not come yet
+	ContentItemStateScheduled = "scheduled"
+	// ContentItemStateLive is the state of a content item which is within its publish window
+	ContentItemStateLive = "live"
+	// ContentItemStateExpired is the state of a content item which expire time has passed
+	ContentItemStateExpired = "expired"
+)
+
+const (
+	// ContentItemWorkflowDraft is the workflow state of a content item which is being edited
+	ContentItemWorkflowDraft = "draft"
+	// ContentItemWorkflowInReview is the workflow state of a content item which waits for approval
+	ContentItemWorkflowInReview = "in_review"
+	// ContentItemWorkflowPublished is the workflow state of a content item which is served to the clients
+	ContentItemWorkflowPublished = "published"
+	// ContentItemWorkflowArchived is the workflow state of a content item which is no longer in use
+	ContentItemWorkflowArchived = "archived"
+)
+
+// ContentItemWorkflowStates are all the workflow states of the content items
+var ContentItemWorkflowStates = []string{ContentItemWorkflowDraft, ContentItemWorkflowInReview, ContentItemWorkflowPublished, ContentItemWorkflowArchived}
+
+const (
+	// ContentItemTransitionSubmit sends a draft for review
+	ContentItemTransitionSubmit = "submit"
+	// ContentItemTransitionApprove publishes a reviewed item
+	ContentItemTransitionApprove = "approve"
+	// ContentItemTransitionReject returns a reviewed item to draft
+	ContentItemTransitionReject = "reject"
+	// ContentItemTransitionArchive archives an item
+	ContentItemTransitionArchive = "archive"
+	// ContentItemTransitionRestore returns an archived item to draft
+	ContentItemTransitionRestore = "restore"
+)
+
+// ErrContentItemNotFound is returned when there is no content item with the requested id
+var ErrContentItemNotFound = errors.New("content item not found")
+
+// ErrContentItemVersionNotFound is returned when a content item has no stored version with the requested number
+var ErrContentItemVersionNotFound = errors.New("content item version not found")
+
+// WorkflowTransitionError is returned when a content item is not in a state the transition is allowed from
+type WorkflowTransitionError struct {
+	ID         string
+	Transition string
+	State      string
+}
+
+func (e *WorkflowTransitionError) Error() string {
+	return fmt.Sprintf("cannot %s content item %s in workflow state %s", e.Transition, e.ID, e.State)
+}
+
 // ContentItem defines abstract data structure that would be used for any purpose
 type ContentItem struct {
-	ID          string      `json:"id" bson:"_id"`
-	Category    string      `json:"category" bson:"category"`
-	DateCreated time.Time   `json:"date_created" bson:"date_created"`
-	DateUpdated *time.Time  `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
-	Data        interface{} `json:"data" bson:"data"` // could be eigther a primitive or nested json or array
-	OrgID       string      `json:"org_id" bson:"org_id"`
-	AppID       *string     `json:"app_id" bson:"app_id"`
+	ID            string                 `json:"id" bson:"_id"`
+	Category      string                 `json:"category" bson:"category"`
+	DateCreated   time.Time              `json:"date_created" bson:"date_created"`
+	DateUpdated   *time.Time             `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+	Data          interface{}            `json:"data" bson:"data"`                                         // could be eigther a primitive or nested json or array
+	DefaultLocale string                 `json:"default_locale,omitempty" bson:"default_locale,omitempty"` // the locale of the data
+	Locales       map[string]interface{} `json:"locales,omitempty" bson:"locales,omitempty"`               // the data translated to other locales, by locale
+	OrgID         string                 `json:"org_id" bson:"org_id"`
+	AppID         *string                `json:"app_id" bson:"app_id"`
+	PublishAt     *time.Time             `json:"publish_at,omitempty" bson:"publish_at,omitempty"`       // the item is hidden from the clients before this time
+	ExpireAt      *time.Time             `json:"expire_at,omitempty" bson:"expire_at,omitempty"`         // the item is hidden from the clients after this time
+	DateArchived  *time.Time             `json:"date_archived,omitempty" bson:"date_archived,omitempty"` // set once the item has expired
+	WorkflowState string                 `json:"workflow_state" bson:"workflow_state,omitempty"`         // empty for the items created before the workflow, they are treated as published
+	SearchText    string                 `json:"-" bson:"search_text"`                                   // the text of the data used by the full-text search
 } // @name ContentItem
+
+// ContentItemVersion is a prior revision of a content item kept in the history
+type ContentItemVersion struct {
+	ID            string                 `json:"id" bson:"_id"`
+	ContentItemID string                 `json:"content_item_id" bson:"content_item_id"`
+	Version       int                    `json:"version" bson:"version"`
+	Category      string                 `json:"category" bson:"category"`
+	Data          interface{}            `json:"data" bson:"data"`
+	DefaultLocale string                 `json:"default_locale,omitempty" bson:"default_locale,omitempty"`
+	Locales       map[string]interface{} `json:"locales,omitempty" bson:"locales,omitempty"`
+	OrgID         string                 `json:"org_id" bson:"org_id"`
+	AppID         *string                `json:"app_id" bson:"app_id"`
+	PublishAt     *time.Time             `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
+	ExpireAt      *time.Time             `json:"expire_at,omitempty" bson:"expire_at,omitempty"`
+	DateRevised   time.Time              `json:"date_revised" bson:"date_revised"` // when the revision became current
+	DateCreated   time.Time              `json:"date_created" bson:"date_created"` // when the revision was replaced
+} // @name ContentItemVersion
+
+// ContentItemVersionDiff holds the changes between two revisions of a content item
+type ContentItemVersionDiff struct {
+	ContentItemID string              `json:"content_item_id"`
+	From          int                 `json:"from"`
+	To            *int                `json:"to"` // nil when compared against the current revision
+	Changes       []ContentItemChange `json:"changes"`
+} // @name ContentItemVersionDiff
+
+// ContentItemChange is a single change between two revisions
+type ContentItemChange struct {
+	Path string      `json:"path"` // JSON pointer to the changed element
+	Op   string      `json:"op"`   // added, removed or changed
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+} // @name ContentItemChange
@@ -0,0 +1,59 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "encoding/json"
+
+// Nullable is a field of an update which tells the field left out of the request from the field set to null,
+// the updates keep the stored value of a field which is left out and clear the value of a field set to null
+type Nullable[T any] struct {
+	Set   bool // the field is in the request, null or not
+	Value T    // the zero value when the field is null
+}
+
+// NewNullable gives a field which is set to a value
+func NewNullable[T any](value T) Nullable[T] {
+	return Nullable[T]{Set: true, Value: value}
+}
+
+// Or gives the value of the field, the stored value when the field is left out
+func (n Nullable[T]) Or(stored T) T {
+	if !n.Set {
+		return stored
+	}
+	return n.Value
+}
+
+// UnmarshalJSON is called only for the fields which are in the json, null included
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	if string(data) != "null" {
+		err := json.Unmarshal(data, &value)
+		if err != nil {
+			return err
+		}
+	}
+	n.Set = true
+	n.Value = value
+	return nil
+}
+
+// MarshalJSON gives null for a field which is left out
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
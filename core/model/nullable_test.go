@@ -0,0 +1,72 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNullableUnmarshalJSON(t *testing.T) {
+	type body struct {
+		DefaultLocale Nullable[string]                 `json:"default_locale"`
+		Locales       Nullable[map[string]interface{}] `json:"locales"`
+	}
+
+	tests := []struct {
+		name string
+		json string
+		want body
+	}{
+		{name: "left out", json: `{}`, want: body{}},
+		{name: "null", json: `{"default_locale":null,"locales":null}`, want: body{DefaultLocale: Nullable[string]{Set: true}, Locales: Nullable[map[string]interface{}]{Set: true}}},
+		{name: "set", json: `{"default_locale":"en","locales":{"es":"hola"}}`,
+			want: body{DefaultLocale: NewNullable("en"), Locales: NewNullable(map[string]interface{}{"es": "hola"})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got body
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("json.Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullableOr(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Nullable[string]
+		want  string
+	}{
+		{name: "left out", field: Nullable[string]{}, want: "stored"},
+		{name: "null", field: Nullable[string]{Set: true}, want: ""},
+		{name: "set", field: NewNullable("new"), want: "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.Or("stored"); got != tt.want {
+				t.Errorf("Or() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
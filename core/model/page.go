@@ -0,0 +1,69 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PageCursor is the position in a list after which the next page starts - the items are ordered by date_created and then by id
+type PageCursor struct {
+	DateCreated time.Time `json:"d"`
+	ID          string    `json:"i"`
+}
+
+// Encode gives the opaque token the clients pass back to get the next page
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor reads a token given by PageCursor.Encode
+func DecodePageCursor(token string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor PageCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.DateCreated.IsZero() || len(cursor.ID) == 0 {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// ContentItemsPage is a page of content items
+type ContentItemsPage struct {
+	Items      []ContentItemResponse `json:"items"`
+	NextCursor *string               `json:"next_cursor"`     // nil on the last page
+	Total      *int64                `json:"total,omitempty"` // count of all the items in the list, only when requested
+} // @name ContentItemsPage
+
+// DataContentItemsPage is a page of data content items
+type DataContentItemsPage struct {
+	Items      []*DataContentItem `json:"items"`
+	NextCursor *string            `json:"next_cursor"`     // nil on the last page
+	Total      *int64             `json:"total,omitempty"` // count of all the items in the list, only when requested
+} // @name DataContentItemsPage
+
+// CategoriesPage is a page of categories
+type CategoriesPage struct {
+	Items      []CategorySummary `json:"items"`
+	NextCursor *string           `json:"next_cursor"`     // nil on the last page
+	Total      *int64            `json:"total,omitempty"` // count of all the categories, only when requested
+} // @name CategoriesPage
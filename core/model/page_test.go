@@ -0,0 +1,71 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor PageCursor
+	}{
+		{name: "utc", cursor: PageCursor{DateCreated: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), ID: "a1"}},
+		{name: "nanoseconds", cursor: PageCursor{DateCreated: time.Date(2024, 5, 1, 10, 30, 0, 123456789, time.UTC), ID: "b2"}},
+		{name: "id with separators", cursor: PageCursor{DateCreated: time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC), ID: "x/y+z="}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePageCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodePageCursor() error = %v", err)
+			}
+			if !got.DateCreated.Equal(tt.cursor.DateCreated) || got.ID != tt.cursor.ID {
+				t.Errorf("DecodePageCursor() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	encode := func(data string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(data))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "not base64", token: "!!!"},
+		{name: "not json", token: encode("cursor")},
+		{name: "missing date", token: encode(`{"i":"a"}`)},
+		{name: "missing id", token: encode(`{"d":"2024-05-01T10:30:00Z"}`)},
+		{name: "invalid date", token: encode(`{"d":"yesterday","i":"a"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePageCursor(tt.token)
+			if err == nil {
+				t.Errorf("DecodePageCursor() = %+v, want an error", *got)
+			}
+		})
+	}
+}
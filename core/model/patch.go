@@ -0,0 +1,40 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "encoding/json"
+
+// Patch formats
+const (
+	PatchFormatMerge = "merge" // RFC 7396 JSON merge patch
+	PatchFormatJSON  = "json"  // RFC 6902 JSON patch
+)
+
+// DataPatch is a patch of the data of an item
+type DataPatch struct {
+	Format   string
+	Document json.RawMessage
+}
+
+// PatchError is returned when a patch cannot be applied to the data of an item
+type PatchError struct {
+	Reason    string
+	Malformed bool // the patch document is not valid
+	Conflict  bool // a test operation does not match the data
+}
+
+func (e *PatchError) Error() string {
+	return "cannot apply the patch: " + e.Reason
+}
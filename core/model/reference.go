@@ -0,0 +1,78 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference types, a reference in the data of an item is an object like {"$ref": "content_item:<id>"}
+const (
+	ReferenceTypeContentItem     = "content_item"      // by id
+	ReferenceTypeDataContentItem = "data_content_item" // by key
+	ReferenceTypeHealthLocation  = "health_location"   // by id
+	ReferenceTypeStudentGuide    = "student_guide"     // by id
+)
+
+const (
+	// ReferenceField is the field of the objects which reference other items
+	ReferenceField = "$ref"
+	// ReferenceValueField is the field the expansion adds to the references with the referenced item
+	ReferenceValueField = "$value"
+	// MaxReferenceDepth is the most levels of references an expansion resolves
+	MaxReferenceDepth = 3
+)
+
+// Dangling reference reasons
+const (
+	DanglingReferenceNotFound = "not_found" // there is no such item within the app and the organization
+	DanglingReferenceInvalid  = "invalid"   // the reference is not in the type:id form or the type is unknown
+)
+
+// Reference is a parsed reference to an item
+type Reference struct {
+	Type string
+	ID   string // the key for the data content items
+}
+
+func (r Reference) String() string {
+	return r.Type + ":" + r.ID
+}
+
+// ParseReference parses a reference in the type:id form
+func ParseReference(value string) (*Reference, error) {
+	refType, id, found := strings.Cut(value, ":")
+	if !found || len(id) == 0 {
+		return nil, fmt.Errorf("reference %s is not in the type:id form", value)
+	}
+	switch refType {
+	case ReferenceTypeContentItem, ReferenceTypeDataContentItem, ReferenceTypeHealthLocation, ReferenceTypeStudentGuide:
+		return &Reference{Type: refType, ID: id}, nil
+	default:
+		return nil, fmt.Errorf("unknown reference type %s", refType)
+	}
+}
+
+// DanglingReference is a reference in an item which does not resolve
+type DanglingReference struct {
+	Collection string `json:"collection"` // content_items or data_content_items
+	ID         string `json:"id"`
+	Key        string `json:"key,omitempty"` // set for the data content items
+	Category   string `json:"category"`
+	Path       string `json:"path"` // json pointer of the reference within the item, like /data/steps/0
+	Ref        string `json:"ref"`
+	Reason     string `json:"reason"` // not_found or invalid
+} // @name DanglingReference
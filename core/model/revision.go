@@ -0,0 +1,84 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ItemRevision identifies a stored revision of an item, the entity tags are computed from it
+type ItemRevision struct {
+	ID          string
+	DateUpdated time.Time // the date created for the items which have never been updated
+}
+
+// Revision gives the current revision of the content item
+func (c ContentItem) Revision() ItemRevision {
+	return NewItemRevision(c.ID, c.DateCreated, c.DateUpdated)
+}
+
+// Revision gives the current revision of the data content item
+func (d DataContentItem) Revision() ItemRevision {
+	return NewItemRevision(d.ID, d.DateCreated, d.DateUpdated)
+}
+
+// NewItemRevision gives the revision of an item from its dates
+func NewItemRevision(id string, dateCreated time.Time, dateUpdated *time.Time) ItemRevision {
+	revision := ItemRevision{ID: id, DateUpdated: dateCreated}
+	if dateUpdated != nil {
+		revision.DateUpdated = *dateUpdated
+	}
+	//the database keeps milliseconds
+	revision.DateUpdated = revision.DateUpdated.UTC().Truncate(time.Millisecond)
+	return revision
+}
+
+// EntityTag gives a strong entity tag for a representation of the revisions,
+// the variant tells apart the representations of the same revisions - for example the locale
+func EntityTag(variant string, revisions ...ItemRevision) string {
+	hash := sha256.New()
+	hash.Write([]byte(variant))
+	for _, revision := range revisions {
+		hash.Write([]byte{0})
+		hash.Write([]byte(revision.ID))
+		hash.Write([]byte{0})
+		hash.Write([]byte(strconv.FormatInt(revision.DateUpdated.UnixMilli(), 10)))
+	}
+	return `"` + base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:18]) + `"`
+}
+
+// LastModified gives the latest update time of the revisions
+func LastModified(revisions ...ItemRevision) time.Time {
+	var lastModified time.Time
+	for _, revision := range revisions {
+		if revision.DateUpdated.After(lastModified) {
+			lastModified = revision.DateUpdated
+		}
+	}
+	return lastModified
+}
+
+// PreconditionFailedError is returned when an item has been modified since the client got it
+type PreconditionFailedError struct {
+	ETag string // the entity tag of the current revision
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("the item has been modified, its current entity tag is %s", e.ETag)
+}
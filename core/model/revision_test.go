@@ -0,0 +1,82 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntityTag(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	revision := NewItemRevision("a", created, &updated)
+	tag := EntityTag("", revision)
+
+	tests := []struct {
+		name      string
+		other     string
+		wantEqual bool
+	}{
+		{name: "same revision", other: EntityTag("", NewItemRevision("a", created, &updated)), wantEqual: true},
+		{name: "same revision in another zone", other: EntityTag("", NewItemRevision("a", created, timePtr(updated.In(time.FixedZone("CST", -6*3600))))), wantEqual: true},
+		{name: "below the stored precision", other: EntityTag("", NewItemRevision("a", created, timePtr(updated.Add(time.Microsecond)))), wantEqual: true},
+		{name: "updated again", other: EntityTag("", NewItemRevision("a", created, timePtr(updated.Add(time.Millisecond))))},
+		{name: "never updated", other: EntityTag("", NewItemRevision("a", created, nil))},
+		{name: "another item", other: EntityTag("", NewItemRevision("b", created, &updated))},
+		{name: "another variant", other: EntityTag("es", revision)},
+		{name: "more revisions", other: EntityTag("", revision, NewItemRevision("b", created, nil))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.other == tag) != tt.wantEqual {
+				t.Errorf("EntityTag() = %s and %s, want equal %v", tag, tt.other, tt.wantEqual)
+			}
+		})
+	}
+
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.HasPrefix(tag, "W/") {
+		t.Errorf("EntityTag() = %s, want a quoted strong entity tag", tag)
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	first := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		revisions []ItemRevision
+		want      time.Time
+	}{
+		{name: "none", want: time.Time{}},
+		{name: "one", revisions: []ItemRevision{{ID: "a", DateUpdated: first}}, want: first},
+		{name: "latest", revisions: []ItemRevision{{ID: "a", DateUpdated: second}, {ID: "b", DateUpdated: first}}, want: second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LastModified(tt.revisions...); !got.Equal(tt.want) {
+				t.Errorf("LastModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func timePtr(value time.Time) *time.Time {
+	return &value
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// ImportReport tells what an import of content has done, or would do for a dry run
+type ImportReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Imported bool          `json:"imported"` // false when nothing has been stored - a dry run or a rejected import
+	Created  int           `json:"created"`
+	Updated  int           `json:"updated"`
+	Errors   []ImportError `json:"errors"` // nothing is stored when there is any
+} // @name ImportReport
+
+// ImportError is a record of an import which cannot be stored
+type ImportError struct {
+	Record  int    `json:"record"` // 1-based, the csv header row is not counted
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+} // @name ImportError
+
+// NewImportReport creates an empty import report
+func NewImportReport(dryRun bool) *ImportReport {
+	return &ImportReport{DryRun: dryRun, Errors: []ImportError{}}
+}
+
+// AddError adds a record which cannot be stored
+func (r *ImportReport) AddError(record int, id string, message string) {
+	r.Errors = append(r.Errors, ImportError{Record: record, ID: id, Message: message})
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// StorageUsageImages is the usage category of the uploaded images
+	StorageUsageImages = "images"
+	// StorageUsageProfilePhotos is the usage category of the profile photos
+	StorageUsageProfilePhotos = "profile_photos"
+	// StorageUsageVoiceRecords is the usage category of the voice records
+	StorageUsageVoiceRecords = "voice_records"
+)
+
+// StorageUsageMonthLayout is the layout of the months of the usage, like 2025-06
+const StorageUsageMonthLayout = "2006-01"
+
+// StorageUsage is what an app/org uploaded and deleted in a category within a month, the files take the category of their items
+type StorageUsage struct {
+	AppID    string `json:"app_id" bson:"app_id"`
+	OrgID    string `json:"org_id" bson:"org_id"`
+	Category string `json:"category" bson:"category"`
+	Month    string `json:"month" bson:"month"`
+
+	UploadedSize    int64 `json:"uploaded_size" bson:"uploaded_size"`
+	UploadedObjects int64 `json:"uploaded_objects" bson:"uploaded_objects"`
+	DeletedSize     int64 `json:"deleted_size" bson:"deleted_size"`
+	DeletedObjects  int64 `json:"deleted_objects" bson:"deleted_objects"`
+}
+
+// NewStorageUsage gives an empty usage of the current month
+func NewStorageUsage(appID string, orgID string, category string) StorageUsage {
+	return StorageUsage{AppID: appID, OrgID: orgID, Category: category, Month: time.Now().UTC().Format(StorageUsageMonthLayout)}
+}
+
+// Upload adds uploaded objects
+func (u *StorageUsage) Upload(size int64, objects int64) {
+	u.UploadedSize += size
+	u.UploadedObjects += objects
+}
+
+// Delete adds deleted objects, replacing an object deletes the previous one
+func (u *StorageUsage) Delete(size int64, objects int64) {
+	u.DeletedSize += size
+	u.DeletedObjects += objects
+}
+
+// Empty tells if nothing was uploaded or deleted
+func (u StorageUsage) Empty() bool {
+	return u.UploadedObjects == 0 && u.DeletedObjects == 0
+}
+
+// StorageQuota is the max size an app/org may keep in a category, or in all the categories together when the category is empty.
+// The quotas of the organization without an app are shared by all its apps.
+type StorageQuota struct {
+	ID          string     `json:"id" bson:"_id"`
+	AppID       *string    `json:"app_id" bson:"app_id"`
+	OrgID       string     `json:"org_id" bson:"org_id"`
+	Category    string     `json:"category" bson:"category"`
+	MaxSize     int64      `json:"max_size" bson:"max_size"`
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+} // @name StorageQuota
+
+// ErrStorageQuotaNotFound is the error of a quota which does not exist
+var ErrStorageQuotaNotFound = errors.New("storage quota not found")
+
+// StorageQuotaExceededError is the error of an upload which does not fit in a quota
+type StorageQuotaExceededError struct {
+	Category string // empty for the quota of all the categories
+	Usage    int64
+	MaxSize  int64
+}
+
+func (e *StorageQuotaExceededError) Error() string {
+	if len(e.Category) == 0 {
+		return fmt.Sprintf("the storage quota of %d bytes is exceeded, %d bytes are used", e.MaxSize, e.Usage)
+	}
+	return fmt.Sprintf("the storage quota of %d bytes of category %s is exceeded, %d bytes are used", e.MaxSize, e.Category, e.Usage)
+}
+
+// StorageUsageReport is the usage by category and month, the sizes and the objects are the stored ones of all the months
+type StorageUsageReport struct {
+	Size       int64                  `json:"size"`
+	Objects    int64                  `json:"objects"`
+	MaxSize    *int64                 `json:"max_size,omitempty"` // the quota of all the categories
+	Categories []CategoryStorageUsage `json:"categories"`
+} // @name StorageUsageReport
+
+// CategoryStorageUsage is the usage of a category
+type CategoryStorageUsage struct {
+	Category string              `json:"category"`
+	Size     int64               `json:"size"`
+	Objects  int64               `json:"objects"`
+	MaxSize  *int64              `json:"max_size,omitempty"`
+	Months   []MonthStorageUsage `json:"months"`
+} // @name CategoryStorageUsage
+
+// MonthStorageUsage is what was uploaded and deleted in a category within a month
+type MonthStorageUsage struct {
+	Month           string `json:"month"`
+	UploadedSize    int64  `json:"uploaded_size"`
+	UploadedObjects int64  `json:"uploaded_objects"`
+	DeletedSize     int64  `json:"deleted_size"`
+	DeletedObjects  int64  `json:"deleted_objects"`
+} // @name MonthStorageUsage
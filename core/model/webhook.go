@@ -0,0 +1,123 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	// WebhookEventContentItemCreated is sent when a content item is created
+	WebhookEventContentItemCreated = "content_item.created"
+	// WebhookEventContentItemUpdated is sent when a content item is updated or moved in the workflow
+	WebhookEventContentItemUpdated = "content_item.updated"
+	// WebhookEventContentItemPublished is sent when a content item is approved within its publish window, an item approved for a later publish time gets WebhookEventContentItemUpdated
+	WebhookEventContentItemPublished = "content_item.published"
+	// WebhookEventContentItemDeleted is sent when a content item is deleted
+	WebhookEventContentItemDeleted = "content_item.deleted"
+	// WebhookEventDataContentItemCreated is sent when a data content item is created
+	WebhookEventDataContentItemCreated = "data_content_item.created"
+	// WebhookEventDataContentItemUpdated is sent when a data content item is updated
+	WebhookEventDataContentItemUpdated = "data_content_item.updated"
+	// WebhookEventDataContentItemDeleted is sent when a data content item is deleted
+	WebhookEventDataContentItemDeleted = "data_content_item.deleted"
+)
+
+// WebhookEvents are all the events the webhooks can subscribe to
+var WebhookEvents = []string{WebhookEventContentItemCreated, WebhookEventContentItemUpdated, WebhookEventContentItemPublished,
+	WebhookEventContentItemDeleted, WebhookEventDataContentItemCreated, WebhookEventDataContentItemUpdated, WebhookEventDataContentItemDeleted}
+
+const (
+	// WebhookDeliveryPending is the status of a delivery which is still being attempted
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliverySucceeded is the status of a delivery which the receiver accepted
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryFailed is the status of a delivery which ran out of attempts
+	WebhookDeliveryFailed = "failed"
+)
+
+// Webhook is a subscription of another service to the content changes
+type Webhook struct {
+	ID          string     `json:"id" bson:"_id"`
+	AppID       *string    `json:"app_id" bson:"app_id"`
+	OrgID       string     `json:"org_id" bson:"org_id"`
+	URL         string     `json:"url" bson:"url"`
+	Secret      string     `json:"secret,omitempty" bson:"secret"` // signs the payloads, it is given back only when the webhook is created
+	Categories  []string   `json:"categories" bson:"categories"`   // empty for all the categories
+	Events      []string   `json:"events" bson:"events"`           // empty for all the events
+	Active      bool       `json:"active" bson:"active"`
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+} // @name Webhook
+
+// Matches tells if the webhook is subscribed to the event, a webhook of all the apps gets the events of every app of the organization
+func (w Webhook) Matches(event WebhookEvent) bool {
+	if !w.Active || w.OrgID != event.OrgID {
+		return false
+	}
+	if w.AppID != nil && (event.AppID == nil || *w.AppID != *event.AppID) {
+		return false
+	}
+	return matchesAny(w.Events, event.Type) && matchesAny(w.Categories, event.Category)
+}
+
+// matchesAny tells if the value is in the list, an empty list matches all the values
+func matchesAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, current := range list {
+		if current == value {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the payload sent to the webhooks
+type WebhookEvent struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	Date     time.Time   `json:"date"`
+	AppID    *string     `json:"app_id"`
+	OrgID    string      `json:"org_id"`
+	Category string      `json:"category"`
+	ItemID   string      `json:"item_id"`
+	Key      string      `json:"key,omitempty"`  // set for the data content items
+	Item     interface{} `json:"item,omitempty"` // the item after the change, not set for the deleted items
+} // @name WebhookEvent
+
+// WebhookDelivery is the delivery of an event to a webhook with the log of its attempts
+type WebhookDelivery struct {
+	ID            string                   `json:"id" bson:"_id"`
+	WebhookID     string                   `json:"webhook_id" bson:"webhook_id"`
+	AppID         *string                  `json:"app_id" bson:"app_id"`
+	OrgID         string                   `json:"org_id" bson:"org_id"`
+	EventID       string                   `json:"event_id" bson:"event_id"`
+	EventType     string                   `json:"event_type" bson:"event_type"`
+	Payload       string                   `json:"payload" bson:"payload"` // the json body which is sent and signed
+	Status        string                   `json:"status" bson:"status"`
+	Attempts      []WebhookDeliveryAttempt `json:"attempts" bson:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"` // set while the delivery is pending
+	RedeliveryOf  string                   `json:"redelivery_of,omitempty" bson:"redelivery_of,omitempty"`     // the delivery which was sent again
+	DateCreated   time.Time                `json:"date_created" bson:"date_created"`
+	DateUpdated   *time.Time               `json:"date_updated,omitempty" bson:"date_updated,omitempty"`
+} // @name WebhookDelivery
+
+// WebhookDeliveryAttempt is a single attempt to deliver an event
+type WebhookDeliveryAttempt struct {
+	Date       time.Time `json:"date" bson:"date"`
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code,omitempty"` // not set when the receiver could not be reached
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms" bson:"duration_ms"`
+} // @name WebhookDeliveryAttempt
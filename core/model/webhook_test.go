@@ -0,0 +1,59 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "testing"
+
+func TestWebhookMatches(t *testing.T) {
+	appID := "app"
+	otherAppID := "other-app"
+	event := WebhookEvent{Type: WebhookEventContentItemUpdated, AppID: &appID, OrgID: "org", Category: "events"}
+
+	tests := []struct {
+		name    string
+		webhook Webhook
+		event   WebhookEvent
+		want    bool
+	}{
+		{name: "all events and categories", webhook: Webhook{AppID: &appID, OrgID: "org", Active: true}, event: event, want: true},
+		{name: "subscribed event and category", webhook: Webhook{AppID: &appID, OrgID: "org", Active: true,
+			Events: []string{WebhookEventContentItemCreated, WebhookEventContentItemUpdated}, Categories: []string{"events"}}, event: event, want: true},
+		{name: "other event", webhook: Webhook{AppID: &appID, OrgID: "org", Active: true, Events: []string{WebhookEventContentItemDeleted}}, event: event},
+		{name: "other category", webhook: Webhook{AppID: &appID, OrgID: "org", Active: true, Categories: []string{"news"}}, event: event},
+		{name: "inactive", webhook: Webhook{AppID: &appID, OrgID: "org"}, event: event},
+		{name: "other organization", webhook: Webhook{AppID: &appID, OrgID: "other-org", Active: true}, event: event},
+		{name: "other app", webhook: Webhook{AppID: &otherAppID, OrgID: "org", Active: true}, event: event},
+		{name: "same app by value", webhook: Webhook{AppID: stringPtr("app"), OrgID: "org", Active: true}, event: event, want: true},
+		{name: "all apps webhook and app event", webhook: Webhook{OrgID: "org", Active: true}, event: event, want: true},
+		{name: "all apps webhook and event of another organization", webhook: Webhook{OrgID: "other-org", Active: true}, event: event},
+		{name: "all apps webhook and other category", webhook: Webhook{OrgID: "org", Active: true, Categories: []string{"news"}}, event: event},
+		{name: "app webhook and all apps event", webhook: Webhook{AppID: &appID, OrgID: "org", Active: true},
+			event: WebhookEvent{Type: WebhookEventContentItemUpdated, OrgID: "org", Category: "events"}},
+		{name: "all apps webhook and all apps event", webhook: Webhook{OrgID: "org", Active: true},
+			event: WebhookEvent{Type: WebhookEventContentItemUpdated, OrgID: "org", Category: "events"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.webhook.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
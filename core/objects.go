@@ -0,0 +1,107 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// profileImageTypes are the sizes every profile image is stored in
+var profileImageTypes = []string{"default", "medium", "small"}
+
+func profileImageKey(userID string, imageType string) string {
+	return fmt.Sprintf("profile-images/%s-%s.webp", userID, imageType)
+}
+
+// profileImagesPrefix is the beginning of the keys of all the profile images of a user
+func profileImagesPrefix(userID string) string {
+	return fmt.Sprintf("profile-images/%s-", userID)
+}
+
+func profileImageKeys(userID string) []string {
+	keys := make([]string, len(profileImageTypes))
+	for i, imageType := range profileImageTypes {
+		keys[i] = profileImageKey(userID, imageType)
+	}
+	return keys
+}
+
+func voiceRecordKey(userID string) string {
+	return fmt.Sprintf("names-records/%s.m4a", userID)
+}
+
+// imageKey gives the key of a webp image within a path, the images without a file name get a random one
+func imageKey(path string, fileName *string) string {
+	name := uuid.NewString()
+	if fileName != nil {
+		name = *fileName
+	}
+	if strings.HasSuffix(path, "/") {
+		return path + name + ".webp"
+	}
+	return path + "/" + name + ".webp"
+}
+
+func deleteProfileImages(objectStorage interfaces.ObjectStorage, userID string) error {
+	for _, imageType := range profileImageTypes {
+		err := objectStorage.Delete(model.ObjectBucketProfileImages, profileImageKey(userID, imageType))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadObject reads a whole object
+func (s *servicesImpl) downloadObject(bucket string, key string) ([]byte, error) {
+	object, err := s.app.objectStorage.Download(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
+
+func (s *servicesImpl) DownloadPresignedObject(request model.PresignedObjectRequest) (io.ReadCloser, error) {
+	request.Method = http.MethodGet
+	err := s.app.objectStorage.VerifyPresigned(request)
+	if err != nil {
+		return nil, &model.ObjectAccessError{Reason: err.Error()}
+	}
+	if len(request.UploadID) > 0 {
+		return nil, &model.ObjectAccessError{Reason: "the parts cannot be downloaded"}
+	}
+	return s.app.objectStorage.Download(request.Bucket, request.Key)
+}
+
+func (s *servicesImpl) UploadPresignedObject(request model.PresignedObjectRequest, body io.Reader) (string, error) {
+	request.Method = http.MethodPut
+	err := s.app.objectStorage.VerifyPresigned(request)
+	if err != nil {
+		return "", &model.ObjectAccessError{Reason: err.Error()}
+	}
+	if len(request.UploadID) > 0 {
+		return s.app.objectStorage.UploadPart(request.Bucket, request.Key, request.UploadID, request.PartNumber, body)
+	}
+	_, err = s.app.objectStorage.Upload(request.Bucket, request.Key, body, false)
+	return "", err
+}
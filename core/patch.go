@@ -0,0 +1,52 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"content/utils"
+	"encoding/json"
+	"errors"
+)
+
+// applyDataPatch applies a patch to the data of an item, the data is not changed
+func applyDataPatch(data interface{}, patch model.DataPatch) (interface{}, error) {
+	current, err := jsonValue(utils.NormalizeData(data))
+	if err != nil {
+		return nil, err
+	}
+
+	switch patch.Format {
+	case model.PatchFormatMerge:
+		var document interface{}
+		err = json.Unmarshal(patch.Document, &document)
+		if err != nil {
+			return nil, &model.PatchError{Reason: err.Error(), Malformed: true}
+		}
+		return utils.MergePatch(current, document), nil
+	case model.PatchFormatJSON:
+		operations, err := utils.ParseJSONPatch(patch.Document)
+		if err != nil {
+			return nil, &model.PatchError{Reason: err.Error(), Malformed: true}
+		}
+		patched, err := utils.ApplyJSONPatch(current, operations)
+		if err != nil {
+			return nil, &model.PatchError{Reason: err.Error(), Conflict: errors.Is(err, utils.ErrJSONPatchTestFailed)}
+		}
+		return patched, nil
+	default:
+		return nil, &model.PatchError{Reason: "unsupported patch format " + patch.Format, Malformed: true}
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"content/utils"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// referenceResolver loads the items the references point to within an app and an organization.
+// With claims it loads only what the clients see - the live published content items and the data content items of the categories the claims can read.
+type referenceResolver struct {
+	storage interfaces.Storage
+	appID   string
+	orgID   string
+	claims  *tokenauth.Claims
+
+	items    map[string]interface{} // the referenced items by reference, nil for the references which do not resolve
+	readable map[string]bool        // the read access of the claims by category
+}
+
+func newReferenceResolver(storage interfaces.Storage, appID string, orgID string, claims *tokenauth.Claims) *referenceResolver {
+	return &referenceResolver{storage: storage, appID: appID, orgID: orgID, claims: claims,
+		items: map[string]interface{}{}, readable: map[string]bool{}}
+}
+
+// load loads the items of the references which are not loaded yet, the items of a type are loaded together
+func (r *referenceResolver) load(refs []model.Reference) error {
+	idsByType := map[string][]string{}
+	for _, ref := range refs {
+		if _, ok := r.items[ref.String()]; ok {
+			continue
+		}
+		r.items[ref.String()] = nil
+		idsByType[ref.Type] = append(idsByType[ref.Type], ref.ID)
+	}
+
+	if ids := idsByType[model.ReferenceTypeContentItem]; len(ids) > 0 {
+		var state, workflowState *string
+		if r.claims != nil {
+			live := model.ContentItemStateLive
+			published := model.ContentItemWorkflowPublished
+			state, workflowState = &live, &published
+		}
+		items, err := r.storage.GetContentItems(nil, r.orgID, ids, nil, state, workflowState, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			//the items for all the apps are referenced from every app, the items of the other apps are not
+			if appID, _ := item["app_id"].(string); len(appID) > 0 && appID != r.appID {
+				continue
+			}
+			r.setItem(model.ReferenceTypeContentItem, item["_id"], item)
+		}
+	}
+
+	for _, key := range idsByType[model.ReferenceTypeDataContentItem] {
+		item, err := r.storage.FindDataContentItem(&r.appID, r.orgID, key)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				continue
+			}
+			return err
+		}
+		canRead, err := r.canRead(item.Category)
+		if err != nil {
+			return err
+		}
+		if canRead {
+			r.items[model.Reference{Type: model.ReferenceTypeDataContentItem, ID: key}.String()] = item
+		}
+	}
+
+	if ids := idsByType[model.ReferenceTypeHealthLocation]; len(ids) > 0 {
+		items, err := r.storage.GetHealthLocations(r.appID, r.orgID, ids)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			r.setItem(model.ReferenceTypeHealthLocation, item["_id"], item)
+		}
+	}
+
+	if ids := idsByType[model.ReferenceTypeStudentGuide]; len(ids) > 0 {
+		items, err := r.storage.GetStudentGuides(r.appID, r.orgID, ids)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			r.setItem(model.ReferenceTypeStudentGuide, item["_id"], item)
+		}
+	}
+	return nil
+}
+
+func (r *referenceResolver) setItem(refType string, id interface{}, item interface{}) {
+	r.items[model.Reference{Type: refType, ID: fmt.Sprint(id)}.String()] = item
+}
+
+func (r *referenceResolver) canRead(category string) (bool, error) {
+	if r.claims == nil {
+		return true, nil
+	}
+	canRead, ok := r.readable[category]
+	if !ok {
+		err := checkCategoryRead(r.storage, r.claims, category)
+		var accessErr *model.CategoryAccessError
+		if err != nil && !errors.As(err, &accessErr) {
+			return false, err
+		}
+		canRead = err == nil
+		r.readable[category] = canRead
+	}
+	return canRead, nil
+}
+
+// findReferences calls found with every reference object in the data and its json pointer, the references are not walked into
+func findReferences(data interface{}, path string, found func(object map[string]interface{}, path string)) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		if _, ok := value[model.ReferenceField]; ok {
+			found(value, path)
+			return
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			findReferences(value[key], path+"/"+jsonPointerEscaper.Replace(key), found)
+		}
+	case []interface{}:
+		for i, element := range value {
+			findReferences(element, path+"/"+strconv.Itoa(i), found)
+		}
+	}
+}
+
+func (s *servicesImpl) ExpandContentItemReferences(claims *tokenauth.Claims, items []model.ContentItemResponse, preferredLocales []string, depth int) error {
+	data := make([]interface{}, len(items))
+	for i, item := range items {
+		item["data"] = utils.NormalizeData(item["data"])
+		data[i] = item["data"]
+	}
+	return s.expandReferences(claims, data, preferredLocales, depth)
+}
+
+func (s *servicesImpl) ExpandDataContentItemReferences(claims *tokenauth.Claims, items []*model.DataContentItem, preferredLocales []string, depth int) error {
+	data := make([]interface{}, len(items))
+	for i, item := range items {
+		item.Data = utils.NormalizeData(item.Data)
+		data[i] = item.Data
+	}
+	return s.expandReferences(claims, data, preferredLocales, depth)
+}
+
+// expandReferences adds the referenced items to the references in the data, level by level up to depth. Every reference gets its own copy of the item,
+// so that the expanded data stays a tree when the items reference each other. The references which do not resolve are left as they are.
+func (s *servicesImpl) expandReferences(claims *tokenauth.Claims, data []interface{}, preferredLocales []string, depth int) error {
+	type reference struct {
+		object map[string]interface{}
+		ref    model.Reference
+	}
+
+	resolver := newReferenceResolver(s.app.storage, claims.AppID, claims.OrgID, claims)
+	pending := data
+	for level := 0; level < depth && len(pending) > 0; level++ {
+		var references []reference
+		var refs []model.Reference
+		for _, value := range pending {
+			findReferences(value, "", func(object map[string]interface{}, path string) {
+				refValue, _ := object[model.ReferenceField].(string)
+				ref, err := model.ParseReference(refValue)
+				if err != nil {
+					return
+				}
+				references = append(references, reference{object: object, ref: *ref})
+				refs = append(refs, *ref)
+			})
+		}
+
+		err := resolver.load(refs)
+		if err != nil {
+			return err
+		}
+
+		pending = nil
+		for _, reference := range references {
+			item := resolver.items[reference.ref.String()]
+			if item == nil {
+				continue
+			}
+			value, err := s.referencedValue(item, preferredLocales)
+			if err != nil {
+				return err
+			}
+			reference.object[model.ReferenceValueField] = value
+			if itemData, ok := value["data"]; ok {
+				pending = append(pending, itemData)
+			}
+		}
+	}
+	return nil
+}
+
+// referencedValue gives a localized json copy of a referenced item
+func (s *servicesImpl) referencedValue(item interface{}, preferredLocales []string) (map[string]interface{}, error) {
+	var value interface{}
+	switch item := item.(type) {
+	case model.ContentItemResponse:
+		localized := make(model.ContentItemResponse, len(item))
+		for key, field := range item {
+			localized[key] = field
+		}
+		s.LocalizeContentItem(localized, preferredLocales)
+		value = localized
+	case *model.DataContentItem:
+		localized := *item
+		s.LocalizeDataContentItem(&localized, preferredLocales)
+		value = localized
+	default:
+		value = item
+	}
+
+	result, err := jsonValue(utils.NormalizeData(value))
+	if err != nil {
+		return nil, err
+	}
+	resultMap, _ := result.(map[string]interface{})
+	return resultMap, nil
+}
+
+func (s *servicesImpl) GetDanglingReferences(allApps bool, appID string, orgID string) ([]model.DanglingReference, error) {
+	type reference struct {
+		item model.DanglingReference
+		ref  *model.Reference
+	}
+
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	var references []reference
+	var refs []model.Reference
+	collect := func(item model.DanglingReference, data interface{}, locales map[string]interface{}) {
+		add := func(data interface{}, path string) {
+			findReferences(utils.NormalizeData(data), path, func(object map[string]interface{}, path string) {
+				dangling := item
+				dangling.Path = path
+				dangling.Ref = fmt.Sprint(object[model.ReferenceField])
+				ref, err := model.ParseReference(dangling.Ref)
+				if err == nil {
+					refs = append(refs, *ref)
+				}
+				references = append(references, reference{item: dangling, ref: ref})
+			})
+		}
+
+		add(data, "/data")
+		localeKeys := make([]string, 0, len(locales))
+		for locale := range locales {
+			localeKeys = append(localeKeys, locale)
+		}
+		sort.Strings(localeKeys)
+		for _, locale := range localeKeys {
+			add(locales[locale], "/locales/"+jsonPointerEscaper.Replace(locale))
+		}
+	}
+
+	err := s.app.storage.IterateContentItems(appIDParam, orgID, nil, func(item model.ContentItem) error {
+		collect(model.DanglingReference{Collection: "content_items", ID: item.ID, Category: item.Category}, item.Data, item.Locales)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	//the data content items are always associated with an app
+	err = s.app.storage.IterateDataContentItems(&appID, orgID, nil, func(item model.DataContentItem) error {
+		collect(model.DanglingReference{Collection: "data_content_items", ID: item.ID, Key: item.Key, Category: item.Category}, item.Data, item.Locales)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := newReferenceResolver(s.app.storage, appID, orgID, nil)
+	err = resolver.load(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	dangling := []model.DanglingReference{}
+	for _, reference := range references {
+		if reference.ref == nil {
+			reference.item.Reason = model.DanglingReferenceInvalid
+		} else if resolver.items[reference.ref.String()] == nil {
+			reference.item.Reason = model.DanglingReferenceNotFound
+		} else {
+			continue
+		}
+		dangling = append(dangling, reference.item)
+	}
+	return dangling, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []string
+	}{
+		{name: "no data", data: nil, want: []string{}},
+		{name: "primitive", data: "text", want: []string{}},
+		{name: "no references", data: map[string]interface{}{"title": "a", "tags": []interface{}{"x"}}, want: []string{}},
+		{name: "root reference", data: map[string]interface{}{"$ref": "content_items/1"}, want: []string{""}},
+		{
+			name: "nested references in key order",
+			data: map[string]interface{}{
+				"speaker": map[string]interface{}{"$ref": "content_items/2"},
+				"author":  map[string]interface{}{"$ref": "content_items/1"},
+			},
+			want: []string{"/author", "/speaker"},
+		},
+		{
+			name: "references in a list",
+			data: map[string]interface{}{"related": []interface{}{"x", map[string]interface{}{"$ref": "a"}, []interface{}{map[string]interface{}{"$ref": "b"}}}},
+			want: []string{"/related/1", "/related/2/0"},
+		},
+		{
+			name: "not walked into",
+			data: map[string]interface{}{"$ref": "a", "inner": map[string]interface{}{"$ref": "b"}},
+			want: []string{""},
+		},
+		{
+			name: "escaped keys",
+			data: map[string]interface{}{"a/b": map[string]interface{}{"c~d": map[string]interface{}{"$ref": "a"}}},
+			want: []string{"/a~1b/c~0d"},
+		},
+		{name: "null reference", data: map[string]interface{}{"x": map[string]interface{}{"$ref": nil}}, want: []string{"/x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			findReferences(tt.data, "", func(object map[string]interface{}, path string) {
+				if _, ok := object["$ref"]; !ok {
+					t.Errorf("findReferences() found %v at %s, it is not a reference", object, path)
+				}
+				got = append(got, path)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"content/core/interfaces"
+	"content/core/model"
+	"content/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const categorySchemaURL = "urn:content:category-schema"
+
+// compileCategorySchema compiles a category schema, nil means the category has no schema
+func compileCategorySchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	if len(bytes.TrimSpace(schema)) == 0 || string(bytes.TrimSpace(schema)) == "null" {
+		return nil, nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema json: %s", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	//the schema must be self-contained, do not resolve references to files or remote resources
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	err = compiler.AddResource(categorySchemaURL, doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+	compiled, err := compiler.Compile(categorySchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+	return compiled, nil
+}
+
+// validateSchemaData gives the places where the data does not conform to the schema
+func validateSchemaData(schema *jsonschema.Schema, data interface{}) ([]model.SchemaViolation, error) {
+	//bring the data to the types the validator works with
+	dataJSON, err := json.Marshal(utils.NormalizeData(data))
+	if err != nil {
+		return nil, err
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(dataJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	return schemaViolations(validationErr, message.NewPrinter(language.English), []model.SchemaViolation{}), nil
+}
+
+// schemaViolations collects the leaf errors of the validation error tree
+func schemaViolations(validationErr *jsonschema.ValidationError, printer *message.Printer, violations []model.SchemaViolation) []model.SchemaViolation {
+	if len(validationErr.Causes) == 0 {
+		pointer := ""
+		for _, token := range validationErr.InstanceLocation {
+			pointer += "/" + jsonPointerEscaper.Replace(token)
+		}
+		return append(violations, model.SchemaViolation{Pointer: pointer, Message: validationErr.ErrorKind.LocalizedString(printer)})
+	}
+
+	for _, cause := range validationErr.Causes {
+		violations = schemaViolations(cause, printer, violations)
+	}
+	return violations
+}
+
+// validateLocalizedData validates the data and all its translations
+func validateLocalizedData(schema *jsonschema.Schema, data interface{}, locales map[string]interface{}) ([]model.SchemaViolation, error) {
+	violations, err := validateSchemaData(schema, data)
+	if err != nil {
+		return nil, err
+	}
+
+	localeKeys := make([]string, 0, len(locales))
+	for locale := range locales {
+		localeKeys = append(localeKeys, locale)
+	}
+	sort.Strings(localeKeys)
+	for _, locale := range localeKeys {
+		localeViolations, err := validateSchemaData(schema, locales[locale])
+		if err != nil {
+			return nil, err
+		}
+		for _, violation := range localeViolations {
+			violation.Locale = locale
+			violations = append(violations, violation)
+		}
+	}
+	return violations, nil
+}
+
+// validateCategoryData checks the data and its translations against the schema of the category if the category has one
+func validateCategoryData(category *model.Category, data interface{}, locales map[string]interface{}) error {
+	schema, err := compileCategorySchema(category.Schema)
+	if err != nil {
+		return fmt.Errorf("error compiling the schema of category %s: %s", category.Name, err)
+	}
+	if schema == nil {
+		return nil
+	}
+
+	violations, err := validateLocalizedData(schema, data, locales)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &model.SchemaValidationError{Category: category.Name, Violations: violations}
+	}
+	return nil
+}
+
+// validateContentItemData checks the data of a content item against its category schema,
+// content item categories without a category record are not validated
+func (s *servicesImpl) validateContentItemData(appID string, orgID string, category string, data interface{}, locales map[string]interface{}) error {
+	categoryItem, err := s.app.storage.FindCategory(&appID, orgID, category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+	return validateCategoryData(categoryItem, data, locales)
+}
+
+// checkCategorySchema validates all stored items of a category against a schema
+func (s *servicesImpl) checkCategorySchema(storage interfaces.Storage, appID string, orgID string, category string, schema json.RawMessage) (*model.CategorySchemaReport, error) {
+	compiled, err := compileCategorySchema(schema)
+	if err != nil {
+		return nil, &model.InvalidSchemaError{Category: category, Reason: err.Error()}
+	}
+
+	report := model.CategorySchemaReport{Category: category, Valid: true, InvalidItems: []model.CategorySchemaInvalidItem{}}
+	if compiled == nil {
+		return &report, nil
+	}
+
+	contentItems, err := storage.GetContentItems(&appID, orgID, nil, []string{category}, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range contentItems {
+		locales, _ := utils.NormalizeData(item["locales"]).(map[string]interface{})
+		violations, err := validateLocalizedData(compiled, item["data"], locales)
+		if err != nil {
+			return nil, err
+		}
+		report.CheckedCount++
+		if len(violations) > 0 {
+			id, _ := item["_id"].(string)
+			report.InvalidItems = append(report.InvalidItems, model.CategorySchemaInvalidItem{Collection: "content_items", ID: id, Violations: violations})
+		}
+	}
+
+	dataContentItems, err := storage.FindDataContentItems(&appID, orgID, []string{category})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dataContentItems {
+		violations, err := validateLocalizedData(compiled, item.Data, item.Locales)
+		if err != nil {
+			return nil, err
+		}
+		report.CheckedCount++
+		if len(violations) > 0 {
+			report.InvalidItems = append(report.InvalidItems, model.CategorySchemaInvalidItem{Collection: "data_content_items", ID: item.ID, Key: item.Key, Violations: violations})
+		}
+	}
+
+	report.Valid = len(report.InvalidItems) == 0
+	return &report, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+const testCategorySchema = `{"type": "object", "required": ["title"], "properties": {"title": {"type": "string"}, "a/b": {"type": "integer"}}}`
+
+func TestCompileCategorySchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "no schema", wantNil: true},
+		{name: "null schema", schema: " null ", wantNil: true},
+		{name: "schema", schema: testCategorySchema},
+		{name: "invalid json", schema: `{"type": `, wantErr: true},
+		{name: "invalid schema", schema: `{"type": 5}`, wantErr: true},
+		{name: "remote reference", schema: `{"$ref": "https://example.com/schema.json"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileCategorySchema(json.RawMessage(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileCategorySchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("compileCategorySchema() = %v, want nil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestValidateCategoryData(t *testing.T) {
+	category := &model.Category{Name: "events", Schema: json.RawMessage(testCategorySchema)}
+
+	tests := []struct {
+		name     string
+		category *model.Category
+		data     interface{}
+		locales  map[string]interface{}
+		want     []model.SchemaViolation
+	}{
+		{name: "category without a schema", category: &model.Category{Name: "events"}, data: "anything"},
+		{name: "valid data", category: category, data: map[string]interface{}{"title": "a", "a/b": 1},
+			locales: map[string]interface{}{"es": map[string]interface{}{"title": "b"}}},
+		{name: "missing field", category: category, data: map[string]interface{}{}, want: []model.SchemaViolation{{Pointer: ""}}},
+		{name: "wrong type", category: category, data: map[string]interface{}{"title": 5}, want: []model.SchemaViolation{{Pointer: "/title"}}},
+		{name: "escaped pointer", category: category, data: map[string]interface{}{"title": "a", "a/b": "c"}, want: []model.SchemaViolation{{Pointer: "/a~1b"}}},
+		{name: "invalid translations", category: category, data: map[string]interface{}{"title": "a"},
+			locales: map[string]interface{}{"fr": map[string]interface{}{}, "es": map[string]interface{}{"title": 5}},
+			want:    []model.SchemaViolation{{Locale: "es", Pointer: "/title"}, {Locale: "fr", Pointer: ""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCategoryData(tt.category, tt.data, tt.locales)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("validateCategoryData() error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *model.SchemaValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("validateCategoryData() error = %v, want a schema validation error", err)
+			}
+			var got []model.SchemaViolation
+			for _, violation := range validationErr.Violations {
+				if len(violation.Message) == 0 {
+					t.Errorf("violation %+v has no message", violation)
+				}
+				got = append(got, model.SchemaViolation{Locale: violation.Locale, Pointer: violation.Pointer})
+			}
+			if validationErr.Category != "events" || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateCategoryData() = %s %+v, want events %+v", validationErr.Category, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentItemSchemaValidation(t *testing.T) {
+	appID := "app"
+	categories := []model.Category{{Name: "events", AppID: &appID, OrgID: "org", Schema: json.RawMessage(testCategorySchema)}}
+	items := []model.ContentItem{{ID: "item", Category: "events", Data: map[string]interface{}{"title": "a"}, AppID: &appID, OrgID: "org",
+		Locales: map[string]interface{}{"es": map[string]interface{}{"title": "b"}}, WorkflowState: model.ContentItemWorkflowDraft}}
+
+	tests := []struct {
+		name    string
+		call    func(services *servicesImpl) error
+		wantErr bool
+	}{
+		{name: "create", call: func(services *servicesImpl) error {
+			_, err := services.CreateContentItem(nil, false, appID, "org", "events", map[string]interface{}{"title": 5}, "", nil, nil, nil, model.ContentItemWorkflowDraft)
+			return err
+		}, wantErr: true},
+		{name: "create with an invalid translation", call: func(services *servicesImpl) error {
+			_, err := services.CreateContentItem(nil, false, appID, "org", "events", map[string]interface{}{"title": "a"}, "",
+				map[string]interface{}{"es": map[string]interface{}{}}, nil, nil, model.ContentItemWorkflowDraft)
+			return err
+		}, wantErr: true},
+		{name: "create in a category without a record", call: func(services *servicesImpl) error {
+			_, err := services.CreateContentItem(nil, false, appID, "org", "news", map[string]interface{}{"title": 5}, "", nil, nil, nil, model.ContentItemWorkflowDraft)
+			return err
+		}},
+		{name: "update", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItem(nil, false, appID, "org", "item", "events", map[string]interface{}{}, model.Nullable[string]{},
+				model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+			return err
+		}, wantErr: true},
+		{name: "update with an invalid translation", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItem(nil, false, appID, "org", "item", "events", map[string]interface{}{"title": "c"}, model.Nullable[string]{},
+				model.NewNullable(map[string]interface{}{"es": map[string]interface{}{"title": 5}}), nil, nil, nil)
+			return err
+		}, wantErr: true},
+		{name: "patch", call: func(services *servicesImpl) error {
+			_, err := services.PatchContentItem(nil, false, appID, "org", "item", model.DataPatch{Format: model.PatchFormatMerge, Document: []byte(`{"title": null}`)}, nil)
+			return err
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{categories: categories, contentItems: slices.Clone(items)}
+
+			err := tt.call(testServices(storage))
+			var validationErr *model.SchemaValidationError
+			if errors.As(err, &validationErr) != tt.wantErr {
+				t.Fatalf("error = %v, want a schema validation error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !reflect.DeepEqual(storage.contentItems, items) {
+				t.Errorf("stored items = %+v, want them unchanged", storage.contentItems)
+			}
+		})
+	}
+}
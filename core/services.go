@@ -16,14 +16,20 @@ package core
 
 import (
 	"bytes"
+	"content/core/interfaces"
 	"content/core/model"
-	"errors"
+	"content/utils"
+	"encoding/json"
 	"fmt"
 	"image"
 	_ "image/gif"  // Allow image.Decode to detect GIFs
 	_ "image/jpeg" // Allow image.Decode to detect JPEGs
 	_ "image/png"  // Allow image.Decode to detect PNGs
 	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -59,25 +65,44 @@ func (s *servicesImpl) GetStudentGuide(appID string, orgID string, id string) (b
 	return item, nil
 }
 
-func (s *servicesImpl) CreateStudentGuide(appID string, orgID string, item bson.M) (bson.M, error) {
+func (s *servicesImpl) CreateStudentGuide(actor *model.AuditActor, appID string, orgID string, item bson.M) (bson.M, error) {
 	items, err := s.app.storage.CreateStudentGuide(appID, orgID, item)
 	if err != nil {
 		return nil, err
 	}
+
+	s.auditCommitted(actor, model.AuditResourceStudentGuide, fmt.Sprint(items["_id"]), "", model.AuditOperationCreate, nil, items)
 	return items, nil
 }
 
-func (s *servicesImpl) UpdateStudentGuide(appID string, orgID string, id string, item bson.M) (bson.M, error) {
+func (s *servicesImpl) UpdateStudentGuide(actor *model.AuditActor, appID string, orgID string, id string, item bson.M) (bson.M, error) {
+	before, err := s.app.storage.GetStudentGuide(appID, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
 	items, err := s.app.storage.UpdateStudentGuide(appID, orgID, id, item)
 	if err != nil {
 		return nil, err
 	}
+
+	s.auditCommitted(actor, model.AuditResourceStudentGuide, id, "", model.AuditOperationUpdate, before, items)
 	return items, nil
 }
 
-func (s *servicesImpl) DeleteStudentGuide(appID string, orgID string, id string) error {
-	err := s.app.storage.DeleteStudentGuide(appID, orgID, id)
-	return err
+func (s *servicesImpl) DeleteStudentGuide(actor *model.AuditActor, appID string, orgID string, id string) error {
+	before, err := s.app.storage.GetStudentGuide(appID, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.storage.DeleteStudentGuide(appID, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceStudentGuide, id, "", model.AuditOperationDelete, before, nil)
+	return nil
 }
 
 // Health Locations
@@ -98,25 +123,44 @@ func (s *servicesImpl) GetHealthLocation(appID string, orgID string, id string)
 	return item, nil
 }
 
-func (s *servicesImpl) CreateHealthLocation(appID string, orgID string, item bson.M) (bson.M, error) {
+func (s *servicesImpl) CreateHealthLocation(actor *model.AuditActor, appID string, orgID string, item bson.M) (bson.M, error) {
 	items, err := s.app.storage.CreateHealthLocation(appID, orgID, item)
 	if err != nil {
 		return nil, err
 	}
+
+	s.auditCommitted(actor, model.AuditResourceHealthLocation, fmt.Sprint(items["_id"]), "", model.AuditOperationCreate, nil, items)
 	return items, nil
 }
 
-func (s *servicesImpl) UpdateHealthLocation(appID string, orgID string, id string, item bson.M) (bson.M, error) {
+func (s *servicesImpl) UpdateHealthLocation(actor *model.AuditActor, appID string, orgID string, id string, item bson.M) (bson.M, error) {
+	before, err := s.app.storage.GetHealthLocation(appID, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
 	items, err := s.app.storage.UpdateHealthLocation(appID, orgID, id, item)
 	if err != nil {
 		return nil, err
 	}
+
+	s.auditCommitted(actor, model.AuditResourceHealthLocation, id, "", model.AuditOperationUpdate, before, items)
 	return items, nil
 }
 
-func (s *servicesImpl) DeleteHealthLocation(appID string, orgID string, id string) error {
-	err := s.app.storage.DeleteHealthLocation(appID, orgID, id)
-	return err
+func (s *servicesImpl) DeleteHealthLocation(actor *model.AuditActor, appID string, orgID string, id string) error {
+	before, err := s.app.storage.GetHealthLocation(appID, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.storage.DeleteHealthLocation(appID, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceHealthLocation, id, "", model.AuditOperationDelete, before, nil)
+	return nil
 }
 
 // Content Items
@@ -130,354 +174,1028 @@ func (s *servicesImpl) GetContentItemsCategories(allApps bool, appID string, org
 	return s.app.storage.GetContentItemsCategories(appIDParam, orgID)
 }
 
-func (s *servicesImpl) GetContentItems(allApps bool, appID string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error) {
+func (s *servicesImpl) GetContentItems(allApps bool, appID string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
-	return s.app.storage.GetContentItems(appIDParam, orgID, ids, categoryList, offset, limit, order)
+	return s.app.storage.GetContentItems(appIDParam, orgID, ids, categoryList, state, workflowState, dataFilter, offset, limit, order)
 }
 
-func (s *servicesImpl) GetContentItem(allApps bool, appID string, orgID string, id string) (*model.ContentItemResponse, error) {
+func (s *servicesImpl) GetContentItemsPage(allApps bool, appID string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter,
+	cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.ContentItemsPage, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
-	return s.app.storage.GetContentItem(appIDParam, orgID, id)
+	return s.app.storage.GetContentItemsPage(appIDParam, orgID, ids, categoryList, state, workflowState, dataFilter, cursor, limit, order, withTotal)
 }
 
-func (s *servicesImpl) CreateContentItem(allApps bool, appID string, orgID string, category string, data interface{}) (*model.ContentItem, error) {
+func (s *servicesImpl) GetContentItem(allApps bool, appID string, orgID string, id string, state *string, workflowState *string) (*model.ContentItemResponse, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
-	cItem := model.ContentItem{ID: uuid.NewString(), Category: category, DateCreated: time.Now().UTC(),
-		Data: data, OrgID: orgID, AppID: appIDParam}
-	return s.app.storage.CreateContentItem(cItem)
+	return s.app.storage.GetContentItem(appIDParam, orgID, id, state, workflowState)
 }
 
-func (s *servicesImpl) UpdateContentItem(allApps bool, appID string, orgID string, id string, category string, data interface{}) (*model.ContentItem, error) {
+func (s *servicesImpl) CreateContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, category string, data interface{}, defaultLocale string, locales map[string]interface{}, publishAt *time.Time, expireAt *time.Time, workflowState string) (*model.ContentItem, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
+	err := s.validateContentItemData(appID, orgID, category, data, locales)
+	if err != nil {
+		return nil, err
+	}
 
-	//update
-	item, err := s.app.storage.UpdateContentItem(appIDParam, orgID, id, category, data)
+	cItem := model.ContentItem{ID: uuid.NewString(), Category: category, DateCreated: time.Now().UTC(),
+		Data: data, DefaultLocale: defaultLocale, Locales: locales, OrgID: orgID, AppID: appIDParam, PublishAt: publishAt, ExpireAt: expireAt,
+		WorkflowState: workflowState}
+	item, err := s.app.storage.CreateContentItem(cItem)
 	if err != nil {
 		return nil, err
 	}
 
+	s.auditCommitted(actor, model.AuditResourceContentItem, item.ID, item.Category, model.AuditOperationCreate, nil, plainContentItem(*item))
+	s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemCreated, *item))
 	return item, nil
 }
 
-func (s *servicesImpl) UpdateContentItemData(allApps bool, appID string, orgID string, id string, category string, data interface{}) (*model.ContentItem, error) {
+func (s *servicesImpl) UpdateContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string, data interface{}, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], publishAt *time.Time, expireAt *time.Time, ifMatch []string) (*model.ContentItem, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
 
-	//find the item
-	items, err := s.app.storage.FindContentItems(appIDParam, orgID, []string{id}, []string{category}, nil, nil, nil)
-	if err != nil {
-		return nil, err
-	}
-	if len(items) != 1 {
-		return nil, errors.New("not found")
-	}
-	item := items[0]
+	var item *model.ContentItem
+	var updateErr error
+	transaction := func(storage interfaces.Storage) error {
+		updateErr = nil
+
+		//find the item
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			updateErr = model.ErrContentItemNotFound
+			return updateErr
+		}
+
+		//reject the write if the client has not seen the current revision
+		updateErr = checkIfMatch(ifMatch, items[0].Revision())
+		if updateErr != nil {
+			return updateErr
+		}
+
+		//the translations are kept unless they are provided
+		itemLocales := locales.Or(items[0].Locales)
+		updateErr = s.validateContentItemData(appID, orgID, category, data, itemLocales)
+		if updateErr != nil {
+			return updateErr
+		}
+
+		//keep the current revision
+		err = s.storeContentItemVersion(storage, items[0])
+		if err != nil {
+			return err
+		}
+
+		//update
+		item, err = storage.UpdateContentItem(appIDParam, orgID, id, category, data, defaultLocale.Or(items[0].DefaultLocale), itemLocales, publishAt, expireAt,
+			editedWorkflowState(items[0].WorkflowState))
+		if err != nil {
+			return err
+		}
 
-	//update the data
-	item.Data = data
-	now := time.Now()
-	item.DateUpdated = &now
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, category, model.AuditOperationUpdate,
+			plainContentItem(items[0]), plainContentItem(*item))
+	}
 
-	//save it
-	err = s.app.storage.SaveContentItem(item)
+	err := s.app.storage.PerformTransaction(transaction)
+	if updateErr != nil {
+		//the transaction hides the error details
+		return nil, updateErr
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &item, nil
+	s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemUpdated, *item))
+	return item, nil
 }
 
-func (s *servicesImpl) DeleteContentItem(allApps bool, appID string, orgID string, id string) error {
+func (s *servicesImpl) PatchContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, patch model.DataPatch, ifMatch []string) (*model.ContentItem, error) {
 	//logic
 	var appIDParam *string
 	if !allApps {
 		appIDParam = &appID //associated with current app
 	}
-	return s.app.storage.DeleteContentItem(appIDParam, orgID, id)
-}
 
-func (s *servicesImpl) DeleteContentItemByCategory(allApps bool, appID string, orgID string, id string, category string) error {
-	//logic
-	var appIDParam *string
-	if !allApps {
-		appIDParam = &appID //associated with current app
-	}
+	var item model.ContentItem
+	var patchErr error
+	transaction := func(storage interfaces.Storage) error {
+		patchErr = nil
 
-	//find the item
-	items, err := s.app.storage.FindContentItems(appIDParam, orgID, []string{id}, []string{category}, nil, nil, nil)
-	if err != nil {
-		return err
-	}
-	if len(items) != 1 {
-		return errors.New("not found")
-	}
+		//find the item
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			patchErr = model.ErrContentItemNotFound
+			return patchErr
+		}
+		item = items[0]
 
-	//delete it
-	err = s.app.storage.DeleteContentItem(appIDParam, orgID, id)
-	if err != nil {
-		return err
-	}
+		//reject the write if the client has not seen the current revision
+		patchErr = checkIfMatch(ifMatch, item.Revision())
+		if patchErr != nil {
+			return patchErr
+		}
 
-	return nil
-}
+		//the patch is applied to the data read in the transaction, so concurrent patches do not overwrite each other
+		data, err := applyDataPatch(item.Data, patch)
+		if err != nil {
+			patchErr = err
+			return err
+		}
+		patchErr = s.validateContentItemData(appID, orgID, item.Category, data, item.Locales)
+		if patchErr != nil {
+			return patchErr
+		}
 
-// Misc
+		//keep the current revision
+		err = s.storeContentItemVersion(storage, items[0])
+		if err != nil {
+			return err
+		}
 
-func (s *servicesImpl) UploadImage(imageBytes []byte, path string, spec model.ImageSpec) (*string, error) {
-	image, _, err := image.Decode(bytes.NewReader(imageBytes))
-	if err != nil {
-		return nil, fmt.Errorf("Error decoding image: %s", err)
-	}
+		item.Data = data
+		item.WorkflowState = editedWorkflowState(item.WorkflowState)
+		now := time.Now()
+		item.DateUpdated = &now
+		err = storage.SaveContentItem(item)
+		if err != nil {
+			return err
+		}
 
-	if spec.Height > 0 || spec.Width > 0 {
-		image = resize.Resize(uint(spec.Width), uint(spec.Height), image, resize.Lanczos3)
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, item.Category, model.AuditOperationUpdate,
+			plainContentItem(items[0]), plainContentItem(item))
 	}
 
-	quality := 75
-	if spec.Quality > 0 {
-		quality = spec.Quality
+	err := s.app.storage.PerformTransaction(transaction)
+	if patchErr != nil {
+		//the transaction hides the error details
+		return nil, patchErr
 	}
-
-	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, float32(quality))
 	if err != nil {
-		return nil, fmt.Errorf("Error creating webp encoder options: %s", err)
-	}
-
-	var output bytes.Buffer
-	if err := webp.Encode(&output, image, options); err != nil {
-		return nil, fmt.Errorf("Error encoding webp: %s", err)
+		return nil, err
 	}
 
-	url, err := s.app.awsAdapter.CreateImage(&output, path, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to upload to S3: %s", err)
-	}
+	s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemUpdated, item))
+	return &item, nil
+}
 
-	if url != nil {
-		return url, nil
+func (s *servicesImpl) UpdateContentItemData(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string, data interface{}, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], publishAt *time.Time, expireAt *time.Time) (*model.ContentItem, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
 	}
 
-	return nil, nil
-}
+	var item model.ContentItem
+	var updateErr error
+	transaction := func(storage interfaces.Storage) error {
+		updateErr = nil
 
-func (s *servicesImpl) GetProfileImage(userID string, imageType string) ([]byte, error) {
-	return s.app.awsAdapter.LoadProfileImage(fmt.Sprintf("profile-images/%s-%s.webp", userID, imageType))
-}
+		//find the item
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, []string{category}, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			updateErr = model.ErrContentItemNotFound
+			return updateErr
+		}
+		item = items[0]
+
+		//the translations are kept unless they are provided
+		item.DefaultLocale = defaultLocale.Or(item.DefaultLocale)
+		item.Locales = locales.Or(item.Locales)
+		updateErr = s.validateContentItemData(appID, orgID, category, data, item.Locales)
+		if updateErr != nil {
+			return updateErr
+		}
 
-func (s *servicesImpl) UploadProfileImage(userID string, imageBytes []byte) error {
-	var mediumImage image.Image
-	var smallImage image.Image
+		//keep the current revision
+		err = s.storeContentItemVersion(storage, items[0])
+		if err != nil {
+			return err
+		}
 
-	defaultFileNameWebp := fmt.Sprintf("%s-default", userID)
-	mediumFileNameWebp := fmt.Sprintf("%s-medium", userID)
-	smallFileNameWebp := fmt.Sprintf("%s-small", userID)
+		//update the data, the category endpoints publish directly so the workflow state is kept
+		item.Data = data
+		now := time.Now()
+		item.DateUpdated = &now
 
-	defaultImage, _, err := image.Decode(bytes.NewReader(imageBytes))
-	if err != nil {
-		return fmt.Errorf("Error decoding image: %s", err)
-	}
+		//the publish window is kept unless a new one is provided
+		if publishAt != nil {
+			item.PublishAt = publishAt
+			item.DateArchived = nil
+		}
+		if expireAt != nil {
+			item.ExpireAt = expireAt
+			item.DateArchived = nil
+		}
 
-	bounds := defaultImage.Bounds()
-	if bounds.Dx() > 512 || bounds.Dy() > 512 {
-		image := resize.Resize(512, 0, defaultImage, resize.Lanczos3)
-		mediumImage = image
-	} else {
-		mediumImage = defaultImage
-	}
+		//save it
+		err = storage.SaveContentItem(item)
+		if err != nil {
+			return err
+		}
 
-	if bounds.Dx() > 256 || bounds.Dy() > 256 {
-		image := resize.Resize(256, 0, defaultImage, resize.Lanczos3)
-		smallImage = image
-	} else {
-		smallImage = defaultImage
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, category, model.AuditOperationUpdate,
+			plainContentItem(items[0]), plainContentItem(item))
 	}
 
-	_, err = s.UploadProfileImageToAws(defaultImage, defaultFileNameWebp, "profile-images/", model.ImageSpec{})
-	if err != nil {
-		return fmt.Errorf("Unable to upload de file: %s. Error: %s", defaultFileNameWebp, err)
-	}
-	_, err = s.UploadProfileImageToAws(mediumImage, mediumFileNameWebp, "profile-images/", model.ImageSpec{})
-	if err != nil {
-		return fmt.Errorf("Unable to upload file: %s. Error: %s", mediumFileNameWebp, err)
+	err := s.app.storage.PerformTransaction(transaction)
+	if updateErr != nil {
+		//the transaction hides the error details
+		return nil, updateErr
 	}
-	_, err = s.UploadProfileImageToAws(smallImage, smallFileNameWebp, "profile-images/", model.ImageSpec{})
 	if err != nil {
-		return fmt.Errorf("Unable to upload file: %s. Error: %s", smallFileNameWebp, err)
+		return nil, err
 	}
 
-	return nil
+	s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemUpdated, item))
+	return &item, nil
 }
 
-func (s *servicesImpl) DeleteProfileImage(userID string) error {
-	err := s.app.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-default.webp", userID))
-	if err != nil {
-		return err
+func (s *servicesImpl) DeleteContentItem(actor *model.AuditActor, allApps bool, appID string, orgID string, id string) error {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
 	}
-	err = s.app.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-medium.webp", userID))
-	if err != nil {
-		return err
+
+	var item *model.ContentItem
+	transaction := func(storage interfaces.Storage) error {
+		//keep the item for the webhooks
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) == 1 {
+			item = &items[0]
+		}
+
+		err = storage.DeleteContentItem(appIDParam, orgID, id)
+		if err != nil {
+			return err
+		}
+
+		//the history goes with the item
+		err = storage.DeleteContentItemVersions(appIDParam, orgID, id)
+		if err != nil {
+			return err
+		}
+
+		if item == nil {
+			return nil
+		}
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, item.Category, model.AuditOperationDelete, plainContentItem(*item), nil)
 	}
-	err = s.app.awsAdapter.DeleteProfileImage(fmt.Sprintf("profile-images/%s-small.webp", userID))
+	err := s.app.storage.PerformTransaction(transaction)
 	if err != nil {
 		return err
 	}
+
+	if item != nil {
+		s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemDeleted, *item))
+	}
 	return nil
 }
 
-func (s *servicesImpl) UploadProfileImageToAws(image image.Image, filename string, path string, spec model.ImageSpec) (*string, error) {
-	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, 75)
-	if err != nil {
-		return nil, fmt.Errorf("Error creating webp encoder options: %s", err)
+func (s *servicesImpl) DeleteContentItemByCategory(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, category string) error {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
 	}
 
-	var output bytes.Buffer
-	if err := webp.Encode(&output, image, options); err != nil {
-		return nil, fmt.Errorf("Error encoding webp: %s", err)
-	}
+	var item model.ContentItem
+	var notFoundErr error
+	transaction := func(storage interfaces.Storage) error {
+		notFoundErr = nil
 
-	url, err := s.app.awsAdapter.CreateProfileImage(&output, path, &filename)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to upload to S3: %s", err)
-	}
+		//find the item
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, []string{category}, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			notFoundErr = model.ErrContentItemNotFound
+			return notFoundErr
+		}
+		item = items[0]
 
-	if url != nil {
-		return url, nil
-	}
+		//delete it
+		err = storage.DeleteContentItem(appIDParam, orgID, id)
+		if err != nil {
+			return err
+		}
 
-	return nil, nil
-}
+		//the history goes with the item
+		err = storage.DeleteContentItemVersions(appIDParam, orgID, id)
+		if err != nil {
+			return err
+		}
 
-func (s *servicesImpl) UploadVoiceRecord(userID string, bytes []byte) error {
-	_, err := s.app.awsAdapter.CreateUserVoiceRecord(bytes, userID)
-	if err != nil {
-		return err
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, category, model.AuditOperationDelete, plainContentItem(item), nil)
 	}
-
-	return nil
-}
-
-func (s *servicesImpl) GetVoiceRecord(userID string) ([]byte, error) {
-	fileContent, err := s.app.awsAdapter.LoadUserVoiceRecord(userID)
-	if err != nil {
-		return nil, err
+	err := s.app.storage.PerformTransaction(transaction)
+	if notFoundErr != nil {
+		//the transaction hides the error details
+		return notFoundErr
 	}
-	return fileContent, nil
-}
-
-func (s *servicesImpl) DeleteVoiceRecord(userID string) error {
-	err := s.app.awsAdapter.DeleteUserVoiceRecord(userID)
 	if err != nil {
 		return err
 	}
 
+	s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemDeleted, item))
 	return nil
 }
 
-func (s *servicesImpl) GetTwitterPosts(userID string, twitterQueryParams string, force bool) (map[string]interface{}, error) {
-	var err error
-	posts := s.app.cacheAdapter.GetTwitterPosts(userID, twitterQueryParams)
-	if posts == nil || force {
-		s.app.cacheLock.Lock()
-		posts = s.app.cacheAdapter.GetTwitterPosts(userID, twitterQueryParams)
-		if posts == nil || force {
-			if force {
-				s.app.cacheAdapter.ClearTwitterCacheForUser(userID)
-			}
-			posts, err = s.app.twitterAdapter.GetTwitterPosts(userID, twitterQueryParams)
-			if err == nil {
-				s.app.cacheAdapter.SetTwitterPosts(userID, twitterQueryParams, posts)
-			} else {
-				fmt.Printf("error feeding twitter: %s", err)
-			}
-		}
-		s.app.cacheLock.Unlock()
+func (s *servicesImpl) GetContentItemVersions(allApps bool, appID string, orgID string, id string, offset *int64, limit *int64) ([]model.ContentItemVersion, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
 	}
-	return posts, err
+	return s.app.storage.FindContentItemVersions(appIDParam, orgID, id, offset, limit)
 }
 
-func (s *servicesImpl) GetDataContentItem(claims *tokenauth.Claims, key string) (*model.DataContentItem, error) {
-	item, err := s.app.storage.FindDataContentItem(&claims.AppID, claims.OrgID, key)
-	if err != nil {
-		return nil, err
+func (s *servicesImpl) GetContentItemVersionsDiff(allApps bool, appID string, orgID string, id string, from int, to *int) (*model.ContentItemVersionDiff, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
 	}
-	return item, nil
-}
 
-func (s *servicesImpl) GetDataContentItems(claims *tokenauth.Claims, category string) ([]*model.DataContentItem, error) {
-	item, err := s.app.storage.FindDataContentItems(&claims.AppID, claims.OrgID, category)
+	fromVersion, err := s.app.storage.FindContentItemVersion(appIDParam, orgID, id, from)
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
-}
-
-func (s *servicesImpl) CreateOrUpdateMetaData(key string, value map[string]interface{}) (*model.MetaData, error) {
-	var metaData *model.MetaData
 
-	findMetaData, err := s.app.storage.FindMetaData(&key)
-	if err != nil {
-		return nil, err
-	}
-	if findMetaData == nil {
-		metaData, err = s.app.storage.CreateMetaData(key, value)
+	//compare against the requested revision or against the current item
+	var toCategory string
+	var toData interface{}
+	if to != nil {
+		toVersion, err := s.app.storage.FindContentItemVersion(appIDParam, orgID, id, *to)
 		if err != nil {
 			return nil, err
 		}
+		toCategory = toVersion.Category
+		toData = toVersion.Data
 	} else {
-		metaData, err = s.app.storage.UpdateMetaData(findMetaData, value)
+		current, err := s.app.storage.GetContentItem(appIDParam, orgID, id, nil, nil)
 		if err != nil {
 			return nil, err
 		}
+		toCategory, _ = (*current)["category"].(string)
+		toData = (*current)["data"]
 	}
 
-	return metaData, nil
-}
-
-func (s *servicesImpl) GetMetaData(key *string) (*model.MetaData, error) {
-	item, err := s.app.storage.FindMetaData(key)
+	fromValue, err := jsonValue(fromVersion.Data)
 	if err != nil {
 		return nil, err
 	}
+	toValue, err := jsonValue(toData)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []model.ContentItemChange{}
+	if fromVersion.Category != toCategory {
+		changes = append(changes, model.ContentItemChange{Path: "/category", Op: "changed", From: fromVersion.Category, To: toCategory})
+	}
+	changes = append(changes, diffData("/data", fromValue, toValue)...)
+
+	return &model.ContentItemVersionDiff{ContentItemID: id, From: from, To: to, Changes: changes}, nil
+}
+
+func (s *servicesImpl) RestoreContentItemVersion(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, version int) (*model.ContentItem, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	itemVersion, err := s.app.storage.FindContentItemVersion(appIDParam, orgID, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	//restore through the regular update, so the replaced revision goes to the history as well, the translations of the version replace the current ones
+	return s.UpdateContentItem(actor, allApps, appID, orgID, id, itemVersion.Category, itemVersion.Data, model.NewNullable(itemVersion.DefaultLocale),
+		model.NewNullable(itemVersion.Locales), itemVersion.PublishAt, itemVersion.ExpireAt, nil)
+}
+
+// contentItemTransitions gives the workflow states each transition is allowed from and the state it leads to
+var contentItemTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	model.ContentItemTransitionSubmit:  {from: []string{model.ContentItemWorkflowDraft}, to: model.ContentItemWorkflowInReview},
+	model.ContentItemTransitionApprove: {from: []string{model.ContentItemWorkflowInReview}, to: model.ContentItemWorkflowPublished},
+	model.ContentItemTransitionReject:  {from: []string{model.ContentItemWorkflowInReview}, to: model.ContentItemWorkflowDraft},
+	model.ContentItemTransitionArchive: {from: []string{model.ContentItemWorkflowDraft, model.ContentItemWorkflowInReview, model.ContentItemWorkflowPublished}, to: model.ContentItemWorkflowArchived},
+	model.ContentItemTransitionRestore: {from: []string{model.ContentItemWorkflowArchived}, to: model.ContentItemWorkflowDraft},
+}
+
+// contentItemWorkflowState gives the workflow state of a content item, the items created before the workflow are published
+func contentItemWorkflowState(item model.ContentItem) string {
+	if len(item.WorkflowState) == 0 {
+		return model.ContentItemWorkflowPublished
+	}
+	return item.WorkflowState
+}
+
+// editedWorkflowState gives the workflow state of a content item once its data, translations or publish window change.
+// A published or reviewed item goes back to draft, so the change is approved before the clients see it.
+func editedWorkflowState(state string) string {
+	switch state {
+	case model.ContentItemWorkflowDraft, model.ContentItemWorkflowArchived:
+		return state
+	default:
+		return model.ContentItemWorkflowDraft
+	}
+}
+
+// contentItemApprovePermissions are the permissions of the policy which allows approving the content items
+var contentItemApprovePermissions = []string{"approve_content-items", "all_content-items"}
+
+// canApproveContentItems says if an admin may publish the content items without the review
+func canApproveContentItems(actor *model.AuditActor) bool {
+	if actor == nil {
+		return false
+	}
+	for _, permission := range actor.Permissions {
+		if slices.Contains(contentItemApprovePermissions, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *servicesImpl) TransitionContentItemWorkflow(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, transition string) (*model.ContentItem, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	workflowTransition, ok := contentItemTransitions[transition]
+	if !ok {
+		return nil, fmt.Errorf("unsupported workflow transition %s", transition)
+	}
+
+	var item *model.ContentItem
+	var transitionErr error
+	transaction := func(storage interfaces.Storage) error {
+		transitionErr = nil
+
+		items, err := storage.FindContentItems(appIDParam, orgID, []string{id}, nil, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			transitionErr = model.ErrContentItemNotFound
+			return transitionErr
+		}
+		state := contentItemWorkflowState(items[0])
+		if !slices.Contains(workflowTransition.from, state) {
+			transitionErr = &model.WorkflowTransitionError{ID: id, Transition: transition, State: state}
+			return transitionErr
+		}
+
+		//the storage moves the item only if it is still in one of the allowed states
+		item, err = storage.UpdateContentItemWorkflowState(appIDParam, orgID, id, workflowTransition.from, workflowTransition.to)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(storage, actor, model.AuditResourceContentItem, id, item.Category, model.AuditOperationUpdate,
+			plainContentItem(items[0]), plainContentItem(*item))
+	}
+	err := s.app.storage.PerformTransaction(transaction)
+	if transitionErr != nil {
+		//the transaction hides the error details
+		return nil, transitionErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//an item approved for a later publish time is not published yet
+	eventType := model.WebhookEventContentItemUpdated
+	itemState := &model.ContentItemChangeState{WorkflowState: item.WorkflowState, PublishAt: item.PublishAt, ExpireAt: item.ExpireAt}
+	if itemState.Served(time.Now().UTC()) {
+		eventType = model.WebhookEventContentItemPublished
+	}
+	s.app.webhooksLogic.notify(contentItemEvent(eventType, *item))
+	return item, nil
+}
+
+func (s *servicesImpl) SearchContentItems(claims *tokenauth.Claims, allApps bool, appID string, orgID string, text string, categoryList []string, state *string, workflowState *string, offset int64, limit int64) ([]model.SearchResult, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	//the data content items the claims cannot read are left out by the search, so they do not take places on the page
+	unreadable, err := s.unreadableCategories(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	//every collection gives its best matches for the requested page, then they are ranked together
+	contentItems, err := s.app.storage.SearchContentItems(appIDParam, orgID, text, categoryList, state, workflowState, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+	dataContentItems, err := s.app.storage.SearchDataContentItems(appIDParam, orgID, text, categoryList, unreadable, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := append(contentItems, dataContentItems...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if offset >= int64(len(results)) {
+		return []model.SearchResult{}, nil
+	}
+	end := offset + limit
+	if end > int64(len(results)) {
+		end = int64(len(results))
+	}
+	return results[offset:end], nil
+}
+
+func (s *servicesImpl) ExportContentItems(allApps bool, appID string, orgID string, categoryList []string, handle func(item model.ContentItem) error) error {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	return s.app.storage.IterateContentItems(appIDParam, orgID, categoryList, handle)
+}
+
+func (s *servicesImpl) ImportContentItems(actor *model.AuditActor, allApps bool, appID string, orgID string, items []model.ContentItem, dryRun bool) (*model.ImportReport, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	return s.performImport(dryRun, func(storage interfaces.Storage) (*model.ImportReport, []model.WebhookEvent, error) {
+		return s.importContentItems(storage, actor, appIDParam, appID, orgID, items, dryRun)
+	})
+}
+
+func (s *servicesImpl) ApplyContentItemsBatch(actor *model.AuditActor, allApps bool, appID string, orgID string, operations []model.ContentItemBatchOperation) (*model.BatchResult, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	ops := make([]string, len(operations))
+	for i, operation := range operations {
+		ops[i] = operation.Op
+	}
+	return s.performBatch(ops, func(storage interfaces.Storage, index int) (string, interface{}, *model.WebhookEvent, error) {
+		return s.applyContentItemOperation(storage, actor, appIDParam, appID, orgID, operations[index])
+	})
+}
+
+// storeContentItemVersion keeps the current revision of an item in the history before it gets overwritten
+func (s *servicesImpl) storeContentItemVersion(storage interfaces.Storage, item model.ContentItem) error {
+	latestLimit := int64(1)
+	latest, err := storage.FindContentItemVersions(item.AppID, item.OrgID, item.ID, nil, &latestLimit)
+	if err != nil {
+		return err
+	}
+	version := 1
+	if len(latest) > 0 {
+		version = latest[0].Version + 1
+	}
+
+	dateRevised := item.DateCreated
+	if item.DateUpdated != nil {
+		dateRevised = *item.DateUpdated
+	}
+
+	itemVersion := model.ContentItemVersion{ID: uuid.NewString(), ContentItemID: item.ID, Version: version,
+		Category: item.Category, Data: item.Data, DefaultLocale: item.DefaultLocale, Locales: item.Locales, OrgID: item.OrgID, AppID: item.AppID,
+		PublishAt: item.PublishAt, ExpireAt: item.ExpireAt, DateRevised: dateRevised, DateCreated: time.Now().UTC()}
+	return storage.CreateContentItemVersion(itemVersion)
+}
+
+// Misc
+
+func (s *servicesImpl) UploadImage(actor *model.AuditActor, claims *tokenauth.Claims, imageBytes []byte, path string, spec model.ImageSpec) (*string, error) {
+	image, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding image: %s", err)
+	}
+
+	if spec.Height > 0 || spec.Width > 0 {
+		image = resize.Resize(uint(spec.Width), uint(spec.Height), image, resize.Lanczos3)
+	}
+
+	quality := 75
+	if spec.Quality > 0 {
+		quality = spec.Quality
+	}
+
+	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, float32(quality))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating webp encoder options: %s", err)
+	}
+
+	var output bytes.Buffer
+	if err := webp.Encode(&output, image, options); err != nil {
+		return nil, fmt.Errorf("Error encoding webp: %s", err)
+	}
+
+	size := int64(output.Len())
+	err = checkStorageQuota(s.app.storage, claims.AppID, claims.OrgID, model.StorageUsageImages, size)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.app.objectStorage.Upload(model.ObjectBucketContent, imageKey(path, nil), &output, true)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to upload the image: %s", err)
+	}
+
+	usage := model.NewStorageUsage(claims.AppID, claims.OrgID, model.StorageUsageImages)
+	usage.Upload(size, 1)
+	recordStorageUsage(s.app.storage, s.app.logger, usage)
+
+	s.auditCommitted(actor, model.AuditResourceImage, path, "", model.AuditOperationCreate, nil, map[string]interface{}{"url": url})
+	return &url, nil
+}
+
+func (s *servicesImpl) GetProfileImage(userID string, imageType string) ([]byte, error) {
+	return s.downloadObject(model.ObjectBucketProfileImages, profileImageKey(userID, imageType))
+}
+
+func (s *servicesImpl) UploadProfileImage(claims *tokenauth.Claims, imageBytes []byte) error {
+	userID := claims.Subject
+	return changeObjects(s.app.storage, s.app.objectStorage, s.app.logger, claims.AppID, claims.OrgID, model.StorageUsageProfilePhotos,
+		model.ObjectBucketProfileImages, profileImagesPrefix(userID), profileImageKeys(userID), int64(len(imageBytes)), func() error {
+			return s.uploadProfileImage(userID, imageBytes)
+		})
+}
+
+func (s *servicesImpl) uploadProfileImage(userID string, imageBytes []byte) error {
+	var mediumImage image.Image
+	var smallImage image.Image
+
+	defaultFileNameWebp := fmt.Sprintf("%s-default", userID)
+	mediumFileNameWebp := fmt.Sprintf("%s-medium", userID)
+	smallFileNameWebp := fmt.Sprintf("%s-small", userID)
+
+	defaultImage, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return fmt.Errorf("Error decoding image: %s", err)
+	}
+
+	bounds := defaultImage.Bounds()
+	if bounds.Dx() > 512 || bounds.Dy() > 512 {
+		image := resize.Resize(512, 0, defaultImage, resize.Lanczos3)
+		mediumImage = image
+	} else {
+		mediumImage = defaultImage
+	}
+
+	if bounds.Dx() > 256 || bounds.Dy() > 256 {
+		image := resize.Resize(256, 0, defaultImage, resize.Lanczos3)
+		smallImage = image
+	} else {
+		smallImage = defaultImage
+	}
+
+	_, err = s.UploadProfileImageToAws(defaultImage, defaultFileNameWebp, "profile-images/", model.ImageSpec{})
+	if err != nil {
+		return fmt.Errorf("Unable to upload de file: %s. Error: %s", defaultFileNameWebp, err)
+	}
+	_, err = s.UploadProfileImageToAws(mediumImage, mediumFileNameWebp, "profile-images/", model.ImageSpec{})
+	if err != nil {
+		return fmt.Errorf("Unable to upload file: %s. Error: %s", mediumFileNameWebp, err)
+	}
+	_, err = s.UploadProfileImageToAws(smallImage, smallFileNameWebp, "profile-images/", model.ImageSpec{})
+	if err != nil {
+		return fmt.Errorf("Unable to upload file: %s. Error: %s", smallFileNameWebp, err)
+	}
+
+	return nil
+}
+
+func (s *servicesImpl) DeleteProfileImage(claims *tokenauth.Claims) error {
+	userID := claims.Subject
+	return changeObjects(s.app.storage, s.app.objectStorage, s.app.logger, claims.AppID, claims.OrgID, model.StorageUsageProfilePhotos,
+		model.ObjectBucketProfileImages, profileImagesPrefix(userID), profileImageKeys(userID), 0, func() error {
+			return deleteProfileImages(s.app.objectStorage, userID)
+		})
+}
+
+func (s *servicesImpl) UploadProfileImageToAws(image image.Image, filename string, path string, spec model.ImageSpec) (*string, error) {
+	options, err := encoder.NewLossyEncoderOptions(encoder.PresetDefault, 75)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating webp encoder options: %s", err)
+	}
+
+	var output bytes.Buffer
+	if err := webp.Encode(&output, image, options); err != nil {
+		return nil, fmt.Errorf("Error encoding webp: %s", err)
+	}
+
+	url, err := s.app.objectStorage.Upload(model.ObjectBucketProfileImages, imageKey(path, &filename), &output, false)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to upload the profile image: %s", err)
+	}
+	return &url, nil
+}
+
+func (s *servicesImpl) UploadVoiceRecord(claims *tokenauth.Claims, fileContent []byte) error {
+	key := voiceRecordKey(claims.Subject)
+	return changeObjects(s.app.storage, s.app.objectStorage, s.app.logger, claims.AppID, claims.OrgID, model.StorageUsageVoiceRecords,
+		model.ObjectBucketUsersAudios, key, []string{key}, int64(len(fileContent)), func() error {
+			_, err := s.app.objectStorage.Upload(model.ObjectBucketUsersAudios, key, bytes.NewReader(fileContent), false)
+			return err
+		})
+}
+
+func (s *servicesImpl) GetVoiceRecord(userID string) ([]byte, error) {
+	return s.downloadObject(model.ObjectBucketUsersAudios, voiceRecordKey(userID))
+}
+
+func (s *servicesImpl) DeleteVoiceRecord(claims *tokenauth.Claims) error {
+	key := voiceRecordKey(claims.Subject)
+	return changeObjects(s.app.storage, s.app.objectStorage, s.app.logger, claims.AppID, claims.OrgID, model.StorageUsageVoiceRecords,
+		model.ObjectBucketUsersAudios, key, []string{key}, 0, func() error {
+			return s.app.objectStorage.Delete(model.ObjectBucketUsersAudios, key)
+		})
+}
+
+func (s *servicesImpl) GetTwitterPosts(userID string, twitterQueryParams string, force bool) (map[string]interface{}, error) {
+	var err error
+	posts := s.app.cacheAdapter.GetTwitterPosts(userID, twitterQueryParams)
+	if posts == nil || force {
+		s.app.cacheLock.Lock()
+		posts = s.app.cacheAdapter.GetTwitterPosts(userID, twitterQueryParams)
+		if posts == nil || force {
+			if force {
+				s.app.cacheAdapter.ClearTwitterCacheForUser(userID)
+			}
+			posts, err = s.app.twitterAdapter.GetTwitterPosts(userID, twitterQueryParams)
+			if err == nil {
+				s.app.cacheAdapter.SetTwitterPosts(userID, twitterQueryParams, posts)
+			} else {
+				fmt.Printf("error feeding twitter: %s", err)
+			}
+		}
+		s.app.cacheLock.Unlock()
+	}
+	return posts, err
+}
+
+func (s *servicesImpl) GetDataContentItem(claims *tokenauth.Claims, key string) (*model.DataContentItem, error) {
+	item, err := s.app.storage.FindDataContentItem(&claims.AppID, claims.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCategoryRead(s.app.storage, claims, item.Category)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *servicesImpl) GetDataContentItems(claims *tokenauth.Claims, category string, withDescendants bool) ([]*model.DataContentItem, error) {
+	categories, err := s.dataContentItemsCategories(claims, category, withDescendants)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.app.storage.FindDataContentItems(&claims.AppID, claims.OrgID, categories)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *servicesImpl) GetDataContentItemsPage(claims *tokenauth.Claims, category string, withDescendants bool, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.DataContentItemsPage, error) {
+	categories, err := s.dataContentItemsCategories(claims, category, withDescendants)
+	if err != nil {
+		return nil, err
+	}
+	return s.app.storage.FindDataContentItemsPage(&claims.AppID, claims.OrgID, categories, cursor, limit, order, withTotal)
+}
+
+// dataContentItemsCategories gives the categories the data content items of a category are listed from,
+// the subcategories the claims cannot read are left out
+func (s *servicesImpl) dataContentItemsCategories(claims *tokenauth.Claims, category string, withDescendants bool) ([]string, error) {
+	err := checkCategoryRead(s.app.storage, claims, category)
+	if err != nil {
+		return nil, err
+	}
+	if !withDescendants {
+		return []string{category}, nil
+	}
+
+	categories, children, err := loadCategoryTree(s.app.storage, claims.AppID, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	readable := []string{category}
+	for _, descendant := range descendantCategories(children, category)[1:] {
+		categoryItem := categories[descendant]
+		access, err := effectiveCategoryAccess(&categoryItem, loadedCategoryFinder(categories))
+		if err != nil {
+			return nil, err
+		}
+		if canReadCategory(access, claims) {
+			readable = append(readable, descendant)
+		}
+	}
+	return readable, nil
+}
+
+// unreadableCategories gives the categories which data content items the claims cannot read
+func (s *servicesImpl) unreadableCategories(claims *tokenauth.Claims) ([]string, error) {
+	categories, _, err := loadCategoryTree(s.app.storage, claims.AppID, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	unreadable := []string{}
+	for name, categoryItem := range categories {
+		access, err := effectiveCategoryAccess(&categoryItem, loadedCategoryFinder(categories))
+		if err != nil {
+			return nil, err
+		}
+		if !canReadCategory(access, claims) {
+			unreadable = append(unreadable, name)
+		}
+	}
+	return unreadable, nil
+}
+
+func (s *servicesImpl) ExportDataContentItems(claims *tokenauth.Claims, categoryList []string, handle func(item model.DataContentItem) error) error {
+	return s.app.storage.IterateDataContentItems(&claims.AppID, claims.OrgID, categoryList, handle)
+}
+
+func (s *servicesImpl) ImportDataContentItems(actor *model.AuditActor, claims *tokenauth.Claims, items []model.DataContentItem, dryRun bool) (*model.ImportReport, error) {
+	return s.performImport(dryRun, func(storage interfaces.Storage) (*model.ImportReport, []model.WebhookEvent, error) {
+		return s.importDataContentItems(storage, actor, claims, items, dryRun)
+	})
+}
+
+func (s *servicesImpl) ApplyDataContentItemsBatch(actor *model.AuditActor, claims *tokenauth.Claims, operations []model.DataContentItemBatchOperation) (*model.BatchResult, error) {
+	ops := make([]string, len(operations))
+	for i, operation := range operations {
+		ops[i] = operation.Op
+	}
+	return s.performBatch(ops, func(storage interfaces.Storage, index int) (string, interface{}, *model.WebhookEvent, error) {
+		return s.applyDataContentItemOperation(storage, actor, claims, operations[index])
+	})
+}
+
+func (s *servicesImpl) SubscribeContentChanges(claims *tokenauth.Claims, allApps bool, categories []string) (<-chan model.ContentChange, func()) {
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &claims.AppID //associated with current app
+	}
+
+	subscriber := s.app.changeFeed.subscribe(claims, appIDParam, categories)
+	return subscriber.changes, func() { s.app.changeFeed.unsubscribe(subscriber) }
+}
+
+func (s *servicesImpl) CreateOrUpdateMetaData(actor *model.AuditActor, claims *tokenauth.Claims, admin bool, global bool, key string, value map[string]interface{}, permissions *[]string) (*model.MetaData, error) {
+	appID, orgID := metaDataScope(claims, global)
+
+	var metaData *model.MetaData
+	findMetaData, err := s.app.storage.FindMetaData(appID, orgID, &key)
+	if err != nil {
+		return nil, err
+	}
+	if findMetaData == nil {
+		//the clients change only the meta data the admins have created
+		if !admin {
+			return nil, &model.MetaDataAccessError{Key: key}
+		}
+		var itemPermissions []string
+		if permissions != nil {
+			itemPermissions = *permissions
+		}
+		metaData, err = s.app.storage.CreateMetaData(appID, orgID, key, value, itemPermissions)
+		if err != nil {
+			return nil, err
+		}
+		s.auditCommitted(actor, model.AuditResourceMetaData, key, "", model.AuditOperationCreate, nil, metaData.Value)
+	} else {
+		if !canChangeMetaData(findMetaData, claims, admin) {
+			return nil, &model.MetaDataAccessError{Key: key}
+		}
+
+		//the permissions are kept when they are not passed
+		itemPermissions := findMetaData.Permissions
+		if permissions != nil {
+			itemPermissions = *permissions
+		}
+		before := findMetaData.Value
+		metaData, err = s.app.storage.UpdateMetaData(findMetaData, value, itemPermissions)
+		if err != nil {
+			return nil, err
+		}
+		s.auditCommitted(actor, model.AuditResourceMetaData, key, "", model.AuditOperationUpdate, before, metaData.Value)
+	}
+
+	return metaData, nil
+}
+
+// GetMetaData gives the meta data of the app, or the global one when the app has none with the key
+func (s *servicesImpl) GetMetaData(claims *tokenauth.Claims, key *string) (*model.MetaData, error) {
+	item, err := s.app.storage.FindMetaData(&claims.AppID, &claims.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return s.app.storage.FindMetaData(nil, nil, key)
+	}
 	return item, nil
 }
 
-func (s *servicesImpl) DeleteMetaData(key string) error {
-	err := s.app.storage.DeleteMetaData(key)
+func (s *servicesImpl) GetMetaDataList(claims *tokenauth.Claims, global bool, keyPrefix *string, offset *int64, limit *int64) ([]model.MetaData, error) {
+	appID, orgID := metaDataScope(claims, global)
+	return s.app.storage.FindMetaDataList(appID, orgID, keyPrefix, offset, limit)
+}
+
+func (s *servicesImpl) DeleteMetaData(actor *model.AuditActor, claims *tokenauth.Claims, admin bool, global bool, key string) error {
+	appID, orgID := metaDataScope(claims, global)
+
+	before, err := s.app.storage.FindMetaData(appID, orgID, &key)
+	if err != nil {
+		return err
+	}
+	if before != nil && !canChangeMetaData(before, claims, admin) {
+		return &model.MetaDataAccessError{Key: key}
+	}
+
+	err = s.app.storage.DeleteMetaData(appID, orgID, key)
 	if err != nil {
 		return err
 	}
 
+	if before != nil {
+		s.auditCommitted(actor, model.AuditResourceMetaData, key, "", model.AuditOperationDelete, before.Value, nil)
+	}
 	return nil
 }
 
-func (s *servicesImpl) CreateDataContentItem(claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error) {
+func (s *servicesImpl) CreateDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error) {
 
 	category, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
 	if err != nil {
 		return nil, err
 	}
 
-	if !checkPermissions(category.Permissions, claims.Permissions) {
-		return nil, fmt.Errorf("unauthorized to create data content item: [%s]", strings.Join(category.Permissions, ", "))
+	permissions, err := categoryPermissions(s.app.storage, category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if !checkPermissions(permissions, claims.Permissions) {
+		return nil, fmt.Errorf("unauthorized to create data content item: [%s]", strings.Join(permissions, ", "))
+	}
+
+	err = validateCategoryData(category, item.Data, item.Locales)
+	if err != nil {
+		return nil, err
 	}
 
 	item.ID = uuid.NewString()
@@ -488,131 +1206,530 @@ func (s *servicesImpl) CreateDataContentItem(claims *tokenauth.Claims, item *mod
 	if err != nil {
 		return nil, err
 	}
+
+	s.auditCommitted(actor, model.AuditResourceDataContentItem, item.Key, item.Category, model.AuditOperationCreate, nil, plainDataContentItem(*item))
+	s.app.webhooksLogic.notify(dataContentItemEvent(model.WebhookEventDataContentItemCreated, *item))
+	return item, nil
+}
+
+func (s *servicesImpl) UpdateDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, item *model.DataContentItem, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}], ifMatch []string) (*model.DataContentItem, error) {
+	var dataItem *model.DataContentItem
+
+	category, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := categoryPermissions(s.app.storage, category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if !checkPermissions(permissions, claims.Permissions) {
+		return nil, fmt.Errorf("unauthorized to update data content item: [%s]", strings.Join(permissions, ", "))
+	}
+
+	var updateErr error
+	transaction := func(storage interfaces.Storage) error {
+		updateErr = nil
+
+		oldItem, err := storage.FindDataContentItem(&claims.AppID, claims.OrgID, item.Key)
+		if err != nil {
+			return err
+		}
+
+		//reject the write if the client has not seen the current revision
+		updateErr = checkIfMatch(ifMatch, oldItem.Revision())
+		if updateErr != nil {
+			return updateErr
+		}
+
+		//the translations are kept unless they are provided
+		item.DefaultLocale = defaultLocale.Or(oldItem.DefaultLocale)
+		item.Locales = locales.Or(oldItem.Locales)
+		updateErr = validateCategoryData(category, item.Data, item.Locales)
+		if updateErr != nil {
+			return updateErr
+		}
+
+		if item.Category != oldItem.Category {
+			category, err := storage.FindCategory(&claims.AppID, claims.OrgID, oldItem.Category)
+			if err != nil {
+				return err
+			}
+
+			permissions, err := categoryPermissions(storage, category, categoryAccessWrite)
+			if err != nil {
+				return err
+			}
+			if !checkPermissions(permissions, claims.Permissions) {
+				return fmt.Errorf("unauthorized to update data content item: [%s]", strings.Join(permissions, ", "))
+			}
+		}
+
+		dataItem, err = storage.UpdateDataContentItem(&claims.AppID, claims.OrgID, item)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(storage, actor, model.AuditResourceDataContentItem, item.Key, dataItem.Category, model.AuditOperationUpdate,
+			plainDataContentItem(*oldItem), plainDataContentItem(*dataItem))
+	}
+
+	err = s.app.storage.PerformTransaction(transaction)
+	if updateErr != nil {
+		//the transaction hides the error details
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.app.webhooksLogic.notify(dataContentItemEvent(model.WebhookEventDataContentItemUpdated, *dataItem))
+	return dataItem, nil
+}
+
+func (s *servicesImpl) PatchDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, key string, patch model.DataPatch, ifMatch []string) (*model.DataContentItem, error) {
+	var dataItem *model.DataContentItem
+	var patchErr error
+	transaction := func(storage interfaces.Storage) error {
+		patchErr = nil
+
+		oldItem, err := storage.FindDataContentItem(&claims.AppID, claims.OrgID, key)
+		if err != nil {
+			return err
+		}
+
+		//reject the write if the client has not seen the current revision
+		patchErr = checkIfMatch(ifMatch, oldItem.Revision())
+		if patchErr != nil {
+			return patchErr
+		}
+
+		category, err := storage.FindCategory(&claims.AppID, claims.OrgID, oldItem.Category)
+		if err != nil {
+			return err
+		}
+		permissions, err := categoryPermissions(storage, category, categoryAccessWrite)
+		if err != nil {
+			return err
+		}
+		if !checkPermissions(permissions, claims.Permissions) {
+			return fmt.Errorf("unauthorized to update data content item: [%s]", strings.Join(permissions, ", "))
+		}
+
+		//the patch is applied to the data read in the transaction, so concurrent patches do not overwrite each other
+		data, err := applyDataPatch(oldItem.Data, patch)
+		if err != nil {
+			patchErr = err
+			return err
+		}
+		patchErr = validateCategoryData(category, data, oldItem.Locales)
+		if patchErr != nil {
+			return patchErr
+		}
+
+		item := *oldItem
+		item.Data = data
+		dataItem, err = storage.UpdateDataContentItem(&claims.AppID, claims.OrgID, &item)
+		if err != nil {
+			return err
+		}
+
+		return s.audit(storage, actor, model.AuditResourceDataContentItem, key, dataItem.Category, model.AuditOperationUpdate,
+			plainDataContentItem(*oldItem), plainDataContentItem(*dataItem))
+	}
+
+	err := s.app.storage.PerformTransaction(transaction)
+	if patchErr != nil {
+		//the transaction hides the error details
+		return nil, patchErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.app.webhooksLogic.notify(dataContentItemEvent(model.WebhookEventDataContentItemUpdated, *dataItem))
+	return dataItem, nil
+}
+
+func (s *servicesImpl) DeleteDataContentItem(actor *model.AuditActor, claims *tokenauth.Claims, key string) error {
+
+	item, err := s.app.storage.FindDataContentItem(&claims.AppID, claims.OrgID, key)
+	if err != nil {
+		return err
+	}
+
+	category, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
+	if err != nil {
+		return err
+	}
+
+	permissions, err := categoryPermissions(s.app.storage, category, categoryAccessDelete)
+	if err != nil {
+		return err
+	}
+	if !checkPermissions(permissions, claims.Permissions) {
+		return fmt.Errorf("unauthorized to delete data content item: [%s]", strings.Join(permissions, ", "))
+	}
+
+	err = s.app.storage.DeleteDataContentItem(&claims.AppID, claims.OrgID, key)
+	if err != nil {
+		return err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceDataContentItem, key, item.Category, model.AuditOperationDelete, plainDataContentItem(*item), nil)
+	s.app.webhooksLogic.notify(dataContentItemEvent(model.WebhookEventDataContentItemDeleted, *item))
+	return nil
+}
+
+func (s *servicesImpl) CreateCategory(actor *model.AuditActor, claims *tokenauth.Claims, item *model.Category) (*model.Category, error) {
+	err := validateCategoryParent(s.app.storage, claims.AppID, claims.OrgID, item.Parent, item.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	//items may already use the category name, so they must conform to the schema as well
+	report, err := s.checkCategorySchema(s.app.storage, claims.AppID, claims.OrgID, item.Name, item.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return nil, &model.CategorySchemaError{Report: *report}
+	}
+
+	item.ID = uuid.NewString()
+	item.AppID = &claims.AppID
+	item.OrgID = claims.OrgID
+	item.DateCreated = time.Now().UTC()
+	item, err = s.app.storage.CreateCategory(item)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceCategory, item.Name, item.Name, model.AuditOperationCreate, nil, item)
+	return item, nil
+}
+
+func (s *servicesImpl) GetCategory(claims *tokenauth.Claims, name string) (*model.Category, error) {
+	item, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, name)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *servicesImpl) UpdateCategory(actor *model.AuditActor, claims *tokenauth.Claims, item *model.Category) (*model.Category, error) {
+	//the category is updated by id, its name may change
+	before, err := s.app.storage.FindCategoryByID(&claims.AppID, claims.OrgID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateCategoryParent(s.app.storage, claims.AppID, claims.OrgID, item.Parent, item.Name, before.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.checkCategorySchema(s.app.storage, claims.AppID, claims.OrgID, item.Name, item.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Valid {
+		return nil, &model.CategorySchemaError{Report: *report}
+	}
+
+	//the files keep the category name in their paths
+	if item.Name != before.Name {
+		files, err := s.countCategoryFiles(claims, before.Name)
+		if err != nil {
+			return nil, err
+		}
+		if files > 0 {
+			return nil, &model.CategoryInUseError{Category: before.Name, Files: files}
+		}
+	}
+
+	transaction := func(storage interfaces.Storage) error {
+		item, err = storage.UpdateCategory(&claims.AppID, claims.OrgID, item)
+		if err != nil {
+			return err
+		}
+
+		//the items and the subcategories refer to the category by name
+		if item.Name != before.Name {
+			return storage.RenameCategoryReferences(&claims.AppID, claims.OrgID, before.Name, item.Name)
+		}
+		return nil
+	}
+	err = s.app.storage.PerformTransaction(transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceCategory, item.Name, item.Name, model.AuditOperationUpdate, before, item)
 	return item, nil
 }
 
-func (s *servicesImpl) UpdateDataContentItem(claims *tokenauth.Claims, item *model.DataContentItem) (*model.DataContentItem, error) {
-	var dataItem *model.DataContentItem
+func (s *servicesImpl) GetCategorySubtree(claims *tokenauth.Claims, name string) (*model.CategoryTree, error) {
+	categories, children, err := loadCategoryTree(s.app.storage, claims.AppID, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := categories[name]
+	if !ok {
+		return nil, fmt.Errorf("category %s is not found", name)
+	}
+
+	tree, err := buildCategoryTree(categories, children, category, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+func (s *servicesImpl) ValidateCategorySchema(claims *tokenauth.Claims, name string, schema json.RawMessage) (*model.CategorySchemaReport, error) {
+	return s.checkCategorySchema(s.app.storage, claims.AppID, claims.OrgID, name, schema)
+}
+
+func (s *servicesImpl) GetCategoriesPage(claims *tokenauth.Claims, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.CategoriesPage, error) {
+	page, err := s.app.storage.FindCategoriesPage(&claims.AppID, claims.OrgID, cursor, limit, order, withTotal)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	names := make([]string, len(page.Items))
+	for i, item := range page.Items {
+		names[i] = item.Name
+	}
+	contentItemsCounts, err := s.app.storage.CountContentItemsByCategory(&claims.AppID, claims.OrgID, names)
+	if err != nil {
+		return nil, err
+	}
+	dataContentItemsCounts, err := s.app.storage.CountDataContentItemsByCategory(&claims.AppID, claims.OrgID, names)
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Items {
+		page.Items[i].ContentItems = contentItemsCounts[page.Items[i].Name]
+		page.Items[i].DataContentItems = dataContentItemsCounts[page.Items[i].Name]
+	}
+	return page, nil
+}
+
+func (s *servicesImpl) RenameCategory(actor *model.AuditActor, claims *tokenauth.Claims, name string, newName string) (*model.Category, error) {
+	item, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, name)
+	if err != nil {
+		return nil, err
+	}
+	if newName == name {
+		return item, nil
+	}
+
+	existing, err := storageCategoryFinder(s.app.storage, &claims.AppID, claims.OrgID)(newName)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &model.CategoryExistsError{Category: newName}
+	}
+
+	//the update moves the items and the subcategories to the new name
+	item.Name = newName
+	return s.UpdateCategory(actor, claims, item)
+}
+
+func (s *servicesImpl) DeleteCategory(actor *model.AuditActor, claims *tokenauth.Claims, name string, cascade bool) error {
+	//the files are never deleted with their category, they must be deleted first
+	files, err := s.countCategoryFiles(claims, name)
+	if err != nil {
+		return err
+	}
+	//the quarantined files go with the category
+	quarantined := true
+	quarantinedFiles, err := s.app.storage.FindFileContentItems(&claims.AppID, claims.OrgID, &name, nil, nil, nil, nil, &quarantined, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var inUseErr *model.CategoryInUseError
+	var contentItems []model.ContentItem
+	var dataContentItems []*model.DataContentItem
+	transaction := func(storage interfaces.Storage) error {
+		inUseErr = nil
+
+		before, err := storage.FindCategory(&claims.AppID, claims.OrgID, name)
+		if err != nil {
+			return err
+		}
+
+		_, children, err := loadCategoryTree(storage, claims.AppID, claims.OrgID)
+		if err != nil {
+			return err
+		}
+		contentItems, err = storage.FindContentItems(&claims.AppID, claims.OrgID, nil, []string{name}, nil, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		dataContentItems, err = storage.FindDataContentItems(&claims.AppID, claims.OrgID, []string{name})
+		if err != nil {
+			return err
+		}
+
+		//the subcategories are never deleted with their parent, they must be moved or deleted first
+		subcategories := len(children[name])
+		if subcategories > 0 || files > 0 || (!cascade && (len(contentItems) > 0 || len(dataContentItems) > 0)) {
+			inUseErr = &model.CategoryInUseError{Category: name, ContentItems: int64(len(contentItems)),
+				DataContentItems: int64(len(dataContentItems)), Subcategories: subcategories, Files: files}
+			return inUseErr
+		}
 
-	category, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
-	if err != nil {
-		return nil, err
-	}
+		if len(contentItems) > 0 {
+			err = storage.DeleteContentItemsByCategory(&claims.AppID, claims.OrgID, name)
+			if err != nil {
+				return err
+			}
+			for _, item := range contentItems {
+				err = storage.DeleteContentItemVersions(&claims.AppID, claims.OrgID, item.ID)
+				if err != nil {
+					return err
+				}
+				err = s.audit(storage, actor, model.AuditResourceContentItem, item.ID, name, model.AuditOperationDelete, plainContentItem(item), nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if len(dataContentItems) > 0 {
+			err = storage.DeleteDataContentItemsByCategory(&claims.AppID, claims.OrgID, name)
+			if err != nil {
+				return err
+			}
+			for _, item := range dataContentItems {
+				err = s.audit(storage, actor, model.AuditResourceDataContentItem, item.Key, name, model.AuditOperationDelete, plainDataContentItem(*item), nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
 
-	if !checkPermissions(category.Permissions, claims.Permissions) {
-		return nil, fmt.Errorf("unauthorized to update data content item: [%s]", strings.Join(category.Permissions, ", "))
+		err = storage.DeleteCategory(&claims.AppID, claims.OrgID, name)
+		if err != nil {
+			return err
+		}
+		err = storage.DeleteCategoryReferences(&claims.AppID, claims.OrgID, name)
+		if err != nil {
+			return err
+		}
+		return s.audit(storage, actor, model.AuditResourceCategory, name, name, model.AuditOperationDelete, before, nil)
+	}
+	err = s.app.storage.PerformTransaction(transaction)
+	if inUseErr != nil {
+		return inUseErr
 	}
-
-	oldItem, err := s.app.storage.FindDataContentItem(&claims.AppID, claims.OrgID, item.Key)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if item.Category != oldItem.Category {
-		category, err = s.app.storage.FindCategory(&claims.AppID, claims.OrgID, oldItem.Category)
+	for _, file := range quarantinedFiles {
+		err = s.app.objectStorage.Delete(model.ObjectBucketQuarantine, file.Path)
 		if err != nil {
-			return nil, err
-		}
-
-		if !checkPermissions(category.Permissions, claims.Permissions) {
-			return nil, fmt.Errorf("unauthorized to update data content item: [%s]", strings.Join(category.Permissions, ", "))
+			s.app.logger.Errorf("error on deleting quarantined file %s of deleted category %s - %s", file.Path, name, err)
 		}
 	}
 
-	dataItem, err = s.app.storage.UpdateDataContentItem(&claims.AppID, claims.OrgID, item)
-	if err != nil {
-		return nil, err
+	for _, item := range contentItems {
+		s.app.webhooksLogic.notify(contentItemEvent(model.WebhookEventContentItemDeleted, item))
 	}
+	for _, item := range dataContentItems {
+		s.app.webhooksLogic.notify(dataContentItemEvent(model.WebhookEventDataContentItemDeleted, *item))
+	}
+	return nil
+}
+
+func (s *servicesImpl) ExportCategories(claims *tokenauth.Claims, handle func(item model.Category) error) error {
+	return s.app.storage.IterateCategories(&claims.AppID, claims.OrgID, handle)
+}
 
-	return dataItem, err
+func (s *servicesImpl) ImportCategories(actor *model.AuditActor, claims *tokenauth.Claims, items []model.Category, dryRun bool) (*model.ImportReport, error) {
+	return s.performImport(dryRun, func(storage interfaces.Storage) (*model.ImportReport, []model.WebhookEvent, error) {
+		report, err := s.importCategories(storage, actor, claims, items, dryRun)
+		return report, nil, err
+	})
 }
 
-func (s *servicesImpl) DeleteDataContentItem(claims *tokenauth.Claims, key string) error {
+func (s *servicesImpl) UploadFileContentItem(actor *model.AuditActor, file io.Reader, claims *tokenauth.Claims, fileName string, category string) (*model.FileContentItem, error) {
 
-	item, err := s.app.storage.FindDataContentItem(&claims.AppID, claims.OrgID, key)
+	path := claims.OrgID + "/" + claims.AppID + "/" + category + "/" + fileName
+
+	categoryItem, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, category)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	category, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
+	permissions, err := categoryPermissions(s.app.storage, categoryItem, categoryAccessWrite)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if !checkPermissions(category.Permissions, claims.Permissions) {
-		return fmt.Errorf("unauthorized to delete data content item: [%s]", strings.Join(category.Permissions, ", "))
+	if !checkPermissions(permissions, claims.Permissions) {
+		return nil, fmt.Errorf("unauthorized to upload file content item: [%s]", strings.Join(permissions, ", "))
 	}
 
-	err = s.app.storage.DeleteDataContentItem(&claims.AppID, claims.OrgID, key)
+	policy, err := effectiveFilePolicy(categoryItem, storageCategoryFinder(s.app.storage, &claims.AppID, claims.OrgID))
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return nil
-}
-
-func (s *servicesImpl) CreateCategory(claims *tokenauth.Claims, item *model.Category) (*model.Category, error) {
-	item.ID = uuid.NewString()
-	item.AppID = &claims.AppID
-	item.OrgID = claims.OrgID
-	item.DateCreated = time.Now().UTC()
-	item, err := s.app.storage.CreateCategory(item)
+	//the file is accepted only after it is checked
+	spool, err := spoolFile(file, policy, category)
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
-}
+	defer spool.close()
 
-func (s *servicesImpl) GetCategory(claims *tokenauth.Claims, name string) (*model.Category, error) {
-	item, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, name)
+	previous, err := s.findFileContentItem(claims, path)
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
-}
-
-func (s *servicesImpl) UpdateCategory(claims *tokenauth.Claims, item *model.Category) (*model.Category, error) {
-	item, err := s.app.storage.UpdateCategory(&claims.AppID, claims.OrgID, item)
+	err = checkStorageQuota(s.app.storage, claims.AppID, claims.OrgID, category, spool.inspector.size-fileSize(previous))
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
-}
 
-func (s *servicesImpl) DeleteCategory(claims *tokenauth.Claims, name string) error {
-	err := s.app.storage.DeleteCategory(&claims.AppID, claims.OrgID, name)
+	result, err := s.scanFile(spool)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
-
-func (s *servicesImpl) UploadFileContentItem(file io.Reader, claims *tokenauth.Claims, fileName string, category string) error {
-
-	path := claims.OrgID + "/" + claims.AppID + "/" + category + "/" + fileName
-
-	categoryItem, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, category)
-	if err != nil {
-		return err
+	if !result.Clean {
+		//the flagged file never reaches the path, the previous file stays
+		return nil, s.quarantineFile(claims, spool, fileName, path, category, "", result.Signature)
 	}
 
-	if !checkPermissions(categoryItem.Permissions, claims.Permissions) {
-		return fmt.Errorf("unauthorized to upload file content item: [%s]", strings.Join(categoryItem.Permissions, ", "))
+	_, err = s.app.objectStorage.Upload(model.ObjectBucketContent, path, spool.file, false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to upload the file: %s", err)
 	}
 
-	_, err = s.app.awsAdapter.UploadFile(file, path)
+	item, err := s.app.storage.SaveFileContentItem(spool.inspector.fileContentItem(claims, fileName, path, category, ""))
 	if err != nil {
-		return fmt.Errorf("unable to upload to S3: %s", err)
+		return nil, fmt.Errorf("unable to record the file: %s", err)
 	}
+	s.recordFileUsage(claims, previous, item)
 
-	return nil
+	s.auditCommitted(actor, model.AuditResourceFile, fileName, category, model.AuditOperationCreate, nil, map[string]interface{}{"path": path})
+	return item, nil
 }
 
 func (s *servicesImpl) GetFileContentItem(claims *tokenauth.Claims, fileName string, category string) (io.ReadCloser, error) {
+	err := checkCategoryRead(s.app.storage, claims, category)
+	if err != nil {
+		return nil, err
+	}
 
 	path := claims.OrgID + "/" + claims.AppID + "/" + category + "/" + fileName
 
-	fileData, err := s.app.awsAdapter.StreamDownloadFile(path)
+	fileData, err := s.app.objectStorage.Download(model.ObjectBucketContent, path)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get data for file download stream: %s", err.Error())
 	}
@@ -622,6 +1739,21 @@ func (s *servicesImpl) GetFileContentItem(claims *tokenauth.Claims, fileName str
 
 func (s *servicesImpl) GetFileContentUploadURLs(claims *tokenauth.Claims, fileNames []string, entityID string, category string,
 	addAppOrgIDToPath bool, handleDuplicateFileNames bool, publicRead bool) ([]model.FileContentItemRef, error) {
+	err := checkCategoryAccess(s.app.storage, claims, category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	//the sizes are known when the uploads are confirmed, the files which are still not confirmed count against the quotas
+	pendingSize, err := pendingUploadsSize(s.app.objectStorage, s.getFilePath(claims, "", category, "", addAppOrgIDToPath))
+	if err != nil {
+		return nil, err
+	}
+	err = checkStorageQuota(s.app.storage, claims.AppID, claims.OrgID, category, pendingSize)
+	if err != nil {
+		return nil, err
+	}
+
 	paths := make([]string, len(fileNames))
 	fileKeys := make([]string, len(fileNames))
 	for i, name := range fileNames {
@@ -634,48 +1766,90 @@ func (s *servicesImpl) GetFileContentUploadURLs(claims *tokenauth.Claims, fileNa
 		paths[i] = s.getFilePath(claims, fileKeys[i], category, entityID, addAppOrgIDToPath)
 	}
 
-	fileRefs, err := s.app.awsAdapter.GetPresignedURLsForUpload(fileKeys, paths, publicRead)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get file upload references: %s", err.Error())
+	fileRefs := make([]model.FileContentItemRef, len(paths))
+	for i, path := range paths {
+		//the file is uploaded to the pending uploads, it is moved to its path once it is confirmed and checked
+		url, err := s.app.objectStorage.PresignUpload(model.ObjectBucketContent, pendingUploadPath(path, publicRead), false)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get file upload references: %s", err.Error())
+		}
+		fileRefs[i] = model.FileContentItemRef{Key: fileKeys[i], URL: url}
 	}
-
 	return fileRefs, nil
 }
 
 func (s *servicesImpl) GetFileContentDownloadURLs(claims *tokenauth.Claims, fileKeys []string, entityID string, category string, addAppOrgIDToPath bool) ([]model.FileContentItemRef, error) {
+	err := checkCategoryRead(s.app.storage, claims, category)
+	if err != nil {
+		return nil, err
+	}
+
 	paths := make([]string, len(fileKeys))
 	for i, key := range fileKeys {
 		paths[i] = s.getFilePath(claims, key, category, entityID, addAppOrgIDToPath)
 	}
 
-	fileRefs, err := s.app.awsAdapter.GetPresignedURLsForDownload(fileKeys, paths)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get file download references: %s", err.Error())
+	fileRefs := make([]model.FileContentItemRef, len(paths))
+	for i, path := range paths {
+		url, err := s.app.objectStorage.PresignDownload(model.ObjectBucketContent, path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get file download references: %s", err.Error())
+		}
+		fileRefs[i] = model.FileContentItemRef{Key: fileKeys[i], URL: url}
 	}
-
 	return fileRefs, nil
 }
 
-func (s *servicesImpl) DeleteFileContentItem(claims *tokenauth.Claims, fileName string, category string) error {
+func (s *servicesImpl) DeleteFileContentItem(actor *model.AuditActor, claims *tokenauth.Claims, fileName string, category string) error {
 	categoryItem, err := s.app.storage.FindCategory(&claims.AppID, claims.OrgID, category)
 	if err != nil {
 		return err
 	}
 
-	if !checkPermissions(categoryItem.Permissions, claims.Permissions) {
-		return fmt.Errorf("unauthorized to delete file content item: [%s]", strings.Join(categoryItem.Permissions, ", "))
+	permissions, err := categoryPermissions(s.app.storage, categoryItem, categoryAccessDelete)
+	if err != nil {
+		return err
+	}
+	if !checkPermissions(permissions, claims.Permissions) {
+		return fmt.Errorf("unauthorized to delete file content item: [%s]", strings.Join(permissions, ", "))
 	}
 
 	path := claims.OrgID + "/" + claims.AppID + "/" + category + "/" + fileName
 
-	err = s.app.awsAdapter.DeleteFile(path)
+	previous, err := s.findFileContentItem(claims, path)
 	if err != nil {
 		return err
 	}
+	err = s.app.objectStorage.Delete(model.ObjectBucketContent, path)
+	if err != nil {
+		return err
+	}
+	err = s.app.storage.DeleteFileContentItem(claims.AppID, claims.OrgID, path)
+	if err != nil {
+		return err
+	}
+	s.recordFileUsage(claims, previous, nil)
 
+	s.auditCommitted(actor, model.AuditResourceFile, fileName, category, model.AuditOperationDelete, map[string]interface{}{"path": path}, nil)
 	return nil
 }
 
+// countCategoryFiles counts the stored files of a category, with the ones waiting in the pending uploads
+func (s *servicesImpl) countCategoryFiles(claims *tokenauth.Claims, category string) (int64, error) {
+	path := s.getFilePath(claims, "", category, "", true)
+	prefixes := []string{path, pendingUploadPath(path, false), pendingUploadPath(path, true)}
+
+	var count int64
+	for _, prefix := range prefixes {
+		objects, err := s.app.objectStorage.List(model.ObjectBucketContent, prefix)
+		if err != nil {
+			return 0, err
+		}
+		count += int64(len(objects))
+	}
+	return count, nil
+}
+
 func (s *servicesImpl) getFilePath(claims *tokenauth.Claims, key string, category string, entityID string, addAppOrgIDToPath bool) string {
 	path := ""
 	if addAppOrgIDToPath {
@@ -689,6 +1863,190 @@ func (s *servicesImpl) getFilePath(claims *tokenauth.Claims, key string, categor
 	return path
 }
 
+func (s *servicesImpl) GetWebhooks(allApps bool, appID string, orgID string) ([]model.Webhook, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	webhooks, err := s.app.storage.FindWebhooks(appIDParam, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *servicesImpl) CreateWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, item model.Webhook) (*model.Webhook, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	if len(item.Secret) == 0 {
+		secret, err := rokwireutils.GenerateRandomString(32)
+		if err != nil {
+			return nil, err
+		}
+		item.Secret = secret
+	}
+	item.ID = uuid.NewString()
+	item.AppID = appIDParam
+	item.OrgID = orgID
+	item.DateCreated = time.Now().UTC()
+	item.DateUpdated = nil
+
+	err := s.app.storage.CreateWebhook(item)
+	if err != nil {
+		return nil, err
+	}
+
+	//the secret does not go to the audit log
+	audited := item
+	audited.Secret = ""
+	s.auditCommitted(actor, model.AuditResourceWebhook, item.ID, "", model.AuditOperationCreate, nil, audited)
+
+	//the secret is given back only now
+	return &item, nil
+}
+
+func (s *servicesImpl) GetWebhook(allApps bool, appID string, orgID string, id string) (*model.Webhook, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	webhook, err := s.app.storage.FindWebhook(appIDParam, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (s *servicesImpl) UpdateWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, id string, item model.Webhook) (*model.Webhook, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	webhook, err := s.app.storage.FindWebhook(appIDParam, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *webhook
+	before.Secret = ""
+
+	webhook.URL = item.URL
+	webhook.Categories = item.Categories
+	webhook.Events = item.Events
+	webhook.Active = item.Active
+	if len(item.Secret) > 0 {
+		//rotate the secret
+		webhook.Secret = item.Secret
+	}
+	now := time.Now().UTC()
+	webhook.DateUpdated = &now
+
+	err = s.app.storage.UpdateWebhook(*webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	s.auditCommitted(actor, model.AuditResourceWebhook, id, "", model.AuditOperationUpdate, before, *webhook)
+	return webhook, nil
+}
+
+func (s *servicesImpl) DeleteWebhook(actor *model.AuditActor, allApps bool, appID string, orgID string, id string) error {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	before, err := s.app.storage.FindWebhook(appIDParam, orgID, id)
+	if err != nil {
+		return err
+	}
+	before.Secret = ""
+
+	err = s.app.storage.DeleteWebhook(appIDParam, orgID, id)
+	if err != nil {
+		return err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceWebhook, id, "", model.AuditOperationDelete, *before, nil)
+	return nil
+}
+
+func (s *servicesImpl) GetWebhookDeliveries(allApps bool, appID string, orgID string, webhookID string, status *string, offset *int64, limit *int64) ([]model.WebhookDelivery, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	return s.app.storage.FindWebhookDeliveries(appIDParam, orgID, webhookID, status, offset, limit)
+}
+
+func (s *servicesImpl) RedeliverWebhookDelivery(allApps bool, appID string, orgID string, webhookID string, id string) (*model.WebhookDelivery, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	delivery, err := s.app.storage.FindWebhookDelivery(appIDParam, orgID, webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	//the same payload goes as a new delivery, the log of the original one stays as it is
+	now := time.Now().UTC()
+	redelivery := model.WebhookDelivery{ID: uuid.NewString(), WebhookID: delivery.WebhookID, AppID: delivery.AppID, OrgID: delivery.OrgID,
+		EventID: delivery.EventID, EventType: delivery.EventType, Payload: delivery.Payload, Status: model.WebhookDeliveryPending,
+		Attempts: []model.WebhookDeliveryAttempt{}, NextAttemptAt: &now, RedeliveryOf: delivery.ID, DateCreated: now}
+	err = s.app.storage.CreateWebhookDelivery(redelivery)
+	if err != nil {
+		return nil, err
+	}
+
+	s.app.webhooksLogic.wake()
+	return &redelivery, nil
+}
+
+func (s *servicesImpl) GetAuditLog(allApps bool, appID string, orgID string, accountID *string, resource *string, resourceID *string, category *string,
+	from *time.Time, to *time.Time, offset *int64, limit *int64) ([]model.AuditLogEntry, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	return s.app.storage.FindAuditLogEntries(appIDParam, orgID, accountID, resource, resourceID, category, from, to, offset, limit)
+}
+
+// checkIfMatch checks the entity tags the client sent in If-Match against the current revision of an item, nil means there is no precondition
+func checkIfMatch(ifMatch []string, revision model.ItemRevision) error {
+	if ifMatch == nil {
+		return nil
+	}
+	etag := model.EntityTag("", revision)
+	for _, tag := range ifMatch {
+		if tag == "*" || tag == etag {
+			return nil
+		}
+	}
+	return &model.PreconditionFailedError{ETag: etag}
+}
+
 func checkPermissions(itemPermissions []string, claimsPermissions string) bool {
 	permissions := strings.Split(claimsPermissions, ",")
 	for _, element := range itemPermissions {
@@ -700,6 +2058,77 @@ func checkPermissions(itemPermissions []string, claimsPermissions string) bool {
 	return false
 }
 
+// jsonValue converts a value to its plain json representation - maps, slices, strings, float64, bool and nil
+func jsonValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// diffData lists the changes between two json values, addressed by json pointers relative to path
+func diffData(path string, from interface{}, to interface{}) []model.ContentItemChange {
+	changes := []model.ContentItemChange{}
+
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		keys := make([]string, 0, len(fromMap)+len(toMap))
+		for key := range fromMap {
+			keys = append(keys, key)
+		}
+		for key := range toMap {
+			if _, ok := fromMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := path + "/" + jsonPointerEscaper.Replace(key)
+			fromValue, inFrom := fromMap[key]
+			toValue, inTo := toMap[key]
+			if !inTo {
+				changes = append(changes, model.ContentItemChange{Path: keyPath, Op: "removed", From: fromValue})
+			} else if !inFrom {
+				changes = append(changes, model.ContentItemChange{Path: keyPath, Op: "added", To: toValue})
+			} else {
+				changes = append(changes, diffData(keyPath, fromValue, toValue)...)
+			}
+		}
+		return changes
+	}
+
+	fromList, fromIsList := from.([]interface{})
+	toList, toIsList := to.([]interface{})
+	if fromIsList && toIsList {
+		for i := 0; i < len(fromList) || i < len(toList); i++ {
+			indexPath := path + "/" + strconv.Itoa(i)
+			if i >= len(toList) {
+				changes = append(changes, model.ContentItemChange{Path: indexPath, Op: "removed", From: fromList[i]})
+			} else if i >= len(fromList) {
+				changes = append(changes, model.ContentItemChange{Path: indexPath, Op: "added", To: toList[i]})
+			} else {
+				changes = append(changes, diffData(indexPath, fromList[i], toList[i])...)
+			}
+		}
+		return changes
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, model.ContentItemChange{Path: path, Op: "changed", From: from, To: to})
+	}
+	return changes
+}
+
 type servicesImpl struct {
 	app *Application
 }
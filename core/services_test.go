@@ -0,0 +1,465 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+func TestDiffData(t *testing.T) {
+	tests := []struct {
+		name string
+		from interface{}
+		to   interface{}
+		want []model.ContentItemChange
+	}{
+		{
+			name: "equal",
+			from: map[string]interface{}{"title": "a", "tags": []interface{}{"x"}},
+			to:   map[string]interface{}{"title": "a", "tags": []interface{}{"x"}},
+			want: []model.ContentItemChange{},
+		},
+		{
+			name: "changed value",
+			from: map[string]interface{}{"title": "a"},
+			to:   map[string]interface{}{"title": "b"},
+			want: []model.ContentItemChange{{Path: "/title", Op: "changed", From: "a", To: "b"}},
+		},
+		{
+			name: "added and removed keys in order",
+			from: map[string]interface{}{"b": 1.0, "c": 2.0},
+			to:   map[string]interface{}{"a": 3.0, "c": 2.0},
+			want: []model.ContentItemChange{
+				{Path: "/a", Op: "added", To: 3.0},
+				{Path: "/b", Op: "removed", From: 1.0},
+			},
+		},
+		{
+			name: "nested object",
+			from: map[string]interface{}{"author": map[string]interface{}{"name": "a"}},
+			to:   map[string]interface{}{"author": map[string]interface{}{"name": "b"}},
+			want: []model.ContentItemChange{{Path: "/author/name", Op: "changed", From: "a", To: "b"}},
+		},
+		{
+			name: "list items",
+			from: map[string]interface{}{"tags": []interface{}{"x", "y"}},
+			to:   map[string]interface{}{"tags": []interface{}{"z", "y", "w"}},
+			want: []model.ContentItemChange{
+				{Path: "/tags/0", Op: "changed", From: "x", To: "z"},
+				{Path: "/tags/2", Op: "added", To: "w"},
+			},
+		},
+		{
+			name: "shorter list",
+			from: []interface{}{"x", "y"},
+			to:   []interface{}{"x"},
+			want: []model.ContentItemChange{{Path: "/1", Op: "removed", From: "y"}},
+		},
+		{
+			name: "escaped keys",
+			from: map[string]interface{}{"a/b": 1.0, "c~d": 1.0},
+			to:   map[string]interface{}{"a/b": 2.0, "c~d": 2.0},
+			want: []model.ContentItemChange{
+				{Path: "/a~1b", Op: "changed", From: 1.0, To: 2.0},
+				{Path: "/c~0d", Op: "changed", From: 1.0, To: 2.0},
+			},
+		},
+		{
+			name: "type change",
+			from: map[string]interface{}{"value": "1"},
+			to:   map[string]interface{}{"value": []interface{}{"1"}},
+			want: []model.ContentItemChange{{Path: "/value", Op: "changed", From: "1", To: []interface{}{"1"}}},
+		},
+		{
+			name: "primitive root",
+			from: "a",
+			to:   "b",
+			want: []model.ContentItemChange{{Path: "", Op: "changed", From: "a", To: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffData("", tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	revision := model.NewItemRevision("a", created, nil)
+	etag := model.EntityTag("", revision)
+	updated := created.Add(time.Minute)
+	staleETag := model.EntityTag("", model.NewItemRevision("a", created, &updated))
+
+	tests := []struct {
+		name    string
+		ifMatch []string
+		wantErr bool
+	}{
+		{name: "no precondition", ifMatch: nil},
+		{name: "current", ifMatch: []string{etag}},
+		{name: "any", ifMatch: []string{"*"}},
+		{name: "one of them current", ifMatch: []string{staleETag, etag}},
+		{name: "stale", ifMatch: []string{staleETag}, wantErr: true},
+		{name: "weak", ifMatch: []string{"W/" + etag}, wantErr: true},
+		{name: "empty", ifMatch: []string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkIfMatch(tt.ifMatch, revision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var preconditionErr *model.PreconditionFailedError
+			if tt.wantErr && (!errors.As(err, &preconditionErr) || preconditionErr.ETag != etag) {
+				t.Errorf("checkIfMatch() error = %v, want the current entity tag %s", err, etag)
+			}
+		})
+	}
+}
+
+func TestCategoryEndpointsPublishDirectly(t *testing.T) {
+	storage := &memoryStorage{}
+	services := testServices(storage)
+	actor := &model.AuditActor{AccountID: "admin", AppID: "app", OrgID: "org"}
+
+	//the category endpoints create the items published
+	created, err := services.CreateContentItem(actor, false, "app", "org", "campus_reminders", map[string]interface{}{"title": "a"}, "", nil, nil, nil,
+		model.ContentItemWorkflowPublished)
+	if err != nil {
+		t.Fatalf("CreateContentItem() error = %v", err)
+	}
+	if !served(*created) {
+		t.Errorf("CreateContentItem() = %s item, want it served", created.WorkflowState)
+	}
+
+	//and keep them published on the updates
+	updated, err := services.UpdateContentItemData(actor, false, "app", "org", created.ID, "campus_reminders", map[string]interface{}{"title": "b"}, model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil)
+	if err != nil {
+		t.Fatalf("UpdateContentItemData() error = %v", err)
+	}
+	if !served(*updated) {
+		t.Errorf("UpdateContentItemData() = %s item, want it served", updated.WorkflowState)
+	}
+	stored, _ := storage.FindContentItems(nil, "org", []string{created.ID}, nil, nil, nil, nil, nil)
+	if len(stored) != 1 || !served(stored[0]) || !reflect.DeepEqual(stored[0].Data, map[string]interface{}{"title": "b"}) {
+		t.Errorf("stored item = %+v, want the served update", stored)
+	}
+
+	//while the edits through the content items endpoints go back to draft
+	edited, err := services.UpdateContentItem(actor, false, "app", "org", created.ID, "campus_reminders", map[string]interface{}{"title": "c"}, model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("UpdateContentItem() error = %v", err)
+	}
+	if edited.WorkflowState != model.ContentItemWorkflowDraft {
+		t.Errorf("UpdateContentItem() = %s item, want %s", edited.WorkflowState, model.ContentItemWorkflowDraft)
+	}
+}
+
+func TestCreateContentItemDraft(t *testing.T) {
+	services := testServices(&memoryStorage{})
+
+	created, err := services.CreateContentItem(nil, false, "app", "org", "events", map[string]interface{}{"title": "a"}, "", nil, nil, nil,
+		model.ContentItemWorkflowDraft)
+	if err != nil {
+		t.Fatalf("CreateContentItem() error = %v", err)
+	}
+	if served(*created) {
+		t.Errorf("CreateContentItem() = %s item, want it not served before the approval", created.WorkflowState)
+	}
+}
+
+func TestContentItemNotFound(t *testing.T) {
+	appID := "app"
+	otherAppID := "other"
+	items := []model.ContentItem{
+		{ID: "item", Category: "events", Data: "a", AppID: &appID, OrgID: "org", WorkflowState: model.ContentItemWorkflowPublished},
+		{ID: "other-app", Category: "events", Data: "a", AppID: &otherAppID, OrgID: "org", WorkflowState: model.ContentItemWorkflowPublished},
+	}
+	mergePatch := model.DataPatch{Format: model.PatchFormatMerge, Document: []byte(`"b"`)}
+
+	tests := []struct {
+		name    string
+		call    func(services *servicesImpl) error
+		wantErr error
+	}{
+		{name: "update", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItem(nil, false, "app", "org", "missing", "events", "b", model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+			return err
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "update of another app", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItem(nil, false, "app", "org", "other-app", "events", "b", model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil, nil)
+			return err
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "patch", call: func(services *servicesImpl) error {
+			_, err := services.PatchContentItem(nil, false, "app", "org", "missing", mergePatch, nil)
+			return err
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "update by category", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItemData(nil, false, "app", "org", "missing", "events", "b", model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil)
+			return err
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "update in another category", call: func(services *servicesImpl) error {
+			_, err := services.UpdateContentItemData(nil, false, "app", "org", "item", "wellness_tips", "b", model.Nullable[string]{}, model.Nullable[map[string]interface{}]{}, nil, nil)
+			return err
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "delete by category", call: func(services *servicesImpl) error {
+			return services.DeleteContentItemByCategory(nil, false, "app", "org", "missing", "events")
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "delete in another category", call: func(services *servicesImpl) error {
+			return services.DeleteContentItemByCategory(nil, false, "app", "org", "item", "wellness_tips")
+		}, wantErr: model.ErrContentItemNotFound},
+		{name: "restore of a missing version", call: func(services *servicesImpl) error {
+			_, err := services.RestoreContentItemVersion(nil, false, "app", "org", "item", 1)
+			return err
+		}, wantErr: model.ErrContentItemVersionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{contentItems: slices.Clone(items)}
+
+			err := tt.call(testServices(storage))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(storage.contentItems, items) {
+				t.Errorf("stored items = %+v, want them unchanged", storage.contentItems)
+			}
+		})
+	}
+}
+
+func TestUpdateLocales(t *testing.T) {
+	appID := "app"
+	stored := map[string]interface{}{"es": map[string]interface{}{"title": "hola"}}
+	provided := map[string]interface{}{"fr": map[string]interface{}{"title": "salut"}}
+	claims := &tokenauth.Claims{AppID: appID, OrgID: "org", Permissions: "write"}
+	category := model.Category{Name: "events", AppID: &appID, OrgID: "org", Permissions: []string{"write"}}
+
+	type localesFunc func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error)
+	paths := map[string]localesFunc{
+		"update": func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error) {
+			item, err := services.UpdateContentItem(nil, false, appID, "org", "item", "events", "b", defaultLocale, locales, nil, nil, nil)
+			if err != nil {
+				return "", nil, err
+			}
+			return item.DefaultLocale, item.Locales, nil
+		},
+		"category update": func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error) {
+			item, err := services.UpdateContentItemData(nil, false, appID, "org", "item", "events", "b", defaultLocale, locales, nil, nil)
+			if err != nil {
+				return "", nil, err
+			}
+			return item.DefaultLocale, item.Locales, nil
+		},
+		"batch update": func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error) {
+			result, err := services.ApplyContentItemsBatch(nil, false, appID, "org", []model.ContentItemBatchOperation{
+				{Op: model.BatchOperationUpdate, ID: "item", Category: "events", Data: "b", DefaultLocale: defaultLocale, Locales: locales}})
+			if err != nil || !result.Applied {
+				return "", nil, fmt.Errorf("batch error = %v, result %+v", err, result)
+			}
+			item := result.Results[0].Item.(model.ContentItem)
+			return item.DefaultLocale, item.Locales, nil
+		},
+		"data update": func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error) {
+			item, err := services.UpdateDataContentItem(nil, claims, &model.DataContentItem{Key: "item", Category: "events", Data: "b"}, defaultLocale, locales, nil)
+			if err != nil {
+				return "", nil, err
+			}
+			return item.DefaultLocale, item.Locales, nil
+		},
+		"data batch update": func(services *servicesImpl, defaultLocale model.Nullable[string], locales model.Nullable[map[string]interface{}]) (string, map[string]interface{}, error) {
+			result, err := services.ApplyDataContentItemsBatch(nil, claims, []model.DataContentItemBatchOperation{
+				{Op: model.BatchOperationUpdate, Key: "item", Category: "events", Data: "b", DefaultLocale: defaultLocale, Locales: locales}})
+			if err != nil || !result.Applied {
+				return "", nil, fmt.Errorf("batch error = %v, result %+v", err, result)
+			}
+			item := result.Results[0].Item.(model.DataContentItem)
+			return item.DefaultLocale, item.Locales, nil
+		},
+	}
+
+	tests := []struct {
+		name              string
+		defaultLocale     model.Nullable[string]
+		locales           model.Nullable[map[string]interface{}]
+		wantDefaultLocale string
+		wantLocales       map[string]interface{}
+	}{
+		{name: "left out", wantDefaultLocale: "en", wantLocales: stored},
+		{name: "null", defaultLocale: model.NewNullable(""), locales: model.NewNullable[map[string]interface{}](nil)},
+		{name: "provided", defaultLocale: model.NewNullable("de"), locales: model.NewNullable(provided), wantDefaultLocale: "de", wantLocales: provided},
+		{name: "only the default locale", defaultLocale: model.NewNullable("de"), wantDefaultLocale: "de", wantLocales: stored},
+	}
+
+	for path, update := range paths {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				storage := &memoryStorage{
+					contentItems: []model.ContentItem{{ID: "item", Category: "events", Data: "a", DefaultLocale: "en", Locales: stored, AppID: &appID, OrgID: "org",
+						DateCreated: time.Now().UTC(), WorkflowState: model.ContentItemWorkflowPublished}},
+					dataContentItems: []model.DataContentItem{{ID: "item", Key: "item", Category: "events", Data: "a", DefaultLocale: "en", Locales: stored,
+						AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC()}},
+					categories: []model.Category{category},
+				}
+
+				defaultLocale, locales, err := update(testServices(storage), tt.defaultLocale, tt.locales)
+				if err != nil {
+					t.Fatalf("update error = %v", err)
+				}
+				//the batches give the plain items, which have no nil maps
+				sameLocales := reflect.DeepEqual(locales, tt.wantLocales) || (len(locales) == 0 && len(tt.wantLocales) == 0)
+				if defaultLocale != tt.wantDefaultLocale || !sameLocales {
+					t.Errorf("update = %q %v, want %q %v", defaultLocale, locales, tt.wantDefaultLocale, tt.wantLocales)
+				}
+			})
+		}
+	}
+}
+
+func TestTransitionContentItemWorkflow(t *testing.T) {
+	appID := "app"
+	actor := &model.AuditActor{AccountID: "admin", AppID: appID, OrgID: "org"}
+
+	tests := []struct {
+		name       string
+		state      string
+		transition string
+		want       string
+		wantErr    bool
+	}{
+		{name: "submit a draft", state: model.ContentItemWorkflowDraft, transition: model.ContentItemTransitionSubmit, want: model.ContentItemWorkflowInReview},
+		{name: "approve a reviewed item", state: model.ContentItemWorkflowInReview, transition: model.ContentItemTransitionApprove, want: model.ContentItemWorkflowPublished},
+		{name: "reject a reviewed item", state: model.ContentItemWorkflowInReview, transition: model.ContentItemTransitionReject, want: model.ContentItemWorkflowDraft},
+		{name: "archive a published item", state: model.ContentItemWorkflowPublished, transition: model.ContentItemTransitionArchive, want: model.ContentItemWorkflowArchived},
+		{name: "archive an item created before the workflow", transition: model.ContentItemTransitionArchive, want: model.ContentItemWorkflowArchived},
+		{name: "restore an archived item", state: model.ContentItemWorkflowArchived, transition: model.ContentItemTransitionRestore, want: model.ContentItemWorkflowDraft},
+		{name: "approve a draft", state: model.ContentItemWorkflowDraft, transition: model.ContentItemTransitionApprove, wantErr: true},
+		{name: "submit a published item", state: model.ContentItemWorkflowPublished, transition: model.ContentItemTransitionSubmit, wantErr: true},
+		{name: "reject a draft", state: model.ContentItemWorkflowDraft, transition: model.ContentItemTransitionReject, wantErr: true},
+		{name: "archive an archived item", state: model.ContentItemWorkflowArchived, transition: model.ContentItemTransitionArchive, wantErr: true},
+		{name: "restore a published item", state: model.ContentItemWorkflowPublished, transition: model.ContentItemTransitionRestore, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []model.ContentItem{{ID: "item", Category: "events", Data: "a", AppID: &appID, OrgID: "org", WorkflowState: tt.state}}
+			storage := &memoryStorage{contentItems: slices.Clone(items)}
+
+			item, err := testServices(storage).TransitionContentItemWorkflow(actor, false, appID, "org", "item", tt.transition)
+			if tt.wantErr {
+				var transitionErr *model.WorkflowTransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("TransitionContentItemWorkflow() error = %v, want a workflow transition error", err)
+				}
+				if !reflect.DeepEqual(storage.contentItems, items) || len(storage.auditLog) != 0 {
+					t.Errorf("stored items = %+v, audit log = %+v, want them unchanged", storage.contentItems, storage.auditLog)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TransitionContentItemWorkflow() error = %v", err)
+			}
+			if item.WorkflowState != tt.want || storage.contentItems[0].WorkflowState != tt.want {
+				t.Errorf("TransitionContentItemWorkflow() = %s item, stored %s, want %s", item.WorkflowState, storage.contentItems[0].WorkflowState, tt.want)
+			}
+			if len(storage.auditLog) != 1 || storage.auditLog[0].Operation != model.AuditOperationUpdate {
+				t.Errorf("audit log = %+v, want one update entry", storage.auditLog)
+			}
+		})
+	}
+
+	t.Run("unsupported transition", func(t *testing.T) {
+		storage := &memoryStorage{contentItems: []model.ContentItem{{ID: "item", AppID: &appID, OrgID: "org", WorkflowState: model.ContentItemWorkflowDraft}}}
+		_, err := testServices(storage).TransitionContentItemWorkflow(actor, false, appID, "org", "item", "publish")
+		var transitionErr *model.WorkflowTransitionError
+		if err == nil || errors.As(err, &transitionErr) {
+			t.Errorf("TransitionContentItemWorkflow() error = %v, want an unsupported transition error", err)
+		}
+	})
+}
+
+func TestSearchContentItems(t *testing.T) {
+	appID := "app"
+	storage := &memoryStorage{
+		categories: []model.Category{
+			{Name: "public", AppID: &appID, OrgID: "org"},
+			{Name: "staff", AppID: &appID, OrgID: "org", ReadPermissions: []string{"staff"}},
+			{Name: "staff_news", AppID: &appID, OrgID: "org", Parent: "staff"},
+			{Name: "members", AppID: &appID, OrgID: "org", RequiresAuth: true},
+		},
+		contentItems: []model.ContentItem{
+			{ID: "event", Category: "events", Data: map[string]interface{}{"title": "quad day quad"}, AppID: &appID, OrgID: "org"},
+			{ID: "translated event", Category: "events", Data: map[string]interface{}{"title": "fiesta"}, AppID: &appID, OrgID: "org",
+				Locales: map[string]interface{}{"en": map[string]interface{}{"title": "quad party"}}},
+		},
+		dataContentItems: []model.DataContentItem{
+			{ID: "public", Key: "public", Category: "public", Data: "quad quad quad", AppID: &appID, OrgID: "org"},
+			{ID: "staff", Key: "staff", Category: "staff", Data: "quad", AppID: &appID, OrgID: "org"},
+			{ID: "staff news", Key: "staff news", Category: "staff_news", Data: "quad", AppID: &appID, OrgID: "org"},
+			{ID: "members", Key: "members", Category: "members", Data: "quad", AppID: &appID, OrgID: "org"},
+			{ID: "other app", Key: "other app", Category: "public", Data: "quad quad quad quad", OrgID: "org"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		claims tokenauth.Claims
+		offset int64
+		limit  int64
+		want   []string
+	}{
+		{name: "staff", claims: tokenauth.Claims{Permissions: "staff"}, limit: 10,
+			want: []string{"public", "event", "translated event", "staff", "staff news", "members"}},
+		{name: "student", claims: tokenauth.Claims{Permissions: "student"}, limit: 10, want: []string{"public", "event", "translated event", "members"}},
+		{name: "anonymous user", claims: tokenauth.Claims{Anonymous: true}, limit: 10, want: []string{"public", "event", "translated event"}},
+		{name: "page", claims: tokenauth.Claims{Permissions: "staff"}, offset: 1, limit: 2, want: []string{"event", "translated event"}},
+		{name: "page of unreadable items", claims: tokenauth.Claims{Anonymous: true}, offset: 2, limit: 2, want: []string{"translated event"}},
+		{name: "page past the matches", claims: tokenauth.Claims{Permissions: "staff"}, offset: 10, limit: 2, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tt.claims
+			claims.AppID, claims.OrgID = appID, "org"
+
+			results, err := testServices(storage).SearchContentItems(&claims, false, appID, "org", "quad", nil, nil, nil, tt.offset, tt.limit)
+			if err != nil {
+				t.Fatalf("SearchContentItems() error = %v", err)
+			}
+			got := []string{}
+			for _, result := range results {
+				got = append(got, result.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchContentItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// performImport runs an import - a dry run reads the current content only, otherwise everything is stored in a single transaction.
+// The webhook events of the stored items are sent once the transaction is committed.
+func (s *servicesImpl) performImport(dryRun bool, importItems func(storage interfaces.Storage) (*model.ImportReport, []model.WebhookEvent, error)) (*model.ImportReport, error) {
+	if dryRun {
+		report, _, err := importItems(s.app.storage)
+		return report, err
+	}
+
+	var report *model.ImportReport
+	var events []model.WebhookEvent
+	transaction := func(storage interfaces.Storage) error {
+		var err error
+		report, events, err = importItems(storage)
+		return err
+	}
+	err := s.app.storage.PerformTransaction(transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		s.app.webhooksLogic.notify(event)
+	}
+	return report, nil
+}
+
+// importContentItems upserts the content items by id, nothing is stored when any of them is invalid.
+// Only the approvers import published items, a replaced item without a workflow state goes back to draft like on the other updates.
+func (s *servicesImpl) importContentItems(storage interfaces.Storage, actor *model.AuditActor, appIDParam *string, appID string, orgID string,
+	items []model.ContentItem, dryRun bool) (*model.ImportReport, []model.WebhookEvent, error) {
+	report := model.NewImportReport(dryRun)
+
+	ids := []string{}
+	idSet := map[string]bool{}
+	for _, item := range items {
+		if len(item.ID) > 0 && !idSet[item.ID] {
+			idSet[item.ID] = true
+			ids = append(ids, item.ID)
+		}
+	}
+
+	existing := map[string]model.ContentItem{}
+	if len(ids) > 0 {
+		currentItems, err := storage.FindContentItems(appIDParam, orgID, ids, nil, nil, nil, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, current := range currentItems {
+			existing[current.ID] = current
+		}
+	}
+
+	seen := map[string]bool{}
+	for i, item := range items {
+		if len(item.ID) > 0 && seen[item.ID] {
+			report.AddError(i+1, item.ID, "duplicate id")
+			continue
+		}
+		seen[item.ID] = true
+
+		if len(item.Category) == 0 {
+			report.AddError(i+1, item.ID, "category is required")
+			continue
+		}
+		if len(item.WorkflowState) > 0 && !slices.Contains(model.ContentItemWorkflowStates, item.WorkflowState) {
+			report.AddError(i+1, item.ID, fmt.Sprintf("invalid workflow state %s", item.WorkflowState))
+			continue
+		}
+		if item.WorkflowState == model.ContentItemWorkflowPublished && !canApproveContentItems(actor) {
+			report.AddError(i+1, item.ID, "only the approvers can import published items")
+			continue
+		}
+		err := s.validateContentItemData(appID, orgID, item.Category, item.Data, item.Locales)
+		if err != nil {
+			report.AddError(i+1, item.ID, err.Error())
+			continue
+		}
+		if _, ok := existing[item.ID]; ok {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil, nil
+	}
+
+	events := make([]model.WebhookEvent, 0, len(items))
+	for i, item := range items {
+		now := time.Now().UTC()
+		item.AppID = appIDParam
+		item.OrgID = orgID
+
+		current, ok := existing[item.ID]
+		if ok {
+			//keep the current revision
+			err := s.storeContentItemVersion(storage, current)
+			if err != nil {
+				return nil, nil, fmt.Errorf("record %d: %s", i+1, err)
+			}
+
+			item.DateCreated = current.DateCreated
+			item.DateUpdated = &now
+			//an item replaced without a workflow state is edited like on the other updates
+			if len(item.WorkflowState) == 0 {
+				item.WorkflowState = editedWorkflowState(contentItemWorkflowState(current))
+			}
+			err = storage.SaveContentItem(item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("record %d: %s", i+1, err)
+			}
+
+			err = s.audit(storage, actor, model.AuditResourceContentItem, item.ID, item.Category, model.AuditOperationUpdate,
+				plainContentItem(current), plainContentItem(item))
+			if err != nil {
+				return nil, nil, err
+			}
+			events = append(events, contentItemEvent(model.WebhookEventContentItemUpdated, item))
+			continue
+		}
+
+		if len(item.ID) == 0 {
+			item.ID = uuid.NewString()
+		}
+		if item.DateCreated.IsZero() {
+			item.DateCreated = now
+		}
+		if len(item.WorkflowState) == 0 {
+			item.WorkflowState = model.ContentItemWorkflowDraft
+		}
+		created, err := storage.CreateContentItem(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("record %d: %s", i+1, err)
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceContentItem, item.ID, item.Category, model.AuditOperationCreate, nil, plainContentItem(*created))
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, contentItemEvent(model.WebhookEventContentItemCreated, *created))
+	}
+
+	report.Imported = true
+	return report, events, nil
+}
+
+// importDataContentItems upserts the data content items by id, an item without id updates the item with its key if there is one.
+// Nothing is stored when any of them is invalid.
+func (s *servicesImpl) importDataContentItems(storage interfaces.Storage, actor *model.AuditActor, claims *tokenauth.Claims,
+	items []model.DataContentItem, dryRun bool) (*model.ImportReport, []model.WebhookEvent, error) {
+	report := model.NewImportReport(dryRun)
+
+	categories := map[string]*model.Category{}
+	existing := make([]*model.DataContentItem, len(items))
+	seenIDs := map[string]bool{}
+	seenKeys := map[string]bool{}
+	for i, item := range items {
+		record := i + 1
+		if len(item.Key) == 0 {
+			report.AddError(record, item.ID, "key is required")
+			continue
+		}
+		if seenKeys[item.Key] || (len(item.ID) > 0 && seenIDs[item.ID]) {
+			report.AddError(record, item.ID, fmt.Sprintf("duplicate key %s or id", item.Key))
+			continue
+		}
+		seenKeys[item.Key] = true
+		seenIDs[item.ID] = true
+
+		category, ok := categories[item.Category]
+		if !ok {
+			var err error
+			category, err = storage.FindCategory(&claims.AppID, claims.OrgID, item.Category)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, nil, err
+			}
+			categories[item.Category] = category
+		}
+		if category == nil {
+			report.AddError(record, item.ID, fmt.Sprintf("category %s does not exist", item.Category))
+			continue
+		}
+		permissions, err := categoryPermissions(storage, category, categoryAccessWrite)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !checkPermissions(permissions, claims.Permissions) {
+			report.AddError(record, item.ID, fmt.Sprintf("unauthorized to import data content item: [%s]", strings.Join(permissions, ", ")))
+			continue
+		}
+		err = validateCategoryData(category, item.Data, item.Locales)
+		if err != nil {
+			report.AddError(record, item.ID, err.Error())
+			continue
+		}
+
+		//the key must not be taken by another item
+		byKey, err := storage.FindDataContentItem(&claims.AppID, claims.OrgID, item.Key)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, err
+		}
+		if byKey != nil && len(item.ID) > 0 && byKey.ID != item.ID {
+			report.AddError(record, item.ID, fmt.Sprintf("key %s is used by data content item %s", item.Key, byKey.ID))
+			continue
+		}
+		if byKey != nil {
+			existing[i] = byKey
+		} else if len(item.ID) > 0 {
+			byID, err := storage.FindDataContentItemByID(&claims.AppID, claims.OrgID, item.ID)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, nil, err
+			}
+			existing[i] = byID
+		}
+
+		if existing[i] != nil {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil, nil
+	}
+
+	events := make([]model.WebhookEvent, 0, len(items))
+	for i, item := range items {
+		now := time.Now().UTC()
+		item.AppID = &claims.AppID
+		item.OrgID = claims.OrgID
+
+		current := existing[i]
+		operation := model.AuditOperationCreate
+		eventType := model.WebhookEventDataContentItemCreated
+		var before interface{}
+		if current != nil {
+			item.ID = current.ID
+			item.DateCreated = current.DateCreated
+			item.DateUpdated = &now
+			operation = model.AuditOperationUpdate
+			eventType = model.WebhookEventDataContentItemUpdated
+			before = plainDataContentItem(*current)
+		} else {
+			if len(item.ID) == 0 {
+				item.ID = uuid.NewString()
+			}
+			if item.DateCreated.IsZero() {
+				item.DateCreated = now
+			}
+		}
+
+		err := storage.SaveDataContentItem(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("record %d: %s", i+1, err)
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceDataContentItem, item.Key, item.Category, operation, before, plainDataContentItem(item))
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, dataContentItemEvent(eventType, item))
+	}
+
+	report.Imported = true
+	return report, events, nil
+}
+
+// importCategories upserts the categories by id, a category without id updates the category with its name if there is one.
+// Nothing is stored when any of them is invalid.
+func (s *servicesImpl) importCategories(storage interfaces.Storage, actor *model.AuditActor, claims *tokenauth.Claims,
+	items []model.Category, dryRun bool) (*model.ImportReport, error) {
+	report := model.NewImportReport(dryRun)
+
+	//the parents may come with the import
+	importedNames := map[string]bool{}
+	for _, item := range items {
+		importedNames[item.Name] = true
+	}
+
+	existing := make([]*model.Category, len(items))
+	seenIDs := map[string]bool{}
+	seenNames := map[string]bool{}
+	for i, item := range items {
+		record := i + 1
+		if len(item.Name) == 0 {
+			report.AddError(record, item.ID, "name is required")
+			continue
+		}
+		if seenNames[item.Name] || (len(item.ID) > 0 && seenIDs[item.ID]) {
+			report.AddError(record, item.ID, fmt.Sprintf("duplicate name %s or id", item.Name))
+			continue
+		}
+		seenNames[item.Name] = true
+		seenIDs[item.ID] = true
+
+		if item.Parent == item.Name {
+			report.AddError(record, item.ID, "a category cannot be its own parent")
+			continue
+		}
+		if len(item.Parent) > 0 && !importedNames[item.Parent] {
+			_, err := storage.FindCategory(&claims.AppID, claims.OrgID, item.Parent)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				report.AddError(record, item.ID, fmt.Sprintf("parent category %s does not exist", item.Parent))
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		//the name must not be taken by another category
+		byName, err := storage.FindCategory(&claims.AppID, claims.OrgID, item.Name)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		if byName != nil && len(item.ID) > 0 && byName.ID != item.ID {
+			report.AddError(record, item.ID, fmt.Sprintf("name %s is used by category %s", item.Name, byName.ID))
+			continue
+		}
+		if byName != nil {
+			existing[i] = byName
+		} else if len(item.ID) > 0 {
+			byID, err := storage.FindCategoryByID(&claims.AppID, claims.OrgID, item.ID)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, err
+			}
+			existing[i] = byID
+		}
+
+		//the files keep the category name in their paths
+		if existing[i] != nil && existing[i].Name != item.Name {
+			files, err := s.countCategoryFiles(claims, existing[i].Name)
+			if err != nil {
+				return nil, err
+			}
+			if files > 0 {
+				inUseErr := model.CategoryInUseError{Category: existing[i].Name, Files: files}
+				report.AddError(record, item.ID, inUseErr.Error())
+				continue
+			}
+		}
+
+		//the items may already use the category name, so they must conform to the schema
+		schemaReport, err := s.checkCategorySchema(storage, claims.AppID, claims.OrgID, item.Name, item.Schema)
+		if err != nil {
+			report.AddError(record, item.ID, err.Error())
+			continue
+		}
+		if !schemaReport.Valid {
+			schemaErr := model.CategorySchemaError{Report: *schemaReport}
+			report.AddError(record, item.ID, schemaErr.Error())
+			continue
+		}
+
+		if existing[i] != nil {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	for i, item := range items {
+		now := time.Now().UTC()
+		item.AppID = &claims.AppID
+		item.OrgID = claims.OrgID
+		current := existing[i]
+		operation := model.AuditOperationCreate
+		var before interface{}
+		if current != nil {
+			item.ID = current.ID
+			item.DateCreated = current.DateCreated
+			item.DateUpdated = &now
+			operation = model.AuditOperationUpdate
+			before = current
+		} else {
+			if len(item.ID) == 0 {
+				item.ID = uuid.NewString()
+			}
+			if item.DateCreated.IsZero() {
+				item.DateCreated = now
+			}
+		}
+
+		err := storage.SaveCategory(item)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %s", i+1, err)
+		}
+		if current != nil && current.Name != item.Name {
+			err = storage.RenameCategoryReferences(&claims.AppID, claims.OrgID, current.Name, item.Name)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %s", i+1, err)
+			}
+		}
+
+		err = s.audit(storage, actor, model.AuditResourceCategory, item.Name, item.Name, operation, before, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.Imported = true
+	return report, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportContentItemsWorkflowState(t *testing.T) {
+	appID := "app"
+	approver := &model.AuditActor{AccountID: "approver", Permissions: []string{"approve_content-items", "update_content-items"}, AppID: appID, OrgID: "org"}
+	editor := &model.AuditActor{AccountID: "editor", Permissions: []string{"update_content-items"}, AppID: appID, OrgID: "org"}
+	published := model.ContentItem{ID: "published", Category: "events", Data: "a", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC(),
+		WorkflowState: model.ContentItemWorkflowPublished}
+	legacy := model.ContentItem{ID: "legacy", Category: "events", Data: "a", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC()}
+
+	tests := []struct {
+		name      string
+		actor     *model.AuditActor
+		item      model.ContentItem
+		want      string // the stored workflow state, empty when the import is rejected
+		wantError bool
+	}{
+		{name: "new item", actor: editor, item: model.ContentItem{ID: "new", Category: "events", Data: "b"}, want: model.ContentItemWorkflowDraft},
+		{name: "new item in review", actor: editor, item: model.ContentItem{ID: "new", Category: "events", Data: "b", WorkflowState: model.ContentItemWorkflowInReview},
+			want: model.ContentItemWorkflowInReview},
+		{name: "new item published by an approver", actor: approver, item: model.ContentItem{ID: "new", Category: "events", Data: "b", WorkflowState: model.ContentItemWorkflowPublished},
+			want: model.ContentItemWorkflowPublished},
+		{name: "new item published by an editor", actor: editor, item: model.ContentItem{ID: "new", Category: "events", Data: "b", WorkflowState: model.ContentItemWorkflowPublished},
+			wantError: true},
+		{name: "invalid state", actor: approver, item: model.ContentItem{ID: "new", Category: "events", Data: "b", WorkflowState: model.ContentItemStateLive},
+			wantError: true},
+		{name: "replaced published item", actor: approver, item: model.ContentItem{ID: "published", Category: "events", Data: "b"}, want: model.ContentItemWorkflowDraft},
+		{name: "replaced item before the workflow", actor: editor, item: model.ContentItem{ID: "legacy", Category: "events", Data: "b"}, want: model.ContentItemWorkflowDraft},
+		{name: "replaced item kept published by an approver", actor: approver, item: model.ContentItem{ID: "published", Category: "events", Data: "b", WorkflowState: model.ContentItemWorkflowPublished},
+			want: model.ContentItemWorkflowPublished},
+		{name: "replaced item kept published by an editor", actor: editor, item: model.ContentItem{ID: "published", Category: "events", Data: "b", WorkflowState: model.ContentItemWorkflowPublished},
+			wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{contentItems: []model.ContentItem{published, legacy}}
+			services := testServices(storage)
+
+			report, err := services.ImportContentItems(tt.actor, false, appID, "org", []model.ContentItem{tt.item}, false)
+			if err != nil {
+				t.Fatalf("ImportContentItems() error = %v", err)
+			}
+			if (len(report.Errors) > 0) != tt.wantError {
+				t.Fatalf("ImportContentItems() errors = %+v, wantError %v", report.Errors, tt.wantError)
+			}
+			if report.Imported == tt.wantError {
+				t.Errorf("ImportContentItems() imported = %v, want %v", report.Imported, !tt.wantError)
+			}
+
+			stored, _ := storage.FindContentItems(nil, "org", []string{tt.item.ID}, nil, nil, nil, nil, nil)
+			if tt.wantError {
+				if len(stored) > 0 && stored[0].Data == tt.item.Data {
+					t.Errorf("stored item = %+v, want the rejected import not stored", stored[0])
+				}
+				return
+			}
+			if len(stored) != 1 || stored[0].WorkflowState != tt.want {
+				t.Errorf("stored item = %+v, want the %s state", stored, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportContentItemsDryRun(t *testing.T) {
+	appID := "app"
+	actor := &model.AuditActor{AccountID: "editor", AppID: appID, OrgID: "org"}
+	items := []model.ContentItem{{ID: "a", Category: "events", Data: "a", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC(),
+		WorkflowState: model.ContentItemWorkflowDraft}}
+
+	tests := []struct {
+		name        string
+		items       []model.ContentItem
+		wantCreated int
+		wantUpdated int
+		wantErrors  []model.ImportError
+	}{
+		{name: "valid items", items: []model.ContentItem{{ID: "a", Category: "events", Data: "b"}, {Category: "events", Data: "c"}, {ID: "d", Category: "events", Data: "d"}},
+			wantCreated: 2, wantUpdated: 1, wantErrors: []model.ImportError{}},
+		{name: "invalid items", items: []model.ContentItem{{ID: "a", Category: "events", Data: "b"}, {ID: "d", Data: "d"}, {ID: "a", Category: "events", Data: "e"}},
+			wantUpdated: 1, wantErrors: []model.ImportError{{Record: 2, ID: "d", Message: "category is required"}, {Record: 3, ID: "a", Message: "duplicate id"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{contentItems: slices.Clone(items)}
+
+			report, err := testServices(storage).ImportContentItems(actor, false, appID, "org", tt.items, true)
+			if err != nil {
+				t.Fatalf("ImportContentItems() error = %v", err)
+			}
+			if !report.DryRun || report.Imported || report.Created != tt.wantCreated || report.Updated != tt.wantUpdated || !reflect.DeepEqual(report.Errors, tt.wantErrors) {
+				t.Errorf("ImportContentItems() = %+v, want a dry run with %d created, %d updated and the errors %+v", report, tt.wantCreated, tt.wantUpdated, tt.wantErrors)
+			}
+			if !reflect.DeepEqual(storage.contentItems, items) || len(storage.versions) != 0 || len(storage.auditLog) != 0 {
+				t.Errorf("stored items = %+v, want the dry run not stored", storage.contentItems)
+			}
+		})
+	}
+}
+
+func TestImportContentItemsRollback(t *testing.T) {
+	appID := "app"
+	actor := &model.AuditActor{AccountID: "editor", AppID: appID, OrgID: "org"}
+	items := []model.ContentItem{{ID: "a", Category: "events", Data: "a", AppID: &appID, OrgID: "org", DateCreated: time.Now().UTC(),
+		WorkflowState: model.ContentItemWorkflowDraft}}
+	imported := []model.ContentItem{{ID: "a", Category: "events", Data: "b"}, {ID: "b", Category: "events", Data: "b"}, {ID: "c", Category: "events", Data: "c"}}
+
+	t.Run("invalid item", func(t *testing.T) {
+		storage := &memoryStorage{contentItems: slices.Clone(items)}
+		invalid := append(slices.Clone(imported), model.ContentItem{ID: "d", Data: "d"})
+
+		report, err := testServices(storage).ImportContentItems(actor, false, appID, "org", invalid, false)
+		if err != nil {
+			t.Fatalf("ImportContentItems() error = %v", err)
+		}
+		if report.Imported || len(report.Errors) != 1 {
+			t.Errorf("ImportContentItems() = %+v, want it rejected", report)
+		}
+		if !reflect.DeepEqual(storage.contentItems, items) || len(storage.versions) != 0 || len(storage.auditLog) != 0 {
+			t.Errorf("stored items = %+v, want none of the import stored", storage.contentItems)
+		}
+	})
+
+	t.Run("failed write", func(t *testing.T) {
+		storage := &memoryStorage{contentItems: slices.Clone(items), failingIDs: []string{"c"}}
+
+		_, err := testServices(storage).ImportContentItems(actor, false, appID, "org", imported, false)
+		if err == nil || !strings.Contains(err.Error(), errWriteFailed.Error()) {
+			t.Fatalf("ImportContentItems() error = %v, want %v", err, errWriteFailed)
+		}
+		if !reflect.DeepEqual(storage.contentItems, items) || len(storage.versions) != 0 || len(storage.auditLog) != 0 {
+			t.Errorf("stored items = %+v, versions = %+v, audit log = %+v, want the import rolled back", storage.contentItems, storage.versions, storage.auditLog)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		storage := &memoryStorage{contentItems: slices.Clone(items)}
+
+		report, err := testServices(storage).ImportContentItems(actor, false, appID, "org", imported, false)
+		if err != nil {
+			t.Fatalf("ImportContentItems() error = %v", err)
+		}
+		if !report.Imported || report.Created != 2 || report.Updated != 1 {
+			t.Errorf("ImportContentItems() = %+v, want 2 created and 1 updated", report)
+		}
+		if len(storage.contentItems) != 3 || len(storage.versions) != 1 || len(storage.auditLog) != 3 {
+			t.Errorf("stored items = %+v, versions = %+v, audit log = %+v, want the import stored", storage.contentItems, storage.versions, storage.auditLog)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *servicesImpl) CreateFileContentMultipartUpload(claims *tokenauth.Claims, fileName string, entityID string, category string,
+	addAppOrgIDToPath bool, handleDuplicateFileNames bool, publicRead bool) (*model.MultipartUpload, error) {
+	err := checkCategoryAccess(s.app.storage, claims, category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	//the size is known when the upload is completed, the files which are still not confirmed count against the quotas
+	pendingSize, err := pendingUploadsSize(s.app.objectStorage, s.getFilePath(claims, "", category, "", addAppOrgIDToPath))
+	if err != nil {
+		return nil, err
+	}
+	err = checkStorageQuota(s.app.storage, claims.AppID, claims.OrgID, category, pendingSize)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey := fileName
+	if handleDuplicateFileNames {
+		fileKey = fmt.Sprintf("%s_%s", uuid.NewString(), fileName)
+	}
+	path := s.getFilePath(claims, fileKey, category, entityID, addAppOrgIDToPath)
+
+	//the parts are put together in the pending uploads, the file is moved to its path once it is checked
+	uploadID, err := s.app.objectStorage.CreateMultipartUpload(model.ObjectBucketContent, pendingUploadPath(path, publicRead), false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the multipart upload: %s", err)
+	}
+
+	upload := model.MultipartUpload{ID: uuid.NewString(), UploadID: uploadID, AppID: claims.AppID, OrgID: claims.OrgID,
+		AccountID: claims.Subject, Category: category, EntityID: entityID, Key: fileKey, Path: path, Public: publicRead, DateCreated: time.Now().UTC()}
+	err = s.app.storage.CreateMultipartUpload(upload)
+	if err != nil {
+		//the sweeper cannot find an upload without a record
+		abortErr := s.app.objectStorage.AbortMultipartUpload(model.ObjectBucketContent, pendingUploadPath(path, publicRead), uploadID)
+		if abortErr != nil {
+			s.app.logger.Errorf("error on aborting the multipart upload %s - %s", uploadID, abortErr)
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (s *servicesImpl) GetFileContentUploadPartURLs(claims *tokenauth.Claims, id string, partNumbers []int) ([]model.UploadPartRef, error) {
+	for _, partNumber := range partNumbers {
+		if partNumber < 1 || partNumber > model.MaxUploadParts {
+			return nil, fmt.Errorf("invalid part number %d, the part numbers are between 1 and %d", partNumber, model.MaxUploadParts)
+		}
+	}
+
+	upload, err := s.findMultipartUpload(claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	partRefs := make([]model.UploadPartRef, len(partNumbers))
+	for i, partNumber := range partNumbers {
+		url, err := s.app.objectStorage.PresignUploadPart(model.ObjectBucketContent, pendingUploadPath(upload.Path, upload.Public), upload.UploadID, partNumber)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get upload part references: %s", err)
+		}
+		partRefs[i] = model.UploadPartRef{PartNumber: partNumber, URL: url}
+	}
+	return partRefs, nil
+}
+
+func (s *servicesImpl) CompleteFileContentMultipartUpload(actor *model.AuditActor, claims *tokenauth.Claims, id string, parts []model.UploadedPart) (*model.FileContentItemRef, error) {
+	if len(parts) == 0 || len(parts) > model.MaxUploadParts {
+		return nil, fmt.Errorf("a multipart upload has between 1 and %d parts", model.MaxUploadParts)
+	}
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return nil, errors.New("the parts must be in ascending order of their part numbers")
+		}
+	}
+
+	upload, err := s.findMultipartUpload(claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.app.objectStorage.CompleteMultipartUpload(model.ObjectBucketContent, pendingUploadPath(upload.Path, upload.Public), upload.UploadID, parts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to complete the multipart upload: %s", err)
+	}
+	err = s.app.storage.DeleteMultipartUpload(upload.ID)
+	if err != nil {
+		//the object is there, the sweeper removes the record later
+		s.app.logger.Errorf("error on deleting the multipart upload %s - %s", upload.ID, err)
+	}
+	//the file is served only once it is checked, a file which cannot be checked now stays pending and may be confirmed later
+	_, location, err := s.reconcileFileContentItem(claims, upload.Key, upload.Path, upload.Category, upload.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceFile, upload.Key, upload.Category, model.AuditOperationCreate, nil,
+		map[string]interface{}{"path": upload.Path, "parts": len(parts)})
+	return &model.FileContentItemRef{Key: upload.Key, URL: location}, nil
+}
+
+func (s *servicesImpl) AbortFileContentMultipartUpload(claims *tokenauth.Claims, id string) error {
+	upload, err := s.findMultipartUpload(claims, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.objectStorage.AbortMultipartUpload(model.ObjectBucketContent, pendingUploadPath(upload.Path, upload.Public), upload.UploadID)
+	if err != nil {
+		return fmt.Errorf("unable to abort the multipart upload: %s", err)
+	}
+	return s.app.storage.DeleteMultipartUpload(upload.ID)
+}
+
+// findMultipartUpload gives a multipart upload of the account, the account must still be allowed to write the items of its category
+func (s *servicesImpl) findMultipartUpload(claims *tokenauth.Claims, id string) (*model.MultipartUpload, error) {
+	upload, err := s.app.storage.FindMultipartUpload(claims.AppID, claims.OrgID, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, id)
+		}
+		return nil, err
+	}
+	if upload.AccountID != claims.Subject {
+		return nil, fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, id)
+	}
+
+	err = checkCategoryAccess(s.app.storage, claims, upload.Category, categoryAccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/model"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+)
+
+// uploadsTestServices gives the services with a category the uploader may write
+func uploadsTestServices() (*servicesImpl, *memoryStorage, *memoryObjectStorage) {
+	appID := "app"
+	storage := &memoryStorage{categories: []model.Category{{Name: "events", AppID: &appID, OrgID: "org", Permissions: []string{"upload"}}}}
+	objects := &memoryObjectStorage{}
+	services := testServices(storage)
+	services.app.objectStorage = objects
+	return services, storage, objects
+}
+
+// uploaderClaims gives the claims of an account which may upload the files of the test category
+func uploaderClaims(appID string, subject string) *tokenauth.Claims {
+	claims := &tokenauth.Claims{AppID: appID, OrgID: "org", Permissions: "upload"}
+	claims.Subject = subject
+	return claims
+}
+
+func TestCompleteFileContentMultipartUpload(t *testing.T) {
+	services, storage, objects := uploadsTestServices()
+	claims := uploaderClaims("app", "account")
+
+	upload, err := services.CreateFileContentMultipartUpload(claims, "big.txt", "entity", "events", true, false, false)
+	if err != nil {
+		t.Fatalf("CreateFileContentMultipartUpload() error = %v", err)
+	}
+	if upload.Path != "org/app/events/entity/big.txt" {
+		t.Errorf("CreateFileContentMultipartUpload() path = %s, want %s", upload.Path, "org/app/events/entity/big.txt")
+	}
+	refs, err := services.GetFileContentUploadPartURLs(claims, upload.ID, []int{1, 2})
+	if err != nil {
+		t.Fatalf("GetFileContentUploadPartURLs() error = %v", err)
+	}
+	if len(refs) != 2 || refs[0].PartNumber != 1 || refs[1].PartNumber != 2 {
+		t.Errorf("GetFileContentUploadPartURLs() = %v, want the references of parts 1 and 2", refs)
+	}
+
+	second := objects.uploadPart(upload.UploadID, 2, "second")
+	first := objects.uploadPart(upload.UploadID, 1, "first ")
+	ref, err := services.CompleteFileContentMultipartUpload(nil, claims, upload.ID, []model.UploadedPart{first, second})
+	if err != nil {
+		t.Fatalf("CompleteFileContentMultipartUpload() error = %v", err)
+	}
+	if ref.Key != "big.txt" {
+		t.Errorf("CompleteFileContentMultipartUpload() key = %s, want %s", ref.Key, "big.txt")
+	}
+
+	//the assembled file is moved from the pending uploads to its path and recorded
+	object, err := objects.Download(model.ObjectBucketContent, upload.Path)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	if string(data) != "first second" {
+		t.Errorf("file = %q, want %q", data, "first second")
+	}
+	pending, _ := objects.List(model.ObjectBucketContent, pendingUploadsPrefix)
+	if len(pending) != 0 {
+		t.Errorf("pending uploads = %v, want none", pending)
+	}
+	item, err := storage.FindFileContentItem("app", "org", upload.Path)
+	if err != nil {
+		t.Fatalf("FindFileContentItem() error = %v", err)
+	}
+	if item.Size != int64(len("first second")) || item.AccountID != "account" || item.EntityID != "entity" {
+		t.Errorf("file record = %+v, want the size, the account and the entity of the upload", item)
+	}
+	_, err = storage.FindMultipartUpload("app", "org", upload.ID)
+	if err == nil {
+		t.Errorf("the record of the completed multipart upload is kept")
+	}
+}
+
+func TestFileContentMultipartUploadErrors(t *testing.T) {
+	services, _, objects := uploadsTestServices()
+	claims := uploaderClaims("app", "account")
+	upload, err := services.CreateFileContentMultipartUpload(claims, "big.txt", "", "events", true, false, false)
+	if err != nil {
+		t.Fatalf("CreateFileContentMultipartUpload() error = %v", err)
+	}
+	first := objects.uploadPart(upload.UploadID, 1, "first")
+	second := objects.uploadPart(upload.UploadID, 2, "second")
+
+	tests := []struct {
+		name     string
+		claims   *tokenauth.Claims
+		id       string
+		parts    []model.UploadedPart
+		notFound bool
+	}{
+		{name: "no parts", claims: claims, id: upload.ID},
+		{name: "descending parts", claims: claims, id: upload.ID, parts: []model.UploadedPart{second, first}},
+		{name: "repeated part", claims: claims, id: upload.ID, parts: []model.UploadedPart{first, first}},
+		{name: "other account", claims: uploaderClaims("app", "other"),
+			id: upload.ID, parts: []model.UploadedPart{first}, notFound: true},
+		{name: "other app", claims: uploaderClaims("other", "account"),
+			id: upload.ID, parts: []model.UploadedPart{first}, notFound: true},
+		{name: "unknown upload", claims: claims, id: "unknown", parts: []model.UploadedPart{first}, notFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := services.CompleteFileContentMultipartUpload(nil, tt.claims, tt.id, tt.parts)
+			if err == nil {
+				t.Fatalf("CompleteFileContentMultipartUpload() error = nil, want an error")
+			}
+			if got := errors.Is(err, model.ErrMultipartUploadNotFound); got != tt.notFound {
+				t.Errorf("CompleteFileContentMultipartUpload() error = %v, want not found %v", err, tt.notFound)
+			}
+		})
+	}
+
+	//the upload can still be completed by its account
+	_, err = services.CompleteFileContentMultipartUpload(nil, claims, upload.ID, []model.UploadedPart{first, second})
+	if err != nil {
+		t.Errorf("CompleteFileContentMultipartUpload() error = %v", err)
+	}
+}
+
+func TestAbortFileContentMultipartUpload(t *testing.T) {
+	services, storage, objects := uploadsTestServices()
+	claims := uploaderClaims("app", "account")
+	upload, err := services.CreateFileContentMultipartUpload(claims, "big.txt", "", "events", true, false, false)
+	if err != nil {
+		t.Fatalf("CreateFileContentMultipartUpload() error = %v", err)
+	}
+	objects.uploadPart(upload.UploadID, 1, "first")
+
+	other := uploaderClaims("app", "other")
+	err = services.AbortFileContentMultipartUpload(other, upload.ID)
+	if !errors.Is(err, model.ErrMultipartUploadNotFound) {
+		t.Errorf("AbortFileContentMultipartUpload() of another account error = %v, want %v", err, model.ErrMultipartUploadNotFound)
+	}
+
+	err = services.AbortFileContentMultipartUpload(claims, upload.ID)
+	if err != nil {
+		t.Fatalf("AbortFileContentMultipartUpload() error = %v", err)
+	}
+	if _, ok := objects.uploads[upload.UploadID]; ok {
+		t.Errorf("the parts of the aborted upload are kept")
+	}
+	if len(storage.multipartUploads) != 0 {
+		t.Errorf("multipart uploads = %v, want none", storage.multipartUploads)
+	}
+}
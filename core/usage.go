@@ -0,0 +1,314 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recordStorageUsage adds a change of the usage, the objects are already changed then, so an error is only logged
+func recordStorageUsage(storage interfaces.Storage, logger *logs.Logger, usage model.StorageUsage) {
+	if usage.Empty() {
+		return
+	}
+	err := storage.AddStorageUsage(usage)
+	if err != nil {
+		logger.Errorf("error on recording the storage usage of category %s - %s", usage.Category, err)
+	}
+}
+
+// storedSize gives the size an app, or all the apps of the organization for nil appID, keep in a category or in all the categories for nil category
+func storedSize(storage interfaces.Storage, appID *string, orgID string, category *string) (int64, error) {
+	usage, err := storage.FindStorageUsage(appID, orgID, category)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, item := range usage {
+		size += item.UploadedSize - item.DeletedSize
+	}
+	return size, nil
+}
+
+// checkStorageQuota rejects an upload which takes the usage over a quota of the app or of its organization, the size is how much the upload adds
+func checkStorageQuota(storage interfaces.Storage, appID string, orgID string, category string, size int64) error {
+	for _, appIDParam := range []*string{&appID, nil} {
+		quotas, err := storage.FindStorageQuotas(appIDParam, orgID)
+		if err != nil {
+			return err
+		}
+		for _, quota := range quotas {
+			var categoryParam *string
+			if len(quota.Category) > 0 {
+				if quota.Category != category {
+					continue
+				}
+				categoryParam = &quota.Category
+			}
+			usage, err := storedSize(storage, appIDParam, orgID, categoryParam)
+			if err != nil {
+				return err
+			}
+			if usage+size > quota.MaxSize {
+				return &model.StorageQuotaExceededError{Category: quota.Category, Usage: usage, MaxSize: quota.MaxSize}
+			}
+		}
+	}
+	return nil
+}
+
+// pendingUploadsSize gives the size of the files under a path waiting in the pending uploads, they count against the quotas until they are checked
+func pendingUploadsSize(objectStorage interfaces.ObjectStorage, path string) (int64, error) {
+	var size int64
+	for _, public := range []bool{false, true} {
+		objects, err := objectStorage.List(model.ObjectBucketContent, pendingUploadPath(path, public))
+		if err != nil {
+			return 0, err
+		}
+		for _, object := range objects {
+			size += object.Size
+		}
+	}
+	return size, nil
+}
+
+// changeObjects runs a change of the objects with fixed keys, like the profile photos of an account, and records the change of their usage.
+// The objects are listed before and after the change, an upload of the size must fit in the quotas.
+func changeObjects(storage interfaces.Storage, objectStorage interfaces.ObjectStorage, logger *logs.Logger, appID string, orgID string, category string,
+	bucket string, prefix string, keys []string, size int64, change func() error) error {
+	beforeSize, beforeObjects, err := objectsSize(objectStorage, bucket, prefix, keys)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		err = checkStorageQuota(storage, appID, orgID, category, size-beforeSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	changeErr := change()
+
+	//a failed change may still have changed some of the objects
+	afterSize, afterObjects, err := objectsSize(objectStorage, bucket, prefix, keys)
+	if err != nil {
+		logger.Errorf("error on listing the objects %s for the storage usage - %s", prefix, err)
+		return changeErr
+	}
+	if changeErr == nil || afterSize != beforeSize || afterObjects != beforeObjects {
+		usage := model.NewStorageUsage(appID, orgID, category)
+		usage.Delete(beforeSize, beforeObjects)
+		usage.Upload(afterSize, afterObjects)
+		recordStorageUsage(storage, logger, usage)
+	}
+	return changeErr
+}
+
+// objectsSize gives the size and the count of the stored objects with the keys, they all start with the prefix
+func objectsSize(objectStorage interfaces.ObjectStorage, bucket string, prefix string, keys []string) (int64, int64, error) {
+	objects, err := objectStorage.List(bucket, prefix)
+	if err != nil {
+		return 0, 0, err
+	}
+	var size, count int64
+	for _, object := range objects {
+		if slices.Contains(keys, object.Key) {
+			size += object.Size
+			count++
+		}
+	}
+	return size, count, nil
+}
+
+// findFileContentItem gives the record of a file, nil when the file is not recorded
+func (s *servicesImpl) findFileContentItem(claims *tokenauth.Claims, path string) (*model.FileContentItem, error) {
+	item, err := s.app.storage.FindFileContentItem(claims.AppID, claims.OrgID, path)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// fileSize gives the size a recorded file takes in the usage, the quarantined files are not counted
+func fileSize(item *model.FileContentItem) int64 {
+	if item == nil || item.Quarantined {
+		return 0
+	}
+	return item.Size
+}
+
+// recordFileUsage records the change of the usage when the record of a file is replaced, created or deleted
+func (s *servicesImpl) recordFileUsage(claims *tokenauth.Claims, previous *model.FileContentItem, current *model.FileContentItem) {
+	if previous != nil && !previous.Quarantined {
+		usage := model.NewStorageUsage(claims.AppID, claims.OrgID, previous.Category)
+		usage.Delete(previous.Size, 1)
+		recordStorageUsage(s.app.storage, s.app.logger, usage)
+	}
+	if current != nil && !current.Quarantined {
+		usage := model.NewStorageUsage(claims.AppID, claims.OrgID, current.Category)
+		usage.Upload(current.Size, 1)
+		recordStorageUsage(s.app.storage, s.app.logger, usage)
+	}
+}
+
+func (s *servicesImpl) GetStorageUsage(allApps bool, appID string, orgID string, category *string, from *string, to *string) (*model.StorageUsageReport, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	usage, err := s.app.storage.FindStorageUsage(appIDParam, orgID, category)
+	if err != nil {
+		return nil, err
+	}
+	//the quotas of the organization apply to all the apps
+	quotas, err := s.app.storage.FindStorageQuotas(appIDParam, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := model.StorageUsageReport{Categories: []model.CategoryStorageUsage{}}
+	categories := map[string]*model.CategoryStorageUsage{}
+	categoryUsage := func(name string) *model.CategoryStorageUsage {
+		if _, ok := categories[name]; !ok {
+			categories[name] = &model.CategoryStorageUsage{Category: name, Months: []model.MonthStorageUsage{}}
+		}
+		return categories[name]
+	}
+
+	//the usage comes by category and month, the apps have one item each
+	for _, item := range usage {
+		current := categoryUsage(item.Category)
+		current.Size += item.UploadedSize - item.DeletedSize
+		current.Objects += item.UploadedObjects - item.DeletedObjects
+		report.Size += item.UploadedSize - item.DeletedSize
+		report.Objects += item.UploadedObjects - item.DeletedObjects
+
+		if (from != nil && item.Month < *from) || (to != nil && item.Month > *to) {
+			continue
+		}
+		if len(current.Months) == 0 || current.Months[len(current.Months)-1].Month != item.Month {
+			current.Months = append(current.Months, model.MonthStorageUsage{Month: item.Month})
+		}
+		month := &current.Months[len(current.Months)-1]
+		month.UploadedSize += item.UploadedSize
+		month.UploadedObjects += item.UploadedObjects
+		month.DeletedSize += item.DeletedSize
+		month.DeletedObjects += item.DeletedObjects
+	}
+	for _, quota := range quotas {
+		maxSize := quota.MaxSize
+		if len(quota.Category) == 0 {
+			report.MaxSize = &maxSize
+		} else if category == nil || *category == quota.Category {
+			categoryUsage(quota.Category).MaxSize = &maxSize
+		}
+	}
+
+	for _, current := range categories {
+		report.Categories = append(report.Categories, *current)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool { return report.Categories[i].Category < report.Categories[j].Category })
+	return &report, nil
+}
+
+func (s *servicesImpl) GetStorageQuotas(allApps bool, appID string, orgID string) ([]model.StorageQuota, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	return s.app.storage.FindStorageQuotas(appIDParam, orgID)
+}
+
+func (s *servicesImpl) SaveStorageQuota(actor *model.AuditActor, allApps bool, appID string, orgID string, category string, maxSize int64) (*model.StorageQuota, error) {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	before, err := s.findStorageQuota(appIDParam, orgID, category)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := s.app.storage.SaveStorageQuota(model.StorageQuota{ID: uuid.NewString(), AppID: appIDParam, OrgID: orgID, Category: category,
+		MaxSize: maxSize, DateCreated: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil {
+		s.auditCommitted(actor, model.AuditResourceStorageQuota, quota.ID, category, model.AuditOperationCreate, nil, *quota)
+	} else {
+		s.auditCommitted(actor, model.AuditResourceStorageQuota, quota.ID, category, model.AuditOperationUpdate, *before, *quota)
+	}
+	return quota, nil
+}
+
+func (s *servicesImpl) DeleteStorageQuota(actor *model.AuditActor, allApps bool, appID string, orgID string, category string) error {
+	//logic
+	var appIDParam *string
+	if !allApps {
+		appIDParam = &appID //associated with current app
+	}
+
+	before, err := s.findStorageQuota(appIDParam, orgID, category)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("%w: %s", model.ErrStorageQuotaNotFound, category)
+	}
+
+	err = s.app.storage.DeleteStorageQuota(appIDParam, orgID, category)
+	if err != nil {
+		return err
+	}
+
+	s.auditCommitted(actor, model.AuditResourceStorageQuota, before.ID, category, model.AuditOperationDelete, *before, nil)
+	return nil
+}
+
+// findStorageQuota gives the quota of a category, nil when there is none
+func (s *servicesImpl) findStorageQuota(appID *string, orgID string, category string) (*model.StorageQuota, error) {
+	quotas, err := s.app.storage.FindStorageQuotas(appID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, quota := range quotas {
+		if quota.Category == category {
+			return &quota, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"content/core/interfaces"
+	"content/core/model"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// quotaStorage keeps the quotas and the usage in memory, the other storage calls are not implemented
+type quotaStorage struct {
+	interfaces.Storage
+
+	quotas []model.StorageQuota
+	usage  []model.StorageUsage
+	err    error
+}
+
+func (s *quotaStorage) FindStorageQuotas(appID *string, orgID string) ([]model.StorageQuota, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var quotas []model.StorageQuota
+	for _, quota := range s.quotas {
+		if quota.OrgID == orgID && reflect.DeepEqual(quota.AppID, appID) {
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas, nil
+}
+
+func (s *quotaStorage) FindStorageUsage(appID *string, orgID string, category *string) ([]model.StorageUsage, error) {
+	var usage []model.StorageUsage
+	for _, item := range s.usage {
+		if item.OrgID == orgID && (appID == nil || item.AppID == *appID) && (category == nil || item.Category == *category) {
+			usage = append(usage, item)
+		}
+	}
+	return usage, nil
+}
+
+func TestCheckStorageQuota(t *testing.T) {
+	appID := "app"
+	otherAppID := "other"
+	usage := []model.StorageUsage{
+		{AppID: "app", OrgID: "org", Category: "events", Month: "2025-01", UploadedSize: 600, DeletedSize: 100},
+		{AppID: "app", OrgID: "org", Category: "events", Month: "2025-02", UploadedSize: 200},
+		{AppID: "app", OrgID: "org", Category: "profile_images", Month: "2025-02", UploadedSize: 300},
+		{AppID: "other", OrgID: "org", Category: "events", Month: "2025-02", UploadedSize: 1000},
+		{AppID: "app", OrgID: "other", Category: "events", Month: "2025-02", UploadedSize: 5000},
+	}
+
+	tests := []struct {
+		name     string
+		quotas   []model.StorageQuota
+		category string
+		size     int64
+		want     *model.StorageQuotaExceededError
+	}{
+		{name: "no quotas", category: "events", size: 1 << 40},
+		{name: "app quota of all the categories", quotas: []model.StorageQuota{{AppID: &appID, OrgID: "org", MaxSize: 1000}},
+			category: "events", size: 1, want: &model.StorageQuotaExceededError{Usage: 1000, MaxSize: 1000}},
+		{name: "upload fills the quota", quotas: []model.StorageQuota{{AppID: &appID, OrgID: "org", MaxSize: 1100}},
+			category: "events", size: 100},
+		{name: "app quota of the category", quotas: []model.StorageQuota{{AppID: &appID, OrgID: "org", Category: "events", MaxSize: 750}},
+			category: "events", size: 51, want: &model.StorageQuotaExceededError{Category: "events", Usage: 700, MaxSize: 750}},
+		{name: "app quota of another category", quotas: []model.StorageQuota{{AppID: &appID, OrgID: "org", Category: "profile_images", MaxSize: 300}},
+			category: "events", size: 1000},
+		{name: "organization quota counts all the apps", quotas: []model.StorageQuota{{OrgID: "org", MaxSize: 2000}},
+			category: "events", size: 1, want: &model.StorageQuotaExceededError{Usage: 2000, MaxSize: 2000}},
+		{name: "organization quota of the category", quotas: []model.StorageQuota{{OrgID: "org", Category: "events", MaxSize: 1800}},
+			category: "events", size: 101, want: &model.StorageQuotaExceededError{Category: "events", Usage: 1700, MaxSize: 1800}},
+		{name: "app quota is checked first", quotas: []model.StorageQuota{{OrgID: "org", MaxSize: 1000}, {AppID: &appID, OrgID: "org", MaxSize: 1000}},
+			category: "events", size: 1, want: &model.StorageQuotaExceededError{Usage: 1000, MaxSize: 1000}},
+		{name: "quota of another app", quotas: []model.StorageQuota{{AppID: &otherAppID, OrgID: "org", MaxSize: 1000}},
+			category: "events", size: 100},
+		{name: "quota of another organization", quotas: []model.StorageQuota{{AppID: &appID, OrgID: "other", MaxSize: 1000}},
+			category: "events", size: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &quotaStorage{quotas: tt.quotas, usage: usage}
+
+			err := checkStorageQuota(storage, appID, "org", tt.category, tt.size)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("checkStorageQuota() error = %v, want nil", err)
+				}
+				return
+			}
+			var exceeded *model.StorageQuotaExceededError
+			if !errors.As(err, &exceeded) {
+				t.Fatalf("checkStorageQuota() error = %v, want %v", err, tt.want)
+			}
+			if !reflect.DeepEqual(exceeded, tt.want) {
+				t.Errorf("checkStorageQuota() error = %+v, want %+v", exceeded, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckStorageQuotaFindError(t *testing.T) {
+	findErr := errors.New("storage is down")
+	storage := &quotaStorage{err: findErr}
+
+	err := checkStorageQuota(storage, "app", "org", "events", 1)
+	if !errors.Is(err, findErr) {
+		t.Errorf("checkStorageQuota() error = %v, want %v", err, findErr)
+	}
+}
@@ -15,20 +15,19 @@
 package awsstorage
 
 import (
-	"bytes"
 	"content/core/model"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-	"github.com/google/uuid"
 )
 
 const (
@@ -36,7 +35,7 @@ const (
 	defaultDownloadPresignExpirationMinutes int = 60 * 24
 )
 
-// Adapter implements the Storage interface
+// Adapter implements the ObjectStorage interface with AWS S3 or an S3 compatible service
 type Adapter struct {
 	config *model.AWSConfig
 
@@ -56,338 +55,256 @@ func NewAWSStorageAdapter(config *model.AWSConfig, uploadPresignExpirationMinute
 	return &Adapter{config: config, uploadPresignExpirationMinutes: uploadPresignExpirationMinutes, downloadPresignExpirationMinutes: downloadPresignExpirationMinutes}
 }
 
-// LoadImage loads image at specific path
-func (a *Adapter) LoadImage(path string) ([]byte, error) {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
+// Upload uploads an object, the public objects get the public-read ACL
+func (a *Adapter) Upload(bucket string, key string, body io.Reader, public bool) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
-	}
-
-	buffer := aws.NewWriteAtBuffer([]byte{})
-
-	downloader := s3manager.NewDownloader(s)
-	_, err = downloader.Download(buffer,
-		&s3.GetObjectInput{
-			Bucket: aws.String(a.config.S3Bucket),
-			Key:    aws.String(path),
-		})
-	if err != nil {
-		return nil, err
-	}
-
-	return buffer.Bytes(), nil
-}
-
-// LoadProfileImage loads profile image at specific path
-func (a *Adapter) LoadProfileImage(path string) ([]byte, error) {
-	s, err := a.createS3Session(false)
-	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
-	}
-
-	buffer := aws.NewWriteAtBuffer([]byte{})
-
-	downloader := s3manager.NewDownloader(s)
-	_, err = downloader.Download(buffer,
-		&s3.GetObjectInput{
-			Bucket: aws.String(a.config.S3ProfileImagesBucket),
-			Key:    aws.String(path),
-		})
-	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return buffer.Bytes(), nil
-}
-
-// CreateImage uploads an image instance from a file and image type
-func (a *Adapter) CreateImage(body io.Reader, path string, preferredFileName *string) (*string, error) {
-	log.Println("Create image")
-
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
-	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+	cannedACL := "private"
+	if public {
+		cannedACL = "public-read"
 	}
-	key := a.prepareKey(path, preferredFileName)
-	objectLocation, err := a.uploadFileToS3(s, body, a.config.S3Bucket, key, "public-read")
+	objectLocation, err := a.uploadFileToS3(s, body, bucketName, key, cannedACL)
 	if err != nil {
 		log.Printf("Could not upload file")
-		return nil, err
+		return "", err
 	}
-
-	return &objectLocation, nil
+	return objectLocation, nil
 }
 
-// CreateProfileImage uploads a profile image
-func (a *Adapter) CreateProfileImage(body io.Reader, path string, preferredFileName *string) (*string, error) {
-	log.Println("Create profile image")
-
-	s, err := a.createS3Session(false)
+// Download streams an object
+func (a *Adapter) Download(bucket string, key string) (io.ReadCloser, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
 		return nil, err
 	}
-	key := a.prepareKey(path, preferredFileName)
-	objectLocation, err := a.uploadFileToS3(s, body, a.config.S3ProfileImagesBucket, key, "private")
+
+	object, err := s3.New(s).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		log.Printf("Could not upload file")
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, fmt.Errorf("%w: %s", model.ErrObjectNotFound, key)
+		}
 		return nil, err
 	}
-
-	return &objectLocation, nil
+	return object.Body, nil
 }
 
-// DeleteProfileImage deletes profile image at specific path
-func (a *Adapter) DeleteProfileImage(path string) error {
-	s, err := a.createS3Session(false)
+// Delete deletes an object
+func (a *Adapter) Delete(bucket string, key string) error {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
 		return err
 	}
 
-	session := s3.New(s)
-	_, err = session.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: &a.config.S3ProfileImagesBucket,
-		Key:    &path,
+	_, err = s3.New(s).DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
 	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return err
 }
 
-// CreateUserVoiceRecord uploads a voice record for the user
-func (a *Adapter) CreateUserVoiceRecord(fileContent []byte, accountID string) (*string, error) {
-	log.Println("Create user voice record")
-
-	s, err := a.createS3Session(false)
+// List lists the objects which keys start with the prefix
+func (a *Adapter) List(bucket string, prefix string) ([]model.StoredObject, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
 		return nil, err
 	}
-	key := fmt.Sprintf("names-records/%s.m4a", accountID)
-	objectLocation, err := a.uploadFileToS3(s, bytes.NewReader(fileContent), a.config.S3UsersAudiosBucket, key, "private")
+
+	objects := []model.StoredObject{}
+	err = s3.New(s).ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, model.StoredObject{Key: aws.StringValue(object.Key), Size: aws.Int64Value(object.Size),
+				LastModified: aws.TimeValue(object.LastModified)})
+		}
+		return true
+	})
 	if err != nil {
-		log.Printf("Could not upload file")
 		return nil, err
 	}
-
-	return &objectLocation, nil
+	return objects, nil
 }
 
-// LoadUserVoiceRecord loads the voice record for the user
-func (a *Adapter) LoadUserVoiceRecord(accountID string) ([]byte, error) {
-	s, err := a.createS3Session(false)
+// PresignUpload gives a presigned URL for uploading an object directly to S3 by a client application
+func (a *Adapter) PresignUpload(bucket string, key string, public bool) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+		return "", err
 	}
 
-	buffer := aws.NewWriteAtBuffer([]byte{})
-
-	key := fmt.Sprintf("names-records/%s.m4a", accountID)
-
-	downloader := s3manager.NewDownloader(s)
-	_, err = downloader.Download(buffer,
-		&s3.GetObjectInput{
-			Bucket: aws.String(a.config.S3UsersAudiosBucket),
-			Key:    aws.String(key),
-		})
-	if err != nil {
-		return nil, err
+	var acl *string
+	if public {
+		acl = aws.String("public-read")
 	}
-
-	return buffer.Bytes(), nil
+	req, _ := s3.New(s).PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		ACL:    acl,
+	})
+	return req.Presign(time.Duration(a.uploadPresignExpirationMinutes) * time.Minute)
 }
 
-// DeleteUserVoiceRecord deletes the voice record for the user
-func (a *Adapter) DeleteUserVoiceRecord(accountID string) error {
-	s, err := a.createS3Session(false)
+// PresignDownload gives a presigned URL for downloading an object directly from S3 by a client application
+func (a *Adapter) PresignDownload(bucket string, key string) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return err
+		return "", err
 	}
 
-	key := fmt.Sprintf("names-records/%s.m4a", accountID)
-
-	session := s3.New(s)
-	_, err = session.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(a.config.S3UsersAudiosBucket),
+	req, _ := s3.New(s).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
 		Key:    aws.String(key),
 	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return req.Presign(time.Duration(a.downloadPresignExpirationMinutes) * time.Minute)
 }
 
-func (a *Adapter) prepareKey(path string, preferredFileName *string) string {
-	var fileName string
-	if preferredFileName == nil {
-		uuid, _ := uuid.NewUUID() // add uuid for file name
-		fileName = uuid.String()
-	} else {
-		fileName = *preferredFileName
-	}
-
-	if strings.HasSuffix(path, "/") {
-		return path + fmt.Sprintf("%s", fileName) + ".webp"
+// CreateMultipartUpload initiates a multipart upload, it gives the id of the upload
+func (a *Adapter) CreateMultipartUpload(bucket string, key string, public bool) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
+	if err != nil {
+		return "", err
 	}
-	return path + "/" + fmt.Sprintf("%s", fileName) + ".webp"
-}
 
-// UploadFile uploads an file content item to the s3 bucket
-func (a *Adapter) UploadFile(body io.Reader, path string) (*string, error) {
-	log.Println("Upload File")
-
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
-	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+	cannedACL := "private"
+	if public {
+		cannedACL = "public-read"
 	}
-	objectLocation, err := a.uploadFileToS3(s, body, a.config.S3Bucket, path, "private")
+	output, err := s3.New(s).CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		ACL:    aws.String(cannedACL),
+	})
 	if err != nil {
-		log.Printf("Could not upload file")
-		return nil, err
+		return "", err
 	}
-
-	return &objectLocation, nil
+	return aws.StringValue(output.UploadId), nil
 }
 
-// GetPresignedURLsForUpload gets a set of presigned URLs for file upload directly to S3 by a client application
-func (a *Adapter) GetPresignedURLsForUpload(fileKeys, paths []string, publicRead bool) ([]model.FileContentItemRef, error) {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
+// PresignUploadPart gives a presigned URL for uploading a part directly to S3 by a client application
+func (a *Adapter) PresignUploadPart(bucket string, key string, uploadID string, partNumber int) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+		return "", err
 	}
 
-	var acl *string
-	if publicRead {
-		acl = aws.String("public-read")
-	}
+	req, _ := s3.New(s).UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(bucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(int64(partNumber)),
+	})
+	return req.Presign(time.Duration(a.uploadPresignExpirationMinutes) * time.Minute)
+}
 
-	refs := make([]model.FileContentItemRef, len(paths))
-	for i, path := range paths {
-		req, _ := s3.New(s).PutObjectRequest(&s3.PutObjectInput{
-			Bucket: aws.String(a.config.S3Bucket),
-			Key:    aws.String(path),
-			ACL:    acl,
-		})
-		url, err := req.Presign(time.Duration(a.uploadPresignExpirationMinutes) * time.Minute)
-		if err != nil {
-			return nil, err
-		}
-		refs[i] = model.FileContentItemRef{Key: fileKeys[i], URL: url}
-	}
-	return refs, nil
+// UploadPart is not supported, the clients upload the parts to S3 directly
+func (a *Adapter) UploadPart(bucket string, key string, uploadID string, partNumber int, body io.Reader) (string, error) {
+	return "", errors.New("the parts are uploaded to S3 directly")
 }
 
-// DownloadFile loads a file at a specific path
-func (a *Adapter) DownloadFile(path string) ([]byte, error) {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
+// CompleteMultipartUpload assembles the object from the uploaded parts
+func (a *Adapter) CompleteMultipartUpload(bucket string, key string, uploadID string, parts []model.UploadedPart) (string, error) {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+		return "", err
 	}
 
-	// file, err := os.Create(path)
-	// if err != nil {
-	// 	log.Printf("Could not create S3 session")
-	// 	return nil, err
-	// }
-
-	buffer := aws.NewWriteAtBuffer([]byte{})
-
-	downloader := s3manager.NewDownloader(s)
-	_, err = downloader.Download(buffer,
-		&s3.GetObjectInput{
-			Bucket: aws.String(a.config.S3Bucket),
-			Key:    aws.String(path),
-		})
-	if err != nil {
-		return nil, err
+	completedParts := make([]*s3.CompletedPart, len(parts))
+	for i, part := range parts {
+		completedParts[i] = &s3.CompletedPart{ETag: aws.String(part.ETag), PartNumber: aws.Int64(int64(part.PartNumber))}
 	}
-
-	return buffer.Bytes(), nil
-}
-
-// GetPresignedURLsForDownload gets a set of presigned URLs for file download directly from S3 by a client application
-func (a *Adapter) GetPresignedURLsForDownload(fileKeys, paths []string) ([]model.FileContentItemRef, error) {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
+	output, err := s3.New(s).CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completedParts},
+	})
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
-	}
-
-	refs := make([]model.FileContentItemRef, len(paths))
-	for i, path := range paths {
-		req, _ := s3.New(s).GetObjectRequest(&s3.GetObjectInput{
-			Bucket: aws.String(a.config.S3Bucket),
-			Key:    aws.String(path),
-		})
-		url, err := req.Presign(time.Duration(a.downloadPresignExpirationMinutes) * time.Minute)
-		if err != nil {
-			return nil, err
-		}
-		refs[i] = model.FileContentItemRef{Key: fileKeys[i], URL: url}
+		return "", err
 	}
-	return refs, nil
+	return aws.StringValue(output.Location), nil
 }
 
-// StreamDownloadFile streams a file downlod from S3
-func (a *Adapter) StreamDownloadFile(path string) (io.ReadCloser, error) {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
+// AbortMultipartUpload aborts a multipart upload and deletes its uploaded parts
+func (a *Adapter) AbortMultipartUpload(bucket string, key string, uploadID string) error {
+	s, bucketName, err := a.bucketSession(bucket)
 	if err != nil {
-		log.Printf("Could not create S3 session")
-		return nil, err
+		return err
 	}
 
-	file, _ := s3.New(s).GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(a.config.S3Bucket),
-		Key:    aws.String(path),
+	_, err = s3.New(s).AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
 	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchUpload {
+		return nil
+	}
+	return err
+}
 
-	return file.Body, nil
+// VerifyPresigned accepts none, the clients use the presigned URLs with S3 directly
+func (a *Adapter) VerifyPresigned(request model.PresignedObjectRequest) error {
+	return errors.New("the presigned URLs are served by S3")
 }
 
-// DeleteFile deletes file at specific path
-func (a *Adapter) DeleteFile(path string) error {
-	s, err := a.createS3Session(a.config.S3BucketAccelerate)
-	if err != nil {
-		log.Printf("Could not create S3 session")
-		return err
+// bucketSession gives the name of the bucket and a session for it
+func (a *Adapter) bucketSession(bucket string) (*session.Session, string, error) {
+	var bucketName string
+	// only allow S3 transfer accleration on the bucket used for all file types for now
+	accelerate := false
+	switch bucket {
+	case model.ObjectBucketContent:
+		bucketName = a.config.S3Bucket
+		accelerate = a.config.S3BucketAccelerate
+	case model.ObjectBucketProfileImages:
+		bucketName = a.config.S3ProfileImagesBucket
+	case model.ObjectBucketUsersAudios:
+		bucketName = a.config.S3UsersAudiosBucket
+	case model.ObjectBucketQuarantine:
+		if len(a.config.S3QuarantineBucket) == 0 {
+			return nil, "", errors.New("no quarantine bucket is configured")
+		}
+		bucketName = a.config.S3QuarantineBucket
+	default:
+		return nil, "", fmt.Errorf("unknown bucket %s", bucket)
 	}
 
-	session := s3.New(s)
-	_, err = session.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: &a.config.S3Bucket,
-		Key:    &path,
-	})
+	s, err := a.createS3Session(accelerate)
 	if err != nil {
-		return err
+		log.Printf("Could not create S3 session")
+		return nil, "", err
 	}
-
-	return nil
+	return s, bucketName, nil
 }
 
 func (a *Adapter) createS3Session(accelerate bool) (*session.Session, error) {
 	region := a.config.S3Region
 	accessKeyID := a.config.AWSAccessKeyID
 	secretAccessKey := a.config.AWSSecretAccessKey
-	s, err := session.NewSession(&aws.Config{
+	config := &aws.Config{
 		Region: aws.String(region),
 		Credentials: credentials.NewStaticCredentials(
 			accessKeyID,
 			secretAccessKey,
 			""),
 		S3UseAccelerate: &accelerate,
-	})
+	}
+	if len(a.config.S3Endpoint) > 0 {
+		//the S3 compatible services have neither the transfer acceleration nor the virtual hosted buckets
+		config.Endpoint = aws.String(a.config.S3Endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+		config.S3UseAccelerate = aws.Bool(false)
+	}
+	s, err := session.NewSession(config)
 	if err != nil {
 		log.Print(err)
 		return nil, err
@@ -0,0 +1,133 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clamav
+
+import (
+	"bufio"
+	"content/core/model"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the INSTREAM chunks, clamd limits a chunk to StreamMaxLength
+const chunkSize = 64 * 1024
+
+// Adapter implements the Scanner interface with the clamd protocol, the address is host:port or unix:/path/to/clamd.sock
+type Adapter struct {
+	network string
+	address string
+	timeout time.Duration // for connecting and for every read and write, the scan of a large file takes longer
+}
+
+// NewClamAVAdapter creates a new clamd scanner instance
+func NewClamAVAdapter(address string, timeout time.Duration) *Adapter {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network = "unix"
+		address = path
+	}
+	return &Adapter{network: network, address: address, timeout: timeout}
+}
+
+// Scan streams the file to clamd with the INSTREAM command
+func (a *Adapter) Scan(body io.Reader) (*model.ScanResult, error) {
+	conn, err := net.DialTimeout(a.network, a.address, a.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = a.write(conn, []byte("zINSTREAM\x00"))
+	if err != nil {
+		return nil, err
+	}
+	err = a.stream(conn, body)
+	if err != nil {
+		//clamd closes the connection when the stream exceeds its limit, the reply says why
+		reply, replyErr := a.reply(conn)
+		if replyErr == nil && strings.HasSuffix(reply, "ERROR") {
+			return nil, fmt.Errorf("clamd: %s", reply)
+		}
+		return nil, err
+	}
+
+	reply, err := a.reply(conn)
+	if err != nil {
+		return nil, err
+	}
+	return parseReply(reply)
+}
+
+// stream sends the file in length prefixed chunks, a zero length chunk ends it
+func (a *Adapter) stream(conn net.Conn, body io.Reader) error {
+	buffer := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(body, buffer[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buffer[:4], uint32(n))
+			writeErr := a.write(conn, buffer[:4+n])
+			if writeErr != nil {
+				return writeErr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return a.write(conn, []byte{0, 0, 0, 0})
+}
+
+func (a *Adapter) write(conn net.Conn, data []byte) error {
+	err := conn.SetWriteDeadline(time.Now().Add(a.timeout))
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// reply reads the null terminated reply of the z prefixed commands
+func (a *Adapter) reply(conn net.Conn) (string, error) {
+	err := conn.SetReadDeadline(time.Now().Add(a.timeout))
+	if err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && len(reply) > 0) {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(reply, "\x00")), nil
+}
+
+// parseReply reads the replies like "stream: OK" and "stream: Win.Test.EICAR_HDB-1 FOUND"
+func parseReply(reply string) (*model.ScanResult, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return &model.ScanResult{Clean: true}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &model.ScanResult{Clean: false, Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
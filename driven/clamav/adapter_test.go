@@ -0,0 +1,187 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"content/core/model"
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    *model.ScanResult
+		wantErr bool
+	}{
+		{name: "clean", reply: "stream: OK", want: &model.ScanResult{Clean: true}},
+		{name: "found", reply: "stream: Win.Test.EICAR_HDB-1 FOUND", want: &model.ScanResult{Signature: "Win.Test.EICAR_HDB-1"}},
+		{name: "without prefix", reply: "OK", want: &model.ScanResult{Clean: true}},
+		{name: "size limit", reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+		{name: "error", reply: "stream: Can't allocate memory ERROR", wantErr: true},
+		{name: "empty", reply: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReply(tt.reply)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseReply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// clamdStub accepts one connection, reads an INSTREAM command and answers with the reply it gives for the streamed file
+func clamdStub(t *testing.T, reply func(file []byte) string, limit int) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		command, err := reader.ReadString(0)
+		if err != nil || command != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			return
+		}
+
+		var file bytes.Buffer
+		for {
+			var size uint32
+			err = binary.Read(reader, binary.BigEndian, &size)
+			if err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if size > chunkSize {
+				t.Errorf("chunk of %d bytes, at most %d are expected", size, chunkSize)
+			}
+			_, err = io.CopyN(&file, reader, int64(size))
+			if err != nil {
+				return
+			}
+			if limit > 0 && file.Len() > limit {
+				//clamd replies and closes the connection without reading the rest
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+		}
+		conn.Write([]byte(reply(file.Bytes()) + "\x00"))
+	}()
+	return listener.Addr().String()
+}
+
+func TestScan(t *testing.T) {
+	eicar := "X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"
+	scanner := func(file []byte) string {
+		if bytes.Contains(file, []byte("EICAR")) {
+			return "stream: Win.Test.EICAR_HDB-1 FOUND"
+		}
+		return "stream: OK"
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		reply   func(file []byte) string
+		limit   int
+		want    *model.ScanResult
+		wantErr bool
+	}{
+		{name: "clean", file: "plain text", reply: scanner, want: &model.ScanResult{Clean: true}},
+		{name: "empty", file: "", reply: scanner, want: &model.ScanResult{Clean: true}},
+		{name: "flagged", file: eicar, reply: scanner, want: &model.ScanResult{Signature: "Win.Test.EICAR_HDB-1"}},
+		{name: "flagged in a later chunk", file: strings.Repeat("a", 3*chunkSize) + eicar, reply: scanner, want: &model.ScanResult{Signature: "Win.Test.EICAR_HDB-1"}},
+		{name: "whole file streamed", file: strings.Repeat("b", 2*chunkSize+1), reply: func(file []byte) string {
+			if len(file) != 2*chunkSize+1 {
+				return "stream: truncated ERROR"
+			}
+			return "stream: OK"
+		}, want: &model.ScanResult{Clean: true}},
+		{name: "scanner error", file: "plain text", reply: func(file []byte) string { return "stream: Can't allocate memory ERROR" }, wantErr: true},
+		{name: "size limit", file: strings.Repeat("c", 64*chunkSize), reply: scanner, limit: chunkSize, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := clamdStub(t, tt.reply, tt.limit)
+
+			got, err := NewClamAVAdapter(address, 5*time.Second).Scan(strings.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	got, err := NewClamAVAdapter(address, time.Second).Scan(strings.NewReader("plain text"))
+	if err == nil {
+		t.Errorf("Scan() = %+v, want an error", got)
+	}
+}
+
+func TestNewClamAVAdapter(t *testing.T) {
+	tests := []struct {
+		address     string
+		wantNetwork string
+		wantAddress string
+	}{
+		{address: "localhost:3310", wantNetwork: "tcp", wantAddress: "localhost:3310"},
+		{address: "unix:/run/clamd.sock", wantNetwork: "unix", wantAddress: "/run/clamd.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			adapter := NewClamAVAdapter(tt.address, time.Second)
+			if adapter.network != tt.wantNetwork || adapter.address != tt.wantAddress {
+				t.Errorf("NewClamAVAdapter() = %s %s, want %s %s", adapter.network, adapter.address, tt.wantNetwork, tt.wantAddress)
+			}
+		})
+	}
+}
@@ -0,0 +1,484 @@
+// Copyright 2025 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstorage
+
+import (
+	"content/core/model"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUploadPresignExpirationMinutes   int = 5
+	defaultDownloadPresignExpirationMinutes int = 60 * 24
+
+	tempFilePrefix = ".upload-"
+
+	//the parts of the incomplete multipart uploads are kept in root/.multipart/<upload id>
+	multipartDir      = ".multipart"
+	multipartInfoFile = "info"
+)
+
+// Adapter implements the ObjectStorage interface with the local file system, every bucket is a directory.
+// The presigned URLs point to this service, which checks their signatures.
+type Adapter struct {
+	root    string
+	baseURL string // the URL the objects are served at, like https://host/content/objects
+	secret  []byte // signs the presigned URLs
+
+	uploadPresignExpirationMinutes   int
+	downloadPresignExpirationMinutes int
+}
+
+// NewLocalStorageAdapter creates a new local storage adapter instance
+func NewLocalStorageAdapter(root string, baseURL string, secret string, uploadPresignExpirationMinutes int, downloadPresignExpirationMinutes int) *Adapter {
+	if uploadPresignExpirationMinutes == 0 {
+		uploadPresignExpirationMinutes = defaultUploadPresignExpirationMinutes
+	}
+	if downloadPresignExpirationMinutes == 0 {
+		downloadPresignExpirationMinutes = defaultDownloadPresignExpirationMinutes
+	}
+	return &Adapter{root: root, baseURL: strings.TrimSuffix(baseURL, "/"), secret: []byte(secret),
+		uploadPresignExpirationMinutes: uploadPresignExpirationMinutes, downloadPresignExpirationMinutes: downloadPresignExpirationMinutes}
+}
+
+// Upload stores an object, the public objects get a download URL which does not expire
+func (a *Adapter) Upload(bucket string, key string, body io.Reader, public bool) (string, error) {
+	path, err := a.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeFile(path, body)
+	if err != nil {
+		return "", err
+	}
+	return a.location(bucket, key, public), nil
+}
+
+// Download streams an object
+func (a *Adapter) Download(bucket string, key string) (io.ReadCloser, error) {
+	path, err := a.objectPath(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", model.ErrObjectNotFound, key)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete deletes an object, deleting a missing object is not an error
+func (a *Adapter) Delete(bucket string, key string) error {
+	path, err := a.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List lists the objects which keys start with the prefix
+func (a *Adapter) List(bucket string, prefix string) ([]model.StoredObject, error) {
+	bucketPath, err := a.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []model.StoredObject{}
+	err = filepath.WalkDir(bucketPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			return nil
+		}
+		relative, err := filepath.Rel(bucketPath, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if !strings.HasPrefix(key, strings.TrimPrefix(prefix, "/")) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, model.StoredObject{Key: key, Size: info.Size(), LastModified: info.ModTime().UTC()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// PresignUpload gives a signed URL for uploading an object through this service with PUT
+func (a *Adapter) PresignUpload(bucket string, key string, public bool) (string, error) {
+	_, err := a.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(time.Duration(a.uploadPresignExpirationMinutes) * time.Minute).Unix()
+	return a.presign(model.PresignedObjectRequest{Method: http.MethodPut, Bucket: bucket, Key: key, Expires: expires}), nil
+}
+
+// PresignDownload gives a signed URL for downloading an object through this service
+func (a *Adapter) PresignDownload(bucket string, key string) (string, error) {
+	_, err := a.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(time.Duration(a.downloadPresignExpirationMinutes) * time.Minute).Unix()
+	return a.presign(model.PresignedObjectRequest{Method: http.MethodGet, Bucket: bucket, Key: key, Expires: expires}), nil
+}
+
+// CreateMultipartUpload initiates a multipart upload, it gives the id of the upload
+func (a *Adapter) CreateMultipartUpload(bucket string, key string, public bool) (string, error) {
+	_, err := a.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	uploadID := uuid.NewString()
+	info := multipartInfo{bucket: bucket, key: strings.TrimPrefix(key, "/"), public: public}
+	err = os.MkdirAll(a.multipartPath(uploadID), 0o755)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(filepath.Join(a.multipartPath(uploadID), multipartInfoFile), []byte(info.String()), 0o644)
+	if err != nil {
+		os.RemoveAll(a.multipartPath(uploadID))
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// PresignUploadPart gives a signed URL for uploading a part through this service with PUT
+func (a *Adapter) PresignUploadPart(bucket string, key string, uploadID string, partNumber int) (string, error) {
+	_, err := a.findMultipartUpload(bucket, key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > model.MaxUploadParts {
+		return "", fmt.Errorf("invalid part number %d", partNumber)
+	}
+	expires := time.Now().Add(time.Duration(a.uploadPresignExpirationMinutes) * time.Minute).Unix()
+	return a.presign(model.PresignedObjectRequest{Method: http.MethodPut, Bucket: bucket, Key: key,
+		UploadID: uploadID, PartNumber: partNumber, Expires: expires}), nil
+}
+
+// UploadPart stores a part of a multipart upload, it gives the ETag of the part. Uploading a part again replaces it.
+func (a *Adapter) UploadPart(bucket string, key string, uploadID string, partNumber int, body io.Reader) (string, error) {
+	_, err := a.findMultipartUpload(bucket, key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > model.MaxUploadParts {
+		return "", fmt.Errorf("invalid part number %d", partNumber)
+	}
+
+	hash := md5.New()
+	err = writeFile(a.partPath(uploadID, partNumber), io.TeeReader(body, hash))
+	if err != nil {
+		return "", err
+	}
+	return etag(hash.Sum(nil)), nil
+}
+
+// CompleteMultipartUpload assembles the object from the uploaded parts, the parts must be in ascending order
+func (a *Adapter) CompleteMultipartUpload(bucket string, key string, uploadID string, parts []model.UploadedPart) (string, error) {
+	info, err := a.findMultipartUpload(bucket, key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		return "", errors.New("no parts")
+	}
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return "", errors.New("the parts are not in ascending order")
+		}
+	}
+	path, err := a.objectPath(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, len(parts))
+	for i, part := range parts {
+		//the client must have the part which was uploaded last
+		partETag, err := fileETag(a.partPath(uploadID, part.PartNumber))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("part %d is not uploaded", part.PartNumber)
+			}
+			return "", err
+		}
+		if partETag != part.ETag {
+			return "", fmt.Errorf("part %d has a different etag", part.PartNumber)
+		}
+		paths[i] = a.partPath(uploadID, part.PartNumber)
+	}
+
+	reader := &partsReader{paths: paths}
+	err = writeFile(path, reader)
+	reader.close()
+	if err != nil {
+		return "", err
+	}
+	os.RemoveAll(a.multipartPath(uploadID))
+	return a.location(bucket, key, info.public), nil
+}
+
+// AbortMultipartUpload aborts a multipart upload and deletes its uploaded parts, aborting a missing upload is not an error
+func (a *Adapter) AbortMultipartUpload(bucket string, key string, uploadID string) error {
+	_, err := a.findMultipartUpload(bucket, key, uploadID)
+	if err != nil {
+		if errors.Is(err, model.ErrMultipartUploadNotFound) {
+			return nil
+		}
+		return err
+	}
+	return os.RemoveAll(a.multipartPath(uploadID))
+}
+
+// VerifyPresigned checks the signature and the expiry of a presigned URL, the URLs of the public objects expire never
+func (a *Adapter) VerifyPresigned(request model.PresignedObjectRequest) error {
+	if request.Expires == 0 && (request.Method != http.MethodGet || len(request.UploadID) > 0) {
+		return errors.New("only the downloads do not expire")
+	}
+	if request.Expires != 0 && time.Now().Unix() > request.Expires {
+		return errors.New("the URL has expired")
+	}
+	expected := a.signature(request)
+	if !hmac.Equal([]byte(expected), []byte(request.Signature)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// multipartInfo is what the multipart upload directory knows about its object
+type multipartInfo struct {
+	bucket string
+	key    string
+	public bool
+}
+
+func (i multipartInfo) String() string {
+	return i.bucket + "\n" + i.key + "\n" + strconv.FormatBool(i.public)
+}
+
+// findMultipartUpload loads the info of a multipart upload, it must be for the same object
+func (a *Adapter) findMultipartUpload(bucket string, key string, uploadID string) (*multipartInfo, error) {
+	if uuid.Validate(uploadID) != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, uploadID)
+	}
+	data, err := os.ReadFile(filepath.Join(a.multipartPath(uploadID), multipartInfoFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, uploadID)
+		}
+		return nil, err
+	}
+	fields := strings.Split(string(data), "\n")
+	if len(fields) != 3 || fields[0] != bucket || fields[1] != strings.TrimPrefix(key, "/") {
+		return nil, fmt.Errorf("%w: %s", model.ErrMultipartUploadNotFound, uploadID)
+	}
+	return &multipartInfo{bucket: fields[0], key: fields[1], public: fields[2] == "true"}, nil
+}
+
+func (a *Adapter) multipartPath(uploadID string) string {
+	return filepath.Join(a.root, multipartDir, uploadID)
+}
+
+func (a *Adapter) partPath(uploadID string, partNumber int) string {
+	return filepath.Join(a.multipartPath(uploadID), fmt.Sprintf("%05d", partNumber))
+}
+
+// writeFile writes to a temporary file first, so that the readers never see a partial file
+func writeFile(path string, body io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), tempFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, body)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// partsReader reads the part files one after the other, only one of them is open at a time
+type partsReader struct {
+	paths   []string
+	current *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			file, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = file
+			r.paths = r.paths[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) close() {
+	if r.current != nil {
+		r.current.Close()
+		r.current = nil
+	}
+}
+
+func fileETag(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return etag(hash.Sum(nil)), nil
+}
+
+// etag is quoted like the ETags of S3
+func etag(md5Sum []byte) string {
+	return `"` + hex.EncodeToString(md5Sum) + `"`
+}
+
+// objectPath gives the file of an object, the keys cannot point outside of the bucket directory
+func (a *Adapter) objectPath(bucket string, key string) (string, error) {
+	bucketPath, err := a.bucketPath(bucket)
+	if err != nil {
+		return "", err
+	}
+	key = strings.TrimPrefix(key, "/")
+	if len(key) == 0 || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("invalid key %s", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." || strings.HasPrefix(part, tempFilePrefix) {
+			return "", fmt.Errorf("invalid key %s", key)
+		}
+	}
+	return filepath.Join(bucketPath, filepath.FromSlash(key)), nil
+}
+
+func (a *Adapter) bucketPath(bucket string) (string, error) {
+	//the buckets cannot be hidden, the multipart uploads are kept in a hidden directory
+	if len(bucket) == 0 || strings.ContainsAny(bucket, `/\`) || strings.HasPrefix(bucket, ".") {
+		return "", fmt.Errorf("invalid bucket %s", bucket)
+	}
+	return filepath.Join(a.root, bucket), nil
+}
+
+func (a *Adapter) objectURL(bucket string, key string) string {
+	parts := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return a.baseURL + "/" + url.PathEscape(bucket) + "/" + strings.Join(parts, "/")
+}
+
+func (a *Adapter) location(bucket string, key string, public bool) string {
+	if public {
+		return a.presign(model.PresignedObjectRequest{Method: http.MethodGet, Bucket: bucket, Key: key})
+	}
+	return a.objectURL(bucket, key)
+}
+
+func (a *Adapter) presign(request model.PresignedObjectRequest) string {
+	query := url.Values{}
+	if len(request.UploadID) > 0 {
+		query.Set("upload_id", request.UploadID)
+		query.Set("part_number", strconv.Itoa(request.PartNumber))
+	}
+	query.Set("expires", strconv.FormatInt(request.Expires, 10))
+	query.Set("signature", a.signature(request))
+	return a.objectURL(request.Bucket, request.Key) + "?" + query.Encode()
+}
+
+func (a *Adapter) signature(request model.PresignedObjectRequest) string {
+	message := request.Method + "\n" + request.Bucket + "\n" + strings.TrimPrefix(request.Key, "/") + "\n" + strconv.FormatInt(request.Expires, 10)
+	if len(request.UploadID) > 0 {
+		//the single object URLs keep their signatures
+		message += "\n" + request.UploadID + "\n" + strconv.Itoa(request.PartNumber)
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
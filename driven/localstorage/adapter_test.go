@@ -0,0 +1,328 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstorage
+
+import (
+	"content/core/model"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectPath(t *testing.T) {
+	root := t.TempDir()
+	adapter := NewLocalStorageAdapter(root, "https://host/content/objects", "secret", 0, 0)
+
+	tests := []struct {
+		name    string
+		bucket  string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "object", bucket: "content", key: "org/app/events/a.png", want: filepath.Join(root, "content", "org", "app", "events", "a.png")},
+		{name: "leading slash", bucket: "content", key: "/a.png", want: filepath.Join(root, "content", "a.png")},
+		{name: "dots in names", bucket: "content", key: "a..b/.c.png", want: filepath.Join(root, "content", "a..b", ".c.png")},
+		{name: "empty key", bucket: "content", key: "", wantErr: true},
+		{name: "directory", bucket: "content", key: "events/", wantErr: true},
+		{name: "parent directory", bucket: "content", key: "../secret", wantErr: true},
+		{name: "parent directory inside", bucket: "content", key: "events/../../secret", wantErr: true},
+		{name: "current directory", bucket: "content", key: "./a.png", wantErr: true},
+		{name: "temp file", bucket: "content", key: "events/" + tempFilePrefix + "1", wantErr: true},
+		{name: "empty bucket", bucket: "", key: "a.png", wantErr: true},
+		{name: "hidden bucket", bucket: multipartDir, key: "a.png", wantErr: true},
+		{name: "bucket with slash", bucket: "content/..", key: "a.png", wantErr: true},
+		{name: "bucket with backslash", bucket: `content\..`, key: "a.png", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := adapter.objectPath(tt.bucket, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("objectPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("objectPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// presignedRequest reads a presigned URL back the way the objects endpoint does
+func presignedRequest(t *testing.T, method string, presignedURL string) model.PresignedObjectRequest {
+	t.Helper()
+	parsed, err := url.Parse(presignedURL)
+	if err != nil {
+		t.Fatalf("invalid presigned URL %s: %s", presignedURL, err)
+	}
+	path := strings.TrimPrefix(parsed.Path, "/content/objects/")
+	bucket, key, _ := strings.Cut(path, "/")
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	partNumber, _ := strconv.Atoi(query.Get("part_number"))
+	return model.PresignedObjectRequest{Method: method, Bucket: bucket, Key: key, UploadID: query.Get("upload_id"),
+		PartNumber: partNumber, Expires: expires, Signature: query.Get("signature")}
+}
+
+func TestVerifyPresigned(t *testing.T) {
+	adapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects/", "secret", 0, 0)
+
+	uploadURL, err := adapter.PresignUpload("content", "/org/app/events/a b.png", false)
+	if err != nil {
+		t.Fatalf("PresignUpload() error = %v", err)
+	}
+	downloadURL, err := adapter.PresignDownload("content", "org/app/events/a.png")
+	if err != nil {
+		t.Fatalf("PresignDownload() error = %v", err)
+	}
+	uploadID, err := adapter.CreateMultipartUpload("content", "org/app/events/big.bin", false)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload() error = %v", err)
+	}
+	partURL, err := adapter.PresignUploadPart("content", "org/app/events/big.bin", uploadID, 2)
+	if err != nil {
+		t.Fatalf("PresignUploadPart() error = %v", err)
+	}
+	publicURL := adapter.location("content", "org/app/events/public.png", true)
+
+	upload := presignedRequest(t, http.MethodPut, uploadURL)
+	download := presignedRequest(t, http.MethodGet, downloadURL)
+	part := presignedRequest(t, http.MethodPut, partURL)
+	public := presignedRequest(t, http.MethodGet, publicURL)
+
+	change := func(request model.PresignedObjectRequest, change func(request *model.PresignedObjectRequest)) model.PresignedObjectRequest {
+		change(&request)
+		return request
+	}
+	otherAdapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects", "other secret", 0, 0)
+
+	tests := []struct {
+		name    string
+		adapter *Adapter
+		request model.PresignedObjectRequest
+		wantErr bool
+	}{
+		{name: "upload", adapter: adapter, request: upload},
+		{name: "download", adapter: adapter, request: download},
+		{name: "part", adapter: adapter, request: part},
+		{name: "public download", adapter: adapter, request: public},
+		{name: "other method", adapter: adapter, request: change(upload, func(r *model.PresignedObjectRequest) { r.Method = http.MethodGet }), wantErr: true},
+		{name: "other key", adapter: adapter, request: change(upload, func(r *model.PresignedObjectRequest) { r.Key = "org/app/events/b.png" }), wantErr: true},
+		{name: "other bucket", adapter: adapter, request: change(download, func(r *model.PresignedObjectRequest) { r.Bucket = "profile_images" }), wantErr: true},
+		{name: "extended expiry", adapter: adapter, request: change(upload, func(r *model.PresignedObjectRequest) { r.Expires += 3600 }), wantErr: true},
+		{name: "expired", adapter: adapter, request: change(upload, func(r *model.PresignedObjectRequest) {
+			r.Expires = time.Now().Add(-time.Minute).Unix()
+			r.Signature = adapter.signature(*r)
+		}), wantErr: true},
+		{name: "upload without expiry", adapter: adapter, request: change(upload, func(r *model.PresignedObjectRequest) {
+			r.Expires = 0
+			r.Signature = adapter.signature(*r)
+		}), wantErr: true},
+		{name: "other part", adapter: adapter, request: change(part, func(r *model.PresignedObjectRequest) { r.PartNumber = 3 }), wantErr: true},
+		{name: "part as object", adapter: adapter, request: change(part, func(r *model.PresignedObjectRequest) { r.UploadID, r.PartNumber = "", 0 }), wantErr: true},
+		{name: "missing signature", adapter: adapter, request: change(download, func(r *model.PresignedObjectRequest) { r.Signature = "" }), wantErr: true},
+		{name: "other secret", adapter: otherAdapter, request: download, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.adapter.VerifyPresigned(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyPresigned() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	adapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects", "secret", 0, 0)
+	key := "org/app/events/big.bin"
+
+	uploadID, err := adapter.CreateMultipartUpload("content", key, false)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload() error = %v", err)
+	}
+	//the parts may be uploaded in any order and again
+	etags := map[int]string{}
+	for _, part := range []struct {
+		number int
+		body   string
+	}{{2, "second "}, {1, "wrong "}, {3, "third"}, {1, "first "}} {
+		etags[part.number], err = adapter.UploadPart("content", key, uploadID, part.number, strings.NewReader(part.body))
+		if err != nil {
+			t.Fatalf("UploadPart() error = %v", err)
+		}
+	}
+
+	parts := []model.UploadedPart{{PartNumber: 1, ETag: etags[1]}, {PartNumber: 2, ETag: etags[2]}, {PartNumber: 3, ETag: etags[3]}}
+	_, err = adapter.CompleteMultipartUpload("content", key, uploadID, parts)
+	if err != nil {
+		t.Fatalf("CompleteMultipartUpload() error = %v", err)
+	}
+
+	file, err := adapter.Download("content", key)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("reading the object error = %v", err)
+	}
+	if string(data) != "first second third" {
+		t.Errorf("object = %q, want %q", data, "first second third")
+	}
+	if _, err := os.Stat(adapter.multipartPath(uploadID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the parts of the completed upload are kept, stat error = %v", err)
+	}
+	_, err = adapter.UploadPart("content", key, uploadID, 1, strings.NewReader("late"))
+	if !errors.Is(err, model.ErrMultipartUploadNotFound) {
+		t.Errorf("UploadPart() of a completed upload error = %v, want %v", err, model.ErrMultipartUploadNotFound)
+	}
+}
+
+func TestCompleteMultipartUploadErrors(t *testing.T) {
+	adapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects", "secret", 0, 0)
+	key := "org/app/events/big.bin"
+
+	tests := []struct {
+		name    string
+		key     string
+		upload  []int
+		parts   func(etags map[int]string) []model.UploadedPart
+		wantErr string
+	}{
+		{name: "no parts", upload: []int{1},
+			parts: func(etags map[int]string) []model.UploadedPart { return nil }, wantErr: "no parts"},
+		{name: "descending parts", upload: []int{1, 2},
+			parts: func(etags map[int]string) []model.UploadedPart {
+				return []model.UploadedPart{{PartNumber: 2, ETag: etags[2]}, {PartNumber: 1, ETag: etags[1]}}
+			}, wantErr: "the parts are not in ascending order"},
+		{name: "repeated part", upload: []int{1},
+			parts: func(etags map[int]string) []model.UploadedPart {
+				return []model.UploadedPart{{PartNumber: 1, ETag: etags[1]}, {PartNumber: 1, ETag: etags[1]}}
+			}, wantErr: "the parts are not in ascending order"},
+		{name: "missing part", upload: []int{1},
+			parts: func(etags map[int]string) []model.UploadedPart {
+				return []model.UploadedPart{{PartNumber: 1, ETag: etags[1]}, {PartNumber: 2, ETag: `"a"`}}
+			}, wantErr: "part 2 is not uploaded"},
+		{name: "other etag", upload: []int{1},
+			parts: func(etags map[int]string) []model.UploadedPart {
+				return []model.UploadedPart{{PartNumber: 1, ETag: `"a"`}}
+			}, wantErr: "part 1 has a different etag"},
+		{name: "other key", key: "org/app/events/other.bin", upload: []int{1},
+			parts: func(etags map[int]string) []model.UploadedPart {
+				return []model.UploadedPart{{PartNumber: 1, ETag: etags[1]}}
+			}, wantErr: model.ErrMultipartUploadNotFound.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploadID, err := adapter.CreateMultipartUpload("content", key, false)
+			if err != nil {
+				t.Fatalf("CreateMultipartUpload() error = %v", err)
+			}
+			etags := map[int]string{}
+			for _, partNumber := range tt.upload {
+				etags[partNumber], err = adapter.UploadPart("content", key, uploadID, partNumber, strings.NewReader("part"))
+				if err != nil {
+					t.Fatalf("UploadPart() error = %v", err)
+				}
+			}
+			completeKey := key
+			if len(tt.key) > 0 {
+				completeKey = tt.key
+			}
+
+			_, err = adapter.CompleteMultipartUpload("content", completeKey, uploadID, tt.parts(etags))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CompleteMultipartUpload() error = %v, want %s", err, tt.wantErr)
+			}
+			//a failed completion keeps the parts for another attempt
+			if _, err := os.Stat(adapter.partPath(uploadID, tt.upload[0])); err != nil {
+				t.Errorf("the parts of the upload are removed, stat error = %v", err)
+			}
+			if _, err := adapter.Download("content", completeKey); !errors.Is(err, model.ErrObjectNotFound) {
+				t.Errorf("Download() error = %v, want %v", err, model.ErrObjectNotFound)
+			}
+		})
+	}
+}
+
+func TestUploadPartNumbers(t *testing.T) {
+	adapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects", "secret", 0, 0)
+	key := "org/app/events/big.bin"
+	uploadID, err := adapter.CreateMultipartUpload("content", key, false)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		uploadID   string
+		partNumber int
+		wantErr    bool
+	}{
+		{name: "first part", uploadID: uploadID, partNumber: 1},
+		{name: "last part", uploadID: uploadID, partNumber: model.MaxUploadParts},
+		{name: "part zero", uploadID: uploadID, partNumber: 0, wantErr: true},
+		{name: "part after the last", uploadID: uploadID, partNumber: model.MaxUploadParts + 1, wantErr: true},
+		{name: "unknown upload", uploadID: "00000000-0000-0000-0000-000000000000", partNumber: 1, wantErr: true},
+		{name: "upload id with a path", uploadID: "../" + uploadID, partNumber: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := adapter.UploadPart("content", key, tt.uploadID, tt.partNumber, strings.NewReader("part"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UploadPart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAbortMultipartUpload(t *testing.T) {
+	adapter := NewLocalStorageAdapter(t.TempDir(), "https://host/content/objects", "secret", 0, 0)
+	key := "org/app/events/big.bin"
+	uploadID, err := adapter.CreateMultipartUpload("content", key, false)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload() error = %v", err)
+	}
+	_, err = adapter.UploadPart("content", key, uploadID, 1, strings.NewReader("part"))
+	if err != nil {
+		t.Fatalf("UploadPart() error = %v", err)
+	}
+
+	err = adapter.AbortMultipartUpload("content", key, uploadID)
+	if err != nil {
+		t.Fatalf("AbortMultipartUpload() error = %v", err)
+	}
+	if _, err := os.Stat(adapter.multipartPath(uploadID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the parts of the aborted upload are kept, stat error = %v", err)
+	}
+	//the sweeper may abort an upload again
+	err = adapter.AbortMultipartUpload("content", key, uploadID)
+	if err != nil {
+		t.Errorf("AbortMultipartUpload() of an aborted upload error = %v", err)
+	}
+}
@@ -17,9 +17,11 @@ package storage
 import (
 	"content/core/interfaces"
 	"content/core/model"
+	"content/utils"
 	"context"
 	"fmt"
 	"log"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -45,6 +47,14 @@ func (sa *Adapter) Start() error {
 	return err
 }
 
+// RegisterStorageListener registers a listener for the content changes
+func (sa *Adapter) RegisterStorageListener(listener interfaces.StorageListener) {
+	sa.db.listenersLock.Lock()
+	defer sa.db.listenersLock.Unlock()
+
+	sa.db.listeners = append(sa.db.listeners, listener)
+}
+
 // PerformTransaction performs a transaction
 func (sa *Adapter) PerformTransaction(transaction func(storage interfaces.Storage) error) error {
 	// transaction
@@ -83,9 +93,7 @@ func (sa *Adapter) GetStudentGuides(appID string, orgID string, ids []string) ([
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID}}
 	if len(ids) > 0 {
-		filter = bson.D{
-			primitive.E{Key: "_id", Value: bson.M{"$in": ids}},
-		}
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$in": ids}})
 	}
 
 	var result []bson.M
@@ -179,9 +187,7 @@ func (sa *Adapter) GetHealthLocations(appID string, orgID string, ids []string)
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID}}
 	if len(ids) > 0 {
-		filter = bson.D{
-			primitive.E{Key: "_id", Value: bson.M{"$in": ids}},
-		}
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$in": ids}})
 	}
 
 	var result []bson.M
@@ -296,7 +302,7 @@ func (sa *Adapter) GetContentItemsCategories(appID *string, orgID string) ([]str
 }
 
 // FindContentItems finds content items
-func (sa *Adapter) FindContentItems(appID *string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItem, error) {
+func (sa *Adapter) FindContentItems(appID *string, orgID string, ids []string, categoryList []string, workflowState *string, offset *int64, limit *int64, order *string) ([]model.ContentItem, error) {
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID}}
 	if len(ids) > 0 {
@@ -305,6 +311,9 @@ func (sa *Adapter) FindContentItems(appID *string, orgID string, ids []string, c
 	if categoryList != nil && len(categoryList) > 0 {
 		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categoryList}})
 	}
+	if workflowState != nil {
+		filter = append(filter, primitive.E{Key: "workflow_state", Value: bson.M{"$in": workflowStatesFilterValue([]string{*workflowState})}})
+	}
 
 	findOptions := options.Find()
 	if order != nil && "desc" == *order {
@@ -328,19 +337,8 @@ func (sa *Adapter) FindContentItems(appID *string, orgID string, ids []string, c
 }
 
 // GetContentItems retrieves all content items
-func (sa *Adapter) GetContentItems(appID *string, orgID string, ids []string, categoryList []string, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error) {
-
-	filter := bson.D{
-		primitive.E{Key: "org_id", Value: orgID}}
-	if appID != nil {
-		filter = append(filter, primitive.E{Key: "app_id", Value: appID})
-	}
-	if len(ids) > 0 {
-		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$in": ids}})
-	}
-	if categoryList != nil && len(categoryList) > 0 {
-		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categoryList}})
-	}
+func (sa *Adapter) GetContentItems(appID *string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter, offset *int64, limit *int64, order *string) ([]model.ContentItemResponse, error) {
+	filter := contentItemsFilter(appID, orgID, ids, categoryList, state, workflowState, dataFilter)
 
 	findOptions := options.Find()
 	if order != nil && "desc" == *order {
@@ -354,6 +352,7 @@ func (sa *Adapter) GetContentItems(appID *string, orgID string, ids []string, ca
 	if offset != nil {
 		findOptions.SetSkip(*offset)
 	}
+	findOptions.SetProjection(bson.D{primitive.E{Key: "search_text", Value: 0}})
 
 	var result []model.ContentItemResponse
 	err := sa.db.contentItems.Find(sa.context, filter, &result, findOptions)
@@ -363,8 +362,94 @@ func (sa *Adapter) GetContentItems(appID *string, orgID string, ids []string, ca
 	return result, nil
 }
 
+// GetContentItemsPage retrieves the page of content items which starts after the cursor
+func (sa *Adapter) GetContentItemsPage(appID *string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter,
+	cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.ContentItemsPage, error) {
+	filter := contentItemsFilter(appID, orgID, ids, categoryList, state, workflowState, dataFilter)
+
+	page := model.ContentItemsPage{Items: []model.ContentItemResponse{}}
+	if withTotal {
+		total, err := sa.db.contentItems.CountDocuments(sa.context, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	descending := order != nil && "desc" == *order
+	//one more item tells if there is a next page
+	findOptions := options.Find().
+		SetSort(pageSort(descending)).
+		SetLimit(limit + 1).
+		SetProjection(bson.D{primitive.E{Key: "search_text", Value: 0}})
+
+	var result []model.ContentItemResponse
+	err := sa.db.contentItems.Find(sa.context, appendPageCursorFilter(filter, cursor, descending), &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(result)) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		dateCreated, _ := last["date_created"].(primitive.DateTime)
+		id, _ := last["_id"].(string)
+		nextCursor := model.PageCursor{DateCreated: dateCreated.Time().UTC(), ID: id}.Encode()
+		page.NextCursor = &nextCursor
+	}
+	if result != nil {
+		page.Items = result
+	}
+	return &page, nil
+}
+
+// contentItemsFilter gives the filter used for listing content items
+func contentItemsFilter(appID *string, orgID string, ids []string, categoryList []string, state *string, workflowState *string, dataFilter *utils.Filter) bson.D {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID}}
+	if appID != nil {
+		filter = append(filter, primitive.E{Key: "app_id", Value: appID})
+	}
+	if len(ids) > 0 {
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$in": ids}})
+	}
+	if categoryList != nil && len(categoryList) > 0 {
+		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categoryList}})
+	}
+	filter = appendContentItemStateFilter(filter, state, time.Now().UTC())
+	if workflowState != nil {
+		filter = append(filter, primitive.E{Key: "workflow_state", Value: bson.M{"$in": workflowStatesFilterValue([]string{*workflowState})}})
+	}
+	return appendDataFilter(filter, dataFilter)
+}
+
+// pageSort is the order of the cursor paginated lists, the id makes it stable for the items created at the same time
+func pageSort(descending bool) bson.D {
+	direction := 1
+	if descending {
+		direction = -1
+	}
+	return bson.D{primitive.E{Key: "date_created", Value: direction}, primitive.E{Key: "_id", Value: direction}}
+}
+
+// appendPageCursorFilter narrows the filter down to the items which come after the cursor
+func appendPageCursorFilter(filter bson.D, cursor *model.PageCursor, descending bool) bson.D {
+	if cursor == nil {
+		return filter
+	}
+
+	operator := "$gt"
+	if descending {
+		operator = "$lt"
+	}
+	return append(filter, primitive.E{Key: "$or", Value: bson.A{
+		bson.D{primitive.E{Key: "date_created", Value: bson.M{operator: cursor.DateCreated}}},
+		bson.D{primitive.E{Key: "date_created", Value: cursor.DateCreated}, primitive.E{Key: "_id", Value: bson.M{operator: cursor.ID}}},
+	}})
+}
+
 // CreateContentItem creates a new content item record
 func (sa *Adapter) CreateContentItem(item model.ContentItem) (*model.ContentItem, error) {
+	item.SearchText = searchText(item.Data, item.Locales)
 	_, err := sa.db.contentItems.InsertOne(sa.context, &item)
 	if err != nil {
 		log.Printf("error create content item: %s", err)
@@ -373,14 +458,40 @@ func (sa *Adapter) CreateContentItem(item model.ContentItem) (*model.ContentItem
 	return &item, nil
 }
 
+// IterateContentItems passes the content items of the categories one by one to handle, the oldest first. All the categories are given for empty categoryList.
+func (sa *Adapter) IterateContentItems(appID *string, orgID string, categoryList []string, handle func(item model.ContentItem) error) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+	if len(categoryList) > 0 {
+		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categoryList}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: 1}, primitive.E{Key: "_id", Value: 1}})
+	return sa.db.contentItems.Iterate(sa.context, filter, func(cur *mongo.Cursor) error {
+		var item model.ContentItem
+		err := cur.Decode(&item)
+		if err != nil {
+			return err
+		}
+		item.Data = utils.NormalizeData(item.Data)
+		item.Locales, _ = utils.NormalizeData(item.Locales).(map[string]interface{})
+		return handle(item)
+	}, findOptions)
+}
+
 // GetContentItem retrieves a content item record by id
-func (sa *Adapter) GetContentItem(appID *string, orgID string, id string) (*model.ContentItemResponse, error) {
+func (sa *Adapter) GetContentItem(appID *string, orgID string, id string, state *string, workflowState *string) (*model.ContentItemResponse, error) {
 
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
 		primitive.E{Key: "_id", Value: id}}
+	filter = appendContentItemStateFilter(filter, state, time.Now().UTC())
+	if workflowState != nil {
+		filter = append(filter, primitive.E{Key: "workflow_state", Value: bson.M{"$in": workflowStatesFilterValue([]string{*workflowState})}})
+	}
+	findOptions := options.Find().SetProjection(bson.D{primitive.E{Key: "search_text", Value: 0}})
 	var result []model.ContentItemResponse
-	err := sa.db.contentItems.Find(sa.context, filter, &result, nil)
+	err := sa.db.contentItems.Find(sa.context, filter, &result, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -395,16 +506,34 @@ func (sa *Adapter) GetContentItem(appID *string, orgID string, id string) (*mode
 
 // UpdateContentItem updates a content item record
 func (sa *Adapter) UpdateContentItem(appID *string, orgID string, id string,
-	category string, data interface{}) (*model.ContentItem, error) {
+	category string, data interface{}, defaultLocale string, locales map[string]interface{}, publishAt *time.Time, expireAt *time.Time, workflowState string) (*model.ContentItem, error) {
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
 		primitive.E{Key: "_id", Value: id}}
+	set := bson.D{
+		primitive.E{Key: "category", Value: category},
+		primitive.E{Key: "data", Value: data},
+		primitive.E{Key: "search_text", Value: searchText(data, locales)},
+		primitive.E{Key: "workflow_state", Value: workflowState},
+		primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+	}
+	unset := bson.D{}
+	//the publish window is kept unless a new one is provided
+	if publishAt != nil {
+		set = append(set, primitive.E{Key: "publish_at", Value: publishAt})
+	}
+	if expireAt != nil {
+		set = append(set, primitive.E{Key: "expire_at", Value: expireAt})
+	}
+	if publishAt != nil || expireAt != nil {
+		unset = append(unset, primitive.E{Key: "date_archived", Value: ""})
+	}
+	set, unset = appendLocalesUpdate(set, unset, defaultLocale, locales)
 	update := bson.D{
-		primitive.E{Key: "$set", Value: bson.D{
-			primitive.E{Key: "category", Value: category},
-			primitive.E{Key: "data", Value: data},
-			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
-		}},
+		primitive.E{Key: "$set", Value: set},
+	}
+	if len(unset) > 0 {
+		update = append(update, primitive.E{Key: "$unset", Value: unset})
 	}
 	_, err := sa.db.contentItems.UpdateOne(sa.context, filter, update, nil)
 	if err != nil {
@@ -453,6 +582,7 @@ func (sa *Adapter) SaveContentItem(item model.ContentItem) error {
 		filter = append(filter, primitive.E{Key: "app_id", Value: item.AppID})
 	}
 
+	item.SearchText = searchText(item.Data, item.Locales)
 	opts := options.Replace().SetUpsert(true)
 	err := sa.db.contentItems.ReplaceOne(sa.context, filter, item, opts)
 	if err != nil {
@@ -461,6 +591,139 @@ func (sa *Adapter) SaveContentItem(item model.ContentItem) error {
 	return nil
 }
 
+// ArchiveExpiredContentItems marks as archived all content items which expire time has passed
+func (sa *Adapter) ArchiveExpiredContentItems(now time.Time) (int64, error) {
+	filter := bson.D{primitive.E{Key: "expire_at", Value: bson.M{"$lte": now}},
+		primitive.E{Key: "date_archived", Value: nil}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "date_archived", Value: now},
+			primitive.E{Key: "workflow_state", Value: model.ContentItemWorkflowArchived},
+			primitive.E{Key: "date_updated", Value: now},
+		}},
+	}
+	result, err := sa.db.contentItems.UpdateMany(sa.context, filter, update, nil)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// UpdateContentItemWorkflowState moves a content item to a new workflow state if it is still in one of the expected states
+func (sa *Adapter) UpdateContentItemWorkflowState(appID *string, orgID string, id string, fromStates []string, toState string) (*model.ContentItem, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: id},
+		primitive.E{Key: "workflow_state", Value: bson.M{"$in": workflowStatesFilterValue(fromStates)}}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "workflow_state", Value: toState},
+			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result model.ContentItem
+	err := sa.db.contentItems.FindOneAndUpdate(sa.context, filter, update, &result, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("content item with id: %s is not in any of the states %v", id, fromStates)
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// dataFilterOperators maps the allowed filter operators to the mongo ones
+var dataFilterOperators = map[string]string{
+	utils.FilterOperatorEq:     "$eq",
+	utils.FilterOperatorNe:     "$ne",
+	utils.FilterOperatorGt:     "$gt",
+	utils.FilterOperatorGte:    "$gte",
+	utils.FilterOperatorLt:     "$lt",
+	utils.FilterOperatorLte:    "$lte",
+	utils.FilterOperatorIn:     "$in",
+	utils.FilterOperatorNotIn:  "$nin",
+	utils.FilterOperatorExists: "$exists",
+}
+
+// appendDataFilter narrows the filter down by the fields of the item data
+func appendDataFilter(filter bson.D, dataFilter *utils.Filter) bson.D {
+	if dataFilter == nil || len(dataFilter.Items) == 0 {
+		return filter
+	}
+
+	conditions := bson.A{}
+	for _, item := range dataFilter.Items {
+		operator, ok := dataFilterOperators[item.Operator]
+		if !ok {
+			operator = "$in"
+		}
+
+		var value interface{} = item.Value
+		if operator != "$in" && operator != "$nin" && len(item.Value) > 0 {
+			value = item.Value[0]
+		}
+		conditions = append(conditions, bson.D{primitive.E{Key: item.Field, Value: bson.D{primitive.E{Key: operator, Value: value}}}})
+	}
+	return append(filter, primitive.E{Key: "$and", Value: conditions})
+}
+
+// searchText gives the text the full-text search indexes for an item and its translations
+func searchText(data interface{}, locales map[string]interface{}) string {
+	if len(locales) == 0 {
+		return utils.ExtractSearchText(data)
+	}
+	return utils.ExtractSearchText([]interface{}{data, locales})
+}
+
+// appendLocalesUpdate sets the locale fields of an item, the empty ones are removed. The services pass the stored translations for the ones an update leaves out.
+func appendLocalesUpdate(set bson.D, unset bson.D, defaultLocale string, locales map[string]interface{}) (bson.D, bson.D) {
+	if len(defaultLocale) > 0 {
+		set = append(set, primitive.E{Key: "default_locale", Value: defaultLocale})
+	} else {
+		unset = append(unset, primitive.E{Key: "default_locale", Value: ""})
+	}
+	if len(locales) > 0 {
+		set = append(set, primitive.E{Key: "locales", Value: locales})
+	} else {
+		unset = append(unset, primitive.E{Key: "locales", Value: ""})
+	}
+	return set, unset
+}
+
+// workflowStatesFilterValue gives the values to match the workflow states against,
+// the items without workflow state are considered published
+func workflowStatesFilterValue(states []string) bson.A {
+	values := bson.A{}
+	for _, state := range states {
+		values = append(values, state)
+		if state == model.ContentItemWorkflowPublished {
+			values = append(values, nil)
+		}
+	}
+	return values
+}
+
+// appendContentItemStateFilter narrows the filter down to the content items which are in the desired state at the moment
+func appendContentItemStateFilter(filter bson.D, state *string, now time.Time) bson.D {
+	if state == nil {
+		return filter
+	}
+
+	switch *state {
+	case model.ContentItemStateScheduled:
+		filter = append(filter, primitive.E{Key: "publish_at", Value: bson.M{"$gt": now}})
+	case model.ContentItemStateLive:
+		//missing publish_at and expire_at mean no restriction
+		filter = append(filter, primitive.E{Key: "publish_at", Value: bson.M{"$not": bson.M{"$gt": now}}},
+			primitive.E{Key: "expire_at", Value: bson.M{"$not": bson.M{"$lte": now}}})
+	case model.ContentItemStateExpired:
+		filter = append(filter, primitive.E{Key: "expire_at", Value: bson.M{"$lte": now}})
+	}
+	return filter
+}
+
 // FindAllContentItems finds all content items
 func (sa *Adapter) FindAllContentItems() ([]model.ContentItemResponse, error) {
 	filter := bson.D{}
@@ -472,159 +735,569 @@ func (sa *Adapter) FindAllContentItems() ([]model.ContentItemResponse, error) {
 	return result, nil
 }
 
-// CreateDataContentItem creates a data content item
-func (sa *Adapter) CreateDataContentItem(item *model.DataContentItem) (*model.DataContentItem, error) {
+// SearchContentItems finds the content items matching a text, the best matches first
+func (sa *Adapter) SearchContentItems(appID *string, orgID string, text string, categoryList []string, state *string, workflowState *string, limit int64) ([]model.SearchResult, error) {
+	filter := contentItemsFilter(appID, orgID, nil, categoryList, state, workflowState, nil)
+	filter = append(filter, primitive.E{Key: "$text", Value: bson.M{"$search": text}})
 
-	_, err := sa.db.dataContentItems.InsertOne(sa.context, &item)
+	result, err := sa.searchCollection(sa.db.contentItems, filter, limit)
 	if err != nil {
 		return nil, err
 	}
-	return item, nil
+	for i := range result {
+		result[i].Collection = "content_items"
+	}
+	return result, nil
 }
 
-// FindDataContentItem gets a data content item
-func (sa *Adapter) FindDataContentItem(appID *string, orgID string, key string) (*model.DataContentItem, error) {
-
-	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
-		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "key", Value: key}}
-
-	var result *model.DataContentItem
-	err := sa.db.dataContentItems.FindOne(sa.context, filter, &result, nil)
+// searchCollection runs a text search filter against a collection and sorts the matches by relevance
+func (sa *Adapter) searchCollection(collection *collectionWrapper, filter bson.D, limit int64) ([]model.SearchResult, error) {
+	score := bson.M{"$meta": "textScore"}
+	findOptions := options.Find().
+		SetProjection(bson.D{primitive.E{Key: "_id", Value: 1},
+			primitive.E{Key: "key", Value: 1},
+			primitive.E{Key: "category", Value: 1},
+			primitive.E{Key: "data", Value: 1},
+			primitive.E{Key: "date_created", Value: 1},
+			primitive.E{Key: "date_updated", Value: 1},
+			primitive.E{Key: "score", Value: score}}).
+		SetSort(bson.D{primitive.E{Key: "score", Value: score}, primitive.E{Key: "_id", Value: 1}}).
+		SetLimit(limit)
+
+	var result []model.SearchResult
+	err := collection.Find(sa.context, filter, &result, findOptions)
 	if err != nil {
 		return nil, err
 	}
+	for i := range result {
+		result[i].Data = utils.NormalizeData(result[i].Data)
+	}
 	return result, nil
 }
 
-// FindDataContentItems gets multiple data content items
-func (sa *Adapter) FindDataContentItems(appID *string, orgID string, category string) ([]*model.DataContentItem, error) {
-	var filter bson.D
-	if len(category) > 0 {
-		filter = bson.D{primitive.E{Key: "app_id", Value: appID},
-			primitive.E{Key: "org_id", Value: orgID},
-			primitive.E{Key: "category", Value: category}}
-	} else {
-		filter = bson.D{primitive.E{Key: "app_id", Value: appID},
-			primitive.E{Key: "org_id", Value: orgID}}
+// CreateContentItemVersion stores a prior revision of a content item
+func (sa *Adapter) CreateContentItemVersion(item model.ContentItemVersion) error {
+	_, err := sa.db.contentItemsVersions.InsertOne(sa.context, &item)
+	if err != nil {
+		log.Printf("error create content item version: %s", err)
+		return err
 	}
+	return nil
+}
 
-	var result []*model.DataContentItem
-	err := sa.db.dataContentItems.Find(sa.context, filter, &result, nil)
+// FindContentItemVersions finds the stored revisions of a content item, newest first
+func (sa *Adapter) FindContentItemVersions(appID *string, orgID string, contentItemID string, offset *int64, limit *int64) ([]model.ContentItemVersion, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "content_item_id", Value: contentItemID}}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{"version": -1})
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+
+	var result []model.ContentItemVersion
+	err := sa.db.contentItemsVersions.Find(sa.context, filter, &result, findOptions)
 	if err != nil {
 		return nil, err
 	}
+	for i := range result {
+		result[i].Data = utils.NormalizeData(result[i].Data)
+	}
 	return result, nil
 }
 
-// UpdateDataContentItem updates a data content item
-func (sa *Adapter) UpdateDataContentItem(appID *string, orgID string, item *model.DataContentItem) (*model.DataContentItem, error) {
-
-	filter := bson.D{
-		primitive.E{Key: "app_id", Value: appID},
+// FindContentItemVersion finds a single revision of a content item
+func (sa *Adapter) FindContentItemVersion(appID *string, orgID string, contentItemID string, version int) (*model.ContentItemVersion, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "key", Value: item.Key}}
-	update := bson.D{
-		primitive.E{Key: "$set", Value: bson.D{
-			primitive.E{Key: "category", Value: item.Category},
-			primitive.E{Key: "data", Value: item.Data},
-			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
-		}},
-	}
-	_, err := sa.db.dataContentItems.UpdateOne(sa.context, filter, update, nil)
+		primitive.E{Key: "content_item_id", Value: contentItemID},
+		primitive.E{Key: "version", Value: version}}
+
+	var result []model.ContentItemVersion
+	err := sa.db.contentItemsVersions.Find(sa.context, filter, &result, nil)
 	if err != nil {
-		log.Printf("error updating data content item: %s", err)
 		return nil, err
 	}
-
-	return item, nil
+	if len(result) == 0 {
+		//not found
+		return nil, fmt.Errorf("version %d of content item with id %s - %w", version, contentItemID, model.ErrContentItemVersionNotFound)
+	}
+	item := result[0]
+	item.Data = utils.NormalizeData(item.Data)
+	return &item, nil
 }
 
-// DeleteDataContentItem deletes a data content item
-func (sa *Adapter) DeleteDataContentItem(appID *string, orgID string, key string) error {
-
+// DeleteContentItemVersions deletes all stored revisions of a content item
+func (sa *Adapter) DeleteContentItemVersions(appID *string, orgID string, contentItemID string) error {
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "key", Value: key}}
-
-	result, err := sa.db.dataContentItems.DeleteOne(sa.context, filter, nil)
+		primitive.E{Key: "content_item_id", Value: contentItemID}}
+	_, err := sa.db.contentItemsVersions.DeleteMany(sa.context, filter, nil)
 	if err != nil {
 		return err
 	}
-	if result == nil {
-		return fmt.Errorf("result is nil for data content item with key %s", key)
-	}
-	deletedCount := result.DeletedCount
-	if deletedCount != 1 {
-		return fmt.Errorf("error occured while deleting a data content item with key %s", key)
-	}
 	return nil
 }
 
-// CreateCategory created a new category
-func (sa *Adapter) CreateCategory(item *model.Category) (*model.Category, error) {
-
-	_, err := sa.db.categories.InsertOne(sa.context, &item)
+// CreateDataContentItem creates a data content item
+func (sa *Adapter) CreateDataContentItem(item *model.DataContentItem) (*model.DataContentItem, error) {
+	item.SearchText = searchText(item.Data, item.Locales)
+	_, err := sa.db.dataContentItems.InsertOne(sa.context, &item)
 	if err != nil {
 		return nil, err
 	}
 	return item, nil
 }
 
-// FindCategory fins a category
-func (sa *Adapter) FindCategory(appID *string, orgID string, name string) (*model.Category, error) {
+// FindDataContentItem gets a data content item
+func (sa *Adapter) FindDataContentItem(appID *string, orgID string, key string) (*model.DataContentItem, error) {
+
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "name", Value: name}}
+		primitive.E{Key: "key", Value: key}}
 
-	var result *model.Category
-	err := sa.db.categories.FindOne(sa.context, filter, &result, nil)
+	var result *model.DataContentItem
+	err := sa.db.dataContentItems.FindOne(sa.context, filter, &result, nil)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-// UpdateCategory updates a  category
-func (sa *Adapter) UpdateCategory(appID *string, orgID string, item *model.Category) (*model.Category, error) {
-	filter := bson.D{
-		primitive.E{Key: "app_id", Value: appID},
+// FindDataContentItemByID gets a data content item by its id
+func (sa *Adapter) FindDataContentItemByID(appID *string, orgID string, id string) (*model.DataContentItem, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
 		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "_id", Value: item.ID}}
-	update := bson.D{
-		primitive.E{Key: "$set", Value: bson.D{
-			primitive.E{Key: "name", Value: item.Name},
-			primitive.E{Key: "permissions", Value: item.Permissions},
-			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
-		}},
-	}
-	_, err := sa.db.categories.UpdateOne(sa.context, filter, update, nil)
+		primitive.E{Key: "_id", Value: id}}
+
+	var result *model.DataContentItem
+	err := sa.db.dataContentItems.FindOne(sa.context, filter, &result, nil)
 	if err != nil {
-		log.Printf("error updating category: %s", err)
 		return nil, err
 	}
-
-	return item, nil
+	return result, nil
 }
 
-// DeleteCategory deletes a category
-func (sa *Adapter) DeleteCategory(appID *string, orgID string, name string) error {
+// IterateDataContentItems passes the data content items of the categories one by one to handle, the oldest first. All the categories are given for empty categoryList.
+func (sa *Adapter) IterateDataContentItems(appID *string, orgID string, categoryList []string, handle func(item model.DataContentItem) error) error {
 	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
-		primitive.E{Key: "org_id", Value: orgID},
-		primitive.E{Key: "name", Value: name}}
-
-	result, err := sa.db.categories.DeleteOne(sa.context, filter, nil)
-	if err != nil {
-		return err
-	}
-	if result == nil {
-		return fmt.Errorf("result is nil for cateogry with id %s", name)
-	}
-	deletedCount := result.DeletedCount
-	if deletedCount != 1 {
-		return fmt.Errorf("error occured while deleting a category with id %s", name)
+		primitive.E{Key: "org_id", Value: orgID}}
+	if len(categoryList) > 0 {
+		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categoryList}})
 	}
-	return nil
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: 1}, primitive.E{Key: "_id", Value: 1}})
+	return sa.db.dataContentItems.Iterate(sa.context, filter, func(cur *mongo.Cursor) error {
+		var item model.DataContentItem
+		err := cur.Decode(&item)
+		if err != nil {
+			return err
+		}
+		item.Data = utils.NormalizeData(item.Data)
+		item.Locales, _ = utils.NormalizeData(item.Locales).(map[string]interface{})
+		return handle(item)
+	}, findOptions)
+}
+
+// SaveDataContentItem stores a data content item as a whole, it is created if there is no item with its id
+func (sa *Adapter) SaveDataContentItem(item model.DataContentItem) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "_id", Value: item.ID}}
+
+	item.SearchText = searchText(item.Data, item.Locales)
+	opts := options.Replace().SetUpsert(true)
+	return sa.db.dataContentItems.ReplaceOne(sa.context, filter, item, opts)
+}
+
+// FindDataContentItems gets multiple data content items
+func (sa *Adapter) FindDataContentItems(appID *string, orgID string, categories []string) ([]*model.DataContentItem, error) {
+	filter := dataContentItemsFilter(appID, orgID, categories)
+
+	var result []*model.DataContentItem
+	err := sa.db.dataContentItems.Find(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindDataContentItemsPage gets the page of data content items which starts after the cursor
+func (sa *Adapter) FindDataContentItemsPage(appID *string, orgID string, categories []string, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.DataContentItemsPage, error) {
+	filter := dataContentItemsFilter(appID, orgID, categories)
+
+	page := model.DataContentItemsPage{Items: []*model.DataContentItem{}}
+	if withTotal {
+		total, err := sa.db.dataContentItems.CountDocuments(sa.context, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	descending := order != nil && "desc" == *order
+	findOptions := options.Find().SetSort(pageSort(descending)).SetLimit(limit + 1)
+
+	var result []*model.DataContentItem
+	err := sa.db.dataContentItems.Find(sa.context, appendPageCursorFilter(filter, cursor, descending), &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(result)) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		nextCursor := model.PageCursor{DateCreated: last.DateCreated.UTC(), ID: last.ID}.Encode()
+		page.NextCursor = &nextCursor
+	}
+	if result != nil {
+		page.Items = result
+	}
+	return &page, nil
+}
+
+// dataContentItemsFilter gives the filter used for listing data content items, all the categories when there are no categories
+func dataContentItemsFilter(appID *string, orgID string, categories []string) bson.D {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+	if len(categories) == 1 {
+		filter = append(filter, primitive.E{Key: "category", Value: categories[0]})
+	} else if len(categories) > 1 {
+		filter = append(filter, primitive.E{Key: "category", Value: bson.M{"$in": categories}})
+	}
+	return filter
+}
+
+// SearchDataContentItems finds the data content items matching a text, the best matches first, leaving out the excluded categories
+func (sa *Adapter) SearchDataContentItems(appID *string, orgID string, text string, categoryList []string, excludedCategories []string, limit int64) ([]model.SearchResult, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "$text", Value: bson.M{"$search": text}}}
+	category := bson.M{}
+	if len(categoryList) > 0 {
+		category["$in"] = categoryList
+	}
+	if len(excludedCategories) > 0 {
+		category["$nin"] = excludedCategories
+	}
+	if len(category) > 0 {
+		filter = append(filter, primitive.E{Key: "category", Value: category})
+	}
+
+	result, err := sa.searchCollection(sa.db.dataContentItems, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Collection = "data_content_items"
+	}
+	return result, nil
+}
+
+// UpdateDataContentItem updates a data content item
+func (sa *Adapter) UpdateDataContentItem(appID *string, orgID string, item *model.DataContentItem) (*model.DataContentItem, error) {
+
+	filter := bson.D{
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "key", Value: item.Key}}
+	set := bson.D{
+		primitive.E{Key: "category", Value: item.Category},
+		primitive.E{Key: "data", Value: item.Data},
+		primitive.E{Key: "search_text", Value: searchText(item.Data, item.Locales)},
+		primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+	}
+	set, unset := appendLocalesUpdate(set, bson.D{}, item.DefaultLocale, item.Locales)
+	update := bson.D{primitive.E{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, primitive.E{Key: "$unset", Value: unset})
+	}
+	//give back the stored item, the clients need its id and update time
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result model.DataContentItem
+	err := sa.db.dataContentItems.FindOneAndUpdate(sa.context, filter, update, &result, opts)
+	if err != nil {
+		log.Printf("error updating data content item: %s", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// DeleteDataContentItem deletes a data content item
+func (sa *Adapter) DeleteDataContentItem(appID *string, orgID string, key string) error {
+
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "key", Value: key}}
+
+	result, err := sa.db.dataContentItems.DeleteOne(sa.context, filter, nil)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("result is nil for data content item with key %s", key)
+	}
+	deletedCount := result.DeletedCount
+	if deletedCount != 1 {
+		return fmt.Errorf("error occured while deleting a data content item with key %s", key)
+	}
+	return nil
+}
+
+// CreateCategory created a new category
+func (sa *Adapter) CreateCategory(item *model.Category) (*model.Category, error) {
+
+	_, err := sa.db.categories.InsertOne(sa.context, &item)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// FindCategory fins a category
+func (sa *Adapter) FindCategory(appID *string, orgID string, name string) (*model.Category, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "name", Value: name}}
+
+	var result *model.Category
+	err := sa.db.categories.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindCategoryByID finds a category by its id
+func (sa *Adapter) FindCategoryByID(appID *string, orgID string, id string) (*model.Category, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: id}}
+
+	var result *model.Category
+	err := sa.db.categories.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IterateCategories passes the categories one by one to handle, by name
+func (sa *Adapter) IterateCategories(appID *string, orgID string, handle func(item model.Category) error) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "name", Value: 1}})
+	return sa.db.categories.Iterate(sa.context, filter, func(cur *mongo.Cursor) error {
+		var item model.Category
+		err := cur.Decode(&item)
+		if err != nil {
+			return err
+		}
+		return handle(item)
+	}, findOptions)
+}
+
+// SaveCategory stores a category as a whole, it is created if there is no category with its id
+func (sa *Adapter) SaveCategory(item model.Category) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "_id", Value: item.ID}}
+
+	opts := options.Replace().SetUpsert(true)
+	return sa.db.categories.ReplaceOne(sa.context, filter, item, opts)
+}
+
+// UpdateCategory updates a  category
+func (sa *Adapter) UpdateCategory(appID *string, orgID string, item *model.Category) (*model.Category, error) {
+	filter := bson.D{
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: item.ID}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "name", Value: item.Name},
+			primitive.E{Key: "parent", Value: item.Parent},
+			primitive.E{Key: "read_permissions", Value: item.ReadPermissions},
+			primitive.E{Key: "permissions", Value: item.Permissions},
+			primitive.E{Key: "delete_permissions", Value: item.DeletePermissions},
+			primitive.E{Key: "requires_auth", Value: item.RequiresAuth},
+			primitive.E{Key: "schema", Value: item.Schema},
+			primitive.E{Key: "file_policy", Value: item.FilePolicy},
+			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+		}},
+	}
+	_, err := sa.db.categories.UpdateOne(sa.context, filter, update, nil)
+	if err != nil {
+		log.Printf("error updating category: %s", err)
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// RenameCategoryReferences moves the items, the subcategories, the history, the file records, the usage, the quotas and the webhooks of a category to its new name
+func (sa *Adapter) RenameCategoryReferences(appID *string, orgID string, oldName string, newName string) error {
+	filter := func(key string) bson.D {
+		return bson.D{primitive.E{Key: "app_id", Value: appID},
+			primitive.E{Key: "org_id", Value: orgID},
+			primitive.E{Key: key, Value: oldName}}
+	}
+	update := func(key string) bson.D {
+		return bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: key, Value: newName}}}}
+	}
+
+	_, err := sa.db.contentItems.UpdateMany(sa.context, filter("category"), update("category"), nil)
+	if err != nil {
+		return err
+	}
+	_, err = sa.db.dataContentItems.UpdateMany(sa.context, filter("category"), update("category"), nil)
+	if err != nil {
+		return err
+	}
+	_, err = sa.db.categories.UpdateMany(sa.context, filter("parent"), update("parent"), nil)
+	if err != nil {
+		return err
+	}
+
+	//the history, the file records, the usage and the quotas keep the category of the items
+	for _, collection := range []*collectionWrapper{sa.db.contentItemsVersions, sa.db.fileContentItems, sa.db.storageUsage, sa.db.storageQuotas} {
+		_, err = collection.UpdateMany(sa.context, filter("category"), update("category"), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	//the webhooks are subscribed to the categories by name
+	webhooksUpdate := bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "categories.$[category]", Value: newName}}}}
+	webhooksOptions := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"category": oldName}}})
+	_, err = sa.db.webhooks.UpdateMany(sa.context, filter("categories"), webhooksUpdate, webhooksOptions)
+	return err
+}
+
+// DeleteCategoryReferences deletes the records of the quarantined files, the usage and the quotas of a deleted category
+func (sa *Adapter) DeleteCategoryReferences(appID *string, orgID string, name string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "category", Value: name}}
+
+	for _, collection := range []*collectionWrapper{sa.db.fileContentItems, sa.db.storageUsage, sa.db.storageQuotas} {
+		_, err := collection.DeleteMany(sa.context, filter, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindCategoriesPage finds a page of the categories, the item counts are not set
+func (sa *Adapter) FindCategoriesPage(appID *string, orgID string, cursor *model.PageCursor, limit int64, order *string, withTotal bool) (*model.CategoriesPage, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+
+	page := model.CategoriesPage{Items: []model.CategorySummary{}}
+	if withTotal {
+		total, err := sa.db.categories.CountDocuments(sa.context, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	descending := order != nil && "desc" == *order
+	findOptions := options.Find().SetSort(pageSort(descending)).SetLimit(limit + 1)
+
+	var result []model.CategorySummary
+	err := sa.db.categories.Find(sa.context, appendPageCursorFilter(filter, cursor, descending), &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(result)) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		nextCursor := model.PageCursor{DateCreated: last.DateCreated.UTC(), ID: last.ID}.Encode()
+		page.NextCursor = &nextCursor
+	}
+	if result != nil {
+		page.Items = result
+	}
+	return &page, nil
+}
+
+type categoryCountData struct {
+	Category string `bson:"_id"`
+	Count    int64  `bson:"count"`
+}
+
+// CountContentItemsByCategory counts the content items of the categories, the categories without items are not in the result
+func (sa *Adapter) CountContentItemsByCategory(appID *string, orgID string, categories []string) (map[string]int64, error) {
+	return sa.countByCategory(sa.db.contentItems, appID, orgID, categories)
+}
+
+// CountDataContentItemsByCategory counts the data content items of the categories, the categories without items are not in the result
+func (sa *Adapter) CountDataContentItemsByCategory(appID *string, orgID string, categories []string) (map[string]int64, error) {
+	return sa.countByCategory(sa.db.dataContentItems, appID, orgID, categories)
+}
+
+func (sa *Adapter) countByCategory(collection *collectionWrapper, appID *string, orgID string, categories []string) (map[string]int64, error) {
+	pipeline := primitive.A{
+		bson.M{"$match": bson.M{"app_id": appID, "org_id": orgID, "category": bson.M{"$in": categories}}},
+		bson.M{"$group": bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}},
+	}
+	var data []categoryCountData
+	err := collection.Aggregate(sa.context, pipeline, &data, &options.AggregateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{}
+	for _, entry := range data {
+		counts[entry.Category] = entry.Count
+	}
+	return counts, nil
+}
+
+// DeleteContentItemsByCategory deletes all the content items of a category
+func (sa *Adapter) DeleteContentItemsByCategory(appID *string, orgID string, category string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "category", Value: category}}
+	_, err := sa.db.contentItems.DeleteMany(sa.context, filter, nil)
+	return err
+}
+
+// DeleteDataContentItemsByCategory deletes all the data content items of a category
+func (sa *Adapter) DeleteDataContentItemsByCategory(appID *string, orgID string, category string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "category", Value: category}}
+	_, err := sa.db.dataContentItems.DeleteMany(sa.context, filter, nil)
+	return err
+}
+
+// DeleteCategory deletes a category
+func (sa *Adapter) DeleteCategory(appID *string, orgID string, name string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "name", Value: name}}
+
+	result, err := sa.db.categories.DeleteOne(sa.context, filter, nil)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("result is nil for cateogry with id %s", name)
+	}
+	deletedCount := result.DeletedCount
+	if deletedCount != 1 {
+		return fmt.Errorf("error occured while deleting a category with id %s", name)
+	}
+	return nil
 }
 
 // StoreMultiTenancyData stores multi-tenancy to already exisiting data in the collections
@@ -656,14 +1329,17 @@ func (sa *Adapter) StoreMultiTenancyData(appID string, orgID string) error {
 	return nil
 }
 
-// CreateMetaData creates meta_data object
-func (sa *Adapter) CreateMetaData(key string, value map[string]interface{}) (*model.MetaData, error) {
+// CreateMetaData creates meta_data object, appID and orgID are nil for the global meta data
+func (sa *Adapter) CreateMetaData(appID *string, orgID *string, key string, value map[string]interface{}, permissions []string) (*model.MetaData, error) {
 	now := time.Now()
 	id, _ := uuid.NewUUID()
 	item := model.MetaData{
 		ID:          id.String(),
+		AppID:       appID,
+		OrgID:       orgID,
 		Key:         key,
 		Value:       value,
+		Permissions: permissions,
 		DateCreated: now,
 	}
 
@@ -675,9 +1351,11 @@ func (sa *Adapter) CreateMetaData(key string, value map[string]interface{}) (*mo
 	return &item, nil
 }
 
-// FindMetaData find meta_data object
-func (sa *Adapter) FindMetaData(key *string) (*model.MetaData, error) {
-	filter := bson.D{primitive.E{Key: "key", Value: key}}
+// FindMetaData find meta_data object, appID and orgID are nil for the global meta data
+func (sa *Adapter) FindMetaData(appID *string, orgID *string, key *string) (*model.MetaData, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "key", Value: key}}
 
 	var result *model.MetaData
 	err := sa.db.metaData.FindOne(sa.context, filter, &result, nil)
@@ -687,9 +1365,35 @@ func (sa *Adapter) FindMetaData(key *string) (*model.MetaData, error) {
 	return result, nil
 }
 
-// DeleteMetaData deletes meta_data object
-func (sa *Adapter) DeleteMetaData(key string) error {
-	filter := bson.D{primitive.E{Key: "key", Value: key}}
+// FindMetaDataList finds the meta data of a scope by key, keyPrefix narrows it down to the keys starting with it
+func (sa *Adapter) FindMetaDataList(appID *string, orgID *string, keyPrefix *string, offset *int64, limit *int64) ([]model.MetaData, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+	if keyPrefix != nil && len(*keyPrefix) > 0 {
+		filter = append(filter, primitive.E{Key: "key", Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(*keyPrefix)}})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "key", Value: 1}})
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+
+	result := []model.MetaData{}
+	err := sa.db.metaData.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteMetaData deletes meta_data object, appID and orgID are nil for the global meta data
+func (sa *Adapter) DeleteMetaData(appID *string, orgID *string, key string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "key", Value: key}}
 
 	result, err := sa.db.metaData.DeleteOne(sa.context, filter, nil)
 	if err != nil {
@@ -706,24 +1410,45 @@ func (sa *Adapter) DeleteMetaData(key string) error {
 }
 
 // UpdateMetaData updates a  metaData
-func (sa *Adapter) UpdateMetaData(item *model.MetaData, value map[string]interface{}) (*model.MetaData, error) {
+func (sa *Adapter) UpdateMetaData(item *model.MetaData, value map[string]interface{}, permissions []string) (*model.MetaData, error) {
 	filter := bson.D{
-		primitive.E{Key: "key", Value: item.Key}}
+		primitive.E{Key: "_id", Value: item.ID}}
+	now := time.Now().UTC()
 	update := bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "value", Value: value},
-			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+			primitive.E{Key: "permissions", Value: permissions},
+			primitive.E{Key: "date_updated", Value: now},
 		}},
 	}
 	_, err := sa.db.metaData.UpdateOne(sa.context, filter, update, nil)
 	if err != nil {
-		log.Printf("error updating category: %s", err)
+		log.Printf("error updating meta data: %s", err)
 		return nil, err
 	}
 
+	item.Value = value
+	item.Permissions = permissions
+	item.DateUpdated = &now
 	return item, nil
 }
 
+// StoreMetaDataMultiTenancyData moves the meta data stored before it was scoped to an app and an organization
+func (sa *Adapter) StoreMetaDataMultiTenancyData(appID string, orgID string) (int64, error) {
+	filter := bson.D{primitive.E{Key: "org_id", Value: bson.M{"$exists": false}}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "app_id", Value: appID},
+			primitive.E{Key: "org_id", Value: orgID},
+		}},
+	}
+	result, err := sa.db.metaData.UpdateMany(sa.context, filter, update, nil)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (sa *Adapter) abortTransaction(sessionContext mongo.SessionContext) {
 	err := sessionContext.AbortTransaction(sessionContext)
 	if err != nil {
@@ -731,6 +1456,141 @@ func (sa *Adapter) abortTransaction(sessionContext mongo.SessionContext) {
 	}
 }
 
+// CreateWebhook creates a webhook
+func (sa *Adapter) CreateWebhook(item model.Webhook) error {
+	_, err := sa.db.webhooks.InsertOne(sa.context, item)
+	return err
+}
+
+// FindWebhooks finds the webhooks of an app and organization
+func (sa *Adapter) FindWebhooks(appID *string, orgID string) ([]model.Webhook, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: 1}})
+
+	var result []model.Webhook
+	err := sa.db.webhooks.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindWebhook finds a webhook
+func (sa *Adapter) FindWebhook(appID *string, orgID string, id string) (*model.Webhook, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: id}}
+
+	var result *model.Webhook
+	err := sa.db.webhooks.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateWebhook updates a webhook
+func (sa *Adapter) UpdateWebhook(item model.Webhook) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "_id", Value: item.ID}}
+	return sa.db.webhooks.ReplaceOne(sa.context, filter, item, nil)
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (sa *Adapter) DeleteWebhook(appID *string, orgID string, id string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: id}}
+	result, err := sa.db.webhooks.DeleteOne(sa.context, filter, nil)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount != 1 {
+		return fmt.Errorf("webhook with id %s is not found", id)
+	}
+
+	deliveriesFilter := bson.D{primitive.E{Key: "webhook_id", Value: id}}
+	_, err = sa.db.webhookDeliveries.DeleteMany(sa.context, deliveriesFilter, nil)
+	return err
+}
+
+// CreateWebhookDelivery creates a webhook delivery
+func (sa *Adapter) CreateWebhookDelivery(item model.WebhookDelivery) error {
+	_, err := sa.db.webhookDeliveries.InsertOne(sa.context, item)
+	return err
+}
+
+// FindWebhookDeliveries finds the deliveries of a webhook, the latest first
+func (sa *Adapter) FindWebhookDeliveries(appID *string, orgID string, webhookID string, status *string, offset *int64, limit *int64) ([]model.WebhookDelivery, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "webhook_id", Value: webhookID}}
+	if status != nil {
+		filter = append(filter, primitive.E{Key: "status", Value: *status})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+
+	var result []model.WebhookDelivery
+	err := sa.db.webhookDeliveries.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindWebhookDelivery finds a webhook delivery
+func (sa *Adapter) FindWebhookDelivery(appID *string, orgID string, webhookID string, id string) (*model.WebhookDelivery, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "webhook_id", Value: webhookID},
+		primitive.E{Key: "_id", Value: id}}
+
+	var result *model.WebhookDelivery
+	err := sa.db.webhookDeliveries.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ClaimDueWebhookDelivery takes the pending delivery which attempt is due the longest, nil when there is none.
+// Its next attempt is moved after the lease so that the other service instances do not take it meanwhile.
+func (sa *Adapter) ClaimDueWebhookDelivery(now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	filter := bson.D{primitive.E{Key: "status", Value: model.WebhookDeliveryPending},
+		primitive.E{Key: "next_attempt_at", Value: bson.M{"$lte": now}}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "next_attempt_at", Value: now.Add(lease)},
+		}},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{primitive.E{Key: "next_attempt_at", Value: 1}})
+
+	var result model.WebhookDelivery
+	err := sa.db.webhookDeliveries.FindOneAndUpdate(sa.context, filter, update, &result, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateWebhookDelivery updates a webhook delivery
+func (sa *Adapter) UpdateWebhookDelivery(item model.WebhookDelivery) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: item.ID}}
+	return sa.db.webhookDeliveries.ReplaceOne(sa.context, filter, item, nil)
+}
+
 // NewStorageAdapter creates a new storage adapter instance
 func NewStorageAdapter(mongoDBAuth string, mongoDBName string, mongoTimeout string, logger *logs.Logger) *Adapter {
 	timeout, err := strconv.Atoi(mongoTimeout)
@@ -744,6 +1604,302 @@ func NewStorageAdapter(mongoDBAuth string, mongoDBName string, mongoTimeout stri
 	return &Adapter{db: db}
 }
 
+// CreateAuditLogEntry records an admin mutation
+func (sa *Adapter) CreateAuditLogEntry(item model.AuditLogEntry) error {
+	_, err := sa.db.auditLog.InsertOne(sa.context, item)
+	return err
+}
+
+// FindAuditLogEntries finds the audit log entries, the latest first. All the apps within the organization are given for nil appID.
+func (sa *Adapter) FindAuditLogEntries(appID *string, orgID string, accountID *string, resource *string, resourceID *string, category *string,
+	from *time.Time, to *time.Time, offset *int64, limit *int64) ([]model.AuditLogEntry, error) {
+	filter := bson.D{primitive.E{Key: "org_id", Value: orgID}}
+	if appID != nil {
+		filter = append(filter, primitive.E{Key: "app_id", Value: *appID})
+	}
+	if accountID != nil {
+		filter = append(filter, primitive.E{Key: "account_id", Value: *accountID})
+	}
+	if resource != nil {
+		filter = append(filter, primitive.E{Key: "resource", Value: *resource})
+	}
+	if resourceID != nil {
+		filter = append(filter, primitive.E{Key: "resource_id", Value: *resourceID})
+	}
+	if category != nil {
+		filter = append(filter, primitive.E{Key: "category", Value: *category})
+	}
+	if from != nil || to != nil {
+		dateFilter := bson.M{}
+		if from != nil {
+			dateFilter["$gte"] = *from
+		}
+		if to != nil {
+			dateFilter["$lt"] = *to
+		}
+		filter = append(filter, primitive.E{Key: "date", Value: dateFilter})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date", Value: -1}})
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+
+	var result []model.AuditLogEntry
+	err := sa.db.auditLog.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveFileContentItem creates the record of a file or updates it when the file is uploaded again to the same path.
+// A quarantined file has its own record, it does not replace the record of the accepted file at the path.
+func (sa *Adapter) SaveFileContentItem(item model.FileContentItem) (*model.FileContentItem, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "path", Value: item.Path},
+		fileQuarantinedFilter(item.Quarantined)}
+	now := time.Now().UTC()
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "key", Value: item.Key},
+			primitive.E{Key: "category", Value: item.Category},
+			primitive.E{Key: "entity_id", Value: item.EntityID},
+			primitive.E{Key: "account_id", Value: item.AccountID},
+			primitive.E{Key: "size", Value: item.Size},
+			primitive.E{Key: "mime_type", Value: item.MimeType},
+			primitive.E{Key: "checksum", Value: item.Checksum},
+			primitive.E{Key: "quarantined", Value: item.Quarantined},
+			primitive.E{Key: "signature", Value: item.Signature},
+			primitive.E{Key: "date_updated", Value: now},
+		}},
+		primitive.E{Key: "$setOnInsert", Value: bson.D{
+			primitive.E{Key: "_id", Value: item.ID},
+			primitive.E{Key: "date_created", Value: now},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var result model.FileContentItem
+	err := sa.db.fileContentItems.FindOneAndUpdate(sa.context, filter, update, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindFileContentItem finds the record of the accepted file at a path
+func (sa *Adapter) FindFileContentItem(appID string, orgID string, path string) (*model.FileContentItem, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "path", Value: path},
+		fileQuarantinedFilter(false)}
+
+	var result *model.FileContentItem
+	err := sa.db.fileContentItems.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindFileContentItems finds the records of the files, the newest first. The search matches the keys containing it.
+func (sa *Adapter) FindFileContentItems(appID *string, orgID string, category *string, entityID *string, accountID *string, search *string, mimeType *string,
+	quarantined *bool, offset *int64, limit *int64) ([]model.FileContentItem, error) {
+	filter := bson.D{primitive.E{Key: "org_id", Value: orgID}}
+	if appID != nil {
+		filter = append(filter, primitive.E{Key: "app_id", Value: *appID})
+	}
+	if category != nil {
+		filter = append(filter, primitive.E{Key: "category", Value: *category})
+	}
+	if entityID != nil {
+		filter = append(filter, primitive.E{Key: "entity_id", Value: *entityID})
+	}
+	if accountID != nil {
+		filter = append(filter, primitive.E{Key: "account_id", Value: *accountID})
+	}
+	if search != nil {
+		filter = append(filter, primitive.E{Key: "key", Value: primitive.Regex{Pattern: regexp.QuoteMeta(*search), Options: "i"}})
+	}
+	if mimeType != nil {
+		//a type like image/ matches all its subtypes
+		filter = append(filter, primitive.E{Key: "mime_type", Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(*mimeType)}})
+	}
+	if quarantined != nil {
+		filter = append(filter, primitive.E{Key: "quarantined", Value: *quarantined})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+
+	var result []model.FileContentItem
+	err := sa.db.fileContentItems.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteFileContentItem deletes the record of the accepted file at a path
+func (sa *Adapter) DeleteFileContentItem(appID string, orgID string, path string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "path", Value: path},
+		fileQuarantinedFilter(false)}
+	_, err := sa.db.fileContentItems.DeleteOne(sa.context, filter, nil)
+	return err
+}
+
+// fileQuarantinedFilter matches the records of the quarantined files or of the accepted ones, which include the records stored before the quarantine
+func fileQuarantinedFilter(quarantined bool) primitive.E {
+	if quarantined {
+		return primitive.E{Key: "quarantined", Value: true}
+	}
+	return primitive.E{Key: "quarantined", Value: bson.M{"$ne": true}}
+}
+
+// AddStorageUsage adds to the usage of a category within a month
+func (sa *Adapter) AddStorageUsage(item model.StorageUsage) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "category", Value: item.Category},
+		primitive.E{Key: "month", Value: item.Month}}
+	update := bson.D{
+		primitive.E{Key: "$inc", Value: bson.D{
+			primitive.E{Key: "uploaded_size", Value: item.UploadedSize},
+			primitive.E{Key: "uploaded_objects", Value: item.UploadedObjects},
+			primitive.E{Key: "deleted_size", Value: item.DeletedSize},
+			primitive.E{Key: "deleted_objects", Value: item.DeletedObjects},
+		}},
+	}
+	_, err := sa.db.storageUsage.UpdateOne(sa.context, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// FindStorageUsage finds the usage by category and month. All the apps within the organization are given for nil appID.
+func (sa *Adapter) FindStorageUsage(appID *string, orgID string, category *string) ([]model.StorageUsage, error) {
+	filter := bson.D{primitive.E{Key: "org_id", Value: orgID}}
+	if appID != nil {
+		filter = append(filter, primitive.E{Key: "app_id", Value: *appID})
+	}
+	if category != nil {
+		filter = append(filter, primitive.E{Key: "category", Value: *category})
+	}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "category", Value: 1}, primitive.E{Key: "month", Value: 1}})
+	var result []model.StorageUsage
+	err := sa.db.storageUsage.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindStorageQuotas finds the quotas of an app, or the quotas of the organization for nil appID
+func (sa *Adapter) FindStorageQuotas(appID *string, orgID string) ([]model.StorageQuota, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID}}
+
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "category", Value: 1}})
+	var result []model.StorageQuota
+	err := sa.db.storageQuotas.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveStorageQuota creates the quota of a category or updates its max size
+func (sa *Adapter) SaveStorageQuota(item model.StorageQuota) (*model.StorageQuota, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: item.AppID},
+		primitive.E{Key: "org_id", Value: item.OrgID},
+		primitive.E{Key: "category", Value: item.Category}}
+	now := time.Now().UTC()
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "max_size", Value: item.MaxSize},
+			primitive.E{Key: "date_updated", Value: now},
+		}},
+		primitive.E{Key: "$setOnInsert", Value: bson.D{
+			primitive.E{Key: "_id", Value: item.ID},
+			primitive.E{Key: "date_created", Value: now},
+		}},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var result model.StorageQuota
+	err := sa.db.storageQuotas.FindOneAndUpdate(sa.context, filter, update, &result, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteStorageQuota deletes the quota of a category
+func (sa *Adapter) DeleteStorageQuota(appID *string, orgID string, category string) error {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "category", Value: category}}
+	result, err := sa.db.storageQuotas.DeleteOne(sa.context, filter, nil)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount != 1 {
+		return fmt.Errorf("storage quota of category %s is not found", category)
+	}
+	return nil
+}
+
+// CreateMultipartUpload creates a multipart upload
+func (sa *Adapter) CreateMultipartUpload(item model.MultipartUpload) error {
+	_, err := sa.db.multipartUploads.InsertOne(sa.context, item)
+	return err
+}
+
+// FindMultipartUpload finds a multipart upload
+func (sa *Adapter) FindMultipartUpload(appID string, orgID string, id string) (*model.MultipartUpload, error) {
+	filter := bson.D{primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "_id", Value: id}}
+
+	var result *model.MultipartUpload
+	err := sa.db.multipartUploads.FindOne(sa.context, filter, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindMultipartUploads finds the multipart uploads created before a time, the oldest first
+func (sa *Adapter) FindMultipartUploads(createdBefore time.Time) ([]model.MultipartUpload, error) {
+	filter := bson.D{primitive.E{Key: "date_created", Value: bson.M{"$lt": createdBefore}}}
+	findOptions := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: 1}})
+
+	var result []model.MultipartUpload
+	err := sa.db.multipartUploads.Find(sa.context, filter, &result, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteMultipartUpload deletes a multipart upload
+func (sa *Adapter) DeleteMultipartUpload(id string) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	_, err := sa.db.multipartUploads.DeleteOne(sa.context, filter, nil)
+	return err
+}
+
 // Creates a new Adapter with provided context
 func (sa *Adapter) withContext(context mongo.SessionContext) *Adapter {
 	return &Adapter{db: sa.db, context: context}
@@ -30,6 +30,8 @@ import (
 type collectionWrapper struct {
 	database *database
 	coll     *mongo.Collection
+
+	preImages bool // the change stream gives the deleted documents
 }
 
 func (collWrapper *collectionWrapper) Find(ctx context.Context, filter interface{}, result interface{}, findOptions *options.FindOptions) error {
@@ -53,6 +55,28 @@ func (collWrapper *collectionWrapper) Find(ctx context.Context, filter interface
 	return err
 }
 
+// Iterate passes the found documents one by one to handle which decodes the current one, it stops on the first error.
+// There is no timeout as handle may take long, e.g. writing the documents to a slow client.
+func (collWrapper *collectionWrapper) Iterate(ctx context.Context, filter interface{}, handle func(cur *mongo.Cursor) error, findOptions *options.FindOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cur, err := collWrapper.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		err = handle(cur)
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
 func (collWrapper *collectionWrapper) FindOne(ctx context.Context, filter interface{}, result interface{}, findOptions *options.FindOneOptions) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -196,6 +220,21 @@ func (collWrapper *collectionWrapper) UpdateMany(ctx context.Context, filter int
 	return updateResult, nil
 }
 
+func (collWrapper *collectionWrapper) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts *options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, collWrapper.database.mongoTimeout)
+	defer cancel()
+
+	result, err := collWrapper.coll.BulkWrite(ctx, models, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (collWrapper *collectionWrapper) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, result interface{}, opts *options.FindOneAndUpdateOptions) error {
 	ctx, cancel := context.WithTimeout(ctx, collWrapper.database.mongoTimeout)
 	defer cancel()
@@ -249,6 +288,9 @@ func (collWrapper *collectionWrapper) watch(pipeline interface{}, resumeToken bs
 
 	opts := options.ChangeStream()
 	opts.SetFullDocument(options.UpdateLookup)
+	if collWrapper.preImages {
+		opts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
 	if resumeToken != nil {
 		opts.SetResumeAfter(resumeToken)
 	}
@@ -261,11 +303,12 @@ func (collWrapper *collectionWrapper) watch(pipeline interface{}, resumeToken bs
 	}
 	defer cur.Close(ctx)
 
-	var changeDoc map[string]interface{}
 	l.Infof("%s: waiting for changes\n", collWrapper.coll.Name())
 	for cur.Next(ctx) {
+		var changeDoc changeEvent
 		if e := cur.Decode(&changeDoc); e != nil {
 			l.Errorf("error decoding: %s\n", e)
+			continue
 		}
 		collWrapper.database.onDataChanged(changeDoc)
 	}
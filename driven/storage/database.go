@@ -15,8 +15,12 @@
 package storage
 
 import (
+	"content/core/interfaces"
+	"content/core/model"
+	"content/utils"
 	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
@@ -27,6 +31,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// searchTextBackfillBatchSize is how many items stored before the search was available are indexed at once
+const searchTextBackfillBatchSize = 500
+
 type database struct {
 	mongoDBAuth  string
 	mongoDBName  string
@@ -42,6 +49,19 @@ type database struct {
 	categories       *collectionWrapper
 	metaData         *collectionWrapper
 
+	webhooks          *collectionWrapper
+	webhookDeliveries *collectionWrapper
+	auditLog          *collectionWrapper
+	multipartUploads  *collectionWrapper
+	fileContentItems  *collectionWrapper
+	storageUsage      *collectionWrapper
+	storageQuotas     *collectionWrapper
+
+	contentItemsVersions *collectionWrapper
+
+	listeners     []interfaces.StorageListener
+	listenersLock sync.RWMutex
+
 	logger *logs.Logger
 }
 
@@ -87,6 +107,12 @@ func (m *database) start() error {
 		return err
 	}
 
+	contentItemsVersions := &collectionWrapper{database: m, coll: db.Collection("content_items_versions")}
+	err = m.applyContentItemsVersionsChecks(contentItemsVersions)
+	if err != nil {
+		return err
+	}
+
 	dataContentItems := &collectionWrapper{database: m, coll: db.Collection("data_content_items")}
 	err = m.applyDataContentItemsChecks(dataContentItems)
 	if err != nil {
@@ -105,6 +131,48 @@ func (m *database) start() error {
 		return err
 	}
 
+	webhooks := &collectionWrapper{database: m, coll: db.Collection("webhooks")}
+	err = m.applyWebhooksChecks(webhooks)
+	if err != nil {
+		return err
+	}
+
+	webhookDeliveries := &collectionWrapper{database: m, coll: db.Collection("webhook_deliveries")}
+	err = m.applyWebhookDeliveriesChecks(webhookDeliveries)
+	if err != nil {
+		return err
+	}
+
+	auditLog := &collectionWrapper{database: m, coll: db.Collection("audit_log")}
+	err = m.applyAuditLogChecks(auditLog)
+	if err != nil {
+		return err
+	}
+
+	multipartUploads := &collectionWrapper{database: m, coll: db.Collection("multipart_uploads")}
+	err = m.applyMultipartUploadsChecks(multipartUploads)
+	if err != nil {
+		return err
+	}
+
+	fileContentItems := &collectionWrapper{database: m, coll: db.Collection("file_content_items")}
+	err = m.applyFileContentItemsChecks(fileContentItems)
+	if err != nil {
+		return err
+	}
+
+	storageUsage := &collectionWrapper{database: m, coll: db.Collection("storage_usage")}
+	err = m.applyStorageUsageChecks(storageUsage)
+	if err != nil {
+		return err
+	}
+
+	storageQuotas := &collectionWrapper{database: m, coll: db.Collection("storage_quotas")}
+	err = m.applyStorageQuotasChecks(storageQuotas)
+	if err != nil {
+		return err
+	}
+
 	//asign the db, db client and the collections
 	m.db = db
 	m.dbClient = client
@@ -112,9 +180,24 @@ func (m *database) start() error {
 	m.studentGuides = studentGuides
 	m.healthLocations = healthLocations
 	m.contentItems = contentItems
+	m.contentItemsVersions = contentItemsVersions
 	m.dataContentItems = dataContentItems
 	m.categories = categories
 	m.metaData = metaData
+	m.webhooks = webhooks
+	m.webhookDeliveries = webhookDeliveries
+	m.auditLog = auditLog
+	m.multipartUploads = multipartUploads
+	m.fileContentItems = fileContentItems
+	m.storageUsage = storageUsage
+	m.storageQuotas = storageQuotas
+
+	//watch the content for the change feed
+	watchPipeline := []bson.M{{"$match": bson.M{"operationType": bson.M{"$in": []string{model.ContentChangeInsert,
+		model.ContentChangeUpdate, model.ContentChangeReplace, model.ContentChangeDelete}}}}}
+	go contentItems.Watch(watchPipeline, m.logger)
+	go dataContentItems.Watch(watchPipeline, m.logger)
+	go metaData.Watch(watchPipeline, m.logger)
 
 	return nil
 }
@@ -169,10 +252,57 @@ func (m *database) applyContentItemsChecks(contentItems *collectionWrapper) erro
 		return err
 	}
 
+	// Add date_created + _id index
+	err = contentItems.AddIndex(bson.D{primitive.E{Key: "date_created", Value: 1}, primitive.E{Key: "_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add expire_at index
+	err = contentItems.AddIndex(bson.D{primitive.E{Key: "expire_at", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add workflow_state index
+	err = contentItems.AddIndex(bson.D{primitive.E{Key: "workflow_state", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add search_text text index
+	err = m.applySearchTextChecks(contentItems)
+	if err != nil {
+		return err
+	}
+
+	m.applyChangeStreamChecks(contentItems)
+
 	log.Println("content_items checks passed")
 	return nil
 }
 
+func (m *database) applyContentItemsVersionsChecks(contentItemsVersions *collectionWrapper) error {
+	log.Println("apply content_items_versions checks.....")
+
+	//Add org_id + app_id index
+	err := contentItemsVersions.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1},
+		primitive.E{Key: "app_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add content_item_id + version index
+	err = contentItemsVersions.AddIndex(bson.D{primitive.E{Key: "content_item_id", Value: 1},
+		primitive.E{Key: "version", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("content_items_versions checks passed")
+	return nil
+}
+
 func (m *database) applyDataContentItemsChecks(dataContentItems *collectionWrapper) error {
 	log.Println("apply data_content_items checks.....")
 
@@ -182,10 +312,103 @@ func (m *database) applyDataContentItemsChecks(dataContentItems *collectionWrapp
 		return err
 	}
 
+	// Add date_created + _id index
+	err = dataContentItems.AddIndex(bson.D{primitive.E{Key: "date_created", Value: 1}, primitive.E{Key: "_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add search_text text index
+	err = m.applySearchTextChecks(dataContentItems)
+	if err != nil {
+		return err
+	}
+
+	m.applyChangeStreamChecks(dataContentItems)
+
 	log.Println("data_content_items checks passed")
 	return nil
 }
 
+// applySearchTextChecks adds the text index used for searching and indexes the items stored before the search was available
+func (m *database) applySearchTextChecks(collection *collectionWrapper) error {
+	//the content is multilingual, so do not apply language specific stemming and stop words
+	err := collection.AddIndexWithOptions(bson.D{primitive.E{Key: "search_text", Value: "text"}},
+		options.Index().SetName("search_text_text").SetDefaultLanguage("none"))
+	if err != nil {
+		return err
+	}
+
+	//the items are read with a cursor and indexed in batches, so they are never all in memory
+	filter := bson.D{primitive.E{Key: "search_text", Value: bson.M{"$exists": false}}}
+	findOptions := options.Find().SetProjection(bson.D{primitive.E{Key: "data", Value: 1}, primitive.E{Key: "locales", Value: 1}}).
+		SetBatchSize(searchTextBackfillBatchSize)
+	var updates []mongo.WriteModel
+	indexed := 0
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		_, err := collection.BulkWrite(context.Background(), updates, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		indexed += len(updates)
+		updates = updates[:0]
+		return nil
+	}
+	err = collection.Iterate(context.Background(), filter, func(cur *mongo.Cursor) error {
+		var item searchTextBackfillItem
+		err := cur.Decode(&item)
+		if err != nil {
+			return err
+		}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "search_text", Value: item.searchText()}}}}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(bson.D{primitive.E{Key: "_id", Value: item.ID}}).SetUpdate(update))
+		if len(updates) < searchTextBackfillBatchSize {
+			return nil
+		}
+		return flush()
+	}, findOptions)
+	if err != nil {
+		return err
+	}
+	err = flush()
+	if err != nil {
+		return err
+	}
+	if indexed > 0 {
+		log.Printf("indexed %d %s items for search", indexed, collection.coll.Name())
+	}
+	return nil
+}
+
+// searchTextBackfillItem is an item stored before the search was available
+type searchTextBackfillItem struct {
+	ID      string                 `bson:"_id"`
+	Data    interface{}            `bson:"data"`
+	Locales map[string]interface{} `bson:"locales"`
+}
+
+// searchText gives the same text the write paths index for the item and its translations
+func (i searchTextBackfillItem) searchText() string {
+	locales, _ := utils.NormalizeData(i.Locales).(map[string]interface{})
+	return searchText(utils.NormalizeData(i.Data), locales)
+}
+
+// applyChangeStreamChecks enables the pre-images, the change feed needs them to scope the deletes.
+// They are available from MongoDB 6.0, the deletes are not sent to the change feed without them.
+func (m *database) applyChangeStreamChecks(collection *collectionWrapper) {
+	command := bson.D{primitive.E{Key: "collMod", Value: collection.coll.Name()},
+		primitive.E{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}}}
+	err := collection.coll.Database().RunCommand(context.Background(), command).Err()
+	if err != nil {
+		log.Printf("pre-images are not available for %s - %s", collection.coll.Name(), err)
+		return
+	}
+	collection.preImages = true
+}
+
 func (m *database) applyCategoriesChecks(categories *collectionWrapper) error {
 	log.Println("apply categories checks.....")
 
@@ -195,6 +418,12 @@ func (m *database) applyCategoriesChecks(categories *collectionWrapper) error {
 		return err
 	}
 
+	//Add the parent index for listing the subcategories
+	err = categories.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "parent", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
 	log.Println("categories checks passed")
 	return nil
 }
@@ -208,27 +437,197 @@ func (m *database) applyMetaDataChecks(metaData *collectionWrapper) error {
 		return err
 	}
 
+	err = metaData.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "key", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	m.applyChangeStreamChecks(metaData)
+
 	log.Println("meta_data checks passed")
 	return nil
 }
 
+func (m *database) applyWebhooksChecks(webhooks *collectionWrapper) error {
+	log.Println("apply webhooks checks.....")
+
+	//Add org_id + app_id index
+	err := webhooks.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("webhooks checks passed")
+	return nil
+}
+
+func (m *database) applyWebhookDeliveriesChecks(webhookDeliveries *collectionWrapper) error {
+	log.Println("apply webhook_deliveries checks.....")
+
+	// Add webhook_id + date_created index
+	err := webhookDeliveries.AddIndex(bson.D{primitive.E{Key: "webhook_id", Value: 1}, primitive.E{Key: "date_created", Value: -1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add status + next_attempt_at index
+	err = webhookDeliveries.AddIndex(bson.D{primitive.E{Key: "status", Value: 1}, primitive.E{Key: "next_attempt_at", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("webhook_deliveries checks passed")
+	return nil
+}
+
+func (m *database) applyAuditLogChecks(auditLog *collectionWrapper) error {
+	log.Println("apply audit_log checks.....")
+
+	// Add org_id + app_id + date index
+	err := auditLog.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "date", Value: -1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add account_id + date index
+	err = auditLog.AddIndex(bson.D{primitive.E{Key: "account_id", Value: 1}, primitive.E{Key: "date", Value: -1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// Add resource + resource_id + date index
+	err = auditLog.AddIndex(bson.D{primitive.E{Key: "resource", Value: 1}, primitive.E{Key: "resource_id", Value: 1}, primitive.E{Key: "date", Value: -1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("audit_log checks passed")
+	return nil
+}
+
+func (m *database) applyMultipartUploadsChecks(multipartUploads *collectionWrapper) error {
+	log.Println("apply multipart_uploads checks.....")
+
+	// Add date_created index for the sweeper
+	err := multipartUploads.AddIndex(bson.D{primitive.E{Key: "date_created", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("multipart_uploads checks passed")
+	return nil
+}
+
+func (m *database) applyFileContentItemsChecks(fileContentItems *collectionWrapper) error {
+	log.Println("apply file_content_items checks.....")
+
+	// Add org_id + app_id + path + quarantined unique index, there is one record of the accepted object and one of the quarantined object per path
+	err := fileContentItems.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "path", Value: 1}, primitive.E{Key: "quarantined", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	// Add org_id + app_id + category + date_created index
+	err = fileContentItems.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "category", Value: 1}, primitive.E{Key: "date_created", Value: -1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("file_content_items checks passed")
+	return nil
+}
+
+func (m *database) applyStorageUsageChecks(storageUsage *collectionWrapper) error {
+	log.Println("apply storage_usage checks.....")
+
+	// Add org_id + app_id + category + month unique index, the usage is added up per month
+	err := storageUsage.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "category", Value: 1}, primitive.E{Key: "month", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("storage_usage checks passed")
+	return nil
+}
+
+func (m *database) applyStorageQuotasChecks(storageQuotas *collectionWrapper) error {
+	log.Println("apply storage_quotas checks.....")
+
+	// Add org_id + app_id + category unique index, there is one quota per category
+	err := storageQuotas.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "category", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("storage_quotas checks passed")
+	return nil
+}
+
 // Event
 
-func (m *database) onDataChanged(changeDoc map[string]interface{}) {
-	if changeDoc == nil {
-		return
+// changeEvent is the part of a change stream event the change feed needs
+type changeEvent struct {
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	NS            struct {
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             *changedDocument `bson:"fullDocument"`
+	FullDocumentBeforeChange *changedDocument `bson:"fullDocumentBeforeChange"`
+}
+
+type changedDocument struct {
+	AppID    *string `bson:"app_id"`
+	OrgID    *string `bson:"org_id"` // nil for the global meta data
+	Category string  `bson:"category"`
+	Key      string  `bson:"key"`
+
+	WorkflowState string     `bson:"workflow_state"`
+	PublishAt     *time.Time `bson:"publish_at"`
+	ExpireAt      *time.Time `bson:"expire_at"`
+}
+
+// contentItemState gives the workflow state and the publish window of a changed content item
+func (d *changedDocument) contentItemState() *model.ContentItemChangeState {
+	if d == nil {
+		return nil
+	}
+	return &model.ContentItemChangeState{WorkflowState: d.WorkflowState, PublishAt: d.PublishAt, ExpireAt: d.ExpireAt}
+}
+
+func (m *database) onDataChanged(changeDoc changeEvent) {
+	change := model.ContentChange{Collection: changeDoc.NS.Coll, Operation: changeDoc.OperationType,
+		ID: changeDoc.DocumentKey.ID, Date: time.Unix(int64(changeDoc.ClusterTime.T), 0).UTC()}
+
+	document := changeDoc.FullDocument
+	if document == nil {
+		document = changeDoc.FullDocumentBeforeChange
 	}
-	log.Printf("onDataChanged: %+v\n", changeDoc)
-	ns := changeDoc["ns"]
-	if ns == nil {
+	if document == nil {
+		//the app and the organization of the item are not known - the deletes are not sent without the pre-images
+		log.Printf("onDataChanged: skipping %s of %s %s", change.Operation, change.Collection, change.ID)
 		return
 	}
-	nsMap := ns.(map[string]interface{})
-	coll := nsMap["coll"]
-
-	if "configs" == coll {
-		log.Println("configs collection changed")
+	change.Key = document.Key
+	change.Category = document.Category
+	change.AppID = document.AppID
+	if document.OrgID != nil {
+		change.OrgID = *document.OrgID
 	} else {
-		log.Println("other collection changed")
+		//only the meta data is stored without an organization, it is for all of them
+		change.Global = change.Collection == "meta_data" && document.AppID == nil
+	}
+	if change.Collection == "content_items" {
+		change.ItemBefore = changeDoc.FullDocumentBeforeChange.contentItemState()
+		change.ItemAfter = changeDoc.FullDocument.contentItemState()
+	}
+
+	m.listenersLock.RLock()
+	defer m.listenersLock.RUnlock()
+	for _, listener := range m.listeners {
+		listener.OnContentChanged(change)
 	}
 }
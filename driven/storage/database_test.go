@@ -0,0 +1,55 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"content/core/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSearchTextBackfillItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{}
+		locales map[string]interface{}
+	}{
+		{name: "text", data: "Quad day"},
+		{name: "object", data: map[string]interface{}{"title": "Quad day", "tags": []interface{}{"campus", "fall"}, "count": 3.0}},
+		{name: "translations", data: map[string]interface{}{"title": "Quad day"},
+			locales: map[string]interface{}{"es": map[string]interface{}{"title": "Día del quad"}, "fr": map[string]interface{}{"title": "Journée"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//the backfill reads the items the way the driver decodes them
+			stored, err := bson.Marshal(model.ContentItem{ID: "item", Data: tt.data, Locales: tt.locales})
+			if err != nil {
+				t.Fatalf("bson.Marshal() error = %v", err)
+			}
+			var item searchTextBackfillItem
+			err = bson.Unmarshal(stored, &item)
+			if err != nil {
+				t.Fatalf("bson.Unmarshal() error = %v", err)
+			}
+
+			want := searchText(tt.data, tt.locales)
+			if got := item.searchText(); got != want {
+				t.Errorf("searchText() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Adapter delivers the webhook payloads over HTTP
+type Adapter struct {
+	client *http.Client
+}
+
+// NewWebhooksAdapter creates new instance, the deliveries are bounded by the contexts given by the core
+func NewWebhooksAdapter() *Adapter {
+	return &Adapter{client: &http.Client{}}
+}
+
+// Deliver posts the payload to the webhook url and gives the response status code.
+// The receivers verify the X-Content-Signature header - the hex HMAC-SHA256 of "<X-Content-Timestamp>.<body>" keyed with the webhook secret.
+func (a *Adapter) Deliver(ctx context.Context, url string, secret string, deliveryID string, eventType string, payload []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Rokwire-Content-Webhooks")
+	req.Header.Set("X-Content-Event", eventType)
+	req.Header.Set("X-Content-Delivery", deliveryID)
+	req.Header.Set("X-Content-Timestamp", timestamp)
+	req.Header.Set("X-Content-Signature", "sha256="+Sign(secret, timestamp, payload))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	//read a bit of the body so that the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode, nil
+}
+
+// Sign gives the signature of a payload sent at the timestamp
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{name: "payload", secret: "secret", timestamp: "1700000000", payload: `{"id":"1"}`,
+			want: "086f6aff7bd084c98679825129c5a64dbad88c760016d6d2c0fb123f27951d54"},
+		{name: "empty", secret: "", timestamp: "0", payload: "",
+			want: "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	payload := []byte(`{"id":"1","type":"content_item.updated"}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		delay      time.Duration
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "accepted", status: http.StatusOK, wantStatus: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "rejected", status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+		{name: "timed out", status: http.StatusOK, delay: time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				switch {
+				case r.Method != http.MethodPost:
+					t.Errorf("method = %s, want %s", r.Method, http.MethodPost)
+				case string(body) != string(payload):
+					t.Errorf("body = %s, want %s", body, payload)
+				case r.Header.Get("Content-Type") != "application/json":
+					t.Errorf("Content-Type = %s", r.Header.Get("Content-Type"))
+				case r.Header.Get("X-Content-Event") != "content_item.updated":
+					t.Errorf("X-Content-Event = %s", r.Header.Get("X-Content-Event"))
+				case r.Header.Get("X-Content-Delivery") != "delivery":
+					t.Errorf("X-Content-Delivery = %s", r.Header.Get("X-Content-Delivery"))
+				case r.Header.Get("X-Content-Signature") != "sha256="+Sign("secret", r.Header.Get("X-Content-Timestamp"), body):
+					t.Errorf("X-Content-Signature = %s does not match the body", r.Header.Get("X-Content-Signature"))
+				}
+
+				if tt.delay > 0 {
+					select {
+					case <-time.After(tt.delay):
+					case <-r.Context().Done():
+					}
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			status, err := NewWebhooksAdapter().Deliver(ctx, server.URL, "secret", "delivery", "content_item.updated", payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Deliver() = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	status, err := NewWebhooksAdapter().Deliver(context.Background(), url, "secret", "delivery", "content_item.updated", []byte("{}"))
+	if err == nil || status != 0 {
+		t.Errorf("Deliver() = %d, %v, want an error", status, err)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	rokwireAuth "github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -89,6 +90,10 @@ func (we Adapter) Start() {
 	contentRouter.HandleFunc("/doc", we.serveDoc)
 	contentRouter.HandleFunc("/version", we.wrapFunc(we.apisHandler.Version)).Methods("GET")
 
+	//the presigned object URLs carry their own signatures
+	contentRouter.HandleFunc("/objects/{bucket}/{key:.+}", we.wrapFunc(we.apisHandler.GetObject)).Methods("GET")
+	contentRouter.HandleFunc("/objects/{bucket}/{key:.+}", we.wrapFunc(we.apisHandler.PutObject)).Methods("PUT")
+
 	contentRouter.HandleFunc("/profile_photo/{user-id}", we.coreAuthWrapFunc(we.apisHandler.GetProfilePhoto, we.auth.coreAuth.userAuth)).Methods("GET")
 	contentRouter.HandleFunc("/profile_photo", we.coreAuthWrapFunc(we.apisHandler.GetUserProfilePhoto, we.auth.coreAuth.userAuth)).Methods("GET")
 	contentRouter.HandleFunc("/profile_photo", we.coreAuthWrapFunc(we.apisHandler.StoreProfilePhoto, we.auth.coreAuth.userAuth)).Methods("POST")
@@ -105,6 +110,7 @@ func (we Adapter) Start() {
 	contentRouter.HandleFunc("/health_locations", we.coreAuthWrapFunc(we.apisHandler.GetHealthLocations, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/health_locations/{id}", we.coreAuthWrapFunc(we.apisHandler.GetHealthLocation, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/content_items", we.coreAuthWrapFunc(we.apisHandler.GetContentItems, we.auth.coreAuth.standardAuth)).Methods("GET", "POST")
+	contentRouter.HandleFunc("/content_items/search", we.coreAuthWrapFunc(we.apisHandler.SearchContentItems, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/content_items/{id}", we.coreAuthWrapFunc(we.apisHandler.GetContentItem, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/content_item/categories", we.coreAuthWrapFunc(we.apisHandler.GetContentItemsCategories, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/image", we.coreAuthWrapFunc(we.apisHandler.UploadImage, we.auth.coreAuth.userAuth)).Methods("POST")
@@ -113,9 +119,15 @@ func (we Adapter) Start() {
 	contentRouter.HandleFunc("/data/{key}", we.coreAuthWrapFunc(we.apisHandler.GetDataContentItem, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/files", we.coreAuthWrapFunc(we.apisHandler.GetFileContentItem, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/files/upload", we.coreAuthWrapFunc(we.apisHandler.GetFileContentUploadURLs, we.auth.coreAuth.standardAuth)).Methods("GET")
-	//TODO: add /files/upload/multipart for large file uploads
+	contentRouter.HandleFunc("/files/list", we.coreAuthWrapFunc(we.apisHandler.GetFileContentItems, we.auth.coreAuth.standardAuth)).Methods("GET")
+	contentRouter.HandleFunc("/files/upload/complete", we.coreAuthWrapFunc(we.apisHandler.ConfirmFileContentUploads, we.auth.coreAuth.standardAuth)).Methods("POST")
+	contentRouter.HandleFunc("/files/upload/multipart", we.coreAuthWrapFunc(we.apisHandler.CreateFileContentMultipartUpload, we.auth.coreAuth.standardAuth)).Methods("POST")
+	contentRouter.HandleFunc("/files/upload/multipart/{id}/parts", we.coreAuthWrapFunc(we.apisHandler.GetFileContentUploadPartURLs, we.auth.coreAuth.standardAuth)).Methods("GET")
+	contentRouter.HandleFunc("/files/upload/multipart/{id}/complete", we.coreAuthWrapFunc(we.apisHandler.CompleteFileContentMultipartUpload, we.auth.coreAuth.standardAuth)).Methods("POST")
+	contentRouter.HandleFunc("/files/upload/multipart/{id}", we.coreAuthWrapFunc(we.apisHandler.AbortFileContentMultipartUpload, we.auth.coreAuth.standardAuth)).Methods("DELETE")
 	contentRouter.HandleFunc("/files/download", we.coreAuthWrapFunc(we.apisHandler.GetFileContentDownloadURLs, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/data", we.coreAuthWrapFunc(we.apisHandler.GetDataContentItems, we.auth.coreAuth.standardAuth)).Methods("GET")
+	contentRouter.HandleFunc("/changes", we.coreAuthWrapFunc(we.apisHandler.GetContentChanges, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.apisHandler.CreateOrUpdateMetaData, we.auth.coreAuth.standardAuth)).Methods("POST")
 	contentRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.apisHandler.GetMetaData, we.auth.coreAuth.standardAuth)).Methods("GET")
 	contentRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.apisHandler.DeleteMetaData, we.auth.coreAuth.standardAuth)).Methods("DELETE")
@@ -124,19 +136,35 @@ func (we Adapter) Start() {
 	adminSubRouter := contentRouter.PathPrefix("/admin").Subrouter()
 
 	adminSubRouter.HandleFunc("/data", we.coreAuthWrapFunc(we.adminApisHandler.CreateDataContentItem, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/data/export", we.coreAuthWrapFunc(we.adminApisHandler.ExportDataContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/data/import", we.coreAuthWrapFunc(we.adminApisHandler.ImportDataContentItems, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/data/batch", we.coreAuthWrapFunc(we.adminApisHandler.ApplyDataContentItemsBatch, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 	adminSubRouter.HandleFunc("/data/{key}", we.coreAuthWrapFunc(we.adminApisHandler.GetDataContentItem, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/data", we.coreAuthWrapFunc(we.adminApisHandler.GetDataContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/data", we.coreAuthWrapFunc(we.adminApisHandler.UpdateDataContentItem, we.auth.coreAuth.permissionsAuth)).Methods("PUT")
+	adminSubRouter.HandleFunc("/data/{key}", we.coreAuthWrapFunc(we.adminApisHandler.PatchDataContentItem, we.auth.coreAuth.permissionsAuth)).Methods("PATCH")
 	adminSubRouter.HandleFunc("/data/{key}", we.coreAuthWrapFunc(we.adminApisHandler.DeleteDataContentItem, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
 
 	adminSubRouter.HandleFunc("/files", we.coreAuthWrapFunc(we.adminApisHandler.UploadFileContentItem, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 	adminSubRouter.HandleFunc("/files", we.coreAuthWrapFunc(we.adminApisHandler.GetFileContentItem, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/files", we.coreAuthWrapFunc(we.adminApisHandler.DeleteFileContentItem, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
+	adminSubRouter.HandleFunc("/files/list", we.coreAuthWrapFunc(we.adminApisHandler.GetFileContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/storage/usage", we.coreAuthWrapFunc(we.adminApisHandler.GetStorageUsage, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/storage/quotas", we.coreAuthWrapFunc(we.adminApisHandler.GetStorageQuotas, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/storage/quotas", we.coreAuthWrapFunc(we.adminApisHandler.SaveStorageQuota, we.auth.coreAuth.permissionsAuth)).Methods("PUT")
+	adminSubRouter.HandleFunc("/storage/quotas", we.coreAuthWrapFunc(we.adminApisHandler.DeleteStorageQuota, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
 
+	adminSubRouter.HandleFunc("/categories", we.coreAuthWrapFunc(we.adminApisHandler.GetCategories, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/categories", we.coreAuthWrapFunc(we.adminApisHandler.CreateCategory, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/categories/export", we.coreAuthWrapFunc(we.adminApisHandler.ExportCategories, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/categories/import", we.coreAuthWrapFunc(we.adminApisHandler.ImportCategories, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 	adminSubRouter.HandleFunc("/categories/{name}", we.coreAuthWrapFunc(we.adminApisHandler.GetCategory, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/categories", we.coreAuthWrapFunc(we.adminApisHandler.UpdateCategory, we.auth.coreAuth.permissionsAuth)).Methods("PUT")
 	adminSubRouter.HandleFunc("/categories/{name}", we.coreAuthWrapFunc(we.adminApisHandler.DeleteCategory, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
+	adminSubRouter.HandleFunc("/categories/{name}/rename", we.coreAuthWrapFunc(we.adminApisHandler.RenameCategory, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/categories/{name}/subtree", we.coreAuthWrapFunc(we.adminApisHandler.GetCategorySubtree, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/categories/{name}/schema/validate", we.coreAuthWrapFunc(we.adminApisHandler.ValidateCategorySchema, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/categories/{name}/missing_translations", we.coreAuthWrapFunc(we.adminApisHandler.GetMissingTranslations, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 
 	//deprecated
 	adminSubRouter.HandleFunc("/student_guides", we.coreAuthWrapFunc(we.adminApisHandler.GetStudentGuides, we.auth.coreAuth.permissionsAuth)).Methods("GET")
@@ -191,11 +219,34 @@ func (we Adapter) Start() {
 
 	adminSubRouter.HandleFunc("/content_items", we.coreAuthWrapFunc(we.adminApisHandler.GetContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/content_items", we.coreAuthWrapFunc(we.adminApisHandler.CreateContentItem, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/content_items/search", we.coreAuthWrapFunc(we.adminApisHandler.SearchContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/content_items/export", we.coreAuthWrapFunc(we.adminApisHandler.ExportContentItems, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/content_items/import", we.coreAuthWrapFunc(we.adminApisHandler.ImportContentItems, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/content_items/dangling_references", we.coreAuthWrapFunc(we.adminApisHandler.GetDanglingReferences, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/content_items/batch", we.coreAuthWrapFunc(we.adminApisHandler.ApplyContentItemsBatch, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 	adminSubRouter.HandleFunc("/content_items/{id}", we.coreAuthWrapFunc(we.adminApisHandler.GetContentItem, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 	adminSubRouter.HandleFunc("/content_items/{id}", we.coreAuthWrapFunc(we.adminApisHandler.UpdateContentItem, we.auth.coreAuth.permissionsAuth)).Methods("PUT")
+	adminSubRouter.HandleFunc("/content_items/{id}", we.coreAuthWrapFunc(we.adminApisHandler.PatchContentItem, we.auth.coreAuth.permissionsAuth)).Methods("PATCH")
 	adminSubRouter.HandleFunc("/content_items/{id}", we.coreAuthWrapFunc(we.adminApisHandler.DeleteContentItem, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
+	adminSubRouter.HandleFunc("/content_items/{id}/versions", we.coreAuthWrapFunc(we.adminApisHandler.GetContentItemVersions, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/content_items/{id}/versions/diff", we.coreAuthWrapFunc(we.adminApisHandler.GetContentItemVersionsDiff, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/content_items/{id}/versions/{version}/restore", we.coreAuthWrapFunc(we.adminApisHandler.RestoreContentItemVersion, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/content_items/{id}/workflow/{transition}", we.coreAuthWrapFunc(we.adminApisHandler.TransitionContentItemWorkflow, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 	adminSubRouter.HandleFunc("/content_item/categories", we.coreAuthWrapFunc(we.adminApisHandler.GetContentItemsCategories, we.auth.coreAuth.permissionsAuth)).Methods("GET")
 
+	adminSubRouter.HandleFunc("/webhooks", we.coreAuthWrapFunc(we.adminApisHandler.GetWebhooks, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/webhooks", we.coreAuthWrapFunc(we.adminApisHandler.CreateWebhook, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/webhooks/{id}", we.coreAuthWrapFunc(we.adminApisHandler.GetWebhook, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/webhooks/{id}", we.coreAuthWrapFunc(we.adminApisHandler.UpdateWebhook, we.auth.coreAuth.permissionsAuth)).Methods("PUT")
+	adminSubRouter.HandleFunc("/webhooks/{id}", we.coreAuthWrapFunc(we.adminApisHandler.DeleteWebhook, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
+	adminSubRouter.HandleFunc("/webhooks/{id}/deliveries", we.coreAuthWrapFunc(we.adminApisHandler.GetWebhookDeliveries, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", we.coreAuthWrapFunc(we.adminApisHandler.RedeliverWebhookDelivery, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+
+	adminSubRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.adminApisHandler.GetMetaDataList, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+	adminSubRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.adminApisHandler.CreateOrUpdateMetaData, we.auth.coreAuth.permissionsAuth)).Methods("POST")
+	adminSubRouter.HandleFunc("/meta-data", we.coreAuthWrapFunc(we.adminApisHandler.DeleteMetaData, we.auth.coreAuth.permissionsAuth)).Methods("DELETE")
+	adminSubRouter.HandleFunc("/audit", we.coreAuthWrapFunc(we.adminApisHandler.GetAuditLog, we.auth.coreAuth.permissionsAuth)).Methods("GET")
+
 	adminSubRouter.HandleFunc("/image", we.coreAuthWrapFunc(we.adminApisHandler.UploadImage, we.auth.coreAuth.permissionsAuth)).Methods("POST")
 
 	// handle bbs apis
@@ -266,6 +317,12 @@ type coreAuthFunc = func(*tokenauth.Claims, http.ResponseWriter, *http.Request)
 
 func (we Adapter) coreAuthWrapFunc(handler coreAuthFunc, authorization Authorization) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
+		//the clients may provide the request id, otherwise it is generated
+		if len(req.Header.Get(utils.RequestIDHeader)) == 0 {
+			req.Header.Set(utils.RequestIDHeader, uuid.NewString())
+		}
+		w.Header().Set(utils.RequestIDHeader, req.Header.Get(utils.RequestIDHeader))
+
 		utils.LogRequest(req)
 
 		responseStatus, claims, err := authorization.check(req)
@@ -17,7 +17,10 @@ package rest
 import (
 	"content/core"
 	"content/core/model"
+	"content/utils"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -25,10 +28,12 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
 	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/text/language"
 )
 
 // AdminApisHandler handles the rest Admin APIs implementation
@@ -133,7 +138,7 @@ func (h AdminApisHandler) UpdateStudentGuide(claims *tokenauth.Claims, w http.Re
 		return
 	}
 
-	resData, err := h.app.Services.UpdateStudentGuide(claims.AppID, claims.OrgID, guideID, item)
+	resData, err := h.app.Services.UpdateStudentGuide(auditActor(claims, r), claims.AppID, claims.OrgID, guideID, item)
 	if err != nil {
 		log.Printf("Error on updating student guide with id - %s\n %s", guideID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -170,7 +175,7 @@ func (h AdminApisHandler) CreateStudentGuide(claims *tokenauth.Claims, w http.Re
 		return
 	}
 
-	createdItem, err := h.app.Services.CreateStudentGuide(claims.AppID, claims.OrgID, item)
+	createdItem, err := h.app.Services.CreateStudentGuide(auditActor(claims, r), claims.AppID, claims.OrgID, item)
 	if err != nil {
 		log.Printf("Error on creating student guide: %s\n", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -201,7 +206,7 @@ func (h AdminApisHandler) DeleteStudentGuide(claims *tokenauth.Claims, w http.Re
 	vars := mux.Vars(r)
 	guideID := vars["id"]
 
-	err := h.app.Services.DeleteStudentGuide(claims.AppID, claims.OrgID, guideID)
+	err := h.app.Services.DeleteStudentGuide(auditActor(claims, r), claims.AppID, claims.OrgID, guideID)
 	if err != nil {
 		log.Printf("Error on deleting student guide with id - %s\n %s", guideID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -309,7 +314,7 @@ func (h AdminApisHandler) UpdateHealthLocation(claims *tokenauth.Claims, w http.
 		return
 	}
 
-	resData, err := h.app.Services.UpdateHealthLocation(claims.AppID, claims.OrgID, locationID, item)
+	resData, err := h.app.Services.UpdateHealthLocation(auditActor(claims, r), claims.AppID, claims.OrgID, locationID, item)
 	if err != nil {
 		log.Printf("Error on updating health location with id - %s\n %s", locationID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -346,7 +351,7 @@ func (h AdminApisHandler) CreateHealthLocation(claims *tokenauth.Claims, w http.
 		return
 	}
 
-	createdItem, err := h.app.Services.CreateHealthLocation(claims.AppID, claims.OrgID, item)
+	createdItem, err := h.app.Services.CreateHealthLocation(auditActor(claims, r), claims.AppID, claims.OrgID, item)
 	if err != nil {
 		log.Printf("Error on creating health location: %s\n", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -377,7 +382,7 @@ func (h AdminApisHandler) DeleteHealthLocation(claims *tokenauth.Claims, w http.
 	vars := mux.Vars(r)
 	locationID := vars["id"]
 
-	err := h.app.Services.DeleteHealthLocation(claims.AppID, claims.OrgID, locationID)
+	err := h.app.Services.DeleteHealthLocation(auditActor(claims, r), claims.AppID, claims.OrgID, locationID)
 	if err != nil {
 		log.Printf("Error on deleting health location with id - %s\n %s", locationID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -397,6 +402,8 @@ func (h AdminApisHandler) DeleteHealthLocation(claims *tokenauth.Claims, w http.
 // @Param offset query string false "offset"
 // @Param limit query string false "limit - limit the result"
 // @Param order query string false "order - Possible values: asc, desc. Default: desc"
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page. The offset is not used then."
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
 // @Accept json
 // @Success 200 {array} model.ContentItem
 // @Security AdminUserAuth
@@ -453,6 +460,8 @@ func (h AdminApisHandler) DeleteHealthLocationV2(claims *tokenauth.Claims, w htt
 // @Param offset query string false "offset"
 // @Param limit query string false "limit - limit the result"
 // @Param order query string false "order - Possible values: asc, desc. Default: desc"
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page. The offset is not used then."
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
 // @Accept json
 // @Success 200 {array} model.ContentItem
 // @Security AdminUserAuth
@@ -819,17 +828,50 @@ func (h AdminApisHandler) getContentItemsByCategory(claims *tokenauth.Claims, w
 		order = &orders[0]
 	}
 
+	state, err := getContentItemStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflowState, err := getContentItemWorkflowStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	categories := []string{category}
 
-	resData, err := h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, IDs, categories, offset, limit, order)
+	pageParams, err := getPageQueryParams(r)
 	if err != nil {
-		log.Printf("Error on cgetting content items - %s\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if resData == nil {
-		resData = []model.ContentItemResponse{}
+	var resData interface{}
+	if pageParams != nil {
+		page, err := h.app.Services.GetContentItemsPage(allApps, claims.AppID, claims.OrgID, IDs, categories, state, workflowState, nil,
+			pageParams.cursor, pageParams.limit, order, pageParams.withTotal)
+		if err != nil {
+			log.Printf("Error on getting content items page - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resData = page
+	} else {
+		items, err := h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, IDs, categories, state, workflowState, nil, offset, limit, order)
+		if err != nil {
+			log.Printf("Error on cgetting content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if items == nil {
+			items = []model.ContentItemResponse{}
+		}
+		resData = items
 	}
 
 	data, err := json.Marshal(resData)
@@ -846,8 +888,12 @@ func (h AdminApisHandler) getContentItemsByCategory(claims *tokenauth.Claims, w
 
 // createContentItemByCategoryRequestBody Expected body while creating a new content item
 type createContentItemByCategoryRequestBody struct {
-	AllApps bool        `json:"all_apps"`
-	Data    interface{} `json:"data" bson:"data"`
+	AllApps       bool                   `json:"all_apps"`
+	Data          interface{}            `json:"data" bson:"data"`
+	DefaultLocale string                 `json:"default_locale"`
+	Locales       map[string]interface{} `json:"locales"`
+	PublishAt     *time.Time             `json:"publish_at"`
+	ExpireAt      *time.Time             `json:"expire_at"`
 } // @name createContentItemByCategoryRequestBody
 
 func (h AdminApisHandler) createContentItemByCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request, category string) {
@@ -859,9 +905,28 @@ func (h AdminApisHandler) createContentItemByCategory(claims *tokenauth.Claims,
 		return
 	}
 
-	createdItem, err := h.app.Services.CreateContentItem(item.AllApps, claims.AppID, claims.OrgID, category, item.Data)
+	err = validatePublishWindow(item.PublishAt, item.ExpireAt)
+	if err != nil {
+		log.Printf("Unable to create content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale, item.Locales)
+	if err != nil {
+		log.Printf("Unable to create content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//the category endpoints predate the workflow, their roles cannot approve so the items are published directly
+	createdItem, err := h.app.Services.CreateContentItem(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, category, item.Data, item.DefaultLocale, item.Locales, item.PublishAt, item.ExpireAt,
+		model.ContentItemWorkflowPublished)
 	if err != nil {
 		log.Printf("Error on creating content item: %s\n", err)
+		if writeSchemaError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -880,8 +945,12 @@ func (h AdminApisHandler) createContentItemByCategory(claims *tokenauth.Claims,
 
 // updateContentItemByCategoryRequestBody Expected body while updating a content item
 type updateContentItemByCategoryRequestBody struct {
-	AllApps bool        `json:"all_apps"`
-	Data    interface{} `json:"data"`
+	AllApps       bool                                   `json:"all_apps"`
+	Data          interface{}                            `json:"data"`
+	DefaultLocale model.Nullable[string]                 `json:"default_locale"` // kept when it is left out, removed when it is null
+	Locales       model.Nullable[map[string]interface{}] `json:"locales"`        // kept when they are left out, removed when they are null
+	PublishAt     *time.Time                             `json:"publish_at"`
+	ExpireAt      *time.Time                             `json:"expire_at"`
 } // @name updateContentItemByCategoryRequestBody
 
 func (h AdminApisHandler) updateContentItemByCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request, category string) {
@@ -902,9 +971,26 @@ func (h AdminApisHandler) updateContentItemByCategory(claims *tokenauth.Claims,
 		return
 	}
 
-	resData, err := h.app.Services.UpdateContentItemData(item.AllApps, claims.AppID, claims.OrgID, id, category, item.Data)
+	err = validatePublishWindow(item.PublishAt, item.ExpireAt)
+	if err != nil {
+		log.Printf("Unable to update content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale.Value, item.Locales.Value)
+	if err != nil {
+		log.Printf("Unable to update content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.UpdateContentItemData(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, id, category, item.Data, item.DefaultLocale, item.Locales, item.PublishAt, item.ExpireAt)
 	if err != nil {
 		log.Printf("Error on updating content item with id - %s\n %s", id, err)
+		if writeNotFoundError(w, err) || writeSchemaError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -932,9 +1018,12 @@ func (h AdminApisHandler) deleteContentItemByCategory(claims *tokenauth.Claims,
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	err := h.app.Services.DeleteContentItemByCategory(allApps, claims.AppID, claims.OrgID, id, category)
+	err := h.app.Services.DeleteContentItemByCategory(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, id, category)
 	if err != nil {
 		log.Printf("Error on deleting content item with id - %s\n %s", id, err)
+		if writeNotFoundError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -960,6 +1049,7 @@ type uploadImageResponse struct {
 // @Accept multipart/form-data
 // @Produce json
 // @Success 200 {object} uploadImageResponse
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
 // @Security AdminUserAuth
 // @Router /admin/image [post]
 func (h AdminApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -1013,9 +1103,12 @@ func (h AdminApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseW
 	}
 
 	// pass the file to be processed by the use case handler
-	url, err := h.app.Services.UploadImage(fileBytes, path, imgSpec)
+	url, err := h.app.Services.UploadImage(auditActor(claims, r), claims, fileBytes, path, imgSpec)
 	if err != nil {
 		log.Printf("Error converting image: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error converting image", http.StatusInternalServerError)
 		return
 	}
@@ -1036,6 +1129,7 @@ func (h AdminApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseW
 type getContentItemsRequestBody struct {
 	IDs        []string `json:"ids,omitempty"`        // List of IDs for the filter. Optional and may be null or missing.
 	Categories []string `json:"categories,omitempty"` // List of Categories for the filter. Optional and may be null or missing.
+	Filters    []string `json:"filters,omitempty"`    // List of filter expressions on the item data, e.g. `data.audience in ["students"]` or `data.start_date >= "2024-01-01"`. Optional and may be null or missing.
 } // @name getContentItemsRequestBody
 
 // GetContentItems Retrieves  all content items. <b> The data element could be either a primitive or nested json or array.</b>
@@ -1048,7 +1142,11 @@ type getContentItemsRequestBody struct {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit - limit the result"
 // @Param order query string false "order - Possible values: asc, desc. Default: desc"
-// @Param data body getContentItemsRequestBody false "Optional - body json of the all items ids that need to be filtered. NOTE: Bad/broken json will be interpreted as an empty filter and the request will be proceeded further."
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page. The offset is not used then."
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
+// @Param state query string false "state - filter by publish window. Possible values: scheduled, live, expired"
+// @Param workflow_state query string false "workflow_state - filter by workflow state. Possible values: draft, in_review, published, archived"
+// @Param data body getContentItemsRequestBody false "Optional - body json of the all items ids that need to be filtered and the filter expressions on the item data. NOTE: Bad/broken json will be interpreted as an empty filter and the request will be proceeded further, invalid filter expressions are rejected."
 // @Accept json
 // @Success 200 {array} model.ContentItem
 // @Security AdminUserAuth
@@ -1097,26 +1195,67 @@ func (h AdminApisHandler) GetContentItems(claims *tokenauth.Claims, w http.Respo
 		order = &orders[0]
 	}
 
-	item := getContentItemsRequestBody{IDs: ids, Categories: categories}
-	if len(ids) == 0 && len(categories) == 0 {
-		bodyErr := json.NewDecoder(r.Body).Decode(&item)
+	var item getContentItemsRequestBody
+	bodyData, _ := ioutil.ReadAll(r.Body)
+	if len(bodyData) > 0 {
+		bodyErr := json.Unmarshal(bodyData, &item)
 		if bodyErr != nil {
 			log.Printf("Warning: bad getContentItemsRequestBody request: %s", bodyErr)
-		} else {
+		} else if len(ids) == 0 && len(categories) == 0 {
 			ids = item.IDs
 			categories = item.Categories
 		}
 	}
 
-	resData, err := h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, ids, categories, offset, limit, order)
+	dataFilter, err := utils.ParseFilter(item.Filters, "data")
 	if err != nil {
-		log.Printf("Error on cgetting content items - %s\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if resData == nil {
-		resData = []model.ContentItemResponse{}
+	state, err := getContentItemStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflowState, err := getContentItemWorkflowStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pageParams, err := getPageQueryParams(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resData interface{}
+	if pageParams != nil {
+		page, err := h.app.Services.GetContentItemsPage(allApps, claims.AppID, claims.OrgID, ids, categories, state, workflowState, dataFilter,
+			pageParams.cursor, pageParams.limit, order, pageParams.withTotal)
+		if err != nil {
+			log.Printf("Error on getting content items page - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resData = page
+	} else {
+		items, err := h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, ids, categories, state, workflowState, dataFilter, offset, limit, order)
+		if err != nil {
+			log.Printf("Error on cgetting content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if items == nil {
+			items = []model.ContentItemResponse{}
+		}
+		resData = items
 	}
 
 	data, err := json.Marshal(resData)
@@ -1131,6 +1270,105 @@ func (h AdminApisHandler) GetContentItems(claims *tokenauth.Claims, w http.Respo
 	w.Write(data)
 }
 
+// SearchContentItems Searches the content items and the data content items, the best matches come first
+// @Description Searches the content items and the data content items regardless of their workflow state and publish window unless filtered by the state params.
+// @Tags Admin
+// @ID AdminSearchContentItems
+// @Param q query string true "q - the text to search for"
+// @Param categories query string false "categories - comma separated list of categories to restrict the search to"
+// @Param state query string false "state - Possible values: scheduled, live, expired. Applies to the content items only"
+// @Param workflow_state query string false "workflow_state - Possible values: draft, in_review, published, archived. Applies to the content items only"
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param offset query integer false "offset - Default: 0, max: 1000"
+// @Param limit query integer false "limit - limit the result. Default: 20, max: 100"
+// @Produce json
+// @Success 200 {array} model.SearchResult
+// @Security AdminUserAuth
+// @Router /admin/content_items/search [get]
+func (h AdminApisHandler) SearchContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	params, err := getSearchQueryParams(r)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	state, err := getContentItemStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflowState, err := getContentItemWorkflowStateQueryParam(r)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.SearchContentItems(claims, allApps, claims.AppID, claims.OrgID, params.text, params.categories, state, workflowState, params.offset, params.limit)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the search results")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetDanglingReferences Lists the references in the item data which do not resolve
+// @Description Lists the references like {"$ref": "content_item:<id>"} in the data of the content items and the data content items which point to an item that does not exist within the app and the organization, or which are not valid references.
+// @Tags Admin
+// @ID AdminGetDanglingReferences
+// @Param all-apps query boolean false "It says if the content items for all the apps within the organization are checked instead of the ones of the current app. The data content items of the current app are always checked. It is 'false' by default."
+// @Produce json
+// @Success 200 {array} model.DanglingReference
+// @Security AdminUserAuth
+// @Router /admin/content_items/dangling_references [get]
+func (h AdminApisHandler) GetDanglingReferences(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	resData, err := h.app.Services.GetDanglingReferences(allApps, claims.AppID, claims.OrgID)
+	if err != nil {
+		log.Printf("Error on getting the dangling references - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the dangling references")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
 // GetContentItem Retrieves a content item by id. <b> The data element could be either a primitive or nested json or array.</b>
 // @Description Retrieves a content item by id. <b> The data element could be either a primitive or nested json or array.</b>
 // @Tags Admin
@@ -1152,7 +1390,7 @@ func (h AdminApisHandler) GetContentItem(claims *tokenauth.Claims, w http.Respon
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetContentItem(allApps, claims.AppID, claims.OrgID, id)
+	resData, err := h.app.Services.GetContentItem(allApps, claims.AppID, claims.OrgID, id, nil, nil)
 	if err != nil {
 		log.Printf("Error on getting content item id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -1166,6 +1404,8 @@ func (h AdminApisHandler) GetContentItem(claims *tokenauth.Claims, w http.Respon
 		return
 	}
 
+	//the editors send it back in If-Match when they update the item
+	w.Header().Set("ETag", model.EntityTag("", contentItemRevision(*resData)))
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
@@ -1173,15 +1413,20 @@ func (h AdminApisHandler) GetContentItem(claims *tokenauth.Claims, w http.Respon
 
 // updateContentItemRequestBody Expected body while updating a new content item
 type updateContentItemRequestBody struct {
-	AllApps  bool        `json:"all_apps"`
-	Category string      `json:"category"`
-	Data     interface{} `json:"data"`
+	AllApps       bool                                   `json:"all_apps"`
+	Category      string                                 `json:"category"`
+	Data          interface{}                            `json:"data"`
+	DefaultLocale model.Nullable[string]                 `json:"default_locale"` // kept when it is left out, removed when it is null
+	Locales       model.Nullable[map[string]interface{}] `json:"locales"`        // kept when they are left out, removed when they are null
+	PublishAt     *time.Time                             `json:"publish_at"`
+	ExpireAt      *time.Time                             `json:"expire_at"`
 } // @name updateContentItemRequestBody
 
 // UpdateContentItem Updates a content item with the specified id. <b> The data element could be either a primitive or nested json or array.</b>
-// @Description Updates a content item with the specified id. <b> The data element could be either a primitive or nested json or array.</b>
+// @Description Updates a content item with the specified id. <b> The data element could be either a primitive or nested json or array.</b> The publish_at and expire_at of the item are kept unless new ones are provided. The default_locale and the locales are kept when they are left out and removed when they are null.
 // @Tags Admin
 // @ID AdminUpdateContentItem
+// @Param If-Match header string false "the ETag of the item as the client read it, the update is rejected with 412 if the item has changed since then"
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.ContentItem
@@ -1211,9 +1456,26 @@ func (h AdminApisHandler) UpdateContentItem(claims *tokenauth.Claims, w http.Res
 		return
 	}
 
-	resData, err := h.app.Services.UpdateContentItem(item.AllApps, claims.AppID, claims.OrgID, id, item.Category, item.Data)
+	err = validatePublishWindow(item.PublishAt, item.ExpireAt)
+	if err != nil {
+		log.Printf("Unable to update content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale.Value, item.Locales.Value)
+	if err != nil {
+		log.Printf("Unable to update content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.UpdateContentItem(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, id, item.Category, item.Data, item.DefaultLocale, item.Locales, item.PublishAt, item.ExpireAt, getEntityTagsHeader(r, "If-Match"))
 	if err != nil {
 		log.Printf("Error on updating content item with id - %s\n %s", id, err)
+		if writeNotFoundError(w, err) || writeSchemaError(w, err) || writePreconditionFailed(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1225,6 +1487,64 @@ func (h AdminApisHandler) UpdateContentItem(claims *tokenauth.Claims, w http.Res
 		return
 	}
 
+	w.Header().Set("ETag", model.EntityTag("", resData.Revision()))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// PatchContentItem Patches the data of a content item with the specified id
+// @Description Patches the data of a content item with a JSON merge patch (RFC 7396) or a JSON patch (RFC 6902), by the Content-Type. The patch is applied to the current data in a transaction, so concurrent patches do not overwrite each other.
+// @Tags Admin
+// @ID AdminPatchContentItem
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param If-Match header string false "the ETag of the item as the client read it, the patch is rejected with 412 if the item has changed since then"
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Success 200 {object} model.ContentItem
+// @Security AdminUserAuth
+// @Router /admin/content_items/{id} [patch]
+func (h AdminApisHandler) PatchContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	patch, err := readDataPatch(r)
+	if err != nil {
+		log.Printf("Error on reading the content item patch - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if patch == nil {
+		http.Error(w, fmt.Sprintf("the Content-Type must be %s or %s", mergePatchContentType, jsonPatchContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	resData, err := h.app.Services.PatchContentItem(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, id, *patch, getEntityTagsHeader(r, "If-Match"))
+	if err != nil {
+		log.Printf("Error on patching content item with id - %s\n %s", id, err)
+		if writeNotFoundError(w, err) || writePatchError(w, err) || writeSchemaError(w, err) || writePreconditionFailed(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the patched content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", model.EntityTag("", resData.Revision()))
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonData)
@@ -1232,9 +1552,13 @@ func (h AdminApisHandler) UpdateContentItem(claims *tokenauth.Claims, w http.Res
 
 // createContentItemRequestBody Expected body while creating a new content item
 type createContentItemRequestBody struct {
-	AllApps  bool        `json:"all_apps"`
-	Category string      `json:"category" bson:"category"`
-	Data     interface{} `json:"data" bson:"data"`
+	AllApps       bool                   `json:"all_apps"`
+	Category      string                 `json:"category" bson:"category"`
+	Data          interface{}            `json:"data" bson:"data"`
+	DefaultLocale string                 `json:"default_locale"`
+	Locales       map[string]interface{} `json:"locales"`
+	PublishAt     *time.Time             `json:"publish_at"`
+	ExpireAt      *time.Time             `json:"expire_at"`
 } // @name createContentItemRequestBody
 
 // CreateContentItem creates a new content item. <b> The data element could be either a primitive or nested json or array.</b>
@@ -1260,9 +1584,28 @@ func (h AdminApisHandler) CreateContentItem(claims *tokenauth.Claims, w http.Res
 		return
 	}
 
-	createdItem, err := h.app.Services.CreateContentItem(item.AllApps, claims.AppID, claims.OrgID, item.Category, item.Data)
+	err = validatePublishWindow(item.PublishAt, item.ExpireAt)
+	if err != nil {
+		log.Printf("Unable to create content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale, item.Locales)
+	if err != nil {
+		log.Printf("Unable to create content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//new items need approval before the clients see them
+	createdItem, err := h.app.Services.CreateContentItem(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, item.Category, item.Data, item.DefaultLocale, item.Locales, item.PublishAt, item.ExpireAt,
+		model.ContentItemWorkflowDraft)
 	if err != nil {
 		log.Printf("Error on creating content item: %s\n", err)
+		if writeSchemaError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1298,7 +1641,7 @@ func (h AdminApisHandler) DeleteContentItem(claims *tokenauth.Claims, w http.Res
 	vars := mux.Vars(r)
 	guideID := vars["id"]
 
-	err := h.app.Services.DeleteContentItem(allApps, claims.AppID, claims.OrgID, guideID)
+	err := h.app.Services.DeleteContentItem(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, guideID)
 	if err != nil {
 		log.Printf("Error on deleting content item with id - %s\n %s", guideID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -1309,15 +1652,18 @@ func (h AdminApisHandler) DeleteContentItem(claims *tokenauth.Claims, w http.Res
 	w.WriteHeader(http.StatusOK)
 }
 
-// GetContentItemsCategories Retrieves  all content item categories that have in the database
-// @Description Retrieves  all content item categories that have in the database
+// GetContentItemVersions Retrieves the stored prior revisions of a content item, newest first
+// @Description Retrieves the stored prior revisions of a content item, newest first
 // @Tags Admin
-// @ID AdminGetContentItemsCategories
+// @ID AdminGetContentItemVersions
 // @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
-// @Success 200
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Produce json
+// @Success 200 {array} model.ContentItemVersion
 // @Security AdminUserAuth
-// @Router /admin/content_item/categories [get]
-func (h AdminApisHandler) GetContentItemsCategories(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+// @Router /admin/content_items/{id}/versions [get]
+func (h AdminApisHandler) GetContentItemVersions(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
 	//get all-apps param value
 	allApps := false //false by defautl
 	allAppsParam := r.URL.Query().Get("all-apps")
@@ -1325,20 +1671,26 @@ func (h AdminApisHandler) GetContentItemsCategories(claims *tokenauth.Claims, w
 		allApps, _ = strconv.ParseBool(allAppsParam)
 	}
 
-	resData, err := h.app.Services.GetContentItemsCategories(allApps, claims.AppID, claims.OrgID)
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	resData, err := h.app.Services.GetContentItemVersions(allApps, claims.AppID, claims.OrgID, id, offset, limit)
 	if err != nil {
-		log.Printf("Error on cgetting content items - %s\n", err)
+		log.Printf("Error on getting content item versions for id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	if resData == nil {
-		resData = []string{}
+		resData = []model.ContentItemVersion{}
 	}
 
 	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal all content items")
+		log.Println("Error on marshal the content item versions")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -1348,58 +1700,437 @@ func (h AdminApisHandler) GetContentItemsCategories(claims *tokenauth.Claims, w
 	w.Write(data)
 }
 
-// CreateDataContentItem Creates a new data content type item
-// @Description Creates a new data content type item
+// GetContentItemVersionsDiff Compares two revisions of a content item
+// @Description Compares two revisions of a content item. The changes are addressed by JSON pointers. If "to" is not provided the revision is compared against the current content item.
 // @Tags Admin
-// @ID AdminCreateDataContentItem
-// @Accept json
-// @Success 200
+// @ID AdminGetContentItemVersionsDiff
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param from query integer true "from - the older revision"
+// @Param to query integer false "to - the newer revision. The current content item by default"
+// @Produce json
+// @Success 200 {object} model.ContentItemVersionDiff
 // @Security AdminUserAuth
-// @Router /admin/data [post]
-func (h AdminApisHandler) CreateDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	var item model.DataContentItem
-	err := json.NewDecoder(r.Body).Decode(&item)
+// @Router /admin/content_items/{id}/versions/diff [get]
+func (h AdminApisHandler) GetContentItemVersionsDiff(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
 	if err != nil {
-		log.Printf("Error on unmarshal the create data content item request data - %s\n", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("Error on parsing the from version - %s\n", err)
+		http.Error(w, "Invalid or missing from version", http.StatusBadRequest)
 		return
 	}
 
-	createdItem, err := h.app.Services.CreateDataContentItem(claims, &item)
+	var to *int
+	toParam := r.URL.Query().Get("to")
+	if toParam != "" {
+		toVersion, err := strconv.Atoi(toParam)
+		if err != nil {
+			log.Printf("Error on parsing the to version - %s\n", err)
+			http.Error(w, "Invalid to version", http.StatusBadRequest)
+			return
+		}
+		to = &toVersion
+	}
+
+	resData, err := h.app.Services.GetContentItemVersionsDiff(allApps, claims.AppID, claims.OrgID, id, from, to)
 	if err != nil {
-		log.Printf("Error on creating data content item: %s\n", err)
+		log.Printf("Error on comparing content item versions for id - %s\n %s", id, err)
+		if writeNotFoundError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	jsonData, err := json.Marshal(createdItem)
+	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal the new item")
+		log.Println("Error on marshal the content item versions diff")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	w.Write(data)
 }
 
-// GetDataContentItem Gets a data content type item
-// @Description Gets a data content type item
+// RestoreContentItemVersion Restores a prior revision of a content item
+// @Description Restores a prior revision of a content item. The replaced revision is kept in the history as well.
 // @Tags Admin
-// @ID AdminGetDataContentItem
-// @Accept json
+// @ID AdminRestoreContentItemVersion
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
 // @Produce json
-// @Success 200
+// @Success 200 {object} model.ContentItem
 // @Security AdminUserAuth
-// @Router /admin/data/{key} [get]
-func (h AdminApisHandler) GetDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	key := vars["key"]
-
-	resData, err := h.app.Services.GetDataContentItem(claims, key)
-	if err != nil {
+// @Router /admin/content_items/{id}/versions/{version}/restore [post]
+func (h AdminApisHandler) RestoreContentItemVersion(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		log.Printf("Error on parsing the version - %s\n", err)
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.RestoreContentItemVersion(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, id, version)
+	if err != nil {
+		log.Printf("Error on restoring version %d of content item with id - %s\n %s", version, id, err)
+		if writeNotFoundError(w, err) || writeSchemaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the restored content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// TransitionContentItemWorkflow Moves a content item through the review workflow
+// @Description Moves a content item through the review workflow. Possible transitions: submit (draft to in_review), approve (in_review to published), reject (in_review to draft), archive (to archived), restore (archived to draft). Approving requires its own permission.
+// @Tags Admin
+// @ID AdminTransitionContentItemWorkflow
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Produce json
+// @Success 200 {object} model.ContentItem
+// @Failure 404 {string} string "content item not found"
+// @Failure 409 {string} string "the item is not in a state the transition is allowed from"
+// @Security AdminUserAuth
+// @Router /admin/content_items/{id}/workflow/{transition} [post]
+func (h AdminApisHandler) TransitionContentItemWorkflow(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	transition := vars["transition"]
+
+	switch transition {
+	case model.ContentItemTransitionSubmit, model.ContentItemTransitionApprove, model.ContentItemTransitionReject,
+		model.ContentItemTransitionArchive, model.ContentItemTransitionRestore:
+	default:
+		log.Printf("Unsupported workflow transition - %s\n", transition)
+		http.Error(w, fmt.Sprintf("Unsupported workflow transition %s", transition), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.TransitionContentItemWorkflow(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, id, transition)
+	if err != nil {
+		log.Printf("Error on %s workflow transition of content item with id - %s\n %s", transition, id, err)
+		if writeWorkflowError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ExportContentItems Exports content items
+// @Description Exports the content items as NDJSON - one json object per line, or as CSV with the data and the locales as json. The items are streamed, the oldest first.
+// @Tags Admin
+// @ID AdminExportContentItems
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param categories query string false "categories - coma separated list of categories"
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/content_items/export [get]
+func (h AdminApisHandler) ExportContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var categories []string
+	catogyValues := r.URL.Query()["categories"]
+	if len(catogyValues) > 0 {
+		categories = strings.Split(catogyValues[0], ",")
+	}
+
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on exporting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer, err := newExportWriter(w, format, "content_items", contentItemCSVHeader)
+	if err == nil {
+		err = h.app.Services.ExportContentItems(allApps, claims.AppID, claims.OrgID, categories, func(item model.ContentItem) error {
+			return writer.write(item, func() ([]string, error) { return contentItemCSVRow(item) })
+		})
+	}
+	finishExport(writer, err, "content items")
+}
+
+// ImportContentItems Imports content items
+// @Description Imports content items as NDJSON or CSV in the export format. The items are stored by id - the existing ones are replaced and keep their history, the others are created.
+// @Description An item without workflow_state is created as a draft, a replaced published item goes back to draft. Only the admins who approve content items may import published items.
+// @Description Nothing is stored when any of the items is invalid, the report lists the invalid items. A dry run only validates the items.
+// @Tags Admin
+// @ID AdminImportContentItems
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Param dry_run query boolean false "dry_run - validate the items and report what would be done without storing them"
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce json
+// @Success 200 {object} model.ImportReport
+// @Failure 400 {object} model.ImportReport
+// @Security AdminUserAuth
+// @Router /admin/content_items/import [post]
+func (h AdminApisHandler) ImportContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on importing content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := getBoolQueryParam(r, "dry_run", false)
+
+	records, err := readImportRecords(r.Body, format)
+	if err != nil {
+		log.Printf("Error on reading the content items import - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := model.NewImportReport(dryRun)
+	items := make([]model.ContentItem, 0, len(records))
+	for i, record := range records {
+		item, err := contentItemImportRecord(record)
+		if err != nil {
+			report.AddError(i+1, item.ID, err.Error())
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if len(report.Errors) == 0 {
+		report, err = h.app.Services.ImportContentItems(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, items, dryRun)
+		if err != nil {
+			log.Printf("Error on importing content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeImportReport(w, report)
+}
+
+type contentItemsBatchRequestBody struct {
+	AllApps    bool                              `json:"all_apps"`
+	Operations []model.ContentItemBatchOperation `json:"operations"`
+} // @name contentItemsBatchRequestBody
+
+// ApplyContentItemsBatch Creates, updates and deletes content items together
+// @Description Applies a list of create, update and delete operations on content items in a single transaction - all of them are stored or none of them.
+// @Description The result has the outcome of every operation. The processing stops at the first failed operation, then the done operations are rolled back and the rest are skipped.
+// @Tags Admin
+// @ID AdminApplyContentItemsBatch
+// @Param data body contentItemsBatchRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.BatchResult
+// @Failure 400 {object} model.BatchResult
+// @Security AdminUserAuth
+// @Router /admin/content_items/batch [post]
+func (h AdminApisHandler) ApplyContentItemsBatch(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var requestData contentItemsBatchRequestBody
+	err := json.NewDecoder(r.Body).Decode(&requestData)
+	if err != nil {
+		log.Printf("Error on unmarshal the content items batch request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(requestData.Operations) == 0 || len(requestData.Operations) > maxBatchOperations {
+		log.Printf("Unable to apply content items batch: %d operations", len(requestData.Operations))
+		http.Error(w, fmt.Sprintf("a batch must have from 1 to %d operations", maxBatchOperations), http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]string, len(requestData.Operations))
+	for i, operation := range requestData.Operations {
+		ops[i] = operation.Op
+	}
+	result := model.NewBatchResult(ops)
+	valid := true
+	for i, operation := range requestData.Operations {
+		err = validateContentItemBatchOperation(operation)
+		if err != nil {
+			result.Fail(i, operation.ID, err.Error())
+			valid = false
+		}
+	}
+
+	if valid {
+		result, err = h.app.Services.ApplyContentItemsBatch(auditActor(claims, r), requestData.AllApps, claims.AppID, claims.OrgID, requestData.Operations)
+		if err != nil {
+			log.Printf("Error on applying content items batch - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeBatchResult(w, result)
+}
+
+// GetContentItemsCategories Retrieves  all content item categories that have in the database
+// @Description Retrieves  all content item categories that have in the database
+// @Tags Admin
+// @ID AdminGetContentItemsCategories
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/content_item/categories [get]
+func (h AdminApisHandler) GetContentItemsCategories(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	resData, err := h.app.Services.GetContentItemsCategories(allApps, claims.AppID, claims.OrgID)
+	if err != nil {
+		log.Printf("Error on cgetting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if resData == nil {
+		resData = []string{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal all content items")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// CreateDataContentItem Creates a new data content type item
+// @Description Creates a new data content type item
+// @Tags Admin
+// @ID AdminCreateDataContentItem
+// @Accept json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/data [post]
+func (h AdminApisHandler) CreateDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var item model.DataContentItem
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the create data content item request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale, item.Locales)
+	if err != nil {
+		log.Printf("Unable to create data content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdItem, err := h.app.Services.CreateDataContentItem(auditActor(claims, r), claims, &item)
+	if err != nil {
+		log.Printf("Error on creating data content item: %s\n", err)
+		if writeSchemaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(createdItem)
+	if err != nil {
+		log.Println("Error on marshal the new item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// GetDataContentItem Gets a data content type item
+// @Description Gets a data content type item
+// @Tags Admin
+// @ID AdminGetDataContentItem
+// @Accept json
+// @Produce json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/data/{key} [get]
+func (h AdminApisHandler) GetDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	resData, err := h.app.Services.GetDataContentItem(claims, key)
+	if err != nil {
 		log.Printf("Error on getting data content type with key - %s\n %s", key, err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1411,6 +2142,7 @@ func (h AdminApisHandler) GetDataContentItem(claims *tokenauth.Claims, w http.Re
 		return
 	}
 
+	w.Header().Set("ETag", model.EntityTag("", resData.Revision()))
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
@@ -1421,6 +2153,11 @@ func (h AdminApisHandler) GetDataContentItem(claims *tokenauth.Claims, w http.Re
 // @Tags Admin
 // @ID AdminGetDataContentItems
 // @Param category body string false "category - get all data content items based on category"
+// @Param descendants query boolean false "descendants - include the data content items of the subcategories of the category. Default: false"
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page."
+// @Param limit query integer false "limit - page size, used with cursor. Default: 20, max: 100"
+// @Param order query string false "order - used with cursor. Possible values: asc, desc. Default: asc"
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
 // @Accept json
 // @Produce json
 // @Success 200
@@ -1434,16 +2171,1304 @@ func (h AdminApisHandler) GetDataContentItems(claims *tokenauth.Claims, w http.R
 		return
 	}
 
-	resData, err := h.app.Services.GetDataContentItems(claims, category)
+	pageParams, err := getPageQueryParams(r)
+	if err != nil {
+		log.Printf("Error on getting data content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	withDescendants := getBoolQueryParam(r, "descendants", false)
+
+	var resData interface{}
+	if pageParams != nil {
+		resData, err = h.app.Services.GetDataContentItemsPage(claims, category, withDescendants, pageParams.cursor, pageParams.limit, getStringQueryParam(r, "order"), pageParams.withTotal)
+	} else {
+		resData, err = h.app.Services.GetDataContentItems(claims, category, withDescendants)
+	}
+	if err != nil {
+		log.Printf("Error on getting data content type with id - %s\n", err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal of data content type")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ExportDataContentItems Exports data content items
+// @Description Exports the data content items as NDJSON - one json object per line, or as CSV with the data and the locales as json. The items are streamed, the oldest first.
+// @Tags Admin
+// @ID AdminExportDataContentItems
+// @Param categories query string false "categories - coma separated list of categories"
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/data/export [get]
+func (h AdminApisHandler) ExportDataContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var categories []string
+	catogyValues := r.URL.Query()["categories"]
+	if len(catogyValues) > 0 {
+		categories = strings.Split(catogyValues[0], ",")
+	}
+
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on exporting data content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer, err := newExportWriter(w, format, "data_content_items", dataContentItemCSVHeader)
+	if err == nil {
+		err = h.app.Services.ExportDataContentItems(claims, categories, func(item model.DataContentItem) error {
+			return writer.write(item, func() ([]string, error) { return dataContentItemCSVRow(item) })
+		})
+	}
+	finishExport(writer, err, "data content items")
+}
+
+// ImportDataContentItems Imports data content items
+// @Description Imports data content items as NDJSON or CSV in the export format. The items are stored by id, an item without id replaces the item with its key if there is one.
+// @Description The categories must exist, so they are imported first. Nothing is stored when any of the items is invalid, the report lists the invalid items. A dry run only validates the items.
+// @Tags Admin
+// @ID AdminImportDataContentItems
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Param dry_run query boolean false "dry_run - validate the items and report what would be done without storing them"
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce json
+// @Success 200 {object} model.ImportReport
+// @Failure 400 {object} model.ImportReport
+// @Security AdminUserAuth
+// @Router /admin/data/import [post]
+func (h AdminApisHandler) ImportDataContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on importing data content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := getBoolQueryParam(r, "dry_run", false)
+
+	records, err := readImportRecords(r.Body, format)
+	if err != nil {
+		log.Printf("Error on reading the data content items import - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := model.NewImportReport(dryRun)
+	items := make([]model.DataContentItem, 0, len(records))
+	for i, record := range records {
+		item, err := dataContentItemImportRecord(record)
+		if err != nil {
+			report.AddError(i+1, item.ID, err.Error())
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if len(report.Errors) == 0 {
+		report, err = h.app.Services.ImportDataContentItems(auditActor(claims, r), claims, items, dryRun)
+		if err != nil {
+			log.Printf("Error on importing data content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeImportReport(w, report)
+}
+
+type dataContentItemsBatchRequestBody struct {
+	Operations []model.DataContentItemBatchOperation `json:"operations"`
+} // @name dataContentItemsBatchRequestBody
+
+// ApplyDataContentItemsBatch Creates, updates and deletes data content items together
+// @Description Applies a list of create, update and delete operations on data content items, identified by key, in a single transaction - all of them are stored or none of them.
+// @Description The result has the outcome of every operation. The processing stops at the first failed operation, then the done operations are rolled back and the rest are skipped.
+// @Tags Admin
+// @ID AdminApplyDataContentItemsBatch
+// @Param data body dataContentItemsBatchRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.BatchResult
+// @Failure 400 {object} model.BatchResult
+// @Security AdminUserAuth
+// @Router /admin/data/batch [post]
+func (h AdminApisHandler) ApplyDataContentItemsBatch(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var requestData dataContentItemsBatchRequestBody
+	err := json.NewDecoder(r.Body).Decode(&requestData)
+	if err != nil {
+		log.Printf("Error on unmarshal the data content items batch request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(requestData.Operations) == 0 || len(requestData.Operations) > maxBatchOperations {
+		log.Printf("Unable to apply data content items batch: %d operations", len(requestData.Operations))
+		http.Error(w, fmt.Sprintf("a batch must have from 1 to %d operations", maxBatchOperations), http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]string, len(requestData.Operations))
+	for i, operation := range requestData.Operations {
+		ops[i] = operation.Op
+	}
+	result := model.NewBatchResult(ops)
+	valid := true
+	for i, operation := range requestData.Operations {
+		err = validateDataContentItemBatchOperation(operation)
+		if err != nil {
+			result.Fail(i, operation.Key, err.Error())
+			valid = false
+		}
+	}
+
+	if valid {
+		result, err = h.app.Services.ApplyDataContentItemsBatch(auditActor(claims, r), claims, requestData.Operations)
+		if err != nil {
+			log.Printf("Error on applying data content items batch - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeBatchResult(w, result)
+}
+
+// updateDataContentItemRequestBody Expected body while updating a data content item
+type updateDataContentItemRequestBody struct {
+	Key           string                                 `json:"key"`
+	Category      string                                 `json:"category"`
+	Data          interface{}                            `json:"data"`
+	DefaultLocale model.Nullable[string]                 `json:"default_locale"` // kept when it is left out, removed when it is null
+	Locales       model.Nullable[map[string]interface{}] `json:"locales"`        // kept when they are left out, removed when they are null
+} // @name updateDataContentItemRequestBody
+
+// UpdateDataContentItem Updates a content item.
+// @Description Updates a content item. The default_locale and the locales are kept when they are left out and removed when they are null.
+// @Tags Admin
+// @ID AdminUpdateDataContentItem
+// @Param If-Match header string false "the ETag of the item as the client read it, the update is rejected with 412 if the item has changed since then"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.DataContentItem
+// @Security AdminUserAuth
+// @Router /admin/data/ [put]
+func (h AdminApisHandler) UpdateDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+
+	var item updateDataContentItemRequestBody
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the update data content item request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(item.Category) == 0 {
+		log.Printf("Unable to update content item: Missing category")
+		http.Error(w, "Unable to update content item: Missing category", http.StatusBadRequest)
+		return
+	}
+
+	if item.Data == nil {
+		log.Printf("Unable to update content item: Missing data")
+		http.Error(w, "Unable to update content item: Missing data", http.StatusBadRequest)
+		return
+	}
+
+	err = validateLocales(item.DefaultLocale.Value, item.Locales.Value)
+	if err != nil {
+		log.Printf("Unable to update data content item: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.UpdateDataContentItem(auditActor(claims, r), claims, &model.DataContentItem{Key: item.Key, Category: item.Category, Data: item.Data},
+		item.DefaultLocale, item.Locales, getEntityTagsHeader(r, "If-Match"))
+	if err != nil {
+		log.Printf("Error on updating content item- %s\n", err)
+		if writeSchemaError(w, err) || writePreconditionFailed(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the updated content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", model.EntityTag("", resData.Revision()))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// PatchDataContentItem Patches the data of a data content item with the specified key
+// @Description Patches the data of a data content item with a JSON merge patch (RFC 7396) or a JSON patch (RFC 6902), by the Content-Type. The patch is applied to the current data in a transaction, so concurrent patches do not overwrite each other.
+// @Tags Admin
+// @ID AdminPatchDataContentItem
+// @Param If-Match header string false "the ETag of the item as the client read it, the patch is rejected with 412 if the item has changed since then"
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Success 200 {object} model.DataContentItem
+// @Security AdminUserAuth
+// @Router /admin/data/{key} [patch]
+func (h AdminApisHandler) PatchDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	patch, err := readDataPatch(r)
+	if err != nil {
+		log.Printf("Error on reading the data content item patch - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if patch == nil {
+		http.Error(w, fmt.Sprintf("the Content-Type must be %s or %s", mergePatchContentType, jsonPatchContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	resData, err := h.app.Services.PatchDataContentItem(auditActor(claims, r), claims, key, *patch, getEntityTagsHeader(r, "If-Match"))
+	if err != nil {
+		log.Printf("Error on patching data content item with key - %s\n %s", key, err)
+		if writePatchError(w, err) || writeSchemaError(w, err) || writePreconditionFailed(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the patched data content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", model.EntityTag("", resData.Revision()))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// DeleteDataContentItem Deletes a data content item with a specified key
+// @Description Deletes a data content item with the specified key
+// @Tags Admin
+// @ID AdminDeleteDataContentItem
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/data/{key} [delete]
+func (h AdminApisHandler) DeleteDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	err := h.app.Services.DeleteDataContentItem(auditActor(claims, r), claims, key)
+	if err != nil {
+		log.Printf("Error on deleting data content item with key - %s\n %s", key, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// CreateCategory Creates a category
+// @Description Creates a category
+// @Tags Admin
+// @ID AdminCreateCategory
+// @Accept json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/categories [post]
+func (h AdminApisHandler) CreateCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var item model.Category
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the create a category - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdItem, err := h.app.Services.CreateCategory(auditActor(claims, r), claims, &item)
+	if err != nil {
+		log.Printf("Error on creating category %s\n", err)
+		if writeSchemaError(w, err) {
+			return
+		}
+		var hierarchyErr *model.CategoryHierarchyError
+		if errors.As(err, &hierarchyErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(createdItem)
+	if err != nil {
+		log.Println("Error on marshal the new category")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// GetCategories Gets a page of the categories with the counts of their items
+// @Description Gets a page of the categories with the counts of their content items and data content items. The first page is returned when there is no cursor.
+// @Tags Admin
+// @ID AdminGetCategories
+// @Param cursor query string false "cursor - the next_cursor of the previous page, empty or missing for the first page"
+// @Param limit query integer false "limit - page size. Default: 20, max: 100"
+// @Param order query string false "order - by creation date. Possible values: asc, desc. Default: asc"
+// @Param total query boolean false "total - include the count of all the categories"
+// @Produce json
+// @Success 200 {object} model.CategoriesPage
+// @Security AdminUserAuth
+// @Router /admin/categories [get]
+func (h AdminApisHandler) GetCategories(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	pageParams, err := getPageQueryParams(r)
+	if err != nil {
+		log.Printf("Error on getting categories - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if pageParams == nil {
+		pageParams = &pageQueryParams{limit: defaultPageLimit}
+	}
+
+	resData, err := h.app.Services.GetCategoriesPage(claims, pageParams.cursor, pageParams.limit, getStringQueryParam(r, "order"), pageParams.withTotal)
+	if err != nil {
+		log.Printf("Error on getting categories - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the categories")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetCategory Gets a category
+// @Description Gets a category
+// @Tags Admin
+// @ID AdminGetCategory
+// @Accept json
+// @Produce json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/categories/{name} [get]
+func (h AdminApisHandler) GetCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	resData, err := h.app.Services.GetCategory(claims, name)
+	if err != nil {
+		log.Printf("Error on getting category with name - %s\n %s", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal of category")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// UpdateCategory Updates a category with a specificed id.
+// @Description  Updates a category with a specificed id.
+// @Tags Admin
+// @ID AdminUpdateCategory
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Category
+// @Failure 409 {object} model.CategoryInUseError
+// @Security AdminUserAuth
+// @Router /admin/categories [put]
+func (h AdminApisHandler) UpdateCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var item model.Category
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the update category request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.UpdateCategory(auditActor(claims, r), claims, &item)
+	if err != nil {
+		log.Printf("Error on updating category  - %s", err)
+		if writeSchemaError(w, err) {
+			return
+		}
+		var hierarchyErr *model.CategoryHierarchyError
+		if errors.As(err, &hierarchyErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if writeCategoryInUseError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the updated category")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// GetCategorySubtree Gets a category with its subcategories
+// @Description Gets a category with all its subcategories as a tree. Every category has the permissions it has effectively - its own ones or the ones it inherits.
+// @Tags Admin
+// @ID AdminGetCategorySubtree
+// @Produce json
+// @Success 200 {object} model.CategoryTree
+// @Security AdminUserAuth
+// @Router /admin/categories/{name}/subtree [get]
+func (h AdminApisHandler) GetCategorySubtree(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	resData, err := h.app.Services.GetCategorySubtree(claims, name)
+	if err != nil {
+		log.Printf("Error on getting the subtree of category with name - %s\n %s", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal of category subtree")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ValidateCategorySchema Validates the stored items of a category against a schema
+// @Description Validates the stored content items and data content items of a category against a JSON Schema without saving it. The request body is the schema itself.
+// @Tags Admin
+// @ID AdminValidateCategorySchema
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.CategorySchemaReport
+// @Security AdminUserAuth
+// @Router /admin/categories/{name}/schema/validate [post]
+func (h AdminApisHandler) ValidateCategorySchema(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	var schema json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&schema)
+	if err != nil {
+		log.Printf("Error on unmarshal the validate category schema request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.ValidateCategorySchema(claims, name, schema)
+	if err != nil {
+		log.Printf("Error on validating the schema of category %s - %s", name, err)
+		if writeSchemaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the category schema report")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// GetMissingTranslations Lists the items of a category which are not translated to all the locales
+// @Description Lists the content items and data content items of a category which are not translated to all the locales. The locales are the ones used by the items of the category unless specified.
+// @Tags Admin
+// @ID AdminGetMissingTranslations
+// @Param locales query string false "locales - comma separated list of the locales every item should have"
+// @Produce json
+// @Success 200 {object} model.MissingTranslationsReport
+// @Security AdminUserAuth
+// @Router /admin/categories/{name}/missing_translations [get]
+func (h AdminApisHandler) GetMissingTranslations(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	var locales []string
+	localesParam := getStringQueryParam(r, "locales")
+	if localesParam != nil {
+		for _, locale := range strings.Split(*localesParam, ",") {
+			locale = strings.TrimSpace(locale)
+			if len(locale) == 0 {
+				continue
+			}
+			if _, err := language.Parse(locale); err != nil {
+				log.Printf("Error on getting the missing translations of category %s - invalid locale %s", name, locale)
+				http.Error(w, fmt.Sprintf("invalid locale %s", locale), http.StatusBadRequest)
+				return
+			}
+			locales = append(locales, locale)
+		}
+	}
+
+	resData, err := h.app.Services.GetMissingTranslations(claims, name, locales)
+	if err != nil {
+		log.Printf("Error on getting the missing translations of category %s - %s", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the missing translations report")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+type renameCategoryRequestBody struct {
+	Name string `json:"name"`
+} // @name renameCategoryRequestBody
+
+// RenameCategory Renames a category
+// @Description Renames a category, its content items with their history, data content items, subcategories, file records, storage usage, quotas and webhook subscriptions are moved to the new name.
+// @Description A category with files cannot be renamed, the files keep the category name in their paths.
+// @Tags Admin
+// @ID AdminRenameCategory
+// @Param data body renameCategoryRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Category
+// @Failure 409 {object} model.CategoryInUseError
+// @Security AdminUserAuth
+// @Router /admin/categories/{name}/rename [post]
+func (h AdminApisHandler) RenameCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	var body renameCategoryRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		log.Printf("Error on unmarshal the rename category request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.Name) == 0 {
+		log.Print("Missing the new category name\n")
+		http.Error(w, "missing 'name'", http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.RenameCategory(auditActor(claims, r), claims, name, body.Name)
+	if err != nil {
+		log.Printf("Error on renaming category with name - %s\n %s", name, err)
+		if writeSchemaError(w, err) {
+			return
+		}
+		var existsErr *model.CategoryExistsError
+		if errors.As(err, &existsErr) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if writeCategoryInUseError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the renamed category")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// DeleteCategory Deletes a category with specified key
+// @Description Deletes a category with specified key. A category which still has items is not deleted unless cascade is set, then its items are deleted with it. A category with subcategories or files is never deleted.
+// @Description The storage usage, the quotas and the quarantined files of the category are deleted with it.
+// @Tags Admin
+// @ID AdminDeleteCategory
+// @Param cascade query boolean false "cascade - delete the content items and the data content items of the category as well. Default: false"
+// @Success 200
+// @Failure 409 {object} model.CategoryInUseError
+// @Security AdminUserAuth
+// @Router /admin/categories/{name} [delete]
+func (h AdminApisHandler) DeleteCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	cascade := getBoolQueryParam(r, "cascade", false)
+
+	err := h.app.Services.DeleteCategory(auditActor(claims, r), claims, name, cascade)
+	if err != nil {
+		log.Printf("Error on deleting category with name - %s\n %s", name, err)
+		if writeCategoryInUseError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ExportCategories Exports the categories
+// @Description Exports the categories as NDJSON - one json object per line, or as CSV with the permissions as a coma separated list and the schema as json.
+// @Tags Admin
+// @ID AdminExportCategories
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/categories/export [get]
+func (h AdminApisHandler) ExportCategories(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on exporting categories - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer, err := newExportWriter(w, format, "categories", categoryCSVHeader)
+	if err == nil {
+		err = h.app.Services.ExportCategories(claims, func(item model.Category) error {
+			return writer.write(item, func() ([]string, error) { return categoryCSVRow(item) })
+		})
+	}
+	finishExport(writer, err, "categories")
+}
+
+// ImportCategories Imports categories
+// @Description Imports categories as NDJSON or CSV in the export format. The categories are stored by id, a category without id replaces the category with its name if there is one.
+// @Description The stored items of a category must conform to its schema. Nothing is stored when any of the categories is invalid, the report lists the invalid categories. A dry run only validates the categories.
+// @Tags Admin
+// @ID AdminImportCategories
+// @Param format query string false "format - Possible values: ndjson, csv. Default - ndjson"
+// @Param dry_run query boolean false "dry_run - validate the categories and report what would be done without storing them"
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce json
+// @Success 200 {object} model.ImportReport
+// @Failure 400 {object} model.ImportReport
+// @Security AdminUserAuth
+// @Router /admin/categories/import [post]
+func (h AdminApisHandler) ImportCategories(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	format, err := getTransferFormatQueryParam(r)
+	if err != nil {
+		log.Printf("Error on importing categories - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := getBoolQueryParam(r, "dry_run", false)
+
+	records, err := readImportRecords(r.Body, format)
+	if err != nil {
+		log.Printf("Error on reading the categories import - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := model.NewImportReport(dryRun)
+	items := make([]model.Category, 0, len(records))
+	for i, record := range records {
+		item, err := categoryImportRecord(record)
+		if err != nil {
+			report.AddError(i+1, item.ID, err.Error())
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if len(report.Errors) == 0 {
+		report, err = h.app.Services.ImportCategories(auditActor(claims, r), claims, items, dryRun)
+		if err != nil {
+			log.Printf("Error on importing categories - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeImportReport(w, report)
+}
+
+// UploadFileContentItem Uploads a file to AWS S3
+// @Description Uploads a file to AWS S3, it responds with the record of the file
+// @Tags Admin
+// @ID AdminUploadFileContentItem
+// @Param fileName body string false "fileName - the uploaded file name"
+// @Param category body string false "category - category of file content item"
+// @Produce json
+// @Success 200 {object} model.FileContentItem
+// @Failure 413 {string} string "the file is larger than the max size of the category"
+// @Failure 415 {string} string "the MIME type of the file is not allowed in the category"
+// @Failure 422 {string} string "the file is flagged by the scanner and quarantined"
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
+// @Security AdminUserAuth
+// @Router /admin/files [post]
+func (h AdminApisHandler) UploadFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+
+	fileName := r.FormValue("fileName")
+	if len(fileName) <= 0 {
+		log.Print("Missing file name\n")
+		http.Error(w, "missing 'fileName' form param", http.StatusBadRequest)
+		return
+	}
+
+	category := r.FormValue("category")
+	if len(category) <= 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' form param", http.StatusBadRequest)
+		return
+	}
+
+	// parse and validate file and post parameters
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		log.Print("Invalid file\n")
+		http.Error(w, "Invalid file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// pass the file to be processed by the use case handler
+	resData, err := h.app.Services.UploadFileContentItem(auditActor(claims, r), file, claims, fileName, category)
+	if err != nil {
+		log.Printf("Error converting file: %s\n", err)
+		if writeFileError(w, err) || writeStorageQuotaError(w, err) {
+			return
+		}
+		http.Error(w, "Error converting file", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the file content item")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetFileContentItem Get a file to AWS S3
+// @Description Get a file to AWS S3
+// @Tags Admin
+// @ID AdminGetFileContentItem
+// @Param fileName body string false "fileName - the uploaded file name"
+// @Param category body string false "category - category of file content item"
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/files [get]
+func (h AdminApisHandler) GetFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+
+	fileName := r.URL.Query().Get("fileName")
+	if len(fileName) <= 0 {
+		log.Print("Missing file name\n")
+		http.Error(w, "missing 'fileName' query param", http.StatusBadRequest)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	if len(category) <= 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+		return
+	}
+
+	fileData, err := h.app.Services.GetFileContentItem(claims, fileName, category)
+	if err != nil {
+		log.Printf("Error getting file download stream: %s\n", err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
+		http.Error(w, "Error getting file download stream", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = io.Copy(w, fileData)
+	if err != nil {
+		log.Printf("Error copying file into response: %s\n", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// DeleteFileContentItem Deletes a file content item
+// @Description Deletes a file content item
+// @Tags Admin
+// @ID AdminDeleteFileContentItem
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/files [delete]
+func (h AdminApisHandler) DeleteFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	fileName := r.URL.Query().Get("fileName")
+	if len(fileName) <= 0 {
+		log.Print("Missing file name\n")
+		http.Error(w, "missing 'fileName' query param", http.StatusBadRequest)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	if len(category) <= 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+		return
+	}
+
+	err := h.app.Services.DeleteFileContentItem(auditActor(claims, r), claims, fileName, category)
+	if err != nil {
+		log.Printf("error on delete AWS file: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetFileContentItems Retrieves the records of the uploaded files
+// @Description Retrieves the records of the uploaded files, the newest first
+// @Tags Admin
+// @ID AdminGetFileContentItems
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param category query string false "category"
+// @Param entity_id query string false "the id of the entity the files belong to"
+// @Param account_id query string false "the account which uploaded the files"
+// @Param search query string false "search - the keys containing it, case insensitive"
+// @Param mime_type query string false "mime_type - the MIME types starting with it, like image/"
+// @Param quarantined query boolean false "quarantined - only the files flagged by the scanner or only the accepted files"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Produce json
+// @Success 200 {array} model.FileContentItem
+// @Security AdminUserAuth
+// @Router /admin/files/list [get]
+func (h AdminApisHandler) GetFileContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	category := getStringQueryParam(r, "category")
+	entityID := getStringQueryParam(r, "entity_id")
+	accountID := getStringQueryParam(r, "account_id")
+	search := getStringQueryParam(r, "search")
+	mimeType := getStringQueryParam(r, "mime_type")
+	var quarantined *bool
+	quarantinedParam := r.URL.Query().Get("quarantined")
+	if quarantinedParam != "" {
+		quarantinedVal, err := strconv.ParseBool(quarantinedParam)
+		if err != nil {
+			log.Printf("Error on parsing the quarantined param - %s\n", err)
+			http.Error(w, "invalid 'quarantined' query param", http.StatusBadRequest)
+			return
+		}
+		quarantined = &quarantinedVal
+	}
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	resData, err := h.app.Services.ListFileContentItems(allApps, claims.AppID, claims.OrgID, category, entityID, accountID, search, mimeType, quarantined, offset, limit)
+	if err != nil {
+		log.Printf("Error on getting file content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.FileContentItem{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the file content items")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetStorageUsage Retrieves the storage usage
+// @Description Retrieves the usage of the files, images, profile photos and voice records by category and month with the quotas.
+// @Description The sizes and the objects are the stored ones of all the months, the months have what was uploaded and deleted in them.
+// @Tags Admin
+// @ID AdminGetStorageUsage
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param category query string false "category - the category of the files, or images, profile_photos or voice_records"
+// @Param from query string false "from - the first month, like 2025-01"
+// @Param to query string false "to - the last month, like 2025-12"
+// @Produce json
+// @Success 200 {object} model.StorageUsageReport
+// @Security AdminUserAuth
+// @Router /admin/storage/usage [get]
+func (h AdminApisHandler) GetStorageUsage(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	category := getStringQueryParam(r, "category")
+	from := getStringQueryParam(r, "from")
+	to := getStringQueryParam(r, "to")
+	for _, month := range []*string{from, to} {
+		if month == nil {
+			continue
+		}
+		_, err := time.Parse(model.StorageUsageMonthLayout, *month)
+		if err != nil {
+			log.Printf("Error on parsing the month %s - %s\n", *month, err)
+			http.Error(w, fmt.Sprintf("invalid month %s, expected like 2025-01", *month), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resData, err := h.app.Services.GetStorageUsage(allApps, claims.AppID, claims.OrgID, category, from, to)
+	if err != nil {
+		log.Printf("Error on getting the storage usage - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the storage usage")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetStorageQuotas Retrieves the storage quotas
+// @Description Retrieves the storage quotas of the app, or of the organization with all-apps
+// @Tags Admin
+// @ID AdminGetStorageQuotas
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Produce json
+// @Success 200 {array} model.StorageQuota
+// @Security AdminUserAuth
+// @Router /admin/storage/quotas [get]
+func (h AdminApisHandler) GetStorageQuotas(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	resData, err := h.app.Services.GetStorageQuotas(allApps, claims.AppID, claims.OrgID)
+	if err != nil {
+		log.Printf("Error on getting the storage quotas - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.StorageQuota{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the storage quotas")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// storageQuotaRequestBody Expected body while setting a storage quota
+type storageQuotaRequestBody struct {
+	AllApps  bool   `json:"all_apps"` // the quota of the organization, shared by all its apps
+	Category string `json:"category"` // empty for all the categories together
+	MaxSize  int64  `json:"max_size"`
+} // @name storageQuotaRequestBody
+
+// SaveStorageQuota Sets a storage quota
+// @Description Sets the max size in bytes the app, or the organization with all_apps, may keep in a category, or in all the categories together when the category is empty.
+// @Description The uploads which take the usage over a quota are rejected.
+// @Tags Admin
+// @ID AdminSaveStorageQuota
+// @Param data body storageQuotaRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.StorageQuota
+// @Security AdminUserAuth
+// @Router /admin/storage/quotas [put]
+func (h AdminApisHandler) SaveStorageQuota(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var item storageQuotaRequestBody
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the storage quota request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if item.MaxSize <= 0 {
+		log.Print("Invalid max size\n")
+		http.Error(w, "'max_size' must be positive", http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.SaveStorageQuota(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, item.Category, item.MaxSize)
+	if err != nil {
+		log.Printf("Error on saving the storage quota - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the storage quota")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// DeleteStorageQuota Deletes a storage quota
+// @Description Deletes the storage quota of a category
+// @Tags Admin
+// @ID AdminDeleteStorageQuota
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param category query string false "category - empty for the quota of all the categories"
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/storage/quotas [delete]
+func (h AdminApisHandler) DeleteStorageQuota(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	category := r.URL.Query().Get("category")
+
+	err := h.app.Services.DeleteStorageQuota(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, category)
+	if err != nil {
+		log.Printf("Error on deleting the storage quota of category %s - %s\n", category, err)
+		if errors.Is(err, model.ErrStorageQuotaNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// webhookRequestBody Expected body while creating or updating a webhook
+type webhookRequestBody struct {
+	AllApps    bool     `json:"all_apps"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"` // generated when it is not provided on create, kept when it is not provided on update
+	Categories []string `json:"categories"`
+	Events     []string `json:"events"`
+	Active     *bool    `json:"active"` // true by default
+} // @name webhookRequestBody
+
+// webhook gives the webhook described by the request body
+func (b webhookRequestBody) webhook() model.Webhook {
+	active := b.Active == nil || *b.Active
+	return model.Webhook{URL: b.URL, Secret: b.Secret, Categories: b.Categories, Events: b.Events, Active: active}
+}
+
+// GetWebhooks Retrieves the webhooks
+// @Description Retrieves the webhooks of the app and organization. The secrets are not given back.
+// @Tags Admin
+// @ID AdminGetWebhooks
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Produce json
+// @Success 200 {array} model.Webhook
+// @Security AdminUserAuth
+// @Router /admin/webhooks [get]
+func (h AdminApisHandler) GetWebhooks(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	resData, err := h.app.Services.GetWebhooks(allApps, claims.AppID, claims.OrgID)
+	if err != nil {
+		log.Printf("Error on getting webhooks - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if resData == nil {
+		resData = []model.Webhook{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the webhooks")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// CreateWebhook Creates a webhook
+// @Description Creates a webhook. The events are posted to its url as json, signed with HMAC-SHA256 of "<X-Content-Timestamp>.<body>" keyed with the secret in the X-Content-Signature header. The secret is given back only in this response.
+// @Tags Admin
+// @ID AdminCreateWebhook
+// @Param data body webhookRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Webhook
+// @Security AdminUserAuth
+// @Router /admin/webhooks [post]
+func (h AdminApisHandler) CreateWebhook(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var item webhookRequestBody
+	err := json.NewDecoder(r.Body).Decode(&item)
+	if err != nil {
+		log.Printf("Error on unmarshal the create webhook request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateWebhook(item.URL, item.Secret, item.Events)
+	if err != nil {
+		log.Printf("Unable to create webhook: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.CreateWebhook(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, item.webhook())
+	if err != nil {
+		log.Printf("Error on creating webhook - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the created webhook")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetWebhook Retrieves a webhook
+// @Description Retrieves a webhook. The secret is not given back.
+// @Tags Admin
+// @ID AdminGetWebhook
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Produce json
+// @Success 200 {object} model.Webhook
+// @Security AdminUserAuth
+// @Router /admin/webhooks/{id} [get]
+func (h AdminApisHandler) GetWebhook(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	resData, err := h.app.Services.GetWebhook(allApps, claims.AppID, claims.OrgID, id)
 	if err != nil {
-		log.Printf("Error on getting data content type with id - %s\n", err)
+		log.Printf("Error on getting webhook with id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal of data content type")
+		log.Println("Error on marshal the webhook")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -1453,70 +3478,76 @@ func (h AdminApisHandler) GetDataContentItems(claims *tokenauth.Claims, w http.R
 	w.Write(data)
 }
 
-// UpdateDataContentItem Updates a content item.
-// @Description Updates a content item
+// UpdateWebhook Updates a webhook
+// @Description Updates a webhook. The secret is rotated when a new one is provided, otherwise it is kept.
 // @Tags Admin
-// @ID AdminUpdateDataContentItem
+// @ID AdminUpdateWebhook
+// @Param data body webhookRequestBody true "body json"
 // @Accept json
 // @Produce json
-// @Success 200 {object} model.DataContentItem
+// @Success 200 {object} model.Webhook
 // @Security AdminUserAuth
-// @Router /admin/data/ [put]
-func (h AdminApisHandler) UpdateDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+// @Router /admin/webhooks/{id} [put]
+func (h AdminApisHandler) UpdateWebhook(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
 
-	var item model.DataContentItem
+	var item webhookRequestBody
 	err := json.NewDecoder(r.Body).Decode(&item)
 	if err != nil {
-		log.Printf("Error on unmarshal the update data content item request data - %s\n", err.Error())
+		log.Printf("Error on unmarshal the update webhook request data - %s\n", err.Error())
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if len(item.Category) == 0 {
-		log.Printf("Unable to update content item: Missing category")
-		http.Error(w, "Unable to update content item: Missing category", http.StatusBadRequest)
-		return
-	}
-
-	if item.Data == nil {
-		log.Printf("Unable to update content item: Missing data")
-		http.Error(w, "Unable to update content item: Missing data", http.StatusBadRequest)
+	err = validateWebhook(item.URL, item.Secret, item.Events)
+	if err != nil {
+		log.Printf("Unable to update webhook: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	resData, err := h.app.Services.UpdateDataContentItem(claims, &item)
+	resData, err := h.app.Services.UpdateWebhook(auditActor(claims, r), item.AllApps, claims.AppID, claims.OrgID, id, item.webhook())
 	if err != nil {
-		log.Printf("Error on updating content item- %s\n", err)
+		log.Printf("Error on updating webhook with id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	jsonData, err := json.Marshal(resData)
+	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal the updated content item")
+		log.Println("Error on marshal the updated webhook")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	w.Write(data)
 }
 
-// DeleteDataContentItem Deletes a data content item with a specified key
-// @Description Deletes a data content item with the specified key
+// DeleteWebhook Deletes a webhook with its deliveries
+// @Description Deletes a webhook with its deliveries
 // @Tags Admin
-// @ID AdminDeleteDataContentItem
+// @ID AdminDeleteWebhook
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
 // @Success 200
 // @Security AdminUserAuth
-// @Router /admin/data/{key} [delete]
-func (h AdminApisHandler) DeleteDataContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+// @Router /admin/webhooks/{id} [delete]
+func (h AdminApisHandler) DeleteWebhook(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
 	vars := mux.Vars(r)
-	key := vars["key"]
+	id := vars["id"]
 
-	err := h.app.Services.DeleteDataContentItem(claims, key)
+	err := h.app.Services.DeleteWebhook(auditActor(claims, r), allApps, claims.AppID, claims.OrgID, id)
 	if err != nil {
-		log.Printf("Error on deleting data content item with key - %s\n %s", key, err)
+		log.Printf("Error on deleting webhook with id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1525,65 +3556,92 @@ func (h AdminApisHandler) DeleteDataContentItem(claims *tokenauth.Claims, w http
 	w.WriteHeader(http.StatusOK)
 }
 
-// CreateCategory Creates a category
-// @Description Creates a category
+// GetWebhookDeliveries Retrieves the deliveries of a webhook with the log of their attempts, newest first
+// @Description Retrieves the deliveries of a webhook with the log of their attempts, newest first. The failed attempts are retried with a backoff, the delivery fails after 6 attempts.
 // @Tags Admin
-// @ID AdminCreateCategory
-// @Accept json
-// @Success 200
+// @ID AdminGetWebhookDeliveries
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param status query string false "status - Possible values: pending, succeeded, failed"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Produce json
+// @Success 200 {array} model.WebhookDelivery
 // @Security AdminUserAuth
-// @Router /admin/categories [post]
-func (h AdminApisHandler) CreateCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	var item model.Category
-	err := json.NewDecoder(r.Body).Decode(&item)
-	if err != nil {
-		log.Printf("Error on unmarshal the create a category - %s\n", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h AdminApisHandler) GetWebhookDeliveries(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	status := getStringQueryParam(r, "status")
+	if status != nil && *status != model.WebhookDeliveryPending && *status != model.WebhookDeliverySucceeded && *status != model.WebhookDeliveryFailed {
+		log.Printf("Error on getting webhook deliveries - invalid status %s\n", *status)
+		http.Error(w, fmt.Sprintf("invalid status %s", *status), http.StatusBadRequest)
 		return
 	}
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
 
-	createdItem, err := h.app.Services.CreateCategory(claims, &item)
+	resData, err := h.app.Services.GetWebhookDeliveries(allApps, claims.AppID, claims.OrgID, id, status, offset, limit)
 	if err != nil {
-		log.Printf("Error on creating category %s\n", err)
+		log.Printf("Error on getting webhook deliveries for id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	jsonData, err := json.Marshal(createdItem)
+	if resData == nil {
+		resData = []model.WebhookDelivery{}
+	}
+
+	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal the new category")
+		log.Println("Error on marshal the webhook deliveries")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	w.Write(data)
 }
 
-// GetCategory Gets a category
-// @Description Gets a category
+// RedeliverWebhookDelivery Sends the payload of a delivery again
+// @Description Sends the payload of a delivery again as a new delivery, the original delivery is kept as it is
 // @Tags Admin
-// @ID AdminGetCategory
-// @Accept json
+// @ID AdminRedeliverWebhookDelivery
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
 // @Produce json
-// @Success 200
+// @Success 200 {object} model.WebhookDelivery
 // @Security AdminUserAuth
-// @Router /admin/categories/{name} [get]
-func (h AdminApisHandler) GetCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+// @Router /admin/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h AdminApisHandler) RedeliverWebhookDelivery(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
 	vars := mux.Vars(r)
-	name := vars["name"]
+	id := vars["id"]
+	deliveryID := vars["delivery_id"]
 
-	resData, err := h.app.Services.GetCategory(claims, name)
+	resData, err := h.app.Services.RedeliverWebhookDelivery(allApps, claims.AppID, claims.OrgID, id, deliveryID)
 	if err != nil {
-		log.Printf("Error on getting category with name - %s\n %s", name, err)
+		log.Printf("Error on redelivering webhook delivery - %s\n %s", deliveryID, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal of category")
+		log.Println("Error on marshal the webhook delivery")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -1593,179 +3651,235 @@ func (h AdminApisHandler) GetCategory(claims *tokenauth.Claims, w http.ResponseW
 	w.Write(data)
 }
 
-// UpdateCategory Updates a category with a specificed id.
-// @Description  Updates a category with a specificed id.
+// auditLogCSVHeader is the header row of the audit log csv export
+var auditLogCSVHeader = []string{"date", "id", "app_id", "org_id", "account_id", "account_name", "permissions", "request_id",
+	"resource", "resource_id", "category", "operation", "changes"}
+
+// GetMetaDataList Gets the meta data
+// @Description Gets the meta data of the app or the global one, by key
 // @Tags Admin
-// @ID AdminUpdateCategory
-// @Accept json
+// @ID AdminGetMetaDataList
+// @Param global query boolean false "global - the meta data of all the apps and organizations. Default: false"
+// @Param prefix query string false "prefix - the keys starting with it"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
 // @Produce json
-// @Success 200 {object} model.Category
+// @Success 200 {array} model.MetaData
 // @Security AdminUserAuth
-// @Router /admin/categories [put]
-func (h AdminApisHandler) UpdateCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	var item model.Category
-	err := json.NewDecoder(r.Body).Decode(&item)
-	if err != nil {
-		log.Printf("Error on unmarshal the update category request data - %s\n", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+// @Router /admin/meta-data [get]
+func (h AdminApisHandler) GetMetaDataList(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	global := getBoolQueryParam(r, "global", false)
+	prefix := getStringQueryParam(r, "prefix")
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
 
-	resData, err := h.app.Services.UpdateCategory(claims, &item)
+	resData, err := h.app.Services.GetMetaDataList(claims, global, prefix, offset, limit)
 	if err != nil {
-		log.Printf("Error on updating category  - %s", err)
+		log.Printf("Error on getting meta data - %s\n", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	jsonData, err := json.Marshal(resData)
+	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Println("Error on marshal the updated category")
+		log.Println("Error on marshal the meta data")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	w.Write(data)
 }
 
-// DeleteCategory Deletes a category with specified key
-// @Description Deletes a category with specified key
+type adminCreateOrUpdateMetaDataRequestBody struct {
+	Global      bool                   `json:"global"`
+	Key         string                 `json:"key"`
+	Value       map[string]interface{} `json:"value"`
+	Permissions *[]string              `json:"permissions"` // the current ones are kept when it is missing
+} // @name adminCreateOrUpdateMetaDataRequestBody
+
+// CreateOrUpdateMetaData Creates or updates meta data
+// @Description Creates or updates meta data of the app or a global one. Any of the permissions is needed to update and delete it afterwards, the meta data without permissions is changed only by the admins.
 // @Tags Admin
-// @ID AdminDeleteCategory
-// @Success 200
+// @ID AdminCreateOrUpdateMetaData
+// @Param data body adminCreateOrUpdateMetaDataRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.MetaData
 // @Security AdminUserAuth
-// @Router /admin/categories/{name} [delete]
-func (h AdminApisHandler) DeleteCategory(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+// @Router /admin/meta-data [post]
+func (h AdminApisHandler) CreateOrUpdateMetaData(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	var body adminCreateOrUpdateMetaDataRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		log.Printf("Error on unmarshal the meta data request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.Key) == 0 {
+		log.Print("Missing meta data key\n")
+		http.Error(w, "missing 'key'", http.StatusBadRequest)
+		return
+	}
 
-	err := h.app.Services.DeleteCategory(claims, name)
+	resData, err := h.app.Services.CreateOrUpdateMetaData(auditActor(claims, r), claims, true, body.Global, body.Key, body.Value, body.Permissions)
 	if err != nil {
-		log.Printf("Error on deleting category with name - %s\n %s", name, err)
+		log.Printf("Error on creating or updating meta data with key - %s\n %s", body.Key, err)
+		if writeMetaDataAccessError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the meta data")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
+	w.Write(data)
 }
 
-// UploadFileContentItem Uploads a file to AWS S3
-// @Description Uploads a file to AWS S3
+// DeleteMetaData Deletes meta data
+// @Description Deletes meta data of the app or a global one by key
 // @Tags Admin
-// @ID AdminUploadFileContentItem
-// @Param fileName body string false "fileName - the uploaded file name"
-// @Param category body string false "category - category of file content item"
+// @ID AdminDeleteMetaData
+// @Param key query string true "key"
+// @Param global query boolean false "global - the meta data of all the apps and organizations. Default: false"
 // @Success 200
 // @Security AdminUserAuth
-// @Router /admin/files [post]
-func (h AdminApisHandler) UploadFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-
-	fileName := r.FormValue("fileName")
-	if len(fileName) <= 0 {
-		log.Print("Missing file name\n")
-		http.Error(w, "missing 'fileName' form param", http.StatusBadRequest)
+// @Router /admin/meta-data [delete]
+func (h AdminApisHandler) DeleteMetaData(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if len(key) == 0 {
+		log.Print("Missing key\n")
+		http.Error(w, "missing 'key' query param", http.StatusBadRequest)
 		return
 	}
+	global := getBoolQueryParam(r, "global", false)
 
-	category := r.FormValue("category")
-	if len(category) <= 0 {
-		log.Print("Missing category\n")
-		http.Error(w, "missing 'category' form param", http.StatusBadRequest)
-		return
-	}
-
-	// parse and validate file and post parameters
-	file, _, err := r.FormFile("file")
-	if err != nil {
-		log.Print("Invalid file\n")
-		http.Error(w, "Invalid file", http.StatusBadRequest)
-		return
-	}
-	defer file.Close()
-
-	// pass the file to be processed by the use case handler
-	err = h.app.Services.UploadFileContentItem(file, claims, fileName, category)
+	err := h.app.Services.DeleteMetaData(auditActor(claims, r), claims, true, global, key)
 	if err != nil {
-		log.Printf("Error converting file: %s\n", err)
-		http.Error(w, "Error converting file", http.StatusInternalServerError)
+		log.Printf("Error on deleting meta data with key - %s\n %s", key, err)
+		if writeMetaDataAccessError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 }
 
-// GetFileContentItem Get a file to AWS S3
-// @Description Get a file to AWS S3
+// GetAuditLog Retrieves the audit log of the admin mutations
+// @Description Retrieves the audit log of the admin mutations, the latest first. Every entry has who did the mutation, in which request and the changes it made.
 // @Tags Admin
-// @ID AdminGetFileContentItem
-// @Param fileName body string false "fileName - the uploaded file name"
-// @Param category body string false "category - category of file content item"
-// @Success 200
+// @ID AdminGetAuditLog
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param actor query string false "the account id of the admin"
+// @Param resource query string false "resource - Possible values: content_item, data_content_item, category, meta_data, file, image, student_guide, health_location, webhook"
+// @Param resource_id query string false "the id of the resource - the key for the data content items and the meta data, the name for the categories"
+// @Param category query string false "category"
+// @Param from query string false "RFC 3339 time, the entries since then"
+// @Param to query string false "RFC 3339 time, the entries before then"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Param format query string false "format - Possible values: json, csv. Default - json"
+// @Produce json
+// @Produce text/csv
+// @Success 200 {array} model.AuditLogEntry
 // @Security AdminUserAuth
-// @Router /admin/files [get]
-func (h AdminApisHandler) GetFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+// @Router /admin/audit [get]
+func (h AdminApisHandler) GetAuditLog(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
 
-	fileName := r.URL.Query().Get("fileName")
-	if len(fileName) <= 0 {
-		log.Print("Missing file name\n")
-		http.Error(w, "missing 'fileName' query param", http.StatusBadRequest)
+	actor := getStringQueryParam(r, "actor")
+	resource := getStringQueryParam(r, "resource")
+	resourceID := getStringQueryParam(r, "resource_id")
+	category := getStringQueryParam(r, "category")
+	from, err := getTimeQueryParam(r, "from")
+	if err != nil {
+		log.Printf("Error on getting audit log - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	category := r.URL.Query().Get("category")
-	if len(category) <= 0 {
-		log.Print("Missing category\n")
-		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+	to, err := getTimeQueryParam(r, "to")
+	if err != nil {
+		log.Printf("Error on getting audit log - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
 
-	fileData, err := h.app.Services.GetFileContentItem(claims, fileName, category)
-	if err != nil {
-		log.Printf("Error getting file download stream: %s\n", err)
-		http.Error(w, "Error getting file download stream", http.StatusInternalServerError)
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		log.Printf("Error on getting audit log - invalid format %s\n", format)
+		http.Error(w, fmt.Sprintf("invalid format %s", format), http.StatusBadRequest)
 		return
 	}
 
-	_, err = io.Copy(w, fileData)
+	resData, err := h.app.Services.GetAuditLog(allApps, claims.AppID, claims.OrgID, actor, resource, resourceID, category, from, to, offset, limit)
 	if err != nil {
-		log.Printf("Error copying file into response: %s\n", err.Error())
+		log.Printf("Error on getting audit log - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
-	w.Header().Set("Cache-Control", "no-store")
-}
-
-// DeleteFileContentItem Deletes a file content item
-// @Description Deletes a file content item
-// @Tags Admin
-// @ID AdminDeleteFileContentItem
-// @Success 200
-// @Security AdminUserAuth
-// @Router /admin/files [delete]
-func (h AdminApisHandler) DeleteFileContentItem(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	fileName := r.URL.Query().Get("fileName")
-	if len(fileName) <= 0 {
-		log.Print("Missing file name\n")
-		http.Error(w, "missing 'fileName' query param", http.StatusBadRequest)
+	if format == "csv" {
+		writeAuditLogCSV(w, resData)
 		return
 	}
 
-	category := r.URL.Query().Get("category")
-	if len(category) <= 0 {
-		log.Print("Missing category\n")
-		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
-		return
+	if resData == nil {
+		resData = []model.AuditLogEntry{}
 	}
 
-	err := h.app.Services.DeleteFileContentItem(claims, fileName, category)
+	data, err := json.Marshal(resData)
 	if err != nil {
-		log.Printf("error on delete AWS file: %s", err)
+		log.Println("Error on marshal the audit log")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// writeAuditLogCSV writes the audit log entries as a csv attachment, the changes go as json
+func writeAuditLogCSV(w http.ResponseWriter, entries []model.AuditLogEntry) {
+	rows := make([][]string, 0, len(entries)+1)
+	rows = append(rows, auditLogCSVHeader)
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			log.Printf("Error on marshal the changes of audit log entry %s - %s\n", entry.ID, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		rows = append(rows, []string{entry.Date.Format(time.RFC3339), entry.ID, entry.AppID, entry.OrgID, entry.AccountID, entry.AccountName,
+			strings.Join(entry.Permissions, ","), entry.RequestID, entry.Resource, entry.ResourceID, entry.Category, entry.Operation, string(changes)})
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit_log.csv"`)
 	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	err := writer.WriteAll(rows)
+	if err != nil {
+		log.Printf("Error on writing the audit log csv - %s\n", err)
+	}
 }
@@ -0,0 +1,57 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"content/core/model"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteAuditLogCSV(t *testing.T) {
+	date := time.Date(2022, 5, 17, 10, 30, 0, 0, time.UTC)
+	entries := []model.AuditLogEntry{
+		{ID: "1", AppID: "app", OrgID: "org", AccountID: "admin", AccountName: "Admin, Jr.", Permissions: []string{"all_content-items", "approve_content-items"},
+			RequestID: "request", Resource: model.AuditResourceContentItem, ResourceID: "item", Category: "events", Operation: model.AuditOperationUpdate,
+			Changes: []model.ContentItemChange{{Path: "/data/title", Op: "changed", From: "a", To: "b \"quoted\""}}, Date: date},
+		{ID: "2", AppID: "app", OrgID: "org", AccountID: "admin", Permissions: []string{}, Resource: model.AuditResourceWebhook, ResourceID: "hook",
+			Operation: model.AuditOperationDelete, Changes: []model.ContentItemChange{}, Date: date},
+	}
+
+	w := httptest.NewRecorder()
+	writeAuditLogCSV(w, entries)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		w.Header().Get("Content-Disposition") != `attachment; filename="audit_log.csv"` {
+		t.Fatalf("writeAuditLogCSV() = %d %v, want a csv attachment", w.Code, w.Header())
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll() error = %v", err)
+	}
+	want := [][]string{
+		auditLogCSVHeader,
+		{"2022-05-17T10:30:00Z", "1", "app", "org", "admin", "Admin, Jr.", "all_content-items,approve_content-items", "request",
+			"content_item", "item", "events", "update", `[{"path":"/data/title","op":"changed","from":"a","to":"b \"quoted\""}]`},
+		{"2022-05-17T10:30:00Z", "2", "app", "org", "admin", "", "", "", "webhook", "hook", "", "delete", "[]"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("writeAuditLogCSV() rows = %q, want %q", rows, want)
+	}
+}
@@ -17,14 +17,18 @@ package rest
 import (
 	"content/core"
 	"content/core/model"
+	"content/utils"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
@@ -35,6 +39,11 @@ import (
 
 const maxUploadSize = 15 * 1024 * 1024 // 15 mb
 
+// maxPresignedObjectSize is the most a presigned upload or a part of a multipart upload may put - the most S3 takes in a single request
+const maxPresignedObjectSize = 5 * 1024 * 1024 * 1024 // 5 gb
+
+const contentChangesHeartbeat = 30 * time.Second
+
 // ApisHandler handles the rest APIs implementation
 type ApisHandler struct {
 	app *core.Application
@@ -51,6 +60,85 @@ func (h ApisHandler) Version(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(h.app.Services.GetVersion()))
 }
 
+// GetObject Downloads an object with a presigned URL
+// @Description Downloads an object of the local object storage with a URL presigned by this service. The URLs of the public objects do not expire.
+// @Tags Client
+// @ID GetObject
+// @Param expires query integer true "expires - unix time the URL expires at, 0 for the public objects"
+// @Param signature query string true "signature - the signature of the URL"
+// @Produce octet-stream
+// @Success 200
+// @Router /objects/{bucket}/{key} [get]
+func (h ApisHandler) GetObject(w http.ResponseWriter, r *http.Request) {
+	request, err := getPresignedObjectParams(r)
+	if err != nil {
+		log.Printf("Error on getting object - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	object, err := h.app.Services.DownloadPresignedObject(*request)
+	if err != nil {
+		log.Printf("Error on getting object %s/%s - %s\n", request.Bucket, request.Key, err)
+		if writeObjectError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer object.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(request.Key))
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, object)
+	if err != nil {
+		log.Printf("Error on streaming object %s/%s - %s\n", request.Bucket, request.Key, err)
+	}
+}
+
+// PutObject Uploads an object or a part of a multipart upload with a presigned URL
+// @Description Uploads an object to the local object storage with a URL presigned by this service. The body is the content of the object.
+// @Description The part URLs of a multipart upload also have the upload id and the part number, the ETag header of the response is needed for completing the upload.
+// @Description An object or a part is at most 5 GB.
+// @Tags Client
+// @ID PutObject
+// @Param expires query integer true "expires - unix time the URL expires at"
+// @Param signature query string true "signature - the signature of the URL"
+// @Param upload_id query string false "upload_id - the multipart upload the part belongs to"
+// @Param part_number query integer false "part_number - the number of the part, required with upload_id"
+// @Accept octet-stream
+// @Success 200
+// @Failure 413 {string} string "the object or the part is larger than 5 GB"
+// @Router /objects/{bucket}/{key} [put]
+func (h ApisHandler) PutObject(w http.ResponseWriter, r *http.Request) {
+	request, err := getPresignedObjectParams(r)
+	if err != nil {
+		log.Printf("Error on putting object - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPresignedObjectSize)
+	etag, err := h.app.Services.UploadPresignedObject(*request, r.Body)
+	if err != nil {
+		log.Printf("Error on putting object %s/%s - %s\n", request.Bucket, request.Key, err)
+		if writeObjectError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(etag) > 0 {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // GetProfilePhoto Retrieves the profile photo
 // @Description Retrieves the profile photo
 // @Tags Client
@@ -129,6 +217,7 @@ func (h ApisHandler) GetUserProfilePhoto(claims *tokenauth.Claims, w http.Respon
 // @ID StoreProfilePhoto
 // @Accept json
 // @Success 200
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
 // @Security RokwireAuth
 // @Router /profile_photo [post]
 func (h ApisHandler) StoreProfilePhoto(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -170,9 +259,12 @@ func (h ApisHandler) StoreProfilePhoto(claims *tokenauth.Claims, w http.Response
 		return
 	}
 
-	err = h.app.Services.UploadProfileImage(claims.Subject, fileBytes)
+	err = h.app.Services.UploadProfileImage(claims, fileBytes)
 	if err != nil {
 		log.Printf("Error converting image: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error converting image", http.StatusInternalServerError)
 		return
 	}
@@ -190,7 +282,7 @@ func (h ApisHandler) StoreProfilePhoto(claims *tokenauth.Claims, w http.Response
 // @Router /profile_photo [get]
 func (h ApisHandler) DeleteProfilePhoto(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
 
-	err := h.app.Services.DeleteProfileImage(claims.Subject)
+	err := h.app.Services.DeleteProfileImage(claims)
 	if err != nil {
 		if err != nil {
 			log.Printf("error on delete AWS profile image: %s", err)
@@ -247,9 +339,12 @@ func (h ApisHandler) StoreVoiceRecord(claims *tokenauth.Claims, w http.ResponseW
 	}
 
 	// upload voice record
-	err = h.app.Services.UploadVoiceRecord(claims.Subject, fileBytes)
+	err = h.app.Services.UploadVoiceRecord(claims, fileBytes)
 	if err != nil {
 		log.Printf("Error uploading voice record: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error uploading voice record", http.StatusInternalServerError)
 		return
 	}
@@ -301,7 +396,7 @@ func (h ApisHandler) GetUserVoiceRecord(claims *tokenauth.Claims, w http.Respons
 
 // DeleteVoiceRecord deletes the user voice record
 func (h ApisHandler) DeleteVoiceRecord(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
-	err := h.app.Services.DeleteVoiceRecord(claims.Subject)
+	err := h.app.Services.DeleteVoiceRecord(claims)
 	if err != nil {
 		if err != nil {
 			log.Printf("error on delete AWS voice audio file: %s", err)
@@ -466,11 +561,17 @@ func (h ApisHandler) GetHealthLocation(claims *tokenauth.Claims, w http.Response
 // @Description Retrieves  all content items. <b> The data element could be either a primitive or nested json or array.</b>
 // @Tags Client
 // @ID GetContentItems
+// @Param lang query string false "lang - comma separated list of the preferred locales, it goes before the Accept-Language header"
+// @Param If-None-Match header string false "the ETag of the representation the client has, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "used only without If-None-Match, 304 is returned if nothing has been updated since then"
 // @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
 // @Param offset query string false "offset"
 // @Param limit query string false "limit - limit the result"
 // @Param order query string false "order - Possible values: asc, desc. Default: desc"
-// @Param data body getContentItemsRequestBody false "Optional - body json of the all items ids that need to be filtered. NOTE: Bad/broken json will be interpreted as an empty filter and the request will be proceeded further."
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page. The offset is not used then."
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
+// @Param expand query integer false "expand - how many levels of the references in the data to resolve, up to 3. A reference like {\"$ref\": \"content_item:<id>\"} gets the referenced item as $value. No ETag is used with it."
+// @Param data body getContentItemsRequestBody false "Optional - body json of the all items ids that need to be filtered and the filter expressions on the item data. NOTE: Bad/broken json will be interpreted as an empty filter and the request will be proceeded further, invalid filter expressions are rejected."
 // @Accept json
 // @Success 200 {array} model.ContentItem
 // @Security UserAuth
@@ -517,15 +618,76 @@ func (h ApisHandler) GetContentItems(claims *tokenauth.Claims, w http.ResponseWr
 		}
 	}
 
-	resData, err := h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, body.IDs, body.Categories, offset, limit, order)
+	dataFilter, err := utils.ParseFilter(body.Filters, "data")
 	if err != nil {
-		log.Printf("Error on cgetting content items - %s\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if resData == nil {
-		resData = []model.ContentItemResponse{}
+	pageParams, err := getPageQueryParams(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expand, err := getExpandQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//clients see only the published items within their publish window
+	state := model.ContentItemStateLive
+	workflowState := model.ContentItemWorkflowPublished
+	preferredLocales := getPreferredLocales(r)
+
+	var resData interface{}
+	var items []model.ContentItemResponse
+	variant := listVariant(preferredLocales, nil, nil)
+	if pageParams != nil {
+		page, err := h.app.Services.GetContentItemsPage(allApps, claims.AppID, claims.OrgID, body.IDs, body.Categories, &state, &workflowState, dataFilter,
+			pageParams.cursor, pageParams.limit, order, pageParams.withTotal)
+		if err != nil {
+			log.Printf("Error on getting content items page - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items = page.Items
+		variant = listVariant(preferredLocales, page.NextCursor, page.Total)
+		resData = page
+	} else {
+		items, err = h.app.Services.GetContentItems(allApps, claims.AppID, claims.OrgID, body.IDs, body.Categories, &state, &workflowState, dataFilter, offset, limit, order)
+		if err != nil {
+			log.Printf("Error on cgetting content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if items == nil {
+			items = []model.ContentItemResponse{}
+		}
+		resData = items
+	}
+
+	var revisions []model.ItemRevision
+	for _, item := range items {
+		h.app.Services.LocalizeContentItem(item, preferredLocales)
+		revisions = append(revisions, contentItemRevision(item))
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	if expand > 0 {
+		//the revisions of the referenced items are not known, so there is no ETag
+		err = h.app.Services.ExpandContentItemReferences(claims, items, preferredLocales, expand)
+		if err != nil {
+			log.Printf("Error on expanding the references of the content items - %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if checkNotModified(w, r, model.EntityTag(variant, revisions...), model.LastModified(revisions...)) {
+		return
 	}
 
 	data, err := json.Marshal(resData)
@@ -544,7 +706,11 @@ func (h ApisHandler) GetContentItems(claims *tokenauth.Claims, w http.ResponseWr
 // @Description Retrieves a content item by id. <b> The data element could be either a primitive or nested json or array.</b>
 // @Tags Client
 // @ID GetContentItem
+// @Param lang query string false "lang - comma separated list of the preferred locales, it goes before the Accept-Language header"
+// @Param If-None-Match header string false "the ETag of the representation the client has, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "used only without If-None-Match, 304 is returned if nothing has been updated since then"
 // @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param expand query integer false "expand - how many levels of the references in the data to resolve, up to 3. A reference like {\"$ref\": \"content_item:<id>\"} gets the referenced item as $value. No ETag is used with it."
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.ContentItem
@@ -561,12 +727,41 @@ func (h ApisHandler) GetContentItem(claims *tokenauth.Claims, w http.ResponseWri
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetContentItem(allApps, claims.AppID, claims.OrgID, id)
+	expand, err := getExpandQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting content item id - %s\n %s", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	state := model.ContentItemStateLive
+	workflowState := model.ContentItemWorkflowPublished
+
+	resData, err := h.app.Services.GetContentItem(allApps, claims.AppID, claims.OrgID, id, &state, &workflowState)
 	if err != nil {
 		log.Printf("Error on getting content item id - %s\n %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	preferredLocales := getPreferredLocales(r)
+	locale := h.app.Services.LocalizeContentItem(*resData, preferredLocales)
+
+	if len(locale) > 0 {
+		w.Header().Set("Content-Language", locale)
+	}
+	w.Header().Set("Vary", "Accept-Language")
+	revision := contentItemRevision(*resData)
+	if expand > 0 {
+		//the revisions of the referenced items are not known, so there is no ETag
+		err = h.app.Services.ExpandContentItemReferences(claims, []model.ContentItemResponse{*resData}, preferredLocales, expand)
+		if err != nil {
+			log.Printf("Error on expanding the references of content item id - %s\n %s", id, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if checkNotModified(w, r, model.EntityTag(locale, revision), revision.DateUpdated) {
+		return
+	}
 
 	data, err := json.Marshal(resData)
 	if err != nil {
@@ -580,6 +775,57 @@ func (h ApisHandler) GetContentItem(claims *tokenauth.Claims, w http.ResponseWri
 	w.Write(data)
 }
 
+// SearchContentItems Searches the content items and the data content items, the best matches come first
+// @Description Searches the content items and the data content items, the best matches come first. HTML in the data is searched as plain text.
+// @Tags Client
+// @ID SearchContentItems
+// @Param q query string true "q - the text to search for"
+// @Param categories query string false "categories - comma separated list of categories to restrict the search to"
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Param offset query integer false "offset - Default: 0, max: 1000"
+// @Param limit query integer false "limit - limit the result. Default: 20, max: 100"
+// @Produce json
+// @Success 200 {array} model.SearchResult
+// @Security UserAuth
+// @Router /content_items/search [get]
+func (h ApisHandler) SearchContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	params, err := getSearchQueryParams(r)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//clients see only the published items within their publish window
+	state := model.ContentItemStateLive
+	workflowState := model.ContentItemWorkflowPublished
+
+	resData, err := h.app.Services.SearchContentItems(claims, allApps, claims.AppID, claims.OrgID, params.text, params.categories, &state, &workflowState, params.offset, params.limit)
+	if err != nil {
+		log.Printf("Error on searching content items - %s\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal the search results")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
 // GetContentItemsCategories Retrieves  all content item categories that have in the database
 // @Description Retrieves  all content item categories that have in the database
 // @Tags Client
@@ -631,6 +877,7 @@ func (h ApisHandler) GetContentItemsCategories(claims *tokenauth.Claims, w http.
 // @Accept multipart/form-data
 // @Produce json
 // @Success 200
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
 // @Security UserAuth
 // @Router /image [post]
 func (h ApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -684,9 +931,12 @@ func (h ApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWriter
 	}
 
 	// pass the file to be processed by the use case handler
-	objectLocation, err := h.app.Services.UploadImage(fileBytes, path, imgSpec)
+	objectLocation, err := h.app.Services.UploadImage(nil, claims, fileBytes, path, imgSpec)
 	if err != nil {
 		log.Printf("Error converting image: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error converting image", http.StatusInternalServerError)
 		return
 	}
@@ -757,6 +1007,10 @@ func (h ApisHandler) GetTweeterPosts(claims *tokenauth.Claims, w http.ResponseWr
 // @Description Gets a data content type item
 // @Tags Client
 // @ID GetDataContentItem
+// @Param lang query string false "lang - comma separated list of the preferred locales, it goes before the Accept-Language header"
+// @Param If-None-Match header string false "the ETag of the representation the client has, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "used only without If-None-Match, 304 is returned if nothing has been updated since then"
+// @Param expand query integer false "expand - how many levels of the references in the data to resolve, up to 3. A reference like {\"$ref\": \"content_item:<id>\"} gets the referenced item as $value. No ETag is used with it."
 // @Accept json
 // @Produce json
 // @Success 200
@@ -766,12 +1020,41 @@ func (h ApisHandler) GetDataContentItem(claims *tokenauth.Claims, w http.Respons
 	vars := mux.Vars(r)
 	key := vars["key"]
 
+	expand, err := getExpandQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting data content type with key - %s\n %s", key, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	resData, err := h.app.Services.GetDataContentItem(claims, key)
 	if err != nil {
 		log.Printf("Error on getting data content type with key - %s\n %s", key, err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	preferredLocales := getPreferredLocales(r)
+	locale := h.app.Services.LocalizeDataContentItem(resData, preferredLocales)
+
+	if len(locale) > 0 {
+		w.Header().Set("Content-Language", locale)
+	}
+	w.Header().Set("Vary", "Accept-Language")
+	revision := resData.Revision()
+	if expand > 0 {
+		//the revisions of the referenced items are not known, so there is no ETag
+		err = h.app.Services.ExpandDataContentItemReferences(claims, []*model.DataContentItem{resData}, preferredLocales, expand)
+		if err != nil {
+			log.Printf("Error on expanding the references of data content type with key - %s\n %s", key, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if checkNotModified(w, r, model.EntityTag(locale, revision), revision.DateUpdated) {
+		return
+	}
 
 	data, err := json.Marshal(resData)
 	if err != nil {
@@ -813,6 +1096,9 @@ func (h ApisHandler) GetFileContentItem(claims *tokenauth.Claims, w http.Respons
 	fileData, err := h.app.Services.GetFileContentItem(claims, fileName, category)
 	if err != nil {
 		log.Printf("Error getting file download stream: %s\n", err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
 		http.Error(w, "Error getting file download stream", http.StatusInternalServerError)
 		return
 	}
@@ -835,6 +1121,8 @@ func (h ApisHandler) GetFileContentItem(claims *tokenauth.Claims, w http.Respons
 // @Param category body string false "category - category of file content item"
 // @Param entityID body string false "category - id of entity file content item belongs to"
 // @Success 200
+// @Failure 403 {string} string "unauthorized to write the items of the category"
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
 // @Security UserAuth
 // @Router /files/upload [get]
 func (h ApisHandler) GetFileContentUploadURLs(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -891,6 +1179,9 @@ func (h ApisHandler) GetFileContentUploadURLs(claims *tokenauth.Claims, w http.R
 	fileRefs, err := h.app.Services.GetFileContentUploadURLs(claims, fileNames, entityID, category, addAppOrgIDToPath, handleDuplicateFileNames, publicRead)
 	if err != nil {
 		log.Printf("Error getting file upload references: %s\n", err)
+		if writeCategoryAccessError(w, err) || writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error getting file upload references", http.StatusInternalServerError)
 		return
 	}
@@ -907,6 +1198,337 @@ func (h ApisHandler) GetFileContentUploadURLs(claims *tokenauth.Claims, w http.R
 	w.Write(data)
 }
 
+// ConfirmFileContentUploads Confirms the uploads with presigned URLs
+// @Description Confirms the files uploaded with the presigned URLs of /files/upload, the files are recorded with their size, MIME type and checksum.
+// @Description Confirming a file again updates its record.
+// @Description The files are served only once they are confirmed.
+// @Tags Client
+// @ID ConfirmFileContentUploads
+// @Param fileKeys query string true "fileKeys - comma-separated list of the keys of the upload references"
+// @Param category query string true "category - category of file content item"
+// @Param entityID query string false "entityID - id of entity file content item belongs to"
+// @Param add-path-apporg-id query boolean false "add-path-apporg-id - the path has the org and app ids, true by default"
+// @Produce json
+// @Success 200 {array} model.FileContentItem
+// @Failure 413 {string} string "a file is larger than the max size of the category, it is deleted"
+// @Failure 415 {string} string "the MIME type of a file is not allowed in the category, it is deleted"
+// @Failure 422 {string} string "a file is flagged by the scanner and quarantined"
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
+// @Security UserAuth
+// @Router /files/upload/complete [post]
+func (h ApisHandler) ConfirmFileContentUploads(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	fileKeysStr := r.URL.Query().Get("fileKeys")
+	if len(fileKeysStr) == 0 {
+		log.Print("Missing file keys query param\n")
+		http.Error(w, "missing 'fileKeys' query param", http.StatusBadRequest)
+		return
+	}
+	fileKeys := strings.Split(fileKeysStr, ",")
+
+	entityID := r.URL.Query().Get("entityID")
+	category := r.URL.Query().Get("category")
+	if len(category) == 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+		return
+	}
+
+	addAppOrgIDToPath := true
+	addAppOrgIDToPathStr := r.URL.Query().Get("add-path-apporg-id")
+	if addAppOrgIDToPathStr != "" {
+		addAppOrgIDToPathVal, err := strconv.ParseBool(addAppOrgIDToPathStr)
+		if err == nil {
+			addAppOrgIDToPath = addAppOrgIDToPathVal
+		}
+	}
+
+	resData, err := h.app.Services.ConfirmFileContentUploads(auditActor(claims, r), claims, fileKeys, entityID, category, addAppOrgIDToPath)
+	if err != nil {
+		log.Printf("Error on confirming file uploads: %s\n", err)
+		if writeCategoryAccessError(w, err) || writeObjectError(w, err) || writeFileError(w, err) || writeStorageQuotaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal of file content items")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetFileContentItems Retrieves the records of the files of a category
+// @Description Retrieves the records of the uploaded files of a category, the newest first
+// @Tags Client
+// @ID GetFileContentItems
+// @Param category query string true "category - category of file content item"
+// @Param entityID query string false "entityID - id of entity the files belong to"
+// @Param search query string false "search - the keys containing it, case insensitive"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Produce json
+// @Success 200 {array} model.FileContentItem
+// @Security UserAuth
+// @Router /files/list [get]
+func (h ApisHandler) GetFileContentItems(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	if len(category) == 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+		return
+	}
+	entityID := getStringQueryParam(r, "entityID")
+	search := getStringQueryParam(r, "search")
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	resData, err := h.app.Services.GetFileContentItems(claims, category, entityID, search, offset, limit)
+	if err != nil {
+		log.Printf("Error on getting file content items of %s: %s\n", category, err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.FileContentItem{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Println("Error on marshal of file content items")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// CreateFileContentMultipartUpload Initiates a multipart upload of a large file
+// @Description Initiates a multipart upload of a large file, the parts are uploaded with the URLs of the parts endpoint and the upload is completed with their ETags.
+// @Description The incomplete uploads are aborted after a day.
+// @Tags Client
+// @ID CreateFileContentMultipartUpload
+// @Param fileName query string true "fileName - the name of the file"
+// @Param category query string true "category - category of file content item"
+// @Param entityID query string false "entityID - id of entity file content item belongs to"
+// @Param handle-duplicate-filenames query boolean false "handle-duplicate-filenames - prefix the file name with a random id, true by default"
+// @Param add-path-apporg-id query boolean false "add-path-apporg-id - prefix the path with the org and app ids, true by default"
+// @Param public-read query boolean false "public-read - the file can be read by anyone, true by default"
+// @Produce json
+// @Success 200 {object} model.MultipartUpload
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
+// @Security UserAuth
+// @Router /files/upload/multipart [post]
+func (h ApisHandler) CreateFileContentMultipartUpload(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	fileName := r.URL.Query().Get("fileName")
+	if len(fileName) == 0 {
+		log.Print("Missing file name query param\n")
+		http.Error(w, "missing 'fileName' query param", http.StatusBadRequest)
+		return
+	}
+
+	entityID := r.URL.Query().Get("entityID")
+	category := r.URL.Query().Get("category")
+	if len(category) == 0 {
+		log.Print("Missing category\n")
+		http.Error(w, "missing 'category' query param", http.StatusBadRequest)
+		return
+	}
+
+	handleDuplicateFileNames := true
+	handleDuplicateFileNamesStr := r.URL.Query().Get("handle-duplicate-filenames")
+	if handleDuplicateFileNamesStr != "" {
+		handleDuplicateFileNamesVal, err := strconv.ParseBool(handleDuplicateFileNamesStr)
+		if err == nil {
+			handleDuplicateFileNames = handleDuplicateFileNamesVal
+		}
+	}
+
+	addAppOrgIDToPath := true
+	addAppOrgIDToPathStr := r.URL.Query().Get("add-path-apporg-id")
+	if addAppOrgIDToPathStr != "" {
+		addAppOrgIDToPathVal, err := strconv.ParseBool(addAppOrgIDToPathStr)
+		if err == nil {
+			addAppOrgIDToPath = addAppOrgIDToPathVal
+		}
+	}
+
+	publicRead := true
+	publicReadStr := r.URL.Query().Get("public-read")
+	if publicReadStr != "" {
+		publicReadVal, err := strconv.ParseBool(publicReadStr)
+		if err == nil {
+			publicRead = publicReadVal
+		}
+	}
+
+	upload, err := h.app.Services.CreateFileContentMultipartUpload(claims, fileName, entityID, category, addAppOrgIDToPath, handleDuplicateFileNames, publicRead)
+	if err != nil {
+		log.Printf("Error on creating multipart upload of %s: %s\n", fileName, err)
+		if writeCategoryAccessError(w, err) || writeStorageQuotaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(upload)
+	if err != nil {
+		log.Println("Error on marshal of multipart upload")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetFileContentUploadPartURLs Gets URLs to upload parts of a multipart upload
+// @Description Gets presigned URLs to upload parts of a multipart upload with PUT, the ETag header of every part upload response is needed for completing the upload.
+// @Tags Client
+// @ID GetFileContentUploadPartURLs
+// @Param id path string true "id - the id of the multipart upload"
+// @Param partNumbers query string true "partNumbers - comma-separated list of part numbers between 1 and 10000"
+// @Produce json
+// @Success 200 {array} model.UploadPartRef
+// @Security UserAuth
+// @Router /files/upload/multipart/{id}/parts [get]
+func (h ApisHandler) GetFileContentUploadPartURLs(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	partNumbersStr := r.URL.Query().Get("partNumbers")
+	if len(partNumbersStr) == 0 {
+		log.Print("Missing part numbers query param\n")
+		http.Error(w, "missing 'partNumbers' query param", http.StatusBadRequest)
+		return
+	}
+	partNumbersList := strings.Split(partNumbersStr, ",")
+	partNumbers := make([]int, len(partNumbersList))
+	for i, partNumberStr := range partNumbersList {
+		partNumber, err := strconv.Atoi(strings.TrimSpace(partNumberStr))
+		if err != nil || partNumber < 1 || partNumber > model.MaxUploadParts {
+			log.Printf("Invalid part number %s\n", partNumberStr)
+			http.Error(w, fmt.Sprintf("invalid part number %s, the part numbers are between 1 and %d", partNumberStr, model.MaxUploadParts), http.StatusBadRequest)
+			return
+		}
+		partNumbers[i] = partNumber
+	}
+
+	partRefs, err := h.app.Services.GetFileContentUploadPartURLs(claims, id, partNumbers)
+	if err != nil {
+		log.Printf("Error getting upload part references of %s: %s\n", id, err)
+		if writeMultipartUploadError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(partRefs)
+	if err != nil {
+		log.Println("Error on marshal of upload part references")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+type completeMultipartUploadRequestBody struct {
+	Parts []model.UploadedPart `json:"parts"`
+} // @name completeMultipartUploadRequestBody
+
+// CompleteFileContentMultipartUpload Completes a multipart upload
+// @Description Completes a multipart upload, the file is assembled from the parts in the order of their part numbers.
+// @Tags Client
+// @ID CompleteFileContentMultipartUpload
+// @Param id path string true "id - the id of the multipart upload"
+// @Param data body completeMultipartUploadRequestBody true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.FileContentItemRef
+// @Failure 413 {string} string "the file is larger than the max size of the category, it is deleted"
+// @Failure 415 {string} string "the MIME type of the file is not allowed in the category, it is deleted"
+// @Failure 422 {string} string "the file is flagged by the scanner and quarantined"
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
+// @Security UserAuth
+// @Router /files/upload/multipart/{id}/complete [post]
+func (h ApisHandler) CompleteFileContentMultipartUpload(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var body completeMultipartUploadRequestBody
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		log.Printf("Error on unmarshal the complete multipart upload request data - %s\n", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body.Parts) == 0 {
+		log.Print("Missing the uploaded parts\n")
+		http.Error(w, "missing 'parts'", http.StatusBadRequest)
+		return
+	}
+
+	fileRef, err := h.app.Services.CompleteFileContentMultipartUpload(auditActor(claims, r), claims, id, body.Parts)
+	if err != nil {
+		log.Printf("Error on completing multipart upload %s: %s\n", id, err)
+		if writeMultipartUploadError(w, err) || writeFileError(w, err) || writeStorageQuotaError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(fileRef)
+	if err != nil {
+		log.Println("Error on marshal of file content item reference")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// AbortFileContentMultipartUpload Aborts a multipart upload
+// @Description Aborts a multipart upload, its uploaded parts are deleted
+// @Tags Client
+// @ID AbortFileContentMultipartUpload
+// @Param id path string true "id - the id of the multipart upload"
+// @Success 200
+// @Security UserAuth
+// @Router /files/upload/multipart/{id} [delete]
+func (h ApisHandler) AbortFileContentMultipartUpload(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	err := h.app.Services.AbortFileContentMultipartUpload(claims, id)
+	if err != nil {
+		log.Printf("Error on aborting multipart upload %s: %s\n", id, err)
+		if writeMultipartUploadError(w, err) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // GetFileContentDownloadURLs Get URLs to download files from S3
 // @Description Get URLs to download files from S3
 // @Tags Client
@@ -915,6 +1537,7 @@ func (h ApisHandler) GetFileContentUploadURLs(claims *tokenauth.Claims, w http.R
 // @Param category body string false "category - category of file content item"
 // @Param entityID body string false "category - id of entity file content item belongs to"
 // @Success 200
+// @Failure 403 {string} string "unauthorized to read the items of the category"
 // @Security UserAuth
 // @Router /files/download [get]
 func (h ApisHandler) GetFileContentDownloadURLs(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -953,6 +1576,9 @@ func (h ApisHandler) GetFileContentDownloadURLs(claims *tokenauth.Claims, w http
 	fileRefs, err := h.app.Services.GetFileContentDownloadURLs(claims, fileKeys, entityID, category, addAppOrgIDToPath)
 	if err != nil {
 		log.Printf("Error getting file download references: %s\n", err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
 		http.Error(w, "Error getting file download references", http.StatusInternalServerError)
 		return
 	}
@@ -973,7 +1599,16 @@ func (h ApisHandler) GetFileContentDownloadURLs(claims *tokenauth.Claims, w http
 // @Descriptions Gets data content items
 // @Tags Client
 // @ID GetDataContentItems
+// @Param lang query string false "lang - comma separated list of the preferred locales, it goes before the Accept-Language header"
+// @Param If-None-Match header string false "the ETag of the representation the client has, 304 is returned if it is still current"
+// @Param If-Modified-Since header string false "used only without If-None-Match, 304 is returned if nothing has been updated since then"
 // @Param category body string false "category - get all data content items based on category"
+// @Param descendants query boolean false "descendants - include the data content items of the subcategories of the category. Default: false"
+// @Param cursor query string false "cursor - pass it to get a page envelope with items, next_cursor and total instead of the array. Empty for the first page, then the next_cursor of the previous page."
+// @Param limit query integer false "limit - page size, used with cursor. Default: 20, max: 100"
+// @Param order query string false "order - used with cursor. Possible values: asc, desc. Default: asc"
+// @Param total query boolean false "total - include the count of all the items in the page envelope"
+// @Param expand query integer false "expand - how many levels of the references in the data to resolve, up to 3. A reference like {\"$ref\": \"content_item:<id>\"} gets the referenced item as $value. No ETag is used with it."
 // @Accept json
 // @Produce json
 // @Success 200
@@ -987,13 +1622,66 @@ func (h ApisHandler) GetDataContentItems(claims *tokenauth.Claims, w http.Respon
 		return
 	}
 
-	resData, err := h.app.Services.GetDataContentItems(claims, category)
+	pageParams, err := getPageQueryParams(r)
+	if err != nil {
+		log.Printf("Error on getting data content items with category - %s\n %s", category, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expand, err := getExpandQueryParam(r)
+	if err != nil {
+		log.Printf("Error on getting data content items with category - %s\n %s", category, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	withDescendants := getBoolQueryParam(r, "descendants", false)
+	preferredLocales := getPreferredLocales(r)
+	variant := listVariant(preferredLocales, nil, nil)
+
+	var resData interface{}
+	var items []*model.DataContentItem
+	if pageParams != nil {
+		var page *model.DataContentItemsPage
+		page, err = h.app.Services.GetDataContentItemsPage(claims, category, withDescendants, pageParams.cursor, pageParams.limit, getStringQueryParam(r, "order"), pageParams.withTotal)
+		if page != nil {
+			items = page.Items
+			variant = listVariant(preferredLocales, page.NextCursor, page.Total)
+		}
+		resData = page
+	} else {
+		items, err = h.app.Services.GetDataContentItems(claims, category, withDescendants)
+		resData = items
+	}
 	if err != nil {
 		log.Printf("Error on getting data content items with category - %s\n %s", category, err)
+		if writeCategoryAccessError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	revisions := make([]model.ItemRevision, len(items))
+	for i, item := range items {
+		h.app.Services.LocalizeDataContentItem(item, preferredLocales)
+		revisions[i] = item.Revision()
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	if expand > 0 {
+		//the revisions of the referenced items are not known, so there is no ETag
+		err = h.app.Services.ExpandDataContentItemReferences(claims, items, preferredLocales, expand)
+		if err != nil {
+			log.Printf("Error on expanding the references of data content items with category - %s\n %s", category, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if checkNotModified(w, r, model.EntityTag(variant, revisions...), model.LastModified(revisions...)) {
+		return
+	}
+
 	data, err := json.Marshal(resData)
 	if err != nil {
 		log.Println("Error on marshal of data content type")
@@ -1006,12 +1694,85 @@ func (h ApisHandler) GetDataContentItems(claims *tokenauth.Claims, w http.Respon
 	w.Write(data)
 }
 
+// GetContentChanges Streams the changes of the content as Server-Sent Events
+// @Description Streams the changes of the content items, the data content items and the meta data as Server-Sent Events, so that the apps refresh the content when it changes. Every event is a `change` event with a ContentChange json as data. The stream ends when the client falls behind, then the client reconnects and reloads the content.
+// @Description The content items are streamed while the clients see them and when they stop seeing them, the data content items only for the categories the caller can read. The deletes are streamed only when the database keeps the pre-images.
+// @Tags Client
+// @ID GetContentChanges
+// @Param categories query string false "categories - comma separated list of categories to get the changes for. The meta data changes are sent only without categories."
+// @Param all-apps query boolean false "It says if the data is associated with the current app or it is for all the apps within the organization. It is 'false' by default."
+// @Produce text/event-stream
+// @Success 200 {object} model.ContentChange
+// @Security UserAuth
+// @Router /changes [get]
+func (h ApisHandler) GetContentChanges(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
+	//get all-apps param value
+	allApps := false //false by defautl
+	allAppsParam := r.URL.Query().Get("all-apps")
+	if allAppsParam != "" {
+		allApps, _ = strconv.ParseBool(allAppsParam)
+	}
+
+	var categories []string
+	categoriesParam := getStringQueryParam(r, "categories")
+	if categoriesParam != nil {
+		for _, category := range strings.Split(*categoriesParam, ",") {
+			category = strings.TrimSpace(category)
+			if len(category) > 0 {
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Println("Error on streaming the content changes - the response cannot be flushed")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	changes, unsubscribe := h.app.Services.SubscribeContentChanges(claims, allApps, categories)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") //do not let the proxies buffer the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	//keep the idle connections open
+	heartbeat := time.NewTicker(contentChangesHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				log.Printf("Error on marshal the content change - %s", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
 type createOrUpdateMetaDataRequestBody struct {
 	Key   string                 `json:"key"`
 	Value map[string]interface{} `json:"value"`
 } // @name createMetaDataRequestBody
 
-// CreateOrUpdateMetaData creates or updates meta data object
+// CreateOrUpdateMetaData creates or updates meta data object of the app
 func (h ApisHandler) CreateOrUpdateMetaData(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
 	var body createOrUpdateMetaDataRequestBody
 	bodyData, _ := ioutil.ReadAll(r.Body)
@@ -1022,9 +1783,12 @@ func (h ApisHandler) CreateOrUpdateMetaData(claims *tokenauth.Claims, w http.Res
 		}
 	}
 
-	resData, err := h.app.Services.CreateOrUpdateMetaData(body.Key, body.Value)
+	resData, err := h.app.Services.CreateOrUpdateMetaData(auditActor(claims, r), claims, false, false, body.Key, body.Value, nil)
 	if err != nil {
 		log.Printf("Error on creating  meta- data content items with category")
+		if writeMetaDataAccessError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1041,7 +1805,7 @@ func (h ApisHandler) CreateOrUpdateMetaData(claims *tokenauth.Claims, w http.Res
 	w.Write(data)
 }
 
-// GetMetaData Gets meta data
+// GetMetaData Gets meta data of the app, or the global one when the app has none with the key
 func (h ApisHandler) GetMetaData(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
 	var key *string
 	keyPtr, ok := r.URL.Query()["key"]
@@ -1049,7 +1813,7 @@ func (h ApisHandler) GetMetaData(claims *tokenauth.Claims, w http.ResponseWriter
 		key = &keyPtr[0]
 	}
 
-	resData, err := h.app.Services.GetMetaData(key)
+	resData, err := h.app.Services.GetMetaData(claims, key)
 	if err != nil {
 		log.Printf("Error on getting meta data with key - %s\n %s", *key, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -1068,7 +1832,7 @@ func (h ApisHandler) GetMetaData(claims *tokenauth.Claims, w http.ResponseWriter
 	w.Write(data)
 }
 
-// DeleteMetaData deletes meta data by key
+// DeleteMetaData deletes meta data of the app by key
 func (h ApisHandler) DeleteMetaData(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
 	var key *string
 	keyPtr, ok := r.URL.Query()["key"]
@@ -1076,11 +1840,20 @@ func (h ApisHandler) DeleteMetaData(claims *tokenauth.Claims, w http.ResponseWri
 		key = &keyPtr[0]
 	}
 
-	err := h.app.Services.DeleteMetaData(*key)
+	if key == nil {
+		log.Print("Missing key\n")
+		http.Error(w, "missing 'key' query param", http.StatusBadRequest)
+		return
+	}
+
+	err := h.app.Services.DeleteMetaData(auditActor(claims, r), claims, false, false, *key)
 	if err != nil {
 		if err != nil {
 			log.Printf("error on delete meta data: %s", err)
 		}
+		if writeMetaDataAccessError(w, err) {
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
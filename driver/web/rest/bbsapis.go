@@ -42,6 +42,7 @@ type BBsApisHandler struct {
 // @Accept multipart/form-data
 // @Produce json
 // @Success 200 {object} uploadImageResponse
+// @Failure 507 {string} string "the upload does not fit in a storage quota"
 // @Security AdminUserAuth
 // @Router /admin/image [post]
 func (h BBsApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWriter, r *http.Request) {
@@ -95,9 +96,12 @@ func (h BBsApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWri
 	}
 
 	// pass the file to be processed by the use case handler
-	url, err := h.app.Services.UploadImage(fileBytes, path, imgSpec)
+	url, err := h.app.Services.UploadImage(nil, claims, fileBytes, path, imgSpec)
 	if err != nil {
 		log.Printf("Error converting image: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error converting image", http.StatusInternalServerError)
 		return
 	}
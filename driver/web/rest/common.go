@@ -15,8 +15,24 @@
 package rest
 
 import (
+	"content/core/model"
+	"content/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
 )
 
 func getStringQueryParam(r *http.Request, paramName string) *string {
@@ -62,3 +78,622 @@ func getBoolQueryParam(r *http.Request, paramName string, defaultValue bool) boo
 	}
 	return defaultValue
 }
+
+func getContentItemStateQueryParam(r *http.Request) (*string, error) {
+	state := getStringQueryParam(r, "state")
+	if state == nil {
+		return nil, nil
+	}
+
+	switch *state {
+	case model.ContentItemStateScheduled, model.ContentItemStateLive, model.ContentItemStateExpired:
+		return state, nil
+	default:
+		return nil, fmt.Errorf("invalid state %s - must be one of %s, %s or %s", *state,
+			model.ContentItemStateScheduled, model.ContentItemStateLive, model.ContentItemStateExpired)
+	}
+}
+
+// getTimeQueryParam gives the RFC 3339 time of a query param, nil when it is not provided
+func getTimeQueryParam(r *http.Request, paramName string) (*time.Time, error) {
+	value := getStringQueryParam(r, paramName)
+	if value == nil {
+		return nil, nil
+	}
+	result, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s - must be an RFC 3339 time", paramName, *value)
+	}
+	return &result, nil
+}
+
+func validatePublishWindow(publishAt *time.Time, expireAt *time.Time) error {
+	if publishAt != nil && expireAt != nil && !expireAt.After(*publishAt) {
+		return fmt.Errorf("expire_at must be after publish_at")
+	}
+	return nil
+}
+
+// validateLocales checks the locales of the translations are valid BCP 47 language tags
+func validateLocales(defaultLocale string, locales map[string]interface{}) error {
+	if len(defaultLocale) > 0 {
+		if _, err := language.Parse(defaultLocale); err != nil {
+			return fmt.Errorf("invalid default_locale %s", defaultLocale)
+		}
+	}
+	for locale := range locales {
+		if _, err := language.Parse(locale); err != nil {
+			return fmt.Errorf("invalid locale %s", locale)
+		}
+		if len(defaultLocale) > 0 && strings.EqualFold(locale, defaultLocale) {
+			return fmt.Errorf("locale %s is the default locale, its data goes to data", locale)
+		}
+	}
+	return nil
+}
+
+// maxBatchOperations is the most operations a batch may have, they are all applied in one transaction
+const maxBatchOperations = 100
+
+// validateContentItemBatchOperation checks an operation of a content items batch before the batch is applied
+func validateContentItemBatchOperation(operation model.ContentItemBatchOperation) error {
+	switch operation.Op {
+	case model.BatchOperationCreate, model.BatchOperationUpdate:
+		if operation.Op == model.BatchOperationUpdate && len(operation.ID) == 0 {
+			return errors.New("missing id")
+		}
+		if len(operation.Category) == 0 {
+			return errors.New("missing category")
+		}
+		err := validatePublishWindow(operation.PublishAt, operation.ExpireAt)
+		if err != nil {
+			return err
+		}
+		return validateLocales(operation.DefaultLocale.Value, operation.Locales.Value)
+	case model.BatchOperationDelete:
+		if len(operation.ID) == 0 {
+			return errors.New("missing id")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid op %s - must be one of %s, %s or %s", operation.Op, model.BatchOperationCreate, model.BatchOperationUpdate, model.BatchOperationDelete)
+	}
+}
+
+// validateDataContentItemBatchOperation checks an operation of a data content items batch before the batch is applied
+func validateDataContentItemBatchOperation(operation model.DataContentItemBatchOperation) error {
+	if len(operation.Key) == 0 {
+		return errors.New("missing key")
+	}
+	switch operation.Op {
+	case model.BatchOperationCreate, model.BatchOperationUpdate:
+		if len(operation.Category) == 0 {
+			return errors.New("missing category")
+		}
+		return validateLocales(operation.DefaultLocale.Value, operation.Locales.Value)
+	case model.BatchOperationDelete:
+		return nil
+	default:
+		return fmt.Errorf("invalid op %s - must be one of %s, %s or %s", operation.Op, model.BatchOperationCreate, model.BatchOperationUpdate, model.BatchOperationDelete)
+	}
+}
+
+func writeBatchResult(w http.ResponseWriter, result *model.BatchResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Println("Error on marshal the batch result")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	//nothing is stored when an operation fails
+	status := http.StatusOK
+	if !result.Applied {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// auditActor gives the account doing a mutation, as it goes to the audit log
+func auditActor(claims *tokenauth.Claims, r *http.Request) *model.AuditActor {
+	permissions := []string{}
+	for _, permission := range strings.Split(claims.Permissions, ",") {
+		permission = strings.TrimSpace(permission)
+		if len(permission) > 0 {
+			permissions = append(permissions, permission)
+		}
+	}
+	return &model.AuditActor{AccountID: claims.Subject, AccountName: claims.Name, Permissions: permissions,
+		AppID: claims.AppID, OrgID: claims.OrgID, RequestID: r.Header.Get(utils.RequestIDHeader)}
+}
+
+// validateWebhook checks the webhook url is an absolute http(s) url and the events are known
+func validateWebhook(webhookURL string, secret string, events []string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		return fmt.Errorf("invalid url %s - must be an absolute http or https url", webhookURL)
+	}
+	if len(secret) > 0 && len(secret) < 16 {
+		return fmt.Errorf("secret must be at least 16 characters")
+	}
+	for _, event := range events {
+		known := false
+		for _, item := range model.WebhookEvents {
+			if event == item {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid event %s - must be one of %s", event, strings.Join(model.WebhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// getPreferredLocales gives the locales the client prefers, best first - the lang query param goes before the Accept-Language header
+func getPreferredLocales(r *http.Request) []string {
+	locales := []string{}
+	lang := getStringQueryParam(r, "lang")
+	if lang != nil {
+		for _, locale := range strings.Split(*lang, ",") {
+			locale = strings.TrimSpace(locale)
+			if len(locale) > 0 {
+				locales = append(locales, locale)
+			}
+		}
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err == nil {
+		for _, tag := range tags {
+			locales = append(locales, tag.String())
+		}
+	}
+	return locales
+}
+
+func getContentItemWorkflowStateQueryParam(r *http.Request) (*string, error) {
+	workflowState := getStringQueryParam(r, "workflow_state")
+	if workflowState == nil {
+		return nil, nil
+	}
+
+	switch *workflowState {
+	case model.ContentItemWorkflowDraft, model.ContentItemWorkflowInReview, model.ContentItemWorkflowPublished, model.ContentItemWorkflowArchived:
+		return workflowState, nil
+	default:
+		return nil, fmt.Errorf("invalid workflow state %s - must be one of %s, %s, %s or %s", *workflowState, model.ContentItemWorkflowDraft,
+			model.ContentItemWorkflowInReview, model.ContentItemWorkflowPublished, model.ContentItemWorkflowArchived)
+	}
+}
+
+const (
+	defaultPageLimit int64 = 20
+	maxPageLimit     int64 = 100
+	//every searched collection gives offset+limit matches to rank, so the search does not go deeper
+	maxSearchOffset int64 = 1000
+)
+
+// searchQueryParams are the query params of the full-text search endpoints
+type searchQueryParams struct {
+	text       string
+	categories []string
+	offset     int64
+	limit      int64
+}
+
+func getSearchQueryParams(r *http.Request) (*searchQueryParams, error) {
+	text := getStringQueryParam(r, "q")
+	if text == nil || len(strings.TrimSpace(*text)) == 0 {
+		return nil, errors.New("missing 'q' query param")
+	}
+	params := searchQueryParams{text: *text, offset: 0, limit: defaultPageLimit}
+
+	categories := getStringQueryParam(r, "categories")
+	if categories != nil {
+		for _, category := range strings.Split(*categories, ",") {
+			category = strings.TrimSpace(category)
+			if len(category) > 0 {
+				params.categories = append(params.categories, category)
+			}
+		}
+	}
+
+	offset := getInt64QueryParam(r, "offset")
+	if offset != nil {
+		if *offset < 0 || *offset > maxSearchOffset {
+			return nil, fmt.Errorf("offset must be between 0 and %d", maxSearchOffset)
+		}
+		params.offset = *offset
+	}
+	limit := getInt64QueryParam(r, "limit")
+	if limit != nil {
+		if *limit <= 0 || *limit > maxPageLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		params.limit = *limit
+	}
+	return &params, nil
+}
+
+// pageQueryParams are the query params of the cursor paginated lists
+type pageQueryParams struct {
+	cursor    *model.PageCursor
+	limit     int64
+	withTotal bool
+}
+
+// getPresignedObjectParams gives the object and the signature of a presigned object URL, the part URLs also have the upload
+func getPresignedObjectParams(r *http.Request) (*model.PresignedObjectRequest, error) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, errors.New("missing or invalid 'expires' query param")
+	}
+	signature := query.Get("signature")
+	if len(signature) == 0 {
+		return nil, errors.New("missing 'signature' query param")
+	}
+	request := model.PresignedObjectRequest{Bucket: vars["bucket"], Key: vars["key"], Expires: expires, Signature: signature}
+
+	uploadID := query.Get("upload_id")
+	if len(uploadID) > 0 {
+		partNumber, err := strconv.Atoi(query.Get("part_number"))
+		if err != nil {
+			return nil, errors.New("missing or invalid 'part_number' query param")
+		}
+		request.UploadID = uploadID
+		request.PartNumber = partNumber
+	}
+	return &request, nil
+}
+
+// getExpandQueryParam gives how many levels of the references in the item data are expanded, none without the expand param
+func getExpandQueryParam(r *http.Request) (int, error) {
+	expand := getStringQueryParam(r, "expand")
+	if expand == nil {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(*expand)
+	if err != nil || depth < 0 || depth > model.MaxReferenceDepth {
+		return 0, fmt.Errorf("expand must be between 0 and %d", model.MaxReferenceDepth)
+	}
+	return depth, nil
+}
+
+// getPageQueryParams gives nil when the client asks for the plain array response - the lists are cursor paginated
+// only when the cursor param is passed, it is empty for the first page
+func getPageQueryParams(r *http.Request) (*pageQueryParams, error) {
+	query := r.URL.Query()
+	if !query.Has("cursor") {
+		return nil, nil
+	}
+	params := pageQueryParams{limit: defaultPageLimit}
+
+	token := query.Get("cursor")
+	if len(token) > 0 {
+		cursor, err := model.DecodePageCursor(token)
+		if err != nil {
+			return nil, err
+		}
+		params.cursor = cursor
+	}
+
+	limit := getInt64QueryParam(r, "limit")
+	if limit != nil {
+		if *limit <= 0 || *limit > maxPageLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		params.limit = *limit
+	}
+
+	total := query.Get("total")
+	if len(total) > 0 {
+		withTotal, err := strconv.ParseBool(total)
+		if err != nil {
+			return nil, fmt.Errorf("invalid total %s", total)
+		}
+		params.withTotal = withTotal
+	}
+	return &params, nil
+}
+
+// writeSchemaError responds with the details when the error is caused by a category schema
+func writeSchemaError(w http.ResponseWriter, err error) bool {
+	var body interface{}
+	status := http.StatusBadRequest
+
+	var validationErr *model.SchemaValidationError
+	var invalidSchemaErr *model.InvalidSchemaError
+	var categorySchemaErr *model.CategorySchemaError
+	switch {
+	case errors.As(err, &validationErr):
+		body = validationErr
+	case errors.As(err, &invalidSchemaErr):
+		body = invalidSchemaErr
+	case errors.As(err, &categorySchemaErr):
+		body = categorySchemaErr.Report
+		status = http.StatusConflict
+	default:
+		return false
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Println("Error on marshal the schema error")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+	return true
+}
+
+// contentItemRevision gives the revision of a content item read as a map
+func contentItemRevision(item model.ContentItemResponse) model.ItemRevision {
+	id, _ := item["_id"].(string)
+	dateCreated, _ := timeValue(item["date_created"])
+	dateUpdated, ok := timeValue(item["date_updated"])
+	if !ok {
+		return model.NewItemRevision(id, dateCreated, nil)
+	}
+	return model.NewItemRevision(id, dateCreated, &dateUpdated)
+}
+
+func timeValue(value interface{}) (time.Time, bool) {
+	switch value := value.(type) {
+	case primitive.DateTime:
+		return value.Time(), true
+	case time.Time:
+		return value, true
+	case *time.Time:
+		if value != nil {
+			return *value, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// listVariant tells apart the representations of the same list of items
+func listVariant(preferredLocales []string, nextCursor *string, total *int64) string {
+	variant := strings.Join(preferredLocales, ",")
+	if nextCursor != nil {
+		variant += "|" + *nextCursor
+	}
+	if total != nil {
+		variant += "|" + strconv.FormatInt(*total, 10)
+	}
+	return variant
+}
+
+// getEntityTagsHeader gives the entity tags listed in a header like If-Match, nil when the request does not have the header
+func getEntityTagsHeader(r *http.Request, header string) []string {
+	values := r.Header.Values(header)
+	if len(values) == 0 {
+		return nil
+	}
+	tags := []string{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if len(tag) > 0 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// checkNotModified sets the cache validators of a GET response and responds with 304 when the client already has the representation.
+// If-Modified-Since is used only when there is no If-None-Match, as it cannot tell that an item was removed from a list.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	notModified := false
+	if ifNoneMatch := getEntityTagsHeader(r, "If-None-Match"); ifNoneMatch != nil {
+		for _, tag := range ifNoneMatch {
+			//weak comparison
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				notModified = true
+				break
+			}
+		}
+	} else if ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		notModified = !lastModified.Truncate(time.Second).After(ifModifiedSince)
+	}
+	if notModified {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return notModified
+}
+
+// writePreconditionFailed responds with 412 when the error is caused by a stale If-Match
+func writePreconditionFailed(w http.ResponseWriter, err error) bool {
+	var preconditionErr *model.PreconditionFailedError
+	if !errors.As(err, &preconditionErr) {
+		return false
+	}
+	w.Header().Set("ETag", preconditionErr.ETag)
+	http.Error(w, preconditionErr.Error(), http.StatusPreconditionFailed)
+	return true
+}
+
+// writeNotFoundError responds with 404 when there is no such content item or content item version
+func writeNotFoundError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, model.ErrContentItemNotFound) && !errors.Is(err, model.ErrContentItemVersionNotFound) {
+		return false
+	}
+	http.Error(w, err.Error(), http.StatusNotFound)
+	return true
+}
+
+// writeWorkflowError responds with 404 when there is no such content item and with 409 when the item is not in a state the transition is allowed from
+func writeWorkflowError(w http.ResponseWriter, err error) bool {
+	if writeNotFoundError(w, err) {
+		return true
+	}
+	var transitionErr *model.WorkflowTransitionError
+	if errors.As(err, &transitionErr) {
+		http.Error(w, transitionErr.Error(), http.StatusConflict)
+		return true
+	}
+	return false
+}
+
+// writeCategoryAccessError responds with 403 when the error is caused by the permissions of a category
+func writeCategoryAccessError(w http.ResponseWriter, err error) bool {
+	var accessErr *model.CategoryAccessError
+	if !errors.As(err, &accessErr) {
+		return false
+	}
+	http.Error(w, accessErr.Error(), http.StatusForbidden)
+	return true
+}
+
+// writeObjectError responds with 403 when a presigned object URL is not accepted, with 404 when there is no such object or upload and with 413 when the body is too large
+func writeObjectError(w http.ResponseWriter, err error) bool {
+	var accessErr *model.ObjectAccessError
+	if errors.As(err, &accessErr) {
+		http.Error(w, accessErr.Error(), http.StatusForbidden)
+		return true
+	}
+	if errors.Is(err, model.ErrObjectNotFound) || errors.Is(err, model.ErrMultipartUploadNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return true
+	}
+	return false
+}
+
+// writeMultipartUploadError responds with 404 when there is no such multipart upload of the account and with 403 when the category cannot be written anymore
+func writeMultipartUploadError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, model.ErrMultipartUploadNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return true
+	}
+	return writeCategoryAccessError(w, err)
+}
+
+// writeFileError responds with 413 or 415 when a file is not allowed by the file policy of its category and with 422 when the scanner flags it
+func writeFileError(w http.ResponseWriter, err error) bool {
+	var policyErr *model.FilePolicyError
+	if errors.As(err, &policyErr) {
+		status := http.StatusUnsupportedMediaType
+		if policyErr.Reason == model.FilePolicySize {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, policyErr.Error(), status)
+		return true
+	}
+	var quarantinedErr *model.FileQuarantinedError
+	if errors.As(err, &quarantinedErr) {
+		http.Error(w, quarantinedErr.Error(), http.StatusUnprocessableEntity)
+		return true
+	}
+	return false
+}
+
+// writeStorageQuotaError responds with 507 when an upload does not fit in a storage quota
+func writeStorageQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *model.StorageQuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	http.Error(w, quotaErr.Error(), http.StatusInsufficientStorage)
+	return true
+}
+
+// writeMetaDataAccessError responds with 403 when the error is caused by the permissions of the meta data
+func writeMetaDataAccessError(w http.ResponseWriter, err error) bool {
+	var accessErr *model.MetaDataAccessError
+	if !errors.As(err, &accessErr) {
+		return false
+	}
+	http.Error(w, accessErr.Error(), http.StatusForbidden)
+	return true
+}
+
+// writeCategoryInUseError responds with 409 and the counts when a category cannot be deleted because it is still referenced
+func writeCategoryInUseError(w http.ResponseWriter, err error) bool {
+	var inUseErr *model.CategoryInUseError
+	if !errors.As(err, &inUseErr) {
+		return false
+	}
+
+	data, err := json.Marshal(inUseErr)
+	if err != nil {
+		log.Println("Error on marshal the category in use error")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(data)
+	return true
+}
+
+// the media types of the patches of the item data
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// readDataPatch reads a patch of the item data, its format comes from the Content-Type. It gives nil when the media type is not supported.
+func readDataPatch(r *http.Request) (*model.DataPatch, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil
+	}
+
+	var patch model.DataPatch
+	switch mediaType {
+	case mergePatchContentType:
+		patch.Format = model.PatchFormatMerge
+	case jsonPatchContentType:
+		patch.Format = model.PatchFormatJSON
+	default:
+		return nil, nil
+	}
+
+	patch.Document, err = io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &patch, nil
+}
+
+// writePatchError responds with 400 for an invalid patch, 409 when a test operation fails and 422 when the patch does not fit the data
+func writePatchError(w http.ResponseWriter, err error) bool {
+	var patchErr *model.PatchError
+	if !errors.As(err, &patchErr) {
+		return false
+	}
+
+	status := http.StatusUnprocessableEntity
+	if patchErr.Malformed {
+		status = http.StatusBadRequest
+	} else if patchErr.Conflict {
+		status = http.StatusConflict
+	}
+	http.Error(w, patchErr.Error(), status)
+	return true
+}
@@ -0,0 +1,371 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"content/core/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetPreferredLocales(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		want           []string
+	}{
+		{name: "none", target: "/content_items", want: []string{}},
+		{name: "lang param", target: "/content_items?lang=es,%20fr", want: []string{"es", "fr"}},
+		{name: "empty lang entries", target: "/content_items?lang=,es,", want: []string{"es"}},
+		{name: "accept language by quality", target: "/content_items", acceptLanguage: "fr;q=0.5, es-MX, en;q=0.8", want: []string{"es-MX", "en", "fr"}},
+		{name: "lang param first", target: "/content_items?lang=de", acceptLanguage: "es", want: []string{"de", "es"}},
+		{name: "invalid accept language", target: "/content_items?lang=de", acceptLanguage: "es;q=x", want: []string{"de"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if len(tt.acceptLanguage) > 0 {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := getPreferredLocales(r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getPreferredLocales() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEntityTagsHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "missing", want: nil},
+		{name: "one", values: []string{`"a"`}, want: []string{`"a"`}},
+		{name: "list", values: []string{`"a", W/"b"`}, want: []string{`"a"`, `W/"b"`}},
+		{name: "repeated headers", values: []string{`"a"`, `"b",`}, want: []string{`"a"`, `"b"`}},
+		{name: "empty", values: []string{""}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/content_items", nil)
+			for _, value := range tt.values {
+				r.Header.Add("If-Match", value)
+			}
+			if got := getEntityTagsHeader(r, "If-Match"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEntityTagsHeader() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	etag := `"abc"`
+	lastModified := time.Date(2024, 5, 1, 10, 30, 0, 500000000, time.UTC)
+
+	tests := []struct {
+		name            string
+		method          string
+		ifNoneMatch     string
+		ifModifiedSince string
+		lastModified    time.Time
+		want            bool
+	}{
+		{name: "no validators", method: http.MethodGet, lastModified: lastModified},
+		{name: "matching entity tag", method: http.MethodGet, ifNoneMatch: etag, lastModified: lastModified, want: true},
+		{name: "weak matching entity tag", method: http.MethodGet, ifNoneMatch: `"x", W/"abc"`, lastModified: lastModified, want: true},
+		{name: "any entity tag", method: http.MethodGet, ifNoneMatch: "*", lastModified: lastModified, want: true},
+		{name: "other entity tag", method: http.MethodGet, ifNoneMatch: `"x"`, lastModified: lastModified},
+		{name: "entity tag goes before the date", method: http.MethodGet, ifNoneMatch: `"x"`, ifModifiedSince: lastModified.Format(http.TimeFormat), lastModified: lastModified},
+		{name: "not modified since", method: http.MethodGet, ifModifiedSince: lastModified.Format(http.TimeFormat), lastModified: lastModified, want: true},
+		{name: "modified since", method: http.MethodGet, ifModifiedSince: lastModified.Add(-time.Second).Format(http.TimeFormat), lastModified: lastModified},
+		{name: "invalid date", method: http.MethodGet, ifModifiedSince: "yesterday", lastModified: lastModified},
+		{name: "unknown last modified", method: http.MethodGet, ifModifiedSince: lastModified.Format(http.TimeFormat)},
+		{name: "not a get", method: http.MethodPut, ifNoneMatch: etag, lastModified: lastModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/content_items", nil)
+			if len(tt.ifNoneMatch) > 0 {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if len(tt.ifModifiedSince) > 0 {
+				r.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+
+			got := checkNotModified(w, r, etag, tt.lastModified)
+			if got != tt.want {
+				t.Errorf("checkNotModified() = %v, want %v", got, tt.want)
+			}
+			if got && w.Code != http.StatusNotModified {
+				t.Errorf("checkNotModified() status = %d, want %d", w.Code, http.StatusNotModified)
+			}
+			if tt.method != http.MethodGet {
+				if len(w.Header().Get("ETag")) > 0 {
+					t.Errorf("checkNotModified() set ETag %s on %s", w.Header().Get("ETag"), tt.method)
+				}
+				return
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("checkNotModified() ETag = %s, want %s", w.Header().Get("ETag"), etag)
+			}
+			if !tt.lastModified.IsZero() && w.Header().Get("Last-Modified") != tt.lastModified.Format(http.TimeFormat) {
+				t.Errorf("checkNotModified() Last-Modified = %s, want %s", w.Header().Get("Last-Modified"), tt.lastModified.Format(http.TimeFormat))
+			}
+		})
+	}
+}
+
+func TestWriteNotFoundError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       bool
+		wantStatus int
+	}{
+		{name: "content item", err: model.ErrContentItemNotFound, want: true, wantStatus: http.StatusNotFound},
+		{name: "wrapped version", err: fmt.Errorf("version 3 of content item with id a - %w", model.ErrContentItemVersionNotFound), want: true, wantStatus: http.StatusNotFound},
+		{name: "other error", err: errors.New("not found"), wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			if got := writeNotFoundError(recorder, tt.err); got != tt.want {
+				t.Errorf("writeNotFoundError() = %v, want %v", got, tt.want)
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("writeNotFoundError() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestValidatePublishWindow(t *testing.T) {
+	publishAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := publishAt.Add(-time.Hour)
+	after := publishAt.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		publishAt *time.Time
+		expireAt  *time.Time
+		wantErr   bool
+	}{
+		{name: "no window"},
+		{name: "publish only", publishAt: &publishAt},
+		{name: "expire only", expireAt: &before},
+		{name: "expire after publish", publishAt: &publishAt, expireAt: &after},
+		{name: "expire at publish", publishAt: &publishAt, expireAt: &publishAt, wantErr: true},
+		{name: "expire before publish", publishAt: &publishAt, expireAt: &before, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePublishWindow(tt.publishAt, tt.expireAt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePublishWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetContentItemStateQueryParam(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    *string
+		wantErr bool
+	}{
+		{query: ""},
+		{query: "state=scheduled", want: stringPtr(model.ContentItemStateScheduled)},
+		{query: "state=live", want: stringPtr(model.ContentItemStateLive)},
+		{query: "state=expired", want: stringPtr(model.ContentItemStateExpired)},
+		{query: "state=archived", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/content_items?"+tt.query, nil)
+
+			got, err := getContentItemStateQueryParam(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getContentItemStateQueryParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getContentItemStateQueryParam() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestWriteWorkflowError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantWritten bool
+		wantStatus  int
+	}{
+		{name: "not allowed transition", err: fmt.Errorf("wrapped: %w", &model.WorkflowTransitionError{ID: "a", Transition: "approve", State: "draft"}),
+			wantWritten: true, wantStatus: http.StatusConflict},
+		{name: "missing item", err: model.ErrContentItemNotFound, wantWritten: true, wantStatus: http.StatusNotFound},
+		{name: "other error", err: errors.New("storage error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := writeWorkflowError(w, tt.err); got != tt.wantWritten {
+				t.Fatalf("writeWorkflowError() = %v, want %v", got, tt.wantWritten)
+			}
+			if tt.wantWritten && w.Code != tt.wantStatus {
+				t.Errorf("writeWorkflowError() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWriteSchemaError(t *testing.T) {
+	report := model.CategorySchemaReport{Category: "events", CheckedCount: 1, InvalidItems: []model.CategorySchemaInvalidItem{
+		{Collection: "content_items", ID: "a", Violations: []model.SchemaViolation{{Pointer: "/title", Message: "missing"}}}}}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantWritten bool
+		wantStatus  int
+		wantBody    string
+	}{
+		{name: "invalid data", err: &model.SchemaValidationError{Category: "events", Violations: []model.SchemaViolation{{Locale: "es", Pointer: "/title", Message: "missing"}}},
+			wantWritten: true, wantStatus: http.StatusBadRequest,
+			wantBody: `{"category":"events","violations":[{"locale":"es","pointer":"/title","message":"missing"}]}`},
+		{name: "invalid schema", err: fmt.Errorf("wrapped: %w", &model.InvalidSchemaError{Category: "events", Reason: "invalid json"}),
+			wantWritten: true, wantStatus: http.StatusBadRequest, wantBody: `{"category":"events","reason":"invalid json"}`},
+		{name: "stored items do not conform", err: &model.CategorySchemaError{Report: report}, wantWritten: true, wantStatus: http.StatusConflict,
+			wantBody: `{"category":"events","valid":false,"checked_count":1,"invalid_items":[{"collection":"content_items","id":"a","violations":[{"pointer":"/title","message":"missing"}]}]}`},
+		{name: "other error", err: errors.New("storage error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := writeSchemaError(w, tt.err); got != tt.wantWritten {
+				t.Fatalf("writeSchemaError() = %v, want %v", got, tt.wantWritten)
+			}
+			if !tt.wantWritten {
+				return
+			}
+			if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+				t.Errorf("writeSchemaError() = %d %s, want %d %s", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestValidateContentItemBatchOperation(t *testing.T) {
+	publishAt := time.Date(2022, 5, 17, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		operation model.ContentItemBatchOperation
+		wantErr   bool
+	}{
+		{name: "create", operation: model.ContentItemBatchOperation{Op: model.BatchOperationCreate, Category: "events", Data: "a"}},
+		{name: "create without category", operation: model.ContentItemBatchOperation{Op: model.BatchOperationCreate, Data: "a"}, wantErr: true},
+		{name: "update", operation: model.ContentItemBatchOperation{Op: model.BatchOperationUpdate, ID: "a", Category: "events", Data: "a"}},
+		{name: "update without id", operation: model.ContentItemBatchOperation{Op: model.BatchOperationUpdate, Category: "events", Data: "a"}, wantErr: true},
+		{name: "update with an empty publish window", operation: model.ContentItemBatchOperation{Op: model.BatchOperationUpdate, ID: "a", Category: "events",
+			PublishAt: &publishAt, ExpireAt: &publishAt}, wantErr: true},
+		{name: "update with an invalid locale", operation: model.ContentItemBatchOperation{Op: model.BatchOperationUpdate, ID: "a", Category: "events",
+			Locales: model.NewNullable(map[string]interface{}{"not a locale": "b"})}, wantErr: true},
+		{name: "delete", operation: model.ContentItemBatchOperation{Op: model.BatchOperationDelete, ID: "a"}},
+		{name: "delete without id", operation: model.ContentItemBatchOperation{Op: model.BatchOperationDelete}, wantErr: true},
+		{name: "invalid op", operation: model.ContentItemBatchOperation{Op: "replace", ID: "a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateContentItemBatchOperation(tt.operation); (err != nil) != tt.wantErr {
+				t.Errorf("validateContentItemBatchOperation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteBatchResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     model.BatchResult
+		wantStatus int
+	}{
+		{name: "applied", result: model.BatchResult{Applied: true, Results: []model.BatchOperationResult{{Op: model.BatchOperationDelete, ID: "a", Status: model.BatchStatusApplied}}},
+			wantStatus: http.StatusOK},
+		{name: "rolled back", result: model.BatchResult{Results: []model.BatchOperationResult{{Op: model.BatchOperationDelete, ID: "a", Status: model.BatchStatusFailed, Error: "not found"}}},
+			wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeBatchResult(w, &tt.result)
+
+			var got model.BatchResult
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if w.Code != tt.wantStatus || !reflect.DeepEqual(got, tt.result) {
+				t.Errorf("writeBatchResult() = %d %+v, want %d %+v", w.Code, got, tt.wantStatus, tt.result)
+			}
+		})
+	}
+}
+
+func TestWriteMetaDataAccessError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantWritten bool
+	}{
+		{name: "access error", err: fmt.Errorf("wrapped: %w", &model.MetaDataAccessError{Key: "banner"}), wantWritten: true},
+		{name: "other error", err: errors.New("storage error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := writeMetaDataAccessError(w, tt.err); got != tt.wantWritten {
+				t.Fatalf("writeMetaDataAccessError() = %v, want %v", got, tt.wantWritten)
+			}
+			if tt.wantWritten && w.Code != http.StatusForbidden {
+				t.Errorf("writeMetaDataAccessError() status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -76,9 +76,12 @@ func (h TPsApisHandler) UploadImage(claims *tokenauth.Claims, w http.ResponseWri
 	}
 
 	// pass the file to be processed by the use case handler
-	url, err := h.app.Services.UploadImage(fileBytes, path, imgSpec)
+	url, err := h.app.Services.UploadImage(nil, claims, fileBytes, path, imgSpec)
 	if err != nil {
 		log.Printf("Error converting image: %s\n", err)
+		if writeStorageQuotaError(w, err) {
+			return
+		}
 		http.Error(w, "Error converting image", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,433 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"content/core/model"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	transferFormatNDJSON = "ndjson"
+	transferFormatCSV    = "csv"
+
+	maxImportLineSize = 16 * 1024 * 1024
+
+	//the csv value of a permissions list which is set empty, an empty cell leaves it inherited
+	csvEmptyList = "[]"
+)
+
+var contentItemCSVHeader = []string{"id", "category", "default_locale", "data", "locales", "publish_at", "expire_at", "date_archived",
+	"workflow_state", "date_created", "date_updated"}
+
+var dataContentItemCSVHeader = []string{"id", "key", "category", "default_locale", "data", "locales", "date_created", "date_updated"}
+
+var categoryCSVHeader = []string{"id", "name", "parent", "requires_auth", "read_permissions", "permissions", "delete_permissions", "schema", "file_policy", "date_created", "date_updated"}
+
+func getTransferFormatQueryParam(r *http.Request) (string, error) {
+	format := getStringQueryParam(r, "format")
+	if format == nil {
+		return transferFormatNDJSON, nil
+	}
+
+	switch *format {
+	case transferFormatNDJSON, transferFormatCSV:
+		return *format, nil
+	default:
+		return "", fmt.Errorf("invalid format %s - must be %s or %s", *format, transferFormatNDJSON, transferFormatCSV)
+	}
+}
+
+// exportWriter streams the exported records as NDJSON - one json object per line, or as CSV
+type exportWriter struct {
+	json *json.Encoder
+	csv  *csv.Writer
+}
+
+func newExportWriter(w http.ResponseWriter, format string, fileName string, csvHeader []string) (*exportWriter, error) {
+	if format == transferFormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, fileName))
+		w.WriteHeader(http.StatusOK)
+
+		writer := exportWriter{csv: csv.NewWriter(w)}
+		return &writer, writer.csv.Write(csvHeader)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ndjson"`, fileName))
+	w.WriteHeader(http.StatusOK)
+	return &exportWriter{json: json.NewEncoder(w)}, nil
+}
+
+// write writes a record, csvRow is called for the CSV format only
+func (e *exportWriter) write(record interface{}, csvRow func() ([]string, error)) error {
+	if e.csv == nil {
+		return e.json.Encode(record)
+	}
+
+	row, err := csvRow()
+	if err != nil {
+		return err
+	}
+	return e.csv.Write(row)
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// finishExport flushes an export, the response status is already sent so the errors are only logged
+func finishExport(writer *exportWriter, err error, name string) {
+	if err == nil {
+		err = writer.flush()
+	}
+	if err != nil {
+		log.Printf("Error on exporting %s - %s\n", name, err)
+	}
+}
+
+// importRecord is a record of an import body - a json object or a csv row by column name
+type importRecord struct {
+	json []byte
+	csv  map[string]string
+}
+
+// readImportRecords reads the records of an import body, the blank NDJSON lines are skipped
+func readImportRecords(body io.Reader, format string) ([]importRecord, error) {
+	records := []importRecord{}
+	if format == transferFormatCSV {
+		reader := csv.NewReader(body)
+		header, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				return records, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			values := make(map[string]string, len(header))
+			for i, column := range header {
+				values[strings.TrimSpace(column)] = row[i]
+			}
+			records = append(records, importRecord{csv: values})
+		}
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		records = append(records, importRecord{json: append([]byte(nil), line...)})
+	}
+	return records, scanner.Err()
+}
+
+func writeImportReport(w http.ResponseWriter, report *model.ImportReport) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Println("Error on marshal the import report")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	//an import with invalid records is rejected as a whole
+	status := http.StatusOK
+	if !report.DryRun && len(report.Errors) > 0 {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func csvJSONValue(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	if locales, ok := value.(map[string]interface{}); ok && len(locales) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func csvTimeValue(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339Nano)
+}
+
+func parseCSVJSON(values map[string]string, column string, target interface{}) error {
+	value := strings.TrimSpace(values[column])
+	if len(value) == 0 {
+		return nil
+	}
+	err := json.Unmarshal([]byte(value), target)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", column, err)
+	}
+	return nil
+}
+
+func parseCSVTime(values map[string]string, column string) (*time.Time, error) {
+	value := strings.TrimSpace(values[column])
+	if len(value) == 0 {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", column, err)
+	}
+	return &parsed, nil
+}
+
+// parseCSVList reads a comma separated list column
+func parseCSVList(values map[string]string, column string) []string {
+	list := []string{}
+	for _, value := range strings.Split(values[column], ",") {
+		value = strings.TrimSpace(value)
+		if len(value) > 0 {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func contentItemCSVRow(item model.ContentItem) ([]string, error) {
+	data, err := csvJSONValue(item.Data)
+	if err != nil {
+		return nil, err
+	}
+	locales, err := csvJSONValue(item.Locales)
+	if err != nil {
+		return nil, err
+	}
+	return []string{item.ID, item.Category, item.DefaultLocale, data, locales, csvTimeValue(item.PublishAt), csvTimeValue(item.ExpireAt),
+		csvTimeValue(item.DateArchived), item.WorkflowState, csvTimeValue(&item.DateCreated), csvTimeValue(item.DateUpdated)}, nil
+}
+
+// contentItemImportRecord reads and validates a content item of an import
+func contentItemImportRecord(record importRecord) (model.ContentItem, error) {
+	var item model.ContentItem
+	if record.csv == nil {
+		err := json.Unmarshal(record.json, &item)
+		if err != nil {
+			return item, err
+		}
+	} else {
+		var err error
+		values := record.csv
+		item.ID = strings.TrimSpace(values["id"])
+		item.Category = values["category"]
+		item.DefaultLocale = values["default_locale"]
+		item.WorkflowState = values["workflow_state"]
+		err = parseCSVJSON(values, "data", &item.Data)
+		if err != nil {
+			return item, err
+		}
+		err = parseCSVJSON(values, "locales", &item.Locales)
+		if err != nil {
+			return item, err
+		}
+		for column, target := range map[string]**time.Time{"publish_at": &item.PublishAt, "expire_at": &item.ExpireAt,
+			"date_archived": &item.DateArchived, "date_updated": &item.DateUpdated} {
+			*target, err = parseCSVTime(values, column)
+			if err != nil {
+				return item, err
+			}
+		}
+		dateCreated, err := parseCSVTime(values, "date_created")
+		if err != nil {
+			return item, err
+		}
+		if dateCreated != nil {
+			item.DateCreated = *dateCreated
+		}
+	}
+
+	err := validatePublishWindow(item.PublishAt, item.ExpireAt)
+	if err != nil {
+		return item, err
+	}
+	err = validateLocales(item.DefaultLocale, item.Locales)
+	if err != nil {
+		return item, err
+	}
+	if len(item.WorkflowState) > 0 && !slices.Contains(model.ContentItemWorkflowStates, item.WorkflowState) {
+		return item, fmt.Errorf("invalid workflow state %s", item.WorkflowState)
+	}
+	return item, nil
+}
+
+func dataContentItemCSVRow(item model.DataContentItem) ([]string, error) {
+	data, err := csvJSONValue(item.Data)
+	if err != nil {
+		return nil, err
+	}
+	locales, err := csvJSONValue(item.Locales)
+	if err != nil {
+		return nil, err
+	}
+	return []string{item.ID, item.Key, item.Category, item.DefaultLocale, data, locales, csvTimeValue(&item.DateCreated), csvTimeValue(item.DateUpdated)}, nil
+}
+
+// dataContentItemImportRecord reads and validates a data content item of an import
+func dataContentItemImportRecord(record importRecord) (model.DataContentItem, error) {
+	var item model.DataContentItem
+	if record.csv == nil {
+		err := json.Unmarshal(record.json, &item)
+		if err != nil {
+			return item, err
+		}
+	} else {
+		values := record.csv
+		item.ID = strings.TrimSpace(values["id"])
+		item.Key = values["key"]
+		item.Category = values["category"]
+		item.DefaultLocale = values["default_locale"]
+		err := parseCSVJSON(values, "data", &item.Data)
+		if err != nil {
+			return item, err
+		}
+		err = parseCSVJSON(values, "locales", &item.Locales)
+		if err != nil {
+			return item, err
+		}
+		dateCreated, err := parseCSVTime(values, "date_created")
+		if err != nil {
+			return item, err
+		}
+		if dateCreated != nil {
+			item.DateCreated = *dateCreated
+		}
+		item.DateUpdated, err = parseCSVTime(values, "date_updated")
+		if err != nil {
+			return item, err
+		}
+	}
+
+	return item, validateLocales(item.DefaultLocale, item.Locales)
+}
+
+func categoryCSVRow(item model.Category) ([]string, error) {
+	filePolicy := ""
+	if item.FilePolicy != nil {
+		var err error
+		filePolicy, err = csvJSONValue(item.FilePolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []string{item.ID, item.Name, item.Parent, strconv.FormatBool(item.RequiresAuth), csvPermissionsValue(item.ReadPermissions), csvPermissionsValue(item.Permissions),
+		csvPermissionsValue(item.DeletePermissions), string(item.Schema), filePolicy, csvTimeValue(&item.DateCreated), csvTimeValue(item.DateUpdated)}, nil
+}
+
+// csvPermissionsValue gives the permissions of a category as a comma separated list, an empty cell when they are inherited and csvEmptyList when they are set empty
+func csvPermissionsValue(permissions []string) string {
+	if permissions != nil && len(permissions) == 0 {
+		return csvEmptyList
+	}
+	return strings.Join(permissions, ",")
+}
+
+// parseCSVPermissions reads the permissions of a category, nil for an empty cell so they are inherited
+func parseCSVPermissions(values map[string]string, column string) []string {
+	value := strings.TrimSpace(values[column])
+	if len(value) == 0 {
+		return nil
+	}
+	if value == csvEmptyList {
+		return []string{}
+	}
+	return parseCSVList(values, column)
+}
+
+// categoryImportRecord reads a category of an import, the permissions columns of the CSV format are comma separated lists
+func categoryImportRecord(record importRecord) (model.Category, error) {
+	var item model.Category
+	if record.csv == nil {
+		err := json.Unmarshal(record.json, &item)
+		return item, err
+	}
+
+	values := record.csv
+	item.ID = strings.TrimSpace(values["id"])
+	item.Name = values["name"]
+	item.Parent = values["parent"]
+	item.ReadPermissions = parseCSVPermissions(values, "read_permissions")
+	item.Permissions = parseCSVPermissions(values, "permissions")
+	item.DeletePermissions = parseCSVPermissions(values, "delete_permissions")
+	requiresAuth := strings.TrimSpace(values["requires_auth"])
+	if len(requiresAuth) > 0 {
+		var err error
+		item.RequiresAuth, err = strconv.ParseBool(requiresAuth)
+		if err != nil {
+			return item, fmt.Errorf("invalid requires_auth: %s", err)
+		}
+	}
+	schema := strings.TrimSpace(values["schema"])
+	if len(schema) > 0 {
+		if !json.Valid([]byte(schema)) {
+			return item, fmt.Errorf("invalid schema: not a json value")
+		}
+		item.Schema = json.RawMessage(schema)
+	}
+	err := parseCSVJSON(values, "file_policy", &item.FilePolicy)
+	if err != nil {
+		return item, err
+	}
+	dateCreated, err := parseCSVTime(values, "date_created")
+	if err != nil {
+		return item, err
+	}
+	if dateCreated != nil {
+		item.DateCreated = *dateCreated
+	}
+	item.DateUpdated, err = parseCSVTime(values, "date_updated")
+	return item, err
+}
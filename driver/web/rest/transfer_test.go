@@ -0,0 +1,179 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"bytes"
+	"content/core/model"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategoryCSVPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		want        string
+	}{
+		{name: "inherited", permissions: nil, want: ""},
+		{name: "empty", permissions: []string{}, want: csvEmptyList},
+		{name: "set", permissions: []string{"a", "b"}, want: "a,b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, err := categoryCSVRow(model.Category{Name: "events", ReadPermissions: tt.permissions, Permissions: tt.permissions, DeletePermissions: tt.permissions})
+			if err != nil {
+				t.Fatalf("categoryCSVRow() error = %v", err)
+			}
+			values := map[string]string{}
+			for i, column := range categoryCSVHeader {
+				values[column] = row[i]
+			}
+			if values["permissions"] != tt.want {
+				t.Errorf("categoryCSVRow() permissions = %q, want %q", values["permissions"], tt.want)
+			}
+
+			item, err := categoryImportRecord(importRecord{csv: values})
+			if err != nil {
+				t.Fatalf("categoryImportRecord() error = %v", err)
+			}
+			for _, got := range [][]string{item.ReadPermissions, item.Permissions, item.DeletePermissions} {
+				if !reflect.DeepEqual(got, tt.permissions) {
+					t.Errorf("categoryImportRecord() permissions = %#v, want %#v", got, tt.permissions)
+				}
+			}
+		})
+	}
+}
+
+func TestReadImportRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		body    string
+		want    []importRecord
+		wantErr bool
+	}{
+		{name: "ndjson", format: transferFormatNDJSON, body: "{\"id\": \"a\"}\n\n  \n{\"id\": \"b\"}\r\n",
+			want: []importRecord{{json: []byte(`{"id": "a"}`)}, {json: []byte(`{"id": "b"}`)}}},
+		{name: "empty ndjson", format: transferFormatNDJSON, body: "", want: []importRecord{}},
+		{name: "csv", format: transferFormatCSV, body: "id, category\na,events\nb,\"news, campus\"\n",
+			want: []importRecord{{csv: map[string]string{"id": "a", "category": "events"}}, {csv: map[string]string{"id": "b", "category": "news, campus"}}}},
+		{name: "empty csv", format: transferFormatCSV, body: "", want: []importRecord{}},
+		{name: "csv row of another length", format: transferFormatCSV, body: "id,category\na\n", wantErr: true},
+		{name: "ndjson line too long", format: transferFormatNDJSON, body: strings.Repeat("a", maxImportLineSize+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readImportRecords(strings.NewReader(tt.body), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readImportRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readImportRecords() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentItemCSVRoundTrip(t *testing.T) {
+	publishAt := time.Date(2022, 5, 17, 10, 30, 0, 0, time.UTC)
+	expireAt := time.Date(2022, 6, 17, 10, 30, 0, 500, time.UTC)
+	item := model.ContentItem{ID: "a", Category: "events", DefaultLocale: "en", Data: map[string]interface{}{"title": "Quad, day", "count": 3.0},
+		Locales: map[string]interface{}{"es": map[string]interface{}{"title": "Día"}}, PublishAt: &publishAt, ExpireAt: &expireAt,
+		WorkflowState: model.ContentItemWorkflowInReview, DateCreated: publishAt}
+
+	row, err := contentItemCSVRow(item)
+	if err != nil {
+		t.Fatalf("contentItemCSVRow() error = %v", err)
+	}
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	err = writer.WriteAll([][]string{contentItemCSVHeader, row})
+	if err != nil {
+		t.Fatalf("csv.WriteAll() error = %v", err)
+	}
+
+	records, err := readImportRecords(&body, transferFormatCSV)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("readImportRecords() = %+v, %v, want one record", records, err)
+	}
+	got, err := contentItemImportRecord(records[0])
+	if err != nil {
+		t.Fatalf("contentItemImportRecord() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, item) {
+		t.Errorf("contentItemImportRecord() = %+v, want %+v", got, item)
+	}
+}
+
+func TestContentItemImportRecordErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		record importRecord
+	}{
+		{name: "invalid json", record: importRecord{json: []byte(`{"id": `)}},
+		{name: "invalid workflow state", record: importRecord{json: []byte(`{"id": "a", "category": "events", "workflow_state": "live"}`)}},
+		{name: "expiry before the publish time", record: importRecord{json: []byte(`{"id": "a", "category": "events",
+			"publish_at": "2022-05-17T10:30:00Z", "expire_at": "2022-05-17T10:30:00Z"}`)}},
+		{name: "invalid locale", record: importRecord{json: []byte(`{"id": "a", "category": "events", "locales": {"not a locale": "b"}}`)}},
+		{name: "invalid csv data", record: importRecord{csv: map[string]string{"id": "a", "category": "events", "data": "{"}}},
+		{name: "invalid csv time", record: importRecord{csv: map[string]string{"id": "a", "category": "events", "publish_at": "tomorrow"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := contentItemImportRecord(tt.record); err == nil {
+				t.Errorf("contentItemImportRecord() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWriteImportReport(t *testing.T) {
+	tests := []struct {
+		name       string
+		report     model.ImportReport
+		wantStatus int
+	}{
+		{name: "import", report: model.ImportReport{Imported: true, Created: 1, Errors: []model.ImportError{}}, wantStatus: http.StatusOK},
+		{name: "rejected import", report: model.ImportReport{Errors: []model.ImportError{{Record: 1, Message: "category is required"}}}, wantStatus: http.StatusBadRequest},
+		{name: "dry run with errors", report: model.ImportReport{DryRun: true, Errors: []model.ImportError{{Record: 1, Message: "category is required"}}}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeImportReport(w, &tt.report)
+
+			var got model.ImportReport
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if w.Code != tt.wantStatus || !reflect.DeepEqual(got, tt.report) {
+				t.Errorf("writeImportReport() = %d %+v, want %d %+v", w.Code, got, tt.wantStatus, tt.report)
+			}
+		})
+	}
+}
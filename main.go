@@ -16,16 +16,21 @@ package main
 
 import (
 	"content/core"
+	"content/core/interfaces"
 	"content/core/model"
 	"content/driven/awsstorage"
 	cacheadapter "content/driven/cache"
+	"content/driven/clamav"
 	corebb "content/driven/core"
+	"content/driven/localstorage"
 	storage "content/driven/storage"
 	"content/driven/twitter"
+	"content/driven/webhooks"
 	driver "content/driver/web"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	rokwireAuth "github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/keys"
@@ -87,28 +92,6 @@ func main() {
 		log.Fatal("Cannot start the mongoDB adapter - " + err.Error())
 	}
 
-	// S3 Adapter
-	s3Bucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_BUCKET", true, true)
-	s3BucketAccelerateStr := envLoader.GetAndLogEnvVar(envPrefix+"S3_BUCKET_ACCELERATE", false, false)
-	s3BucketAccelerate := false
-	s3BucketAccelerate, err = strconv.ParseBool(s3BucketAccelerateStr)
-	if err != nil {
-		logger.Warnf("error parsing S3 bucket accelerate: %s - applying default", err.Error())
-	}
-	// only allow S3 transfer accleration on the bucket used for all file types for now
-
-	s3ProfileImagesBucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_PROFILE_IMAGES_BUCKET", true, true)
-	s3UsersAudiosBucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_USERS_AUDIOS_BUCKET", true, true)
-	s3Region := envLoader.GetAndLogEnvVar(envPrefix+"S3_REGION", true, true)
-	awsAccessKeyID := envLoader.GetAndLogEnvVar(envPrefix+"AWS_ACCESS_KEY_ID", true, true)
-	awsSecretAccessKey := envLoader.GetAndLogEnvVar(envPrefix+"AWS_SECRET_ACCESS_KEY", true, true)
-
-	awsConfig := &model.AWSConfig{S3Bucket: s3Bucket,
-		S3BucketAccelerate:    s3BucketAccelerate,
-		S3ProfileImagesBucket: s3ProfileImagesBucket,
-		S3UsersAudiosBucket:   s3UsersAudiosBucket,
-		S3Region:              s3Region, AWSAccessKeyID: awsAccessKeyID, AWSSecretAccessKey: awsSecretAccessKey}
-
 	uploadPresignExpirationMinutesVal := envLoader.GetAndLogEnvVar(envPrefix+"S3_UPLOAD_PRESIGN_EXPIRATION_MINUTES", false, false)
 	uploadPresignExpirationMinutes, err := strconv.Atoi(uploadPresignExpirationMinutesVal)
 	if err != nil {
@@ -119,7 +102,44 @@ func main() {
 	if err != nil {
 		logger.Warnf("error parsing S3 download presign expiration minutes: %s - applying default", err.Error())
 	}
-	awsAdapter := awsstorage.NewAWSStorageAdapter(awsConfig, uploadPresignExpirationMinutes, downloadPresignExpirationMinutes)
+
+	// object storage adapter - S3 by default
+	var objectStorage interfaces.ObjectStorage
+	objectStorageBackend := envLoader.GetAndLogEnvVar(envPrefix+"OBJECT_STORAGE", false, false)
+	switch objectStorageBackend {
+	case "local":
+		localStoragePath := envLoader.GetAndLogEnvVar(envPrefix+"LOCAL_STORAGE_PATH", true, false)
+		localStorageSecret := envLoader.GetAndLogEnvVar(envPrefix+"LOCAL_STORAGE_SECRET", true, true)
+		objectStorage = localstorage.NewLocalStorageAdapter(localStoragePath, contentServiceURL+"/objects", localStorageSecret,
+			uploadPresignExpirationMinutes, downloadPresignExpirationMinutes)
+	case "", "s3":
+		s3Bucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_BUCKET", true, true)
+		s3BucketAccelerateStr := envLoader.GetAndLogEnvVar(envPrefix+"S3_BUCKET_ACCELERATE", false, false)
+		s3BucketAccelerate := false
+		s3BucketAccelerate, err = strconv.ParseBool(s3BucketAccelerateStr)
+		if err != nil {
+			logger.Warnf("error parsing S3 bucket accelerate: %s - applying default", err.Error())
+		}
+		// only allow S3 transfer accleration on the bucket used for all file types for now
+
+		s3ProfileImagesBucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_PROFILE_IMAGES_BUCKET", true, true)
+		s3UsersAudiosBucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_USERS_AUDIOS_BUCKET", true, true)
+		s3QuarantineBucket := envLoader.GetAndLogEnvVar(envPrefix+"S3_QUARANTINE_BUCKET", false, true)
+		s3Region := envLoader.GetAndLogEnvVar(envPrefix+"S3_REGION", true, true)
+		s3Endpoint := envLoader.GetAndLogEnvVar(envPrefix+"S3_ENDPOINT", false, false)
+		awsAccessKeyID := envLoader.GetAndLogEnvVar(envPrefix+"AWS_ACCESS_KEY_ID", true, true)
+		awsSecretAccessKey := envLoader.GetAndLogEnvVar(envPrefix+"AWS_SECRET_ACCESS_KEY", true, true)
+
+		awsConfig := &model.AWSConfig{S3Bucket: s3Bucket,
+			S3BucketAccelerate:    s3BucketAccelerate,
+			S3ProfileImagesBucket: s3ProfileImagesBucket,
+			S3UsersAudiosBucket:   s3UsersAudiosBucket,
+			S3QuarantineBucket:    s3QuarantineBucket,
+			S3Region:              s3Region, S3Endpoint: s3Endpoint, AWSAccessKeyID: awsAccessKeyID, AWSSecretAccessKey: awsSecretAccessKey}
+		objectStorage = awsstorage.NewAWSStorageAdapter(awsConfig, uploadPresignExpirationMinutes, downloadPresignExpirationMinutes)
+	default:
+		log.Fatalf("Unknown object storage %s - must be s3 or local", objectStorageBackend)
+	}
 
 	defaultCacheExpirationSeconds := envLoader.GetAndLogEnvVar(envPrefix+"DEFAULT_CACHE_EXPIRATION_SECONDS", false, false)
 	cacheAdapter := cacheadapter.NewCacheAdapter(defaultCacheExpirationSeconds)
@@ -158,8 +178,17 @@ func main() {
 	}
 	coreAdapter := corebb.NewCoreAdapter(coreBBHost, serviceAccountManager)
 
+	webhooksAdapter := webhooks.NewWebhooksAdapter()
+
+	// scanner of the uploaded files - none by default
+	var scanner interfaces.Scanner
+	clamdAddress := envLoader.GetAndLogEnvVar(envPrefix+"CLAMD_ADDRESS", false, false)
+	if clamdAddress != "" {
+		scanner = clamav.NewClamAVAdapter(clamdAddress, 30*time.Second)
+	}
+
 	// application
-	application := core.NewApplication(Version, Build, storageAdapter, awsAdapter, twitterAdapter, cacheAdapter, mtAppID, mtOrgID, serviceID, coreAdapter, logger)
+	application := core.NewApplication(Version, Build, storageAdapter, objectStorage, twitterAdapter, cacheAdapter, mtAppID, mtOrgID, serviceID, coreAdapter, webhooksAdapter, scanner, logger)
 	application.Start()
 
 	webAdapter := driver.NewWebAdapter(host, port, application, serviceRegManager, logger)
@@ -0,0 +1,351 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSON patch operations
+const (
+	JSONPatchOpAdd     = "add"
+	JSONPatchOpRemove  = "remove"
+	JSONPatchOpReplace = "replace"
+	JSONPatchOpMove    = "move"
+	JSONPatchOpCopy    = "copy"
+	JSONPatchOpTest    = "test"
+)
+
+// ErrJSONPatchTestFailed is returned when a test operation of a JSON patch does not match the document
+var ErrJSONPatchTestFailed = errors.New("test operation failed")
+
+// JSONPatchOperation is an operation of a RFC 6902 JSON patch
+type JSONPatchOperation struct {
+	Op    string
+	Path  string
+	From  string      // the source of move and copy
+	Value interface{} // the value of add, replace and test
+}
+
+// MergePatch applies a RFC 7396 JSON merge patch to a plain json value, the target is not changed
+func MergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result := map[string]interface{}{}
+	if targetObject, ok := target.(map[string]interface{}); ok {
+		for key, value := range targetObject {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = MergePatch(result[key], value)
+	}
+	return result
+}
+
+// ParseJSONPatch reads a RFC 6902 JSON patch document
+func ParseJSONPatch(document []byte) ([]JSONPatchOperation, error) {
+	var items []map[string]json.RawMessage
+	err := json.Unmarshal(document, &items)
+	if err != nil {
+		return nil, fmt.Errorf("a json patch must be an array of operations: %s", err)
+	}
+
+	operations := make([]JSONPatchOperation, len(items))
+	for i, item := range items {
+		operation := &operations[i]
+		for _, field := range []struct {
+			name     string
+			target   *string
+			required bool
+		}{{"op", &operation.Op, true}, {"path", &operation.Path, true}, {"from", &operation.From, false}} {
+			raw, ok := item[field.name]
+			if !ok {
+				if field.required {
+					return nil, fmt.Errorf("operation %d: missing %s", i, field.name)
+				}
+				continue
+			}
+			err = json.Unmarshal(raw, field.target)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: invalid %s", i, field.name)
+			}
+		}
+
+		switch operation.Op {
+		case JSONPatchOpAdd, JSONPatchOpReplace, JSONPatchOpTest:
+			//the value may be null, but it must be there
+			raw, ok := item["value"]
+			if !ok {
+				return nil, fmt.Errorf("operation %d: missing value", i)
+			}
+			err = json.Unmarshal(raw, &operation.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: invalid value", i)
+			}
+		case JSONPatchOpMove, JSONPatchOpCopy:
+			if _, ok := item["from"]; !ok {
+				return nil, fmt.Errorf("operation %d: missing from", i)
+			}
+		case JSONPatchOpRemove:
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op %s", i, operation.Op)
+		}
+	}
+	return operations, nil
+}
+
+// ApplyJSONPatch applies the operations of a RFC 6902 JSON patch to a plain json value one by one, the target is not changed.
+// The patch is applied as a whole or not at all.
+func ApplyJSONPatch(target interface{}, operations []JSONPatchOperation) (interface{}, error) {
+	document := NormalizeData(target)
+	for i, operation := range operations {
+		var err error
+		document, err = applyJSONPatchOperation(document, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return document, nil
+}
+
+func applyJSONPatchOperation(document interface{}, operation JSONPatchOperation) (interface{}, error) {
+	path, err := parseJSONPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case JSONPatchOpAdd:
+		return jsonPatchAdd(document, path, NormalizeData(operation.Value))
+	case JSONPatchOpRemove:
+		document, _, err = jsonPatchRemove(document, path)
+		return document, err
+	case JSONPatchOpReplace:
+		return jsonPatchReplace(document, path, NormalizeData(operation.Value))
+	case JSONPatchOpMove, JSONPatchOpCopy:
+		from, err := parseJSONPointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == JSONPatchOpCopy {
+			value, err := jsonPointerValue(document, from)
+			if err != nil {
+				return nil, err
+			}
+			return jsonPatchAdd(document, path, NormalizeData(value))
+		}
+
+		if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+			return nil, errors.New("a value cannot be moved into itself")
+		}
+		document, value, err := jsonPatchRemove(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(document, path, value)
+	case JSONPatchOpTest:
+		value, err := jsonPointerValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, NormalizeData(operation.Value)) {
+			return nil, ErrJSONPatchTestFailed
+		}
+		return document, nil
+	default:
+		return nil, fmt.Errorf("unknown op %s", operation.Op)
+	}
+}
+
+// parseJSONPointer splits a RFC 6901 JSON pointer into its reference tokens, the whole document has none
+func parseJSONPointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// jsonArrayIndex reads an array index token, "-" is the index after the last element and is allowed only when allowEnd is set
+func jsonArrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %s", token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %d is out of bounds", index)
+	}
+	return index, nil
+}
+
+func jsonPointerValue(document interface{}, path []string) (interface{}, error) {
+	current := document
+	for _, token := range path {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %s does not exist", token)
+			}
+			current = value
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("cannot refer to %s in a scalar value", token)
+		}
+	}
+	return current, nil
+}
+
+// jsonPatchAt walks down to the container of the last token of the path and lets change update it.
+// The containers are set back on the way up as the arrays may grow or shrink.
+func jsonPatchAt(document interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(document, path[0])
+	}
+
+	switch container := document.(type) {
+	case map[string]interface{}:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("member %s does not exist", path[0])
+		}
+		updated, err := jsonPatchAt(child, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = updated
+		return container, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(path[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := jsonPatchAt(container[index], path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot refer to %s in a scalar value", path[0])
+	}
+}
+
+func jsonPatchAdd(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return jsonPatchAt(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("cannot add %s to a scalar value", token)
+		}
+	})
+}
+
+func jsonPatchRemove(document interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("the whole document cannot be removed")
+	}
+
+	var removed interface{}
+	document, err := jsonPatchAt(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %s does not exist", token)
+			}
+			removed = value
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[index]
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %s from a scalar value", token)
+		}
+	})
+	return document, removed, err
+}
+
+func jsonPatchReplace(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return jsonPatchAt(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("member %s does not exist", token)
+			}
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("cannot replace %s in a scalar value", token)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func jsonDocument(t *testing.T, document string) interface{} {
+	t.Helper()
+	var value interface{}
+	err := json.Unmarshal([]byte(document), &value)
+	if err != nil {
+		t.Fatalf("invalid json %s: %s", document, err)
+	}
+	return value
+}
+
+// the examples of RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{target: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{target: `{"e":null}`, patch: `{"a":1}`, want: `{"e":null,"a":1}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			target := jsonDocument(t, tt.target)
+			got := MergePatch(target, jsonDocument(t, tt.patch))
+			if want := jsonDocument(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("MergePatch() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(target, jsonDocument(t, tt.target)) {
+				t.Errorf("MergePatch() changed the target to %v", target)
+			}
+		})
+	}
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []JSONPatchOperation
+		wantErr  bool
+	}{
+		{name: "operations", document: `[{"op":"add","path":"/a","value":null},{"op":"move","from":"/a","path":"/b"},{"op":"remove","path":"/b"}]`,
+			want: []JSONPatchOperation{{Op: JSONPatchOpAdd, Path: "/a"}, {Op: JSONPatchOpMove, Path: "/b", From: "/a"}, {Op: JSONPatchOpRemove, Path: "/b"}}},
+		{name: "not an array", document: `{"op":"add","path":"/a","value":1}`, wantErr: true},
+		{name: "missing op", document: `[{"path":"/a"}]`, wantErr: true},
+		{name: "missing path", document: `[{"op":"remove"}]`, wantErr: true},
+		{name: "missing value", document: `[{"op":"replace","path":"/a"}]`, wantErr: true},
+		{name: "missing from", document: `[{"op":"copy","path":"/a"}]`, wantErr: true},
+		{name: "unknown op", document: `[{"op":"merge","path":"/a"}]`, wantErr: true},
+		{name: "path is not a string", document: `[{"op":"remove","path":1}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSONPatch([]byte(tt.document))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJSONPatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mostly the examples of RFC 6902 appendix A
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		patch    string
+		want     string
+		wantErr  bool
+		wantTest bool // the error is a failed test operation
+	}{
+		{name: "add object member", target: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, want: `{"baz":"qux","foo":"bar"}`},
+		{name: "add array element", target: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, want: `{"foo":["bar","qux","baz"]}`},
+		{name: "add to the end", target: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc"]}]`, want: `{"foo":["bar",["abc"]]}`},
+		{name: "remove object member", target: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, want: `{"foo":"bar"}`},
+		{name: "remove array element", target: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, want: `{"foo":["bar","baz"]}`},
+		{name: "replace", target: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, want: `{"baz":"boo","foo":"bar"}`},
+		{name: "move member", target: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{name: "move array element", target: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want: `{"foo":["all","cows","eat","grass"]}`},
+		{name: "copy", target: `{"foo":{"bar":1}}`, patch: `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			want: `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{name: "test passes", target: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{name: "test fails", target: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, wantErr: true, wantTest: true},
+		{name: "escaped pointer", target: `{"/":9,"~1":10}`, patch: `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, want: `{"~1":10}`},
+		{name: "whole document", target: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"","value":[1]}]`, want: `[1]`},
+		{name: "nested array", target: `{"a":[{"b":[1]}]}`, patch: `[{"op":"add","path":"/a/0/b/0","value":0}]`, want: `{"a":[{"b":[0,1]}]}`},
+		{name: "add to missing parent", target: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, wantErr: true},
+		{name: "replace missing member", target: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":1}]`, wantErr: true},
+		{name: "remove missing member", target: `{"foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, wantErr: true},
+		{name: "index out of bounds", target: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/2","value":2}]`, wantErr: true},
+		{name: "leading zero index", target: `{"foo":[1,2]}`, patch: `[{"op":"remove","path":"/foo/01"}]`, wantErr: true},
+		{name: "end index on remove", target: `{"foo":[1]}`, patch: `[{"op":"remove","path":"/foo/-"}]`, wantErr: true},
+		{name: "move into itself", target: `{"foo":{"bar":1}}`, patch: `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, wantErr: true},
+		{name: "invalid pointer", target: `{"foo":1}`, patch: `[{"op":"remove","path":"foo"}]`, wantErr: true},
+		{name: "remove whole document", target: `{"foo":1}`, patch: `[{"op":"remove","path":""}]`, wantErr: true},
+		{name: "all or nothing", target: `{"foo":1}`, patch: `[{"op":"add","path":"/bar","value":2},{"op":"test","path":"/foo","value":2}]`, wantErr: true, wantTest: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations, err := ParseJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParseJSONPatch() error = %v", err)
+			}
+			target := jsonDocument(t, tt.target)
+
+			got, err := ApplyJSONPatch(target, operations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrJSONPatchTestFailed) != tt.wantTest {
+				t.Errorf("ApplyJSONPatch() error = %v, want a failed test %v", err, tt.wantTest)
+			}
+			if !tt.wantErr {
+				if want := jsonDocument(t, tt.want); !reflect.DeepEqual(got, want) {
+					t.Errorf("ApplyJSONPatch() = %v, want %v", got, want)
+				}
+			}
+			if !reflect.DeepEqual(target, jsonDocument(t, tt.target)) {
+				t.Errorf("ApplyJSONPatch() changed the target to %v", target)
+			}
+		})
+	}
+}
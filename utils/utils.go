@@ -15,15 +15,18 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Filter represents find filter for finding entities by the their fields
@@ -33,10 +36,30 @@ type Filter struct {
 
 // FilterItem represents find filter pair - field/value
 type FilterItem struct {
-	Field string
-	Value []string
+	Field    string
+	Operator string // one of the filter operators, the value must be one of the values when empty
+	Value    []interface{}
 }
 
+// Filter operators
+const (
+	FilterOperatorEq     = "="
+	FilterOperatorNe     = "!="
+	FilterOperatorGt     = ">"
+	FilterOperatorGte    = ">="
+	FilterOperatorLt     = "<"
+	FilterOperatorLte    = "<="
+	FilterOperatorIn     = "in"
+	FilterOperatorNotIn  = "not in"
+	FilterOperatorExists = "exists"
+)
+
+// the longer operators go first so that ">=" is not taken for ">"
+var filterOperators = []string{FilterOperatorNotIn, FilterOperatorIn, FilterOperatorExists,
+	FilterOperatorGte, FilterOperatorLte, FilterOperatorNe, "==", FilterOperatorEq, FilterOperatorGt, FilterOperatorLt}
+
+var filterFieldPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
 // ConstructFilter constructs Filter from the http request params
 func ConstructFilter(r *http.Request) *Filter {
 	values := r.URL.Query()
@@ -48,13 +71,103 @@ func ConstructFilter(r *http.Request) *Filter {
 	var items []FilterItem
 	for k, v := range values {
 		if len(v) > 0 {
-			items = append(items, FilterItem{Field: k, Value: v})
+			value := make([]interface{}, len(v))
+			for i, s := range v {
+				value[i] = s
+			}
+			items = append(items, FilterItem{Field: k, Value: value})
 		}
 	}
 	filter.Items = items
 	return &filter
 }
 
+// ParseFilter constructs Filter from expressions like `data.audience in ["students"]` or `data.start_date >= "2024-01-01"`.
+// Only the fields with the prefix can be filtered. The value is a json literal - an array of primitives for "in" and "not in",
+// a boolean for "exists" and a primitive for the other operators.
+func ParseFilter(expressions []string, fieldPrefix string) (*Filter, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+
+	items := make([]FilterItem, len(expressions))
+	for i, expression := range expressions {
+		item, err := parseFilterExpression(expression, fieldPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %s", expression, err)
+		}
+		items[i] = *item
+	}
+	return &Filter{Items: items}, nil
+}
+
+func parseFilterExpression(expression string, fieldPrefix string) (*FilterItem, error) {
+	expression = strings.TrimSpace(expression)
+	separator := strings.IndexAny(expression, " \t")
+	if separator < 0 {
+		return nil, fmt.Errorf("expected <field> <operator> <value>")
+	}
+
+	field := expression[:separator]
+	if !strings.HasPrefix(field, fieldPrefix+".") || !filterFieldPattern.MatchString(field) {
+		return nil, fmt.Errorf("field must be in the form %s.<name>[.<name>...]", fieldPrefix)
+	}
+
+	rest := strings.TrimSpace(expression[separator:])
+	operator := ""
+	for _, op := range filterOperators {
+		if strings.HasPrefix(rest, op) {
+			operator = op
+			break
+		}
+	}
+	if operator == "" {
+		return nil, fmt.Errorf("unsupported operator")
+	}
+	rawValue := strings.TrimSpace(rest[len(operator):])
+	if operator == "==" {
+		operator = FilterOperatorEq
+	}
+
+	var value interface{}
+	err := json.Unmarshal([]byte(rawValue), &value)
+	if err != nil {
+		return nil, fmt.Errorf("value must be a json literal: %s", err)
+	}
+
+	switch operator {
+	case FilterOperatorIn, FilterOperatorNotIn:
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s expects an array", operator)
+		}
+		for _, v := range values {
+			if !isFilterPrimitive(v) {
+				return nil, fmt.Errorf("%s expects an array of primitives", operator)
+			}
+		}
+		return &FilterItem{Field: field, Operator: operator, Value: values}, nil
+	case FilterOperatorExists:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("%s expects true or false", operator)
+		}
+	default:
+		if !isFilterPrimitive(value) {
+			return nil, fmt.Errorf("%s expects a string, number, boolean or null", operator)
+		}
+	}
+	return &FilterItem{Field: field, Operator: operator, Value: []interface{}{value}}, nil
+}
+
+func isFilterPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
 // ModifyHTMLContent removes all not web href links. It also remove web links which points to pdf document
 // For example:
 // <a href="mailto:email@abc.abc">email@abc.abc</a> -> email@abc.abc
@@ -114,6 +227,9 @@ func ModifyHTMLContent(input string) string {
 	return final
 }
 
+// RequestIDHeader is the header carrying the request id, it ties the audit log entries to the requests
+const RequestIDHeader = "X-Request-ID"
+
 // LogRequest logs the request as hide some header fields because of security reasons
 func LogRequest(req *http.Request) {
 	if req == nil {
@@ -277,3 +393,89 @@ func IsVersionLess(v1 string, v2 string) bool {
 	// they are equals
 	return false
 }
+
+// ExtractSearchText gives the text of all the strings within the data with the HTML markup stripped,
+// so that the data can be full-text indexed
+func ExtractSearchText(data interface{}) string {
+	texts := collectSearchTexts(NormalizeData(data), nil)
+	return strings.Join(texts, " ")
+}
+
+func collectSearchTexts(data interface{}, texts []string) []string {
+	switch value := data.(type) {
+	case string:
+		text := value
+		if strings.Contains(value, "<") {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(value))
+			if err == nil {
+				doc.Find("script,style").Remove()
+				//the text of the sibling elements must not stick together
+				nodeTexts := []string{}
+				doc.Find("*").Contents().Each(func(_ int, node *goquery.Selection) {
+					if goquery.NodeName(node) == "#text" {
+						nodeTexts = append(nodeTexts, node.Text())
+					}
+				})
+				text = strings.Join(nodeTexts, " ")
+			}
+		}
+		text = strings.Join(strings.Fields(text), " ")
+		if len(text) > 0 {
+			texts = append(texts, text)
+		}
+	case map[string]interface{}:
+		//keep the output stable
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			texts = collectSearchTexts(value[key], texts)
+		}
+	case []interface{}:
+		for _, item := range value {
+			texts = collectSearchTexts(item, texts)
+		}
+	}
+	return texts
+}
+
+// NormalizeData converts the ordered documents the driver produces for interface{} fields into plain maps,
+// so that they are encoded as json objects
+func NormalizeData(data interface{}) interface{} {
+	switch value := data.(type) {
+	case primitive.D:
+		result := make(map[string]interface{}, len(value))
+		for _, e := range value {
+			result[e.Key] = NormalizeData(e.Value)
+		}
+		return result
+	case primitive.M:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = NormalizeData(v)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = NormalizeData(v)
+		}
+		return result
+	case primitive.A:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = NormalizeData(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = NormalizeData(v)
+		}
+		return result
+	default:
+		return data
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		expressions []string
+		want        *Filter
+		wantErr     bool
+	}{
+		{
+			name:        "no expressions",
+			expressions: nil,
+			want:        nil,
+		},
+		{
+			name:        "equal string",
+			expressions: []string{`data.type = "event"`},
+			want:        &Filter{Items: []FilterItem{{Field: "data.type", Operator: FilterOperatorEq, Value: []interface{}{"event"}}}},
+		},
+		{
+			name:        "double equal is equal",
+			expressions: []string{`data.type == "event"`},
+			want:        &Filter{Items: []FilterItem{{Field: "data.type", Operator: FilterOperatorEq, Value: []interface{}{"event"}}}},
+		},
+		{
+			name:        "longer operators first",
+			expressions: []string{`data.start_date >= "2024-01-01"`, `data.count <= 5`, `data.state != null`},
+			want: &Filter{Items: []FilterItem{
+				{Field: "data.start_date", Operator: FilterOperatorGte, Value: []interface{}{"2024-01-01"}},
+				{Field: "data.count", Operator: FilterOperatorLte, Value: []interface{}{5.0}},
+				{Field: "data.state", Operator: FilterOperatorNe, Value: []interface{}{nil}},
+			}},
+		},
+		{
+			name:        "in and not in",
+			expressions: []string{`data.audience in ["students", "staff"]`, `data.audience not in ["alumni"]`},
+			want: &Filter{Items: []FilterItem{
+				{Field: "data.audience", Operator: FilterOperatorIn, Value: []interface{}{"students", "staff"}},
+				{Field: "data.audience", Operator: FilterOperatorNotIn, Value: []interface{}{"alumni"}},
+			}},
+		},
+		{
+			name:        "exists",
+			expressions: []string{`data.image exists true`},
+			want:        &Filter{Items: []FilterItem{{Field: "data.image", Operator: FilterOperatorExists, Value: []interface{}{true}}}},
+		},
+		{
+			name:        "nested field with spaces around",
+			expressions: []string{`  data.location.building > 10  `},
+			want:        &Filter{Items: []FilterItem{{Field: "data.location.building", Operator: FilterOperatorGt, Value: []interface{}{10.0}}}},
+		},
+		{
+			name:        "field without prefix",
+			expressions: []string{`category = "events"`},
+			wantErr:     true,
+		},
+		{
+			name:        "field with operators",
+			expressions: []string{`data.$where = "1"`},
+			wantErr:     true,
+		},
+		{
+			name:        "missing operator",
+			expressions: []string{`data.type`},
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported operator",
+			expressions: []string{`data.type ~ "event"`},
+			wantErr:     true,
+		},
+		{
+			name:        "value is not json",
+			expressions: []string{`data.type = event`},
+			wantErr:     true,
+		},
+		{
+			name:        "in without array",
+			expressions: []string{`data.audience in "students"`},
+			wantErr:     true,
+		},
+		{
+			name:        "in with objects",
+			expressions: []string{`data.audience in [{"$ne": 1}]`},
+			wantErr:     true,
+		},
+		{
+			name:        "exists without boolean",
+			expressions: []string{`data.image exists 1`},
+			wantErr:     true,
+		},
+		{
+			name:        "object value",
+			expressions: []string{`data.type = {"$gt": ""}`},
+			wantErr:     true,
+		},
+		{
+			name:        "one invalid expression",
+			expressions: []string{`data.type = "event"`, `data.type`},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.expressions, "data")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSearchText(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{name: "nothing", data: nil, want: ""},
+		{name: "text", data: "  Quad\n\tday ", want: "Quad day"},
+		{name: "numbers and flags", data: []interface{}{3.0, true, "campus"}, want: "campus"},
+		{name: "object by key", data: map[string]interface{}{"title": "Quad day", "description": "Fall", "tags": []interface{}{"campus", ""}}, want: "Fall campus Quad day"},
+		{name: "html", data: "<p>Quad<b>day</b></p><script>alert(1)</script><style>p {}</style>", want: "Quad day"},
+		{name: "comparison", data: "a < b", want: "a < b"},
+		{name: "data with translations", data: []interface{}{map[string]interface{}{"title": "Quad day"}, map[string]interface{}{"es": map[string]interface{}{"title": "Día"}}},
+			want: "Quad day Día"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractSearchText(tt.data); got != tt.want {
+				t.Errorf("ExtractSearchText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}